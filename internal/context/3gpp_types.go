@@ -13,6 +13,7 @@ const (
 	MaxNumOfSlice                     int   = 1024
 	MaxNumOfAllowedSnssais            int   = 8
 	MaxValueOfAmfUeNgapId             int64 = 1099511627775
+	MaxValueOfTrsr                    int64 = 65535
 	MaxNumOfServedGuamiList           int   = 256
 	MaxNumOfPDUSessions               int   = 256
 	MaxNumOfDRBs                      int   = 32
@@ -37,6 +38,21 @@ const (
 	TimeT3565 time.Duration = 6 * time.Second
 )
 
+// guard timer of the responses of Write-Replace-Warning / PWS Cancel from NG-RAN nodes
+const TimePwsResponse time.Duration = 5 * time.Second
+
+// TS 24.501 5.3.7, by default the mobile reachable timer is 4 minutes greater than T3512, the non-3GPP implicit
+// de-registration timer is 4 minutes greater than the non-3GPP de-registration timer, and the implicit
+// de-registration timer started when the mobile reachable timer expires is 4 minutes
+const (
+	TimeMobileReachableOffset      time.Duration = 4 * time.Minute
+	TimeImplicitDeregistration     time.Duration = 4 * time.Minute
+	TimeNon3gppImplicitDeregOffset time.Duration = 4 * time.Minute
+)
+
+// guard timer of the handover resource allocation in target AMF (TS 23.502 4.9.1.3.2 step 9-12)
+const TimeN2HandoverResourceAllocation time.Duration = 10 * time.Second
+
 type CauseAll struct {
 	Cause        *models.SmfPduSessionCause
 	NgapCause    *models.NgApCause
@@ -3,13 +3,18 @@ package context
 import (
 	"fmt"
 	"net"
+	"reflect"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
 	"github.com/free5gc/amf/internal/logger"
+	"github.com/free5gc/aper"
 	"github.com/free5gc/ngap/ngapConvert"
 	"github.com/free5gc/ngap/ngapType"
+	"github.com/free5gc/openapi"
 	"github.com/free5gc/openapi/models"
 )
 
@@ -31,10 +36,17 @@ type AmfRan struct {
 	Conn net.Conn
 	/* Supported TA List */
 	SupportedTAList []SupportedTAI
+	/* Default Paging DRX, nil if not provided by the RAN */
+	DefaultPagingDRX *ngapType.PagingDRX
 
 	/* RAN UE List */
 	RanUeList sync.Map // RanUeNgapId as key
 
+	/* AMF Configuration Update */
+	amfConfigUpdateMutex sync.Mutex
+	amfConfigUpdateState AmfConfigUpdateState
+	amfConfigUpdateRetry *time.Timer
+
 	/* logger */
 	Log *logrus.Entry
 }
@@ -49,8 +61,19 @@ func NewSupportedTAI() (tai SupportedTAI) {
 	return
 }
 
+// The state of the AMF Configuration Update procedure (TS 38.413 8.7.3) towards the RAN
+type AmfConfigUpdateState string
+
+const (
+	AmfConfigUpdateNone         AmfConfigUpdateState = ""
+	AmfConfigUpdatePending      AmfConfigUpdateState = "PENDING"
+	AmfConfigUpdateAcknowledged AmfConfigUpdateState = "ACKNOWLEDGED"
+	AmfConfigUpdateFailed       AmfConfigUpdateState = "FAILED"
+)
+
 func (ran *AmfRan) Remove() {
 	ran.Log.Infof("Remove RAN Context[ID: %+v]", ran.RanID())
+	ran.stopAmfConfigUpdateRetry()
 	ran.RemoveAllRanUe(true)
 	GetSelf().DeleteAmfRan(ran.Conn)
 }
@@ -122,6 +145,80 @@ func (ran *AmfRan) SetRanId(ranNodeId *ngapType.GlobalRANNodeID) {
 	}
 }
 
+// The S-NSSAIs supported in the TA are changed from the ones provided by the RAN before, the TA not provided before
+// is changed as well
+func (ran *AmfRan) TaiSliceSupportChanged(supportedTai SupportedTAI) bool {
+	for _, oldTai := range ran.SupportedTAList {
+		if !reflect.DeepEqual(oldTai.Tai, supportedTai.Tai) {
+			continue
+		}
+		if len(oldTai.SNssaiList) != len(supportedTai.SNssaiList) {
+			return true
+		}
+		for _, snssai := range supportedTai.SNssaiList {
+			if !snssaiInList(snssai, oldTai.SNssaiList) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func snssaiInList(snssai models.Snssai, snssaiList []models.Snssai) bool {
+	for _, item := range snssaiList {
+		if openapi.SnssaiEqualFold(item, snssai) {
+			return true
+		}
+	}
+	return false
+}
+
+func (ran *AmfRan) AmfConfigUpdateState() AmfConfigUpdateState {
+	ran.amfConfigUpdateMutex.Lock()
+	defer ran.amfConfigUpdateMutex.Unlock()
+	return ran.amfConfigUpdateState
+}
+
+// The AMF Configuration Update is sent to the RAN, the pending retry is superseded
+func (ran *AmfRan) AmfConfigUpdateSent() {
+	ran.amfConfigUpdateMutex.Lock()
+	defer ran.amfConfigUpdateMutex.Unlock()
+	ran.stopAmfConfigUpdateRetryLocked()
+	ran.amfConfigUpdateState = AmfConfigUpdatePending
+}
+
+func (ran *AmfRan) AmfConfigUpdateAcknowledged() {
+	ran.amfConfigUpdateMutex.Lock()
+	defer ran.amfConfigUpdateMutex.Unlock()
+	ran.amfConfigUpdateState = AmfConfigUpdateAcknowledged
+}
+
+// The AMF Configuration Update is retried after the time to wait given by the RAN (TS 38.413 8.7.3.3), it is not
+// retried if the time to wait is not given
+func (ran *AmfRan) AmfConfigUpdateFailed(timeToWait time.Duration, retry func()) {
+	ran.amfConfigUpdateMutex.Lock()
+	defer ran.amfConfigUpdateMutex.Unlock()
+	ran.stopAmfConfigUpdateRetryLocked()
+	ran.amfConfigUpdateState = AmfConfigUpdateFailed
+	if timeToWait > 0 && retry != nil {
+		ran.amfConfigUpdateRetry = time.AfterFunc(timeToWait, retry)
+	}
+}
+
+func (ran *AmfRan) stopAmfConfigUpdateRetry() {
+	ran.amfConfigUpdateMutex.Lock()
+	defer ran.amfConfigUpdateMutex.Unlock()
+	ran.stopAmfConfigUpdateRetryLocked()
+}
+
+func (ran *AmfRan) stopAmfConfigUpdateRetryLocked() {
+	if ran.amfConfigUpdateRetry != nil {
+		ran.amfConfigUpdateRetry.Stop()
+		ran.amfConfigUpdateRetry = nil
+	}
+}
+
 func (ran *AmfRan) RanID() string {
 	switch ran.RanPresent {
 	case RanPresentGNbId:
@@ -157,3 +254,66 @@ func (ran *AmfRan) UeRatType() models.RatType {
 		return models.RatType_NR
 	}
 }
+
+func (ran *AmfRan) InTaiList(taiList []models.Tai) bool {
+	for _, supportedTai := range ran.SupportedTAList {
+		if InTaiList(supportedTai.Tai, taiList) {
+			return true
+		}
+	}
+	return false
+}
+
+// TS 38.413 9.3.1.7, the gNB ID is the leftmost bits of the NR Cell Identity
+func (ran *AmfRan) HasNrCell(nrCgi ngapType.NRCGI) bool {
+	if ran.RanPresent != RanPresentGNbId || ran.RanId.GNbId == nil || !ran.inRanPlmn(nrCgi.PLMNIdentity) {
+		return false
+	}
+	gnbId := bitStringPrefix(nrCgi.NRCellIdentity.Value, uint64(ran.RanId.GNbId.BitLength))
+	return ngapConvert.BitStringToHex(&gnbId) == ran.RanId.GNbId.GNBValue
+}
+
+// TS 38.413 9.3.1.9, the ng-eNB ID is the leftmost bits of the E-UTRA Cell Identity
+func (ran *AmfRan) HasEutraCell(eutraCgi ngapType.EUTRACGI) bool {
+	if ran.RanPresent != RanPresentNgeNbId || !ran.inRanPlmn(eutraCgi.PLMNIdentity) {
+		return false
+	}
+	eutraCellIdentity := eutraCgi.EUTRACellIdentity.Value
+	ngeNbType, ngeNbValue, found := strings.Cut(ran.RanId.NgeNbId, "-")
+	if !found {
+		return false
+	}
+	var bitLength uint64
+	switch ngeNbType {
+	case "MacroNGeNB":
+		bitLength = 20
+	case "SMacroNGeNB":
+		bitLength = 18
+	case "LMacroNGeNB":
+		bitLength = 21
+	default:
+		return false
+	}
+	ngeNbId := bitStringPrefix(eutraCellIdentity, bitLength)
+	return ngapConvert.BitStringToHex(&ngeNbId) == ngeNbValue
+}
+
+// The cell identity is unique within the PLMN of the Global RAN Node ID
+func (ran *AmfRan) inRanPlmn(plmnIdentity ngapType.PLMNIdentity) bool {
+	return ran.RanId.PlmnId != nil && ngapConvert.PlmnIdToModels(plmnIdentity) == *ran.RanId.PlmnId
+}
+
+func bitStringPrefix(bitString aper.BitString, bitLength uint64) aper.BitString {
+	if bitLength > bitString.BitLength {
+		return aper.BitString{}
+	}
+	prefix := aper.BitString{
+		Bytes:     make([]byte, (bitLength+7)/8),
+		BitLength: bitLength,
+	}
+	copy(prefix.Bytes, bitString.Bytes)
+	if bitLength%8 != 0 {
+		prefix.Bytes[len(prefix.Bytes)-1] &= byte(0xff) << (8 - bitLength%8)
+	}
+	return prefix
+}
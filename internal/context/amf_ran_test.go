@@ -2,10 +2,15 @@ package context
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
 	"github.com/free5gc/amf/internal/logger"
+	"github.com/free5gc/aper"
+	"github.com/free5gc/ngap/ngapConvert"
+	"github.com/free5gc/ngap/ngapType"
+	"github.com/free5gc/openapi/models"
 )
 
 func TestRemoveAndRemoveAllRanUeRaceCondition(t *testing.T) {
@@ -29,3 +34,108 @@ func runRanUeRemove(ran *AmfRan) {
 	}
 	ran.RemoveAllRanUe(true)
 }
+
+func TestRanHasCell(t *testing.T) {
+	plmnId := models.PlmnId{Mcc: "208", Mnc: "93"}
+	ngapPlmnId := ngapConvert.PlmnIdToNgap(plmnId)
+	otherPlmnId := ngapConvert.PlmnIdToNgap(models.PlmnId{Mcc: "466", Mnc: "92"})
+	nrCgi := func(plmnIdentity ngapType.PLMNIdentity, cellIdentity []byte) ngapType.NRCGI {
+		return ngapType.NRCGI{
+			PLMNIdentity:   plmnIdentity,
+			NRCellIdentity: ngapType.NRCellIdentity{Value: aper.BitString{Bytes: cellIdentity, BitLength: 36}},
+		}
+	}
+	eutraCgi := func(plmnIdentity ngapType.PLMNIdentity, cellIdentity []byte) ngapType.EUTRACGI {
+		return ngapType.EUTRACGI{
+			PLMNIdentity:      plmnIdentity,
+			EUTRACellIdentity: ngapType.EUTRACellIdentity{Value: aper.BitString{Bytes: cellIdentity, BitLength: 28}},
+		}
+	}
+
+	gnb := &AmfRan{
+		RanPresent: RanPresentGNbId,
+		RanId: &models.GlobalRanNodeId{
+			PlmnId: &plmnId,
+			GNbId: &models.GNbId{
+				BitLength: 22,
+				GNBValue:  "000104",
+			},
+		},
+	}
+	// gNB ID 0x000041 (22 bits) followed by a 14 bits local cell ID
+	require.True(t, gnb.HasNrCell(nrCgi(ngapPlmnId, []byte{0x00, 0x01, 0x04, 0x12, 0x30})))
+	require.False(t, gnb.HasNrCell(nrCgi(ngapPlmnId, []byte{0x00, 0x01, 0x08, 0x12, 0x30})))
+	require.False(t, gnb.HasNrCell(nrCgi(otherPlmnId, []byte{0x00, 0x01, 0x04, 0x12, 0x30})))
+	require.False(t, gnb.HasEutraCell(eutraCgi(ngapPlmnId, []byte{0x00, 0x01, 0x04, 0x10})))
+
+	ngeNb := &AmfRan{
+		RanPresent: RanPresentNgeNbId,
+		RanId: &models.GlobalRanNodeId{
+			PlmnId:  &plmnId,
+			NgeNbId: "MacroNGeNB-12345",
+		},
+	}
+	require.True(t, ngeNb.HasEutraCell(eutraCgi(ngapPlmnId, []byte{0x12, 0x34, 0x5a, 0xb0})))
+	require.False(t, ngeNb.HasEutraCell(eutraCgi(ngapPlmnId, []byte{0x12, 0x34, 0x6a, 0xb0})))
+	require.False(t, ngeNb.HasEutraCell(eutraCgi(otherPlmnId, []byte{0x12, 0x34, 0x5a, 0xb0})))
+	require.False(t, ngeNb.HasNrCell(nrCgi(ngapPlmnId, []byte{0x12, 0x34, 0x5a, 0xb0, 0x00})))
+}
+
+func TestTaiSliceSupportChanged(t *testing.T) {
+	tai := models.Tai{PlmnId: &models.PlmnId{Mcc: "208", Mnc: "93"}, Tac: "000001"}
+	ran := &AmfRan{
+		SupportedTAList: []SupportedTAI{
+			{Tai: tai, SNssaiList: []models.Snssai{{Sst: 1, Sd: "010203"}, {Sst: 1, Sd: "112233"}}},
+		},
+	}
+
+	require.False(t, ran.TaiSliceSupportChanged(SupportedTAI{
+		Tai:        models.Tai{PlmnId: &models.PlmnId{Mcc: "208", Mnc: "93"}, Tac: "000001"},
+		SNssaiList: []models.Snssai{{Sst: 1, Sd: "112233"}, {Sst: 1, Sd: "010203"}},
+	}))
+	require.True(t, ran.TaiSliceSupportChanged(SupportedTAI{
+		Tai:        tai,
+		SNssaiList: []models.Snssai{{Sst: 1, Sd: "010203"}, {Sst: 2, Sd: "112233"}},
+	}))
+	require.True(t, ran.TaiSliceSupportChanged(SupportedTAI{
+		Tai:        tai,
+		SNssaiList: []models.Snssai{{Sst: 1, Sd: "010203"}},
+	}))
+	require.True(t, ran.TaiSliceSupportChanged(SupportedTAI{
+		Tai:        models.Tai{PlmnId: tai.PlmnId, Tac: "000002"},
+		SNssaiList: []models.Snssai{{Sst: 1, Sd: "010203"}},
+	}))
+}
+
+func TestAmfConfigUpdateState(t *testing.T) {
+	ran := &AmfRan{
+		Log: logger.NgapLog.WithField("", ""),
+	}
+	require.Equal(t, AmfConfigUpdateNone, ran.AmfConfigUpdateState())
+
+	ran.AmfConfigUpdateSent()
+	require.Equal(t, AmfConfigUpdatePending, ran.AmfConfigUpdateState())
+
+	// the update is retried after the time to wait
+	retried := make(chan struct{})
+	ran.AmfConfigUpdateFailed(10*time.Millisecond, func() {
+		ran.AmfConfigUpdateSent()
+		close(retried)
+	})
+	require.Equal(t, AmfConfigUpdateFailed, ran.AmfConfigUpdateState())
+	select {
+	case <-retried:
+	case <-time.After(time.Second):
+		t.Fatal("AMF Configuration Update is not retried")
+	}
+	require.Equal(t, AmfConfigUpdatePending, ran.AmfConfigUpdateState())
+
+	// the pending retry is superseded by the update sent
+	ran.AmfConfigUpdateFailed(10*time.Millisecond, func() {
+		t.Error("superseded AMF Configuration Update is retried")
+	})
+	ran.AmfConfigUpdateSent()
+	ran.AmfConfigUpdateAcknowledged()
+	time.Sleep(50 * time.Millisecond)
+	require.Equal(t, AmfConfigUpdateAcknowledged, ran.AmfConfigUpdateState())
+}
@@ -70,6 +70,9 @@ type AmfUe struct {
 	NasPduValue                        []byte
 	RetransmissionOfInitialNASMsg      bool
 	RequestIdentityType                uint8
+	EmergencyRegistered                bool // registered for emergency services (TS 23.501 5.16.4)
+	MicoMode                           bool // MICO mode negotiated in the registration (TS 23.501 5.4.1.3)
+	MicoAllPlmnRegistrationArea        bool // all PLMN registration area allocated to the UE in MICO mode
 	/* Used for AMF relocation */
 	TargetAmfProfile *models.NrfNfDiscoveryNfProfile
 	TargetAmfUri     string
@@ -88,6 +91,7 @@ type AmfUe struct {
 	/* User Location */
 	RatType                  models.RatType
 	Location                 models.UserLocation
+	locationMu               sync.RWMutex // guards Location written by SetLocation against GetLocation
 	Tai                      models.Tai
 	LocationChanged          bool
 	LastVisitedRegisteredTai models.Tai
@@ -123,14 +127,35 @@ type AmfUe struct {
 	AmPolicyUri                  string
 	AmPolicyAssociation          *models.PcfAmPolicyControlPolicyAssociation
 	RequestTriggerLocationChange bool // true if AmPolicyAssociation.Trigger contains RequestTrigger_LOC_CH
+	/* SMS over NAS (TS 23.502 4.13.2) */
+	SmsSubscriptionData *models.SmsSubscriptionData
+	SmsfId              string
+	SmsfUri             string
+	// the access types over which the SMS over NAS is activated in the SMSF and allowed to the UE
+	SmsOverNasActivated map[models.AccessType]bool
+	/* Network slice-specific authentication and authorization (TS 23.502 4.2.9) */
+	NssaaStatusList []models.NssaaStatus // the subscribed S-NSSAIs subject to NSSAA
+	PendingNssai    []models.Snssai      // the S-NSSAIs pending NSSAA, not in the allowed NSSAI
+	NssaafUri       string
+	Nssaa           *NssaaProcedure // the ongoing NSSAA procedure
 	/* UeContextForHandover */
 	HandoverNotifyUri string
+	// result of the handover resource allocation in target AMF, used by Namf_Communication_CreateUEContext
+	n2HandoverResult   chan *N2HandoverResult
+	n2HandoverResultMu sync.Mutex
 	/* N1N2Message */
 	N1N2MessageIDGenerator          *idgenerator.IDGenerator
-	N1N2Message                     *N1N2Message
+	N1N2Message                     *N1N2Message // the transfer triggering the ongoing paging
 	N1N2MessageSubscribeIDGenerator *idgenerator.IDGenerator
+	// transfers buffered until the UE is reached, besides the one of N1N2Message
+	N1N2MessageQueue []*N1N2Message
 	// map[int64]models.UeN1N2InfoSubscriptionCreateData; use n1n2MessageSubscriptionID as key
 	N1N2MessageSubscription sync.Map
+	/* Location Services */
+	LocationRequests sync.Map // map[ldrReference]*LocationRequest
+	// closed when the UE becomes CM-CONNECTED in 3GPP access, e.g. after the paging for positioning
+	cmConnected   chan struct{}
+	cmConnectedMu sync.Mutex
 	/* Pdu Sesseion context */
 	SmContextList sync.Map // map[int32]*SmContext, pdu session id as key
 	/* Related Context */
@@ -145,6 +170,8 @@ type AmfUe struct {
 	UeRadioCapabilityForPaging                 *UERadioCapabilityForPaging
 	InfoOnRecommendedCellsAndRanNodesForPaging *InfoOnRecommendedCellsAndRanNodesForPaging
 	UESpecificDRX                              uint8
+	RequestedEdrx                              *EdrxParameters // requested extended DRX parameters in the registration
+	Edrx                                       *EdrxParameters // negotiated extended DRX parameters, nil if not used
 	/* Security Context */
 	SecurityContextAvailable bool
 	UESecurityCapability     nasType.UESecurityCapability // for security command
@@ -186,6 +213,13 @@ type AmfUe struct {
 	T3570 *Timer
 	/* T3555 (for configuration update command) */
 	T3555 *Timer
+	/* Mobile reachable timer and implicit de-registration timers of the UE in 5GMM-IDLE (TS 24.501 5.3.7) */
+	mobileReachableTimer               *Timer
+	implicitDeregistrationTimer        *Timer
+	non3gppImplicitDeregistrationTimer *Timer
+	reachabilityTimersMu               sync.Mutex // guards the timers above and Reachability updated by them
+	/* The time the UE entered CM-IDLE in 3GPP access, to estimate the paging time window of the UE in eDRX */
+	IdleSince time.Time
 	/* Ue Context Release Cause */
 	ReleaseCause map[models.AccessType]*CauseAll
 	/* T3502 (Assigned by AMF, and used by UE to initialize registration procedure) */
@@ -210,12 +244,15 @@ type AmfUeEventSubscription struct {
 	AnyUe             bool
 	RemainReports     *int32
 	EventSubscription *models.ExtAmfEventSubscription
+	// presence of the UE in the areas of PRESENCE_IN_AOI_REPORT and UES_IN_AREA_REPORT in the last evaluation
+	AreaPresence map[models.AmfEventType][]models.PresenceState
 }
 
-type N1N2Message struct {
-	Request     models.N1N2MessageTransferRequest
-	Status      models.N1N2MessageTransferCause
-	ResourceUri string
+// TS 23.502 4.9.1.3.2 step 12, the response of Namf_Communication_CreateUEContext
+type N2HandoverResult struct {
+	UeContextCreatedData *models.UeContextCreatedData
+	BinaryParts          map[string][]byte // NGAP data referenced by the Content-ID in UeContextCreatedData
+	NgapCause            *models.NgApCause // set if the handover resource allocation failed
 }
 
 type OnGoing struct {
@@ -267,6 +304,7 @@ type ConfigurationUpdateCommandFlags struct {
 	NeedConfiguredNSSAI                          bool
 	NeedNetworkSlicingIndication                 bool
 	NeedOperatordefinedAccessCategoryDefinitions bool
+	NeedReRegistration                           bool
 }
 
 func (ue *AmfUe) init() {
@@ -288,6 +326,7 @@ func (ue *AmfUe) init() {
 	ue.onGoing[models.AccessType__3_GPP_ACCESS].Procedure = OnGoingProcedureNothing
 	ue.ReleaseCause = make(map[models.AccessType]*CauseAll)
 	ue.UeCmRegistered = make(map[models.AccessType]bool)
+	ue.SmsOverNasActivated = make(map[models.AccessType]bool)
 	ue.GmmLog = logger.GmmLog
 	ue.NASLog = logger.GmmLog
 	ue.ProducerLog = logger.ProducerLog
@@ -317,6 +356,8 @@ func (ue *AmfUe) Remove() {
 	ue.StopT3522()
 	ue.StopT3570()
 	ue.StopT3555()
+	ue.StopMobileReachableTimers(models.AccessType__3_GPP_ACCESS)
+	ue.StopMobileReachableTimers(models.AccessType_NON_3_GPP_ACCESS)
 
 	for _, ranUe := range ue.RanUe {
 		if err := ranUe.Remove(); err != nil {
@@ -332,6 +373,8 @@ func (ue *AmfUe) Remove() {
 	tmsiGenerator.FreeID(int64(ue.Tmsi))
 	if len(ue.Supi) > 0 {
 		GetSelf().UePool.Delete(ue.Supi)
+	} else if len(ue.Pei) > 0 {
+		GetSelf().UePool.Delete(ue.Pei)
 	}
 	ue.DeleteAllSmContexts()
 
@@ -353,6 +396,9 @@ func (ue *AmfUe) DetachRanUe(anType models.AccessType) {
 
 	delete(ue.RanUe, anType)
 	ue.UpdateLogFields(anType)
+	if anType == models.AccessType__3_GPP_ACCESS {
+		ue.IdleSince = time.Now()
+	}
 }
 
 // Don't call this function directly. Use gmm_common.AttachRanUeToAmfUeAndReleaseOldIfAny().
@@ -360,6 +406,36 @@ func (ue *AmfUe) AttachRanUe(ranUe *RanUe) {
 	ue.RanUe[ranUe.Ran.AnType] = ranUe
 	ranUe.AmfUe = ue
 	ue.UpdateLogFields(ranUe.Ran.AnType)
+	ue.StopMobileReachableTimers(ranUe.Ran.AnType)
+
+	if ranUe.Ran.AnType == models.AccessType__3_GPP_ACCESS {
+		ue.cmConnectedMu.Lock()
+		if ue.cmConnected != nil {
+			close(ue.cmConnected)
+			ue.cmConnected = nil
+		}
+		ue.cmConnectedMu.Unlock()
+	}
+}
+
+// Wait until the UE becomes CM-CONNECTED in 3GPP access, return false if timeout
+func (ue *AmfUe) WaitCmConnected(timeout time.Duration) bool {
+	ue.cmConnectedMu.Lock()
+	if ue.cmConnected == nil {
+		ue.cmConnected = make(chan struct{})
+	}
+	cmConnected := ue.cmConnected
+	ue.cmConnectedMu.Unlock()
+
+	if ue.CmConnect(models.AccessType__3_GPP_ACCESS) {
+		return true
+	}
+	select {
+	case <-cmConnected:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
 }
 
 func (ue *AmfUe) UpdateLogFields(accessType models.AccessType) {
@@ -395,6 +471,20 @@ func (ue *AmfUe) GetAnType() models.AccessType {
 	return ""
 }
 
+// Update the location of the UE, it's read by GetLocation for the other UEs, e.g. the number of UEs in an area
+func (ue *AmfUe) SetLocation(location models.UserLocation) {
+	ue.locationMu.Lock()
+	defer ue.locationMu.Unlock()
+	ue.Location = location
+}
+
+// The snapshot of the location of the UE, which can be taken without holding ue.Lock
+func (ue *AmfUe) GetLocation() models.UserLocation {
+	ue.locationMu.RLock()
+	defer ue.locationMu.RUnlock()
+	return ue.Location
+}
+
 func (ue *AmfUe) GetCmInfo() (cmInfos []models.CmInfo) {
 	var cmInfo models.CmInfo
 	cmInfo.AccessType = models.AccessType__3_GPP_ACCESS
@@ -432,6 +522,62 @@ func (ue *AmfUe) InSubscribedNssai(targetSNssai models.Snssai) bool {
 	return false
 }
 
+// The slice selection subscription data of the UE (TS 29.503 6.1.6.2.2)
+func (ue *AmfUe) SubscribedNssaiData() *models.Nssai {
+	nssai := new(models.Nssai)
+	for _, subscribedSnssai := range ue.SubscribedNssai {
+		if subscribedSnssai.DefaultIndication {
+			nssai.DefaultSingleNssais = append(nssai.DefaultSingleNssais, *subscribedSnssai.SubscribedSnssai)
+		} else {
+			nssai.SingleNssais = append(nssai.SingleNssais, *subscribedSnssai.SubscribedSnssai)
+		}
+	}
+	for _, nssaaStatus := range ue.NssaaStatusList {
+		if !ue.InSubscribedNssai(*nssaaStatus.Snssai) {
+			continue
+		}
+		if nssai.AdditionalSnssaiData == nil {
+			nssai.AdditionalSnssaiData = make(map[string]models.AdditionalSnssaiData)
+		}
+		nssai.AdditionalSnssaiData[openapi.SnssaiModelsToHex(*nssaaStatus.Snssai)] = models.AdditionalSnssaiData{
+			RequiredAuthnAuthz: true,
+		}
+	}
+	return nssai
+}
+
+// Replace the subscribed S-NSSAIs of the UE with the slice selection subscription data
+func (ue *AmfUe) SetSubscribedNssaiData(nssai *models.Nssai) {
+	ue.SubscribedNssai = nil
+	for _, defaultSnssai := range nssai.DefaultSingleNssais {
+		subscribedSnssai := models.SubscribedSnssai{
+			SubscribedSnssai: &models.Snssai{
+				Sst: defaultSnssai.Sst,
+				Sd:  defaultSnssai.Sd,
+			},
+			DefaultIndication: true,
+		}
+		ue.SubscribedNssai = append(ue.SubscribedNssai, subscribedSnssai)
+	}
+	for _, snssai := range nssai.SingleNssais {
+		subscribedSnssai := models.SubscribedSnssai{
+			SubscribedSnssai: &models.Snssai{
+				Sst: snssai.Sst,
+				Sd:  snssai.Sd,
+			},
+			DefaultIndication: false,
+		}
+		ue.SubscribedNssai = append(ue.SubscribedNssai, subscribedSnssai)
+	}
+	// the S-NSSAIs subject to network slice-specific authentication and authorization (TS 23.502 4.2.9.1)
+	for _, subscribedSnssai := range ue.SubscribedNssai {
+		snssai := *subscribedSnssai.SubscribedSnssai
+		if nssai.AdditionalSnssaiData[openapi.SnssaiModelsToHex(snssai)].RequiredAuthnAuthz {
+			ue.AddNssaaSnssai(snssai)
+		}
+	}
+}
+
 func (ue *AmfUe) GetNsiInformationFromSnssai(anType models.AccessType, snssai models.Snssai) *models.NsiInformation {
 	for _, allowedSnssai := range ue.AllowedNssai[anType] {
 		if openapi.SnssaiEqualFold(*allowedSnssai.AllowedSnssai, snssai) {
@@ -464,6 +610,12 @@ func (ue *AmfUe) HasWildCardSubscribedDNN() bool {
 	return false
 }
 
+// The UE registered for emergency services is not authenticated if allowed by the local regulation, and the
+// null integrity and ciphering algorithms are used (TS 33.501 10.2.2)
+func (ue *AmfUe) IsUnauthenticatedEmergency() bool {
+	return ue.EmergencyRegistered && ue.UnauthenticatedSupi
+}
+
 func (ue *AmfUe) SecurityContextIsValid() bool {
 	return ue.SecurityContextAvailable && ue.NgKsi.Ksi != nasMessage.NasKeySetIdentifierNoKeyIsAvailable && !ue.MacFailed
 }
@@ -677,6 +829,39 @@ func (ue *AmfUe) OnGoing(anType models.AccessType) OnGoing {
 	return *ue.onGoing[anType]
 }
 
+// Used by the target AMF of an inter-AMF N2 handover, the returned channel receives the
+// result of the handover resource allocation in target NG-RAN
+func (ue *AmfUe) WaitN2HandoverResult() <-chan *N2HandoverResult {
+	ue.n2HandoverResultMu.Lock()
+	defer ue.n2HandoverResultMu.Unlock()
+	ue.n2HandoverResult = make(chan *N2HandoverResult, 1)
+	return ue.n2HandoverResult
+}
+
+// The result is no longer waited for, it's not sent afterwards
+func (ue *AmfUe) StopWaitingN2HandoverResult() {
+	ue.n2HandoverResultMu.Lock()
+	defer ue.n2HandoverResultMu.Unlock()
+	ue.n2HandoverResult = nil
+}
+
+// Return false if there is no Namf_Communication_CreateUEContext waiting for the result. The waiting one rolls back
+// the UE context if the handover resource allocation failed.
+func (ue *AmfUe) SendN2HandoverResult(result *N2HandoverResult) bool {
+	ue.n2HandoverResultMu.Lock()
+	defer ue.n2HandoverResultMu.Unlock()
+	if ue.n2HandoverResult == nil {
+		return false
+	}
+	select {
+	case ue.n2HandoverResult <- result:
+		ue.n2HandoverResult = nil
+		return true
+	default:
+		return false
+	}
+}
+
 func (ue *AmfUe) RemoveAmPolicyAssociation() {
 	ue.AmPolicyAssociation = nil
 	ue.PolicyAssociationId = ""
@@ -877,6 +1062,11 @@ func (ue *AmfUe) CopyDataFromUeContextModel(ueContext *models.UeContext) {
 					ue.AllowedNssai[mmContext.AccessType] = append(ue.AllowedNssai[mmContext.AccessType], allowedSnssai)
 				}
 			}
+
+			// the NSSAA status is kept in the UE context transfer (TS 23.502 4.2.9.1)
+			if len(mmContext.NssaaStatusList) > 0 {
+				ue.NssaaStatusList = mmContext.NssaaStatusList
+			}
 		}
 	}
 	if ueContext.TraceData != nil {
@@ -1001,3 +1191,71 @@ func (ue *AmfUe) StopT3555() {
 	ue.T3555.Stop()
 	ue.T3555 = nil // clear the timer
 }
+
+// Stop the mobile reachable timer and the implicit de-registration timer of the access when the UE enters
+// 5GMM-CONNECTED, and the UE becomes reachable again
+func (ue *AmfUe) StopMobileReachableTimers(anType models.AccessType) {
+	ue.reachabilityTimersMu.Lock()
+	defer ue.reachabilityTimersMu.Unlock()
+	if anType == models.AccessType_NON_3_GPP_ACCESS {
+		if ue.non3gppImplicitDeregistrationTimer != nil {
+			ue.GmmLog.Infof("Stop Non-3GPP Implicit Deregistration timer")
+			ue.non3gppImplicitDeregistrationTimer.Stop()
+			ue.non3gppImplicitDeregistrationTimer = nil // clear the timer
+		}
+		return
+	}
+
+	if ue.mobileReachableTimer != nil {
+		ue.GmmLog.Infof("Stop Mobile Reachable timer")
+		ue.mobileReachableTimer.Stop()
+		ue.mobileReachableTimer = nil // clear the timer
+	}
+	if ue.implicitDeregistrationTimer != nil {
+		ue.GmmLog.Infof("Stop Implicit Deregistration timer")
+		ue.implicitDeregistrationTimer.Stop()
+		ue.implicitDeregistrationTimer = nil // clear the timer
+	}
+	if ue.Reachability == models.UeReachability_UNREACHABLE {
+		ue.Reachability = models.UeReachability_REACHABLE
+	}
+}
+
+// The UE is unreachable once the mobile reachable timer expires, until the timer is stopped by
+// StopMobileReachableTimers
+func (ue *AmfUe) StartMobileReachableTimer(d time.Duration, expiredFunc func()) {
+	ue.startReachabilityTimer(&ue.mobileReachableTimer, d, expiredFunc)
+}
+
+func (ue *AmfUe) StartImplicitDeregistrationTimer(d time.Duration, expiredFunc func()) {
+	ue.startReachabilityTimer(&ue.implicitDeregistrationTimer, d, expiredFunc)
+}
+
+func (ue *AmfUe) StartNon3gppImplicitDeregistrationTimer(d time.Duration, expiredFunc func()) {
+	ue.startReachabilityTimer(&ue.non3gppImplicitDeregistrationTimer, d, expiredFunc)
+}
+
+// The expiredFunc is called without holding the timer lock, and not called if the timer is stopped or restarted
+// before it expires
+func (ue *AmfUe) startReachabilityTimer(timer **Timer, d time.Duration, expiredFunc func()) {
+	ue.reachabilityTimersMu.Lock()
+	defer ue.reachabilityTimersMu.Unlock()
+	if *timer != nil {
+		(*timer).Stop()
+	}
+	var t *Timer
+	t = NewTimer(d, 0, func(expireTimes int32) {}, func() {
+		ue.reachabilityTimersMu.Lock()
+		if *timer != t {
+			ue.reachabilityTimersMu.Unlock()
+			return
+		}
+		*timer = nil
+		if timer == &ue.mobileReachableTimer {
+			ue.Reachability = models.UeReachability_UNREACHABLE
+		}
+		ue.reachabilityTimersMu.Unlock()
+		expiredFunc()
+	})
+	*timer = t
+}
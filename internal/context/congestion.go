@@ -0,0 +1,125 @@
+package context
+
+import (
+	"math/rand/v2"
+	"strconv"
+
+	"github.com/free5gc/amf/pkg/factory"
+	"github.com/free5gc/nas/nasMessage"
+	"github.com/free5gc/ngap/ngapType"
+	"github.com/free5gc/openapi"
+	"github.com/free5gc/openapi/models"
+)
+
+// NAS level congestion control (TS 23.501 5.19.7), active while the AMF is overloaded
+
+// The default ranges of the back-off timers if not configured
+var (
+	defaultT3346          = factory.BackoffTimer{Min: 15 * 60, Max: 30 * 60}
+	defaultSmBackoffTimer = factory.BackoffTimer{Min: 15 * 60, Max: 30 * 60}
+)
+
+// The general NAS level mobility management congestion control (TS 23.501 5.19.7.2) is active, the registration and
+// service requests are rejected with 5GMM cause #22
+func (c *AMFContext) MmCongestionActive() bool {
+	return c.CongestionControl != nil && c.CongestionControl.MobilityManagement && c.Load.Overloaded()
+}
+
+// The 5GMM cause of the 5GSM message rejected by the DNN and S-NSSAI based congestion control (TS 23.501 5.19.7.3,
+// 5.19.7.4), 0 if the DNN and S-NSSAI are not congested
+func (c *AMFContext) SmCongestionCause(snssai models.Snssai, dnn string) uint8 {
+	if c.CongestionControl == nil || !c.Load.Overloaded() {
+		return 0
+	}
+	dnnCongested := false
+	for _, congestedDnn := range c.CongestionControl.DnnList {
+		if congestedDnn == dnn {
+			dnnCongested = true
+			break
+		}
+	}
+	snssaiCongested := false
+	for _, congestedSnssai := range c.CongestionControl.SnssaiList {
+		if openapi.SnssaiEqualFold(congestedSnssai, snssai) {
+			snssaiCongested = true
+			break
+		}
+	}
+
+	switch {
+	case dnnCongested && snssaiCongested:
+		return nasMessage.Cause5GMMInsufficientResourcesForSpecificSliceAndDNN
+	case snssaiCongested:
+		return nasMessage.Cause5GMMInsufficientResourcesForSpecificSlice
+	case dnnCongested:
+		return nasMessage.Cause5GMMCongestion
+	default:
+		return 0
+	}
+}
+
+// The value of T3346 randomised for the UE, rounded down to the value of GPRS timer 2 (TS 24.008 10.5.7.4); 0 if the
+// congestion control is not configured
+func (c *AMFContext) T3346Value() int {
+	if c.CongestionControl == nil {
+		return 0
+	}
+	t3346 := defaultT3346
+	if c.CongestionControl.T3346 != nil {
+		t3346 = *c.CongestionControl.T3346
+	}
+	return gprsTimer2Value(randomBackoffTimerValue(t3346))
+}
+
+// The value of the back-off timer of the 5GSM message randomised for the UE, unit is second
+func (c *AMFContext) SmBackoffTimerValue() int {
+	if c.CongestionControl == nil {
+		return 0
+	}
+	backoffTimer := defaultSmBackoffTimer
+	if c.CongestionControl.SmBackoffTimer != nil {
+		backoffTimer = *c.CongestionControl.SmBackoffTimer
+	}
+	return randomBackoffTimerValue(backoffTimer)
+}
+
+func randomBackoffTimerValue(backoffTimer factory.BackoffTimer) int {
+	if backoffTimer.Max <= backoffTimer.Min {
+		return backoffTimer.Min
+	}
+	return backoffTimer.Min + rand.IntN(backoffTimer.Max-backoffTimer.Min+1)
+}
+
+// The value of GPRS timer 2 is coded in units of 2 seconds, 1 minute or 1 decihour with at most 31 units
+func gprsTimer2Value(seconds int) int {
+	switch {
+	case seconds < 2:
+		return 2
+	case seconds <= 31*2:
+		return seconds &^ 1
+	case seconds < 31*60:
+		return seconds / 60 * 60
+	case seconds < 31*360:
+		return seconds / 360 * 360
+	default:
+		return 31 * 360
+	}
+}
+
+// The requests of the UE with the emergency or high priority RRC establishment cause are not rejected by the
+// congestion control (TS 23.501 5.19.7.2)
+func (ranUe *RanUe) CongestionControlExempted() bool {
+	rrcEstablishmentCause, err := strconv.Atoi(ranUe.RRCEstablishmentCause)
+	if err != nil {
+		return false
+	}
+	switch int64(rrcEstablishmentCause) {
+	case int64(ngapType.RRCEstablishmentCausePresentEmergency),
+		int64(ngapType.RRCEstablishmentCausePresentHighPriorityAccess),
+		int64(ngapType.RRCEstablishmentCausePresentMpsPriorityAccess),
+		int64(ngapType.RRCEstablishmentCausePresentMcsPriorityAccess):
+		return true
+	default:
+		return false
+	}
+}
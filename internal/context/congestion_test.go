@@ -0,0 +1,64 @@
+package context
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/free5gc/amf/pkg/factory"
+	"github.com/free5gc/nas/nasMessage"
+	"github.com/free5gc/openapi/models"
+)
+
+func TestGprsTimer2Value(t *testing.T) {
+	testCases := []struct {
+		seconds  int
+		expected int
+	}{
+		{seconds: 0, expected: 2},
+		{seconds: 45, expected: 44},
+		{seconds: 63, expected: 60},
+		{seconds: 1000, expected: 960},
+		{seconds: 1900, expected: 1800},
+		{seconds: 20000, expected: 11160},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.expected, gprsTimer2Value(tc.seconds))
+	}
+}
+
+func TestCongestionControl(t *testing.T) {
+	self := GetSelf()
+	defer func() {
+		self.CongestionControl = nil
+		self.Load.SetOverloaded(false)
+	}()
+
+	self.CongestionControl = &factory.Congestion{
+		MobilityManagement: true,
+		T3346:              &factory.BackoffTimer{Min: 120, Max: 600},
+		DnnList:            []string{"internet"},
+		SnssaiList:         []models.Snssai{{Sst: 1, Sd: "010203"}},
+	}
+	snssai := models.Snssai{Sst: 1, Sd: "010203"}
+
+	// the congestion control is not active until the AMF is overloaded
+	require.False(t, self.MmCongestionActive())
+	require.Zero(t, self.SmCongestionCause(snssai, "internet"))
+
+	self.Load.SetOverloaded(true)
+	require.True(t, self.MmCongestionActive())
+	require.Equal(t, nasMessage.Cause5GMMInsufficientResourcesForSpecificSliceAndDNN,
+		self.SmCongestionCause(snssai, "internet"))
+	require.Equal(t, nasMessage.Cause5GMMInsufficientResourcesForSpecificSlice, self.SmCongestionCause(snssai, "ims"))
+	require.Equal(t, nasMessage.Cause5GMMCongestion, self.SmCongestionCause(models.Snssai{Sst: 2}, "internet"))
+	require.Zero(t, self.SmCongestionCause(models.Snssai{Sst: 2}, "ims"))
+
+	for i := 0; i < 10; i++ {
+		t3346 := self.T3346Value()
+		require.GreaterOrEqual(t, t3346, 120)
+		require.LessOrEqual(t, t3346, 600)
+		require.Zero(t, t3346%60)
+	}
+}
@@ -26,6 +26,8 @@ var (
 	tmsiGenerator                    *idgenerator.IDGenerator = nil
 	amfUeNGAPIDGenerator             *idgenerator.IDGenerator = nil
 	amfStatusSubscriptionIDGenerator *idgenerator.IDGenerator = nil
+	n2NotifySubscriptionIDGenerator  *idgenerator.IDGenerator = nil
+	trsrGenerator                    *idgenerator.IDGenerator = nil
 )
 
 func init() {
@@ -40,7 +42,9 @@ func init() {
 	GetSelf().NetworkName.Full = "free5GC"
 	tmsiGenerator = idgenerator.NewGenerator(1, math.MaxInt32)
 	amfStatusSubscriptionIDGenerator = idgenerator.NewGenerator(1, math.MaxInt32)
+	n2NotifySubscriptionIDGenerator = idgenerator.NewGenerator(1, math.MaxInt32)
 	amfUeNGAPIDGenerator = idgenerator.NewGenerator(1, MaxValueOfAmfUeNgapId)
+	trsrGenerator = idgenerator.NewGenerator(1, MaxValueOfTrsr)
 }
 
 type NFContext interface {
@@ -55,6 +59,7 @@ type AMFContext struct {
 	UePool                       sync.Map                // map[supi]*AmfUe
 	RanUePool                    sync.Map                // map[AmfUeNgapID]*RanUe
 	AmfRanPool                   sync.Map                // map[net.Conn]*AmfRan
+	PwsTransactions              sync.Map                // map[pwsTransactionKey]*PwsTransaction
 	LadnPool                     map[string]factory.Ladn // dnn as key
 	SupportTaiLists              []models.Tai
 	ServedGuamiList              []models.Guami
@@ -71,6 +76,7 @@ type AMFContext struct {
 	TNLWeightFactor              int64
 	SupportDnnLists              []string
 	AMFStatusSubscriptions       sync.Map // map[subscriptionID]models.SubscriptionData
+	NonUeN2InfoSubscriptions     sync.Map // map[n2NotifySubscriptionID]models.NonUeN2InfoSubscriptionCreateData
 	NrfUri                       string
 	NrfCertPem                   string
 	SecurityAlgorithm            SecurityAlgorithm
@@ -90,6 +96,28 @@ type AMFContext struct {
 	T3570Cfg factory.TimerValue
 	T3555Cfg factory.TimerValue
 	Locality string
+	// paging area escalated on T3513 expiry, the registration area is paged if nil
+	PagingPolicy *factory.PagingPolicy
+	// emergency services are not supported if nil
+	Emergency *factory.Emergency
+	// MICO mode is not allowed if nil
+	Mico *factory.Mico
+	// eDRX is not allowed if nil
+	Edrx *factory.Edrx
+	// the trace events are not reported if nil
+	TraceCollectionEntity *factory.Tce
+	// the NG-RAN nodes are not requested to reduce the load if nil
+	OverloadControl *factory.OverloadControl
+	Load            AmfLoad
+	// the requests of the UEs are not rejected by the NAS level congestion control if nil
+	CongestionControl *factory.Congestion
+	// the UE contexts are released on the loss of the NG connection if nil
+	UeRetention *factory.UeRetention
+	// the RANs lost the NG connection with the UE contexts retained, Global RAN Node ID as key
+	retainedRans      map[string]*retainedRan
+	retainedRansMutex sync.Mutex
+	// guards Name, ServedGuamiList, PlmnSupportList and RelativeCapacity updated at runtime by the OAM
+	configMutex sync.RWMutex
 
 	OAuth2Required bool
 }
@@ -154,6 +182,14 @@ func InitAmfContext(context *AMFContext) {
 	context.T3570Cfg = configuration.T3570
 	context.T3555Cfg = configuration.T3555
 	context.Locality = configuration.Locality
+	context.PagingPolicy = configuration.PagingPolicy
+	context.Emergency = configuration.Emergency
+	context.Mico = configuration.Mico
+	context.Edrx = configuration.Edrx
+	context.TraceCollectionEntity = configuration.TraceCollectionEntity
+	context.OverloadControl = configuration.OverloadControl
+	context.CongestionControl = configuration.CongestionControl
+	context.UeRetention = configuration.UeRetention
 }
 
 func getIntAlgOrder(integrityOrder []string) (intOrder []uint8) {
@@ -215,7 +251,7 @@ func (context *AMFContext) AllocateAmfUeNgapID() (int64, error) {
 }
 
 func (context *AMFContext) AllocateGutiToUe(ue *AmfUe) {
-	servedGuami := context.ServedGuamiList[0]
+	servedGuami := context.GetServedGuamiList()[0]
 	ue.Tmsi = context.TmsiAllocate()
 
 	plmnID := servedGuami.PlmnId.Mcc + servedGuami.PlmnId.Mnc
@@ -274,6 +310,54 @@ func (context *AMFContext) DeleteAMFStatusSubscription(subscriptionID string) {
 	}
 }
 
+func (context *AMFContext) NewNonUeN2InfoSubscription(subscriptionData models.NonUeN2InfoSubscriptionCreateData) (
+	n2NotifySubscriptionID string,
+) {
+	id, err := n2NotifySubscriptionIDGenerator.Allocate()
+	if err != nil {
+		logger.CtxLog.Errorf("Allocate n2NotifySubscriptionID error: %+v", err)
+		return ""
+	}
+
+	n2NotifySubscriptionID = strconv.Itoa(int(id))
+	context.NonUeN2InfoSubscriptions.Store(n2NotifySubscriptionID, subscriptionData)
+	return
+}
+
+func (context *AMFContext) FindNonUeN2InfoSubscription(n2NotifySubscriptionID string) (
+	*models.NonUeN2InfoSubscriptionCreateData, bool,
+) {
+	if value, ok := context.NonUeN2InfoSubscriptions.Load(n2NotifySubscriptionID); ok {
+		subscriptionData := value.(models.NonUeN2InfoSubscriptionCreateData)
+		return &subscriptionData, ok
+	}
+	return nil, false
+}
+
+func (context *AMFContext) DeleteNonUeN2InfoSubscription(n2NotifySubscriptionID string) {
+	context.NonUeN2InfoSubscriptions.Delete(n2NotifySubscriptionID)
+	if id, err := strconv.ParseInt(n2NotifySubscriptionID, 10, 64); err != nil {
+		logger.CtxLog.Error(err)
+	} else {
+		n2NotifySubscriptionIDGenerator.FreeID(id)
+	}
+}
+
+// Return the subscriptions to the N2 information of the class, which are keyed by n2NotifySubscriptionID
+func (context *AMFContext) NonUeN2InfoSubscriptionsOfClass(n2class models.N2InformationClass) (
+	subscriptions map[string]models.NonUeN2InfoSubscriptionCreateData,
+) {
+	subscriptions = make(map[string]models.NonUeN2InfoSubscriptionCreateData)
+	context.NonUeN2InfoSubscriptions.Range(func(key, value interface{}) bool {
+		subscriptionData := value.(models.NonUeN2InfoSubscriptionCreateData)
+		if subscriptionData.N2InformationClass == n2class {
+			subscriptions[key.(string)] = subscriptionData
+		}
+		return true
+	})
+	return subscriptions
+}
+
 func (context *AMFContext) NewEventSubscription(subscriptionID string, subscription *AMFContextEventSubscription) {
 	context.EventSubscriptions.Store(subscriptionID, subscription)
 }
@@ -303,6 +387,11 @@ func (context *AMFContext) AddAmfUeToUePool(ue *AmfUe, supi string) {
 	context.UePool.Store(ue.Supi, ue)
 }
 
+// The UE registered for emergency services without SUPI is identified by the PEI (TS 23.502 4.2.2.2.2)
+func (context *AMFContext) AddPeiOnlyAmfUeToUePool(ue *AmfUe) {
+	context.UePool.Store(ue.Pei, ue)
+}
+
 func (context *AMFContext) NewAmfUe(supi string) *AmfUe {
 	ue := AmfUe{}
 	ue.init()
@@ -437,7 +526,7 @@ func (context *AMFContext) InSupportDnnList(targetDnn string) bool {
 }
 
 func (context *AMFContext) InPlmnSupportList(snssai models.Snssai) bool {
-	for _, plmnSupportItem := range context.PlmnSupportList {
+	for _, plmnSupportItem := range context.GetPlmnSupportList() {
 		for _, supportSnssai := range plmnSupportItem.SNssaiList {
 			if openapi.SnssaiEqualFold(supportSnssai, snssai) {
 				return true
@@ -447,6 +536,73 @@ func (context *AMFContext) InPlmnSupportList(snssai models.Snssai) bool {
 	return false
 }
 
+// The S-NSSAI is supported by the AMF in the PLMN
+func (context *AMFContext) InPlmnSupportListOfPlmn(plmnId models.PlmnId, snssai models.Snssai) bool {
+	for _, plmnSupportItem := range context.GetPlmnSupportList() {
+		if plmnSupportItem.PlmnId == nil || !reflect.DeepEqual(*plmnSupportItem.PlmnId, plmnId) {
+			continue
+		}
+		if snssaiInList(snssai, plmnSupportItem.SNssaiList) {
+			return true
+		}
+	}
+	return false
+}
+
+// The AMF name, served GUAMIs, PLMN support list and relative capacity can be updated at runtime by the OAM, they
+// are read through the accessors below. The lists are replaced as a whole and never modified in place.
+func (context *AMFContext) GetName() string {
+	context.configMutex.RLock()
+	defer context.configMutex.RUnlock()
+	return context.Name
+}
+
+func (context *AMFContext) GetServedGuamiList() []models.Guami {
+	context.configMutex.RLock()
+	defer context.configMutex.RUnlock()
+	return context.ServedGuamiList
+}
+
+func (context *AMFContext) GetPlmnSupportList() []factory.PlmnSupportItem {
+	context.configMutex.RLock()
+	defer context.configMutex.RUnlock()
+	return context.PlmnSupportList
+}
+
+func (context *AMFContext) GetRelativeCapacity() int64 {
+	context.configMutex.RLock()
+	defer context.configMutex.RUnlock()
+	return context.RelativeCapacity
+}
+
+// Update the AMF configuration at runtime, the empty name and the nil items are not changed. It returns whether the
+// configuration is changed.
+func (context *AMFContext) UpdateAmfConfiguration(name string, servedGuamiList []models.Guami,
+	plmnSupportList []factory.PlmnSupportItem, relativeCapacity *int64,
+) bool {
+	context.configMutex.Lock()
+	defer context.configMutex.Unlock()
+
+	changed := false
+	if name != "" && name != context.Name {
+		context.Name = name
+		changed = true
+	}
+	if servedGuamiList != nil && !reflect.DeepEqual(servedGuamiList, context.ServedGuamiList) {
+		context.ServedGuamiList = servedGuamiList
+		changed = true
+	}
+	if plmnSupportList != nil && !reflect.DeepEqual(plmnSupportList, context.PlmnSupportList) {
+		context.PlmnSupportList = plmnSupportList
+		changed = true
+	}
+	if relativeCapacity != nil && *relativeCapacity != context.RelativeCapacity {
+		context.RelativeCapacity = *relativeCapacity
+		changed = true
+	}
+	return changed
+}
+
 func (context *AMFContext) AmfUeFindByGuti(guti string) (*AmfUe, bool) {
 	var ue *AmfUe
 	var ok bool
@@ -0,0 +1,38 @@
+package context
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/free5gc/amf/pkg/factory"
+	"github.com/free5gc/openapi/models"
+)
+
+func TestUpdateAmfConfiguration(t *testing.T) {
+	c := &AMFContext{
+		Name:             "amf",
+		RelativeCapacity: 0xff,
+		ServedGuamiList: []models.Guami{
+			{PlmnId: &models.PlmnIdNid{Mcc: "208", Mnc: "93"}, AmfId: "cafe00"},
+		},
+		PlmnSupportList: []factory.PlmnSupportItem{
+			{PlmnId: &models.PlmnId{Mcc: "208", Mnc: "93"}, SNssaiList: []models.Snssai{{Sst: 1}}},
+		},
+	}
+
+	// nothing is changed by the absent or the same items
+	relativeCapacity := int64(0xff)
+	require.False(t, c.UpdateAmfConfiguration("", nil, nil, nil))
+	require.False(t, c.UpdateAmfConfiguration("amf", c.GetServedGuamiList(), nil, &relativeCapacity))
+
+	servedGuamiList := []models.Guami{
+		{PlmnId: &models.PlmnIdNid{Mcc: "208", Mnc: "93"}, AmfId: "cafe01"},
+	}
+	relativeCapacity = 10
+	require.True(t, c.UpdateAmfConfiguration("amf1", servedGuamiList, nil, &relativeCapacity))
+	require.Equal(t, "amf1", c.GetName())
+	require.Equal(t, servedGuamiList, c.GetServedGuamiList())
+	require.Equal(t, int64(10), c.GetRelativeCapacity())
+	require.Len(t, c.GetPlmnSupportList(), 1)
+}
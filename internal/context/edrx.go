@@ -0,0 +1,91 @@
+package context
+
+import (
+	"time"
+)
+
+// Extended DRX parameters (TS 24.501 9.11.3.60), the eDRX value and the paging time window are coded as
+// TS 24.008 10.5.5.32 for WB-S1 mode
+type EdrxParameters struct {
+	Value            uint8
+	PagingTimeWindow uint8
+}
+
+// eDRX cycle lengths of the eDRX values (TS 24.008 table 10.5.5.32)
+var edrxCycles = [16]time.Duration{
+	5120 * time.Millisecond,
+	10240 * time.Millisecond,
+	20480 * time.Millisecond,
+	40960 * time.Millisecond,
+	61440 * time.Millisecond,
+	81920 * time.Millisecond,
+	102400 * time.Millisecond,
+	122880 * time.Millisecond,
+	143360 * time.Millisecond,
+	163840 * time.Millisecond,
+	327680 * time.Millisecond,
+	655360 * time.Millisecond,
+	1310720 * time.Millisecond,
+	2621440 * time.Millisecond,
+	5242880 * time.Millisecond,
+	10485760 * time.Millisecond,
+}
+
+const pagingTimeWindowUnit = 1280 * time.Millisecond
+
+// Decode the value part of the Extended DRX parameters IE
+func NewEdrxParameters(octet uint8) *EdrxParameters {
+	return &EdrxParameters{
+		Value:            octet & 0x0f,
+		PagingTimeWindow: octet >> 4,
+	}
+}
+
+// Encode the value part of the Extended DRX parameters IE
+func (p *EdrxParameters) Octet() uint8 {
+	return p.PagingTimeWindow<<4 | p.Value&0x0f
+}
+
+func (p *EdrxParameters) Cycle() time.Duration {
+	return edrxCycles[p.Value&0x0f]
+}
+
+func (p *EdrxParameters) PagingTimeWindowLength() time.Duration {
+	return time.Duration(p.PagingTimeWindow+1) * pagingTimeWindowUnit
+}
+
+// The eDRX value of the longest eDRX cycle not exceeding maxCycle, false if maxCycle is shorter than all the cycles
+func EdrxValueOfMaxCycle(maxCycle time.Duration) (uint8, bool) {
+	for value := len(edrxCycles) - 1; value >= 0; value-- {
+		if edrxCycles[value] <= maxCycle {
+			return uint8(value), true
+		}
+	}
+	return 0, false
+}
+
+// The paging time window value of the shortest paging time window not shorter than length
+func PagingTimeWindowOfLength(length time.Duration) uint8 {
+	ptw := (length + pagingTimeWindowUnit - 1) / pagingTimeWindowUnit
+	if ptw <= 1 {
+		return 0
+	} else if ptw > 16 {
+		return 15
+	}
+	return uint8(ptw - 1)
+}
+
+// The time until the next paging time window of the UE in eDRX, zero if the UE is in its paging time window or
+// eDRX is not used. The AMF is not aware of the H-SFN timing of the NG-RAN, so the paging time windows are
+// estimated to start every eDRX cycle since the UE entered CM-IDLE.
+func (ue *AmfUe) TimeToPagingTimeWindow(now time.Time) time.Duration {
+	if ue.Edrx == nil || ue.IdleSince.IsZero() {
+		return 0
+	}
+	cycle := ue.Edrx.Cycle()
+	elapsed := now.Sub(ue.IdleSince) % cycle
+	if elapsed < ue.Edrx.PagingTimeWindowLength() {
+		return 0
+	}
+	return cycle - elapsed
+}
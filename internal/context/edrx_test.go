@@ -0,0 +1,37 @@
+package context
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEdrxParameters(t *testing.T) {
+	edrx := NewEdrxParameters(0x53)
+	require.Equal(t, uint8(0x53), edrx.Octet())
+	require.Equal(t, 40960*time.Millisecond, edrx.Cycle())
+	require.Equal(t, 7680*time.Millisecond, edrx.PagingTimeWindowLength())
+
+	value, ok := EdrxValueOfMaxCycle(150 * time.Second)
+	require.True(t, ok)
+	require.Equal(t, uint8(8), value)
+	_, ok = EdrxValueOfMaxCycle(5 * time.Second)
+	require.False(t, ok)
+
+	require.Equal(t, uint8(0), PagingTimeWindowOfLength(time.Second))
+	require.Equal(t, uint8(7), PagingTimeWindowOfLength(10*time.Second))
+	require.Equal(t, uint8(15), PagingTimeWindowOfLength(time.Minute))
+}
+
+func TestTimeToPagingTimeWindow(t *testing.T) {
+	idleSince := time.Now()
+	ue := &AmfUe{IdleSince: idleSince}
+	require.Zero(t, ue.TimeToPagingTimeWindow(idleSince.Add(time.Minute)))
+
+	// cycle 40.96s, paging time window 7.68s
+	ue.Edrx = NewEdrxParameters(0x53)
+	require.Zero(t, ue.TimeToPagingTimeWindow(idleSince.Add(5*time.Second)))
+	require.Equal(t, 30960*time.Millisecond, ue.TimeToPagingTimeWindow(idleSince.Add(10*time.Second)))
+	require.Zero(t, ue.TimeToPagingTimeWindow(idleSince.Add(42*time.Second)))
+}
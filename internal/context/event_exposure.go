@@ -0,0 +1,333 @@
+package context
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/free5gc/openapi/models"
+)
+
+// The notification of the event report to the event notify URI of the subscription
+type AmfEventNotify struct {
+	SubscriptionId string
+	Uri            string
+	Notification   models.AmfEventNotification
+}
+
+// The report of the event with the current state of the UE, the state of the subscription is not included
+func (ue *AmfUe) NewAmfEventReport(eventType models.AmfEventType) models.AmfEventReport {
+	report := models.AmfEventReport{
+		Type: eventType,
+		Supi: ue.Supi,
+		Gpsi: ue.Gpsi,
+		Pei:  ue.Pei,
+	}
+	now := time.Now().UTC()
+	report.TimeStamp = &now
+
+	switch eventType {
+	case models.AmfEventType_LOCATION_REPORT:
+		location := ue.GetLocation()
+		report.Location = &location
+	case models.AmfEventType_TIMEZONE_REPORT:
+		report.Timezone = ue.TimeZone
+	case models.AmfEventType_ACCESS_TYPE_REPORT:
+		for accessType, state := range ue.State {
+			if state.Is(Registered) {
+				report.AccessTypeList = append(report.AccessTypeList, accessType)
+			}
+		}
+	case models.AmfEventType_REGISTRATION_STATE_REPORT:
+		var rmInfos []models.RmInfo
+		for accessType, state := range ue.State {
+			rmInfo := models.RmInfo{
+				RmState:    models.RmState_DEREGISTERED,
+				AccessType: accessType,
+			}
+			if state.Is(Registered) {
+				rmInfo.RmState = models.RmState_REGISTERED
+			}
+			rmInfos = append(rmInfos, rmInfo)
+		}
+		report.RmInfoList = rmInfos
+	case models.AmfEventType_CONNECTIVITY_STATE_REPORT:
+		report.CmInfoList = ue.GetCmInfo()
+	case models.AmfEventType_REACHABILITY_REPORT:
+		ue.reachabilityTimersMu.Lock()
+		report.Reachability = ue.Reachability
+		ue.reachabilityTimersMu.Unlock()
+	}
+	return report
+}
+
+// TS 29.518 6.2.6.2.16, the presence of the location in the area of interest. The presence is UNKNOWN if
+// the area is identified by the PRA ID only, or the LADN DNN is not configured.
+func PresenceInArea(location models.UserLocation, area models.AmfEventArea) models.PresenceState {
+	var tai *models.Tai
+	if location.NrLocation != nil {
+		tai = location.NrLocation.Tai
+	} else if location.EutraLocation != nil {
+		tai = location.EutraLocation.Tai
+	}
+	if tai == nil {
+		return models.PresenceState_UNKNOWN
+	}
+
+	if area.LadnInfo != nil {
+		ladn, ok := GetSelf().LadnPool[area.LadnInfo.Ladn]
+		if !ok {
+			return models.PresenceState_UNKNOWN
+		}
+		if InTaiList(*tai, ladn.TaiList) {
+			return models.PresenceState_IN_AREA
+		}
+		return models.PresenceState_OUT_OF_AREA
+	}
+
+	presenceInfo := area.PresenceInfo
+	if presenceInfo == nil {
+		return models.PresenceState_UNKNOWN
+	}
+	if len(presenceInfo.TrackingAreaList) == 0 && len(presenceInfo.NcgiList) == 0 &&
+		len(presenceInfo.EcgiList) == 0 && len(presenceInfo.GlobalRanNodeIdList) == 0 &&
+		len(presenceInfo.GlobaleNbIdList) == 0 {
+		return models.PresenceState_UNKNOWN
+	}
+	if InTaiList(*tai, presenceInfo.TrackingAreaList) {
+		return models.PresenceState_IN_AREA
+	}
+	if nrLocation := location.NrLocation; nrLocation != nil {
+		for _, ncgi := range presenceInfo.NcgiList {
+			if nrLocation.Ncgi != nil && reflect.DeepEqual(ncgi, *nrLocation.Ncgi) {
+				return models.PresenceState_IN_AREA
+			}
+		}
+		for _, ranNodeId := range presenceInfo.GlobalRanNodeIdList {
+			if nrLocation.GlobalGnbId != nil && reflect.DeepEqual(ranNodeId, *nrLocation.GlobalGnbId) {
+				return models.PresenceState_IN_AREA
+			}
+		}
+	} else if eutraLocation := location.EutraLocation; eutraLocation != nil {
+		for _, ecgi := range presenceInfo.EcgiList {
+			if eutraLocation.Ecgi != nil && reflect.DeepEqual(ecgi, *eutraLocation.Ecgi) {
+				return models.PresenceState_IN_AREA
+			}
+		}
+		for _, ranNodeId := range presenceInfo.GlobaleNbIdList {
+			if eutraLocation.GlobalNgenbId != nil && reflect.DeepEqual(ranNodeId, *eutraLocation.GlobalNgenbId) {
+				return models.PresenceState_IN_AREA
+			}
+		}
+	}
+	return models.PresenceState_OUT_OF_AREA
+}
+
+// The areas of the event with the presence of the UE in them, and whether the presence is changed since
+// the last evaluation of the subscription
+func (ue *AmfUe) AreaPresence(event models.AmfEvent, ueSubscription *AmfUeEventSubscription) (
+	[]models.AmfEventArea, bool,
+) {
+	if ueSubscription.AreaPresence == nil {
+		ueSubscription.AreaPresence = make(map[models.AmfEventType][]models.PresenceState)
+	}
+	lastPresences := ueSubscription.AreaPresence[event.Type]
+	changed := len(lastPresences) != len(event.AreaList)
+
+	var areaList []models.AmfEventArea
+	var presences []models.PresenceState
+	for i, area := range event.AreaList {
+		presence := PresenceInArea(ue.GetLocation(), area)
+		if !changed && lastPresences[i] != presence {
+			changed = true
+		}
+		presences = append(presences, presence)
+
+		if area.LadnInfo != nil {
+			area.LadnInfo = &models.LadnInfo{
+				Ladn:     area.LadnInfo.Ladn,
+				Presence: presence,
+			}
+		} else if area.PresenceInfo != nil {
+			area.PresenceInfo = &models.PresenceInfo{
+				PraId:           area.PresenceInfo.PraId,
+				AdditionalPraId: area.PresenceInfo.AdditionalPraId,
+				PresenceState:   presence,
+			}
+		}
+		areaList = append(areaList, area)
+	}
+	ueSubscription.AreaPresence[event.Type] = presences
+	return areaList, changed
+}
+
+// Whether the UE is targeted by the subscription of a single UE, any UE or a group
+func (subscription *AMFContextEventSubscription) includesUe(ue *AmfUe) bool {
+	if subscription.IsAnyUe {
+		return true
+	}
+	if subscription.IsGroupUe {
+		return ue.GroupID != "" && ue.GroupID == subscription.EventSubscription.GroupId
+	}
+	return ue.Supi == subscription.EventSubscription.Supi
+}
+
+// TS 23.502 4.15.4.2, the number of the UEs targeted by the subscription which are present in any of the areas
+func (subscription *AMFContextEventSubscription) NumberOfUesInArea(areaList []models.AmfEventArea) int32 {
+	var numberOfUes int32
+	GetSelf().UePool.Range(func(key, value interface{}) bool {
+		ue := value.(*AmfUe)
+		if !subscription.includesUe(ue) {
+			return true
+		}
+		for _, area := range areaList {
+			// the other UEs are not locked, their locations are taken by the snapshots
+			if PresenceInArea(ue.GetLocation(), area) == models.PresenceState_IN_AREA {
+				numberOfUes++
+				break
+			}
+		}
+		return true
+	})
+	return numberOfUes
+}
+
+// The UE registered after the subscription of any UE or of its group is created joins the subscription
+func (ue *AmfUe) joinEventSubscriptions() {
+	GetSelf().EventSubscriptions.Range(func(key, value interface{}) bool {
+		subscriptionID := key.(string)
+		subscription := value.(*AMFContextEventSubscription)
+		if _, ok := ue.EventSubscriptionsInfo[subscriptionID]; ok {
+			return true
+		}
+		if !subscription.IsAnyUe &&
+			!(subscription.IsGroupUe && ue.GroupID != "" && ue.GroupID == subscription.EventSubscription.GroupId) {
+			return true
+		}
+		for _, supi := range subscription.UeSupiList {
+			// the subscription of the UE has been removed, e.g. the max number of reports is reached
+			if supi == ue.Supi {
+				return true
+			}
+		}
+
+		ueEventSubscription := &AmfUeEventSubscription{
+			Timestamp: time.Now().UTC(),
+			AnyUe:     true,
+			EventSubscription: &models.ExtAmfEventSubscription{
+				EventList:           subscription.EventSubscription.EventList,
+				EventNotifyUri:      subscription.EventSubscription.EventNotifyUri,
+				NotifyCorrelationId: subscription.EventSubscription.NotifyCorrelationId,
+				NfId:                subscription.EventSubscription.NfId,
+				GroupId:             subscription.EventSubscription.GroupId,
+				AnyUE:               subscription.EventSubscription.AnyUE,
+				Options:             subscription.EventSubscription.Options,
+				SourceNfType:        subscription.EventSubscription.SourceNfType,
+			},
+		}
+		if options := subscription.EventSubscription.Options; options != nil &&
+			options.Trigger == models.AmfEventTrigger_CONTINUOUS {
+			ueEventSubscription.RemainReports = new(int32)
+			*ueEventSubscription.RemainReports = options.MaxReports
+		}
+		ue.EventSubscriptionsInfo[subscriptionID] = ueEventSubscription
+		subscription.UeSupiList = append(subscription.UeSupiList, ue.Supi)
+		return true
+	})
+}
+
+// Evaluate the event subscriptions of the UE against the report of the event (TS 29.518 6.2.6.2.3), and return
+// the notifications to be sent. The subscription of the UE is removed once its last report is made or it expires.
+// The caller shall hold ue.Lock.
+func (ue *AmfUe) EvaluateEventSubscriptions(report models.AmfEventReport) []AmfEventNotify {
+	amfSelf := GetSelf()
+	var notifies []AmfEventNotify
+
+	ue.joinEventSubscriptions()
+	for subscriptionID, ueSubscription := range ue.EventSubscriptionsInfo {
+		subscription, ok := amfSelf.FindEventSubscription(subscriptionID)
+		if !ok {
+			delete(ue.EventSubscriptionsInfo, subscriptionID)
+			continue
+		}
+		event, ok := subscription.subscribedEvent(report.Type)
+		if !ok {
+			continue
+		}
+
+		options := subscription.EventSubscription.Options
+		state := &models.AmfEventState{Active: true}
+		if subscription.Expiry != nil {
+			if !time.Now().Before(*subscription.Expiry) {
+				ue.removeEventSubscription(subscriptionID, subscription)
+				continue
+			}
+			state.RemainDuration = int32(time.Until(*subscription.Expiry).Seconds())
+		}
+
+		eventReport := report
+		eventReport.RefId = event.RefId
+		switch report.Type {
+		case models.AmfEventType_PRESENCE_IN_AOI_REPORT:
+			// reported only when the presence of the UE in the areas changes
+			areaList, changed := ue.AreaPresence(*event, ueSubscription)
+			if !changed {
+				continue
+			}
+			eventReport.AreaList = areaList
+		case models.AmfEventType_UES_IN_AREA_REPORT:
+			// reported only when the UE enters or leaves the areas, which changes the number of UEs
+			if _, changed := ue.AreaPresence(*event, ueSubscription); !changed {
+				continue
+			}
+			eventReport.Supi = ""
+			eventReport.Gpsi = ""
+			eventReport.Pei = ""
+			eventReport.AreaList = event.AreaList
+			eventReport.NumberOfUes = subscription.NumberOfUesInArea(event.AreaList)
+		}
+		if options != nil && options.Trigger == models.AmfEventTrigger_ONE_TIME {
+			state.Active = false
+		} else if options != nil && options.MaxReports > 0 && ueSubscription.RemainReports != nil {
+			// the number of reports is not limited if maxReports is absent
+			*ueSubscription.RemainReports--
+			state.RemainReports = *ueSubscription.RemainReports
+			state.Active = state.RemainReports > 0
+		}
+
+		eventReport.AnyUe = ueSubscription.AnyUe
+		eventReport.State = state
+		notifies = append(notifies, AmfEventNotify{
+			SubscriptionId: subscriptionID,
+			Uri:            subscription.EventSubscription.EventNotifyUri,
+			Notification: models.AmfEventNotification{
+				NotifyCorrelationId: subscription.EventSubscription.NotifyCorrelationId,
+				ReportList:          []models.AmfEventReport{eventReport},
+			},
+		})
+
+		if !state.Active {
+			ue.removeEventSubscription(subscriptionID, subscription)
+		}
+	}
+	return notifies
+}
+
+func (subscription *AMFContextEventSubscription) subscribedEvent(eventType models.AmfEventType) (
+	*models.AmfEvent, bool,
+) {
+	for i := range subscription.EventSubscription.EventList {
+		if subscription.EventSubscription.EventList[i].Type == eventType {
+			return &subscription.EventSubscription.EventList[i], true
+		}
+	}
+	return nil, false
+}
+
+// The subscription of a single UE is removed entirely, while only the UE leaves the subscription of
+// any UE or of a group
+func (ue *AmfUe) removeEventSubscription(subscriptionID string, subscription *AMFContextEventSubscription) {
+	delete(ue.EventSubscriptionsInfo, subscriptionID)
+	if !subscription.IsAnyUe && !subscription.IsGroupUe {
+		GetSelf().DeleteEventSubscription(subscriptionID)
+	}
+}
@@ -0,0 +1,179 @@
+package context
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/free5gc/amf/pkg/factory"
+	"github.com/free5gc/openapi/models"
+)
+
+func newTestEventSubscription(t *testing.T, ue *AmfUe, options *models.AmfEventMode) string {
+	id, err := GetSelf().EventSubscriptionIDGenerator.Allocate()
+	require.NoError(t, err)
+	subscriptionID := strconv.Itoa(int(id))
+
+	subscription := &AMFContextEventSubscription{
+		UeSupiList: []string{ue.Supi},
+		EventSubscription: models.AmfEventSubscription{
+			EventList: []models.AmfEvent{
+				{Type: models.AmfEventType_LOCATION_REPORT},
+			},
+			EventNotifyUri: "https://nwdaf.example/notify",
+			Supi:           ue.Supi,
+			Options:        options,
+		},
+	}
+	if options != nil {
+		subscription.Expiry = options.Expiry
+	}
+	GetSelf().NewEventSubscription(subscriptionID, subscription)
+
+	ueSubscription := &AmfUeEventSubscription{
+		EventSubscription: &models.ExtAmfEventSubscription{},
+	}
+	if options != nil && options.Trigger == models.AmfEventTrigger_CONTINUOUS {
+		ueSubscription.RemainReports = new(int32)
+		*ueSubscription.RemainReports = options.MaxReports
+	}
+	ue.EventSubscriptionsInfo[subscriptionID] = ueSubscription
+	return subscriptionID
+}
+
+func TestEvaluateEventSubscriptionsRemainReports(t *testing.T) {
+	ue := &AmfUe{
+		Supi:                   "imsi-208930000000001",
+		EventSubscriptionsInfo: make(map[string]*AmfUeEventSubscription),
+	}
+	subscriptionID := newTestEventSubscription(t, ue, &models.AmfEventMode{
+		Trigger:    models.AmfEventTrigger_CONTINUOUS,
+		MaxReports: 2,
+	})
+
+	// not subscribed event
+	notifies := ue.EvaluateEventSubscriptions(ue.NewAmfEventReport(models.AmfEventType_TIMEZONE_REPORT))
+	require.Empty(t, notifies)
+
+	notifies = ue.EvaluateEventSubscriptions(ue.NewAmfEventReport(models.AmfEventType_LOCATION_REPORT))
+	require.Len(t, notifies, 1)
+	require.Equal(t, "https://nwdaf.example/notify", notifies[0].Uri)
+	state := notifies[0].Notification.ReportList[0].State
+	require.True(t, state.Active)
+	require.Equal(t, int32(1), state.RemainReports)
+
+	notifies = ue.EvaluateEventSubscriptions(ue.NewAmfEventReport(models.AmfEventType_LOCATION_REPORT))
+	require.Len(t, notifies, 1)
+	require.False(t, notifies[0].Notification.ReportList[0].State.Active)
+
+	// the subscription is removed after the last report
+	_, ok := GetSelf().FindEventSubscription(subscriptionID)
+	require.False(t, ok)
+	notifies = ue.EvaluateEventSubscriptions(ue.NewAmfEventReport(models.AmfEventType_LOCATION_REPORT))
+	require.Empty(t, notifies)
+}
+
+func TestEvaluateEventSubscriptionsExpiry(t *testing.T) {
+	ue := &AmfUe{
+		Supi:                   "imsi-208930000000002",
+		EventSubscriptionsInfo: make(map[string]*AmfUeEventSubscription),
+	}
+	expiry := time.Now().Add(-time.Second)
+	subscriptionID := newTestEventSubscription(t, ue, &models.AmfEventMode{
+		Trigger: models.AmfEventTrigger_CONTINUOUS,
+		Expiry:  &expiry,
+	})
+
+	notifies := ue.EvaluateEventSubscriptions(ue.NewAmfEventReport(models.AmfEventType_LOCATION_REPORT))
+	require.Empty(t, notifies)
+	_, ok := GetSelf().FindEventSubscription(subscriptionID)
+	require.False(t, ok)
+	require.Empty(t, ue.EventSubscriptionsInfo)
+}
+
+func TestPresenceInArea(t *testing.T) {
+	tai := models.Tai{
+		PlmnId: &models.PlmnId{Mcc: "208", Mnc: "93"},
+		Tac:    "000001",
+	}
+	otherTai := models.Tai{
+		PlmnId: &models.PlmnId{Mcc: "208", Mnc: "93"},
+		Tac:    "000002",
+	}
+	location := models.UserLocation{
+		NrLocation: &models.NrLocation{Tai: &tai},
+	}
+
+	GetSelf().LadnPool["internet-ladn"] = factory.Ladn{Dnn: "internet-ladn", TaiList: []models.Tai{otherTai}}
+	defer delete(GetSelf().LadnPool, "internet-ladn")
+
+	testCases := []struct {
+		name     string
+		area     models.AmfEventArea
+		presence models.PresenceState
+	}{
+		{
+			name: "in tracking area list",
+			area: models.AmfEventArea{PresenceInfo: &models.PresenceInfo{
+				TrackingAreaList: []models.Tai{otherTai, tai},
+			}},
+			presence: models.PresenceState_IN_AREA,
+		},
+		{
+			name: "out of tracking area list",
+			area: models.AmfEventArea{PresenceInfo: &models.PresenceInfo{
+				TrackingAreaList: []models.Tai{otherTai},
+			}},
+			presence: models.PresenceState_OUT_OF_AREA,
+		},
+		{
+			name:     "unknown presence reporting area",
+			area:     models.AmfEventArea{PresenceInfo: &models.PresenceInfo{PraId: "1"}},
+			presence: models.PresenceState_UNKNOWN,
+		},
+		{
+			name:     "out of LADN",
+			area:     models.AmfEventArea{LadnInfo: &models.LadnInfo{Ladn: "internet-ladn"}},
+			presence: models.PresenceState_OUT_OF_AREA,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.presence, PresenceInArea(location, tc.area))
+		})
+	}
+}
+
+func TestAreaPresenceChanged(t *testing.T) {
+	tai := models.Tai{
+		PlmnId: &models.PlmnId{Mcc: "208", Mnc: "93"},
+		Tac:    "000001",
+	}
+	ue := &AmfUe{
+		Location: models.UserLocation{
+			NrLocation: &models.NrLocation{Tai: &tai},
+		},
+	}
+	event := models.AmfEvent{
+		Type: models.AmfEventType_PRESENCE_IN_AOI_REPORT,
+		AreaList: []models.AmfEventArea{
+			{PresenceInfo: &models.PresenceInfo{PraId: "1", TrackingAreaList: []models.Tai{tai}}},
+		},
+	}
+	ueSubscription := &AmfUeEventSubscription{}
+
+	areaList, changed := ue.AreaPresence(event, ueSubscription)
+	require.True(t, changed)
+	require.Equal(t, "1", areaList[0].PresenceInfo.PraId)
+	require.Equal(t, models.PresenceState_IN_AREA, areaList[0].PresenceInfo.PresenceState)
+
+	_, changed = ue.AreaPresence(event, ueSubscription)
+	require.False(t, changed)
+
+	ue.Location.NrLocation.Tai = &models.Tai{PlmnId: tai.PlmnId, Tac: "000002"}
+	areaList, changed = ue.AreaPresence(event, ueSubscription)
+	require.True(t, changed)
+	require.Equal(t, models.PresenceState_OUT_OF_AREA, areaList[0].PresenceInfo.PresenceState)
+}
@@ -0,0 +1,68 @@
+package context
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// The load of the AMF measured for the overload control of the NG-RAN nodes (TS 23.501 5.19.5.2)
+type AmfLoad struct {
+	ngapMessages atomic.Int64 // NGAP messages dispatched since the last sample
+	dispatchTime atomic.Int64 // total dispatch time of the NGAP messages since the last sample, in nanoseconds
+	sbiRequests  atomic.Int64 // SBI consumer requests in flight
+	overloaded   atomic.Bool  // Overload Start is sent to the NG-RAN nodes
+}
+
+// The sampled load of the AMF
+type LoadSample struct {
+	UeNum           int
+	NgapMessageRate float64 // messages per second
+	DispatchLatency time.Duration
+	SbiBacklog      int64
+}
+
+func (s LoadSample) String() string {
+	return fmt.Sprintf("UEs[%d] NGAP message rate[%.1f/s] dispatch latency[%s] SBI backlog[%d]",
+		s.UeNum, s.NgapMessageRate, s.DispatchLatency, s.SbiBacklog)
+}
+
+func (l *AmfLoad) NgapMessageDispatched(dispatchTime time.Duration) {
+	l.ngapMessages.Add(1)
+	l.dispatchTime.Add(int64(dispatchTime))
+}
+
+func (l *AmfLoad) SbiRequestStarted() {
+	l.sbiRequests.Add(1)
+}
+
+func (l *AmfLoad) SbiRequestCompleted() {
+	l.sbiRequests.Add(-1)
+}
+
+// Sample the load over the interval since the last sample, the NGAP message counters are reset
+func (l *AmfLoad) Sample(interval time.Duration) LoadSample {
+	var sample LoadSample
+	GetSelf().UePool.Range(func(key, value interface{}) bool {
+		sample.UeNum++
+		return true
+	})
+	ngapMessages := l.ngapMessages.Swap(0)
+	dispatchTime := l.dispatchTime.Swap(0)
+	if interval > 0 {
+		sample.NgapMessageRate = float64(ngapMessages) / interval.Seconds()
+	}
+	if ngapMessages > 0 {
+		sample.DispatchLatency = time.Duration(dispatchTime / ngapMessages)
+	}
+	sample.SbiBacklog = l.sbiRequests.Load()
+	return sample
+}
+
+func (l *AmfLoad) Overloaded() bool {
+	return l.overloaded.Load()
+}
+
+func (l *AmfLoad) SetOverloaded(overloaded bool) {
+	l.overloaded.Store(overloaded)
+}
@@ -0,0 +1,30 @@
+package context
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAmfLoadSample(t *testing.T) {
+	var load AmfLoad
+
+	load.NgapMessageDispatched(2 * time.Millisecond)
+	load.NgapMessageDispatched(4 * time.Millisecond)
+	load.SbiRequestStarted()
+	load.SbiRequestStarted()
+	load.SbiRequestCompleted()
+
+	sample := load.Sample(2 * time.Second)
+	require.Equal(t, 1.0, sample.NgapMessageRate)
+	require.Equal(t, 3*time.Millisecond, sample.DispatchLatency)
+	require.Equal(t, int64(1), sample.SbiBacklog)
+
+	// the NGAP message counters are reset by the sample, the SBI backlog is kept until the requests are completed
+	load.SbiRequestCompleted()
+	sample = load.Sample(time.Second)
+	require.Zero(t, sample.NgapMessageRate)
+	require.Zero(t, sample.DispatchLatency)
+	require.Zero(t, sample.SbiBacklog)
+}
@@ -0,0 +1,51 @@
+package context
+
+import (
+	"context"
+)
+
+// The location request of 5GC-MT-LR (TS 23.273 6.1.2 and 6.3.1) from the GMLC, which is kept until
+// the location is determined, or until the periodic or triggered location is cancelled
+type LocationRequest struct {
+	LdrReference     string
+	HgmlcCallBackURI string
+	LmfId            string
+	LmfUri           string
+	// Periodic or triggered location request remains after the first location result
+	Deferred bool
+
+	cancel context.CancelFunc
+}
+
+func (ue *AmfUe) NewLocationRequest(ctx context.Context, ldrReference, hgmlcCallBackURI, lmfId, lmfUri string,
+	deferred bool,
+) (*LocationRequest, context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	locationRequest := &LocationRequest{
+		LdrReference:     ldrReference,
+		HgmlcCallBackURI: hgmlcCallBackURI,
+		LmfId:            lmfId,
+		LmfUri:           lmfUri,
+		Deferred:         deferred,
+		cancel:           cancel,
+	}
+	if ldrReference != "" {
+		ue.LocationRequests.Store(ldrReference, locationRequest)
+	}
+	return locationRequest, ctx
+}
+
+func (ue *AmfUe) LocationRequestFind(ldrReference string) (*LocationRequest, bool) {
+	if value, ok := ue.LocationRequests.Load(ldrReference); ok {
+		return value.(*LocationRequest), true
+	}
+	return nil, false
+}
+
+// The location determination in progress is aborted if any
+func (ue *AmfUe) DeleteLocationRequest(locationRequest *LocationRequest) {
+	locationRequest.cancel()
+	if locationRequest.LdrReference != "" {
+		ue.LocationRequests.CompareAndDelete(locationRequest.LdrReference, locationRequest)
+	}
+}
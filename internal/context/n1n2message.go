@@ -0,0 +1,80 @@
+package context
+
+import (
+	"github.com/free5gc/openapi/models"
+)
+
+// TS 29.518 5.2.2.3.1.2, the N1/N2 message transferred to the UE in CM-IDLE is buffered until the UE is reached.
+// Status of the buffered transfer is ATTEMPTING_TO_REACH_UE while the UE is paged, or
+// WAITING_FOR_ASYNCHRONOUS_TRANSFER if the message is delivered when the UE becomes CM-CONNECTED without paging;
+// the transfer ends with N1_N2_TRANSFER_INITIATED once delivered, or UE_NOT_RESPONDING if the paging fails.
+type N1N2Message struct {
+	Request     models.N1N2MessageTransferRequest
+	Status      models.N1N2MessageTransferCause
+	ResourceUri string
+}
+
+// The transfer is of asynchronous type communication, TS 23.502 4.3.3.2 step 3a: the N2 SM information to modify
+// the PDU session of the UE in CM-IDLE is kept in the UE context instead of paging the UE
+func IsAsynchronousN1N2Transfer(requestData *models.N1N2MessageTransferReqData) bool {
+	return N1N2MessageNgapIeType(requestData) == models.AmfCommunicationNgapIeType_PDU_RES_MOD_REQ
+}
+
+// The NGAP IE type of the N2 SM information in the transfer, empty if there is no N2 SM information
+func N1N2MessageNgapIeType(requestData *models.N1N2MessageTransferReqData) models.AmfCommunicationNgapIeType {
+	if requestData == nil || requestData.N2InfoContainer == nil || requestData.N2InfoContainer.SmInfo == nil ||
+		requestData.N2InfoContainer.SmInfo.N2InfoContent == nil {
+		return ""
+	}
+	return requestData.N2InfoContainer.SmInfo.N2InfoContent.NgapIeType
+}
+
+// Queue the transfer which is delivered after the one of ue.N1N2Message when the UE is reached
+func (ue *AmfUe) QueueN1N2Message(message *N1N2Message) {
+	if message == nil {
+		return
+	}
+	ue.N1N2MessageQueue = append(ue.N1N2MessageQueue, message)
+}
+
+// Dequeue all the queued transfers to deliver them to the UE in CM-CONNECTED
+func (ue *AmfUe) DequeueN1N2Messages() []*N1N2Message {
+	messages := ue.N1N2MessageQueue
+	ue.N1N2MessageQueue = nil
+	return messages
+}
+
+func (ue *AmfUe) FindN1N2Message(resourceUri string) (*N1N2Message, bool) {
+	if ue.N1N2Message != nil && ue.N1N2Message.ResourceUri == resourceUri {
+		return ue.N1N2Message, true
+	}
+	for _, message := range ue.N1N2MessageQueue {
+		if message.ResourceUri == resourceUri {
+			return message, true
+		}
+	}
+	return nil, false
+}
+
+// The transfers attempting to reach the UE are ended with cause when the paging fails, and are returned for
+// the N1/N2 transfer failure notification. The asynchronous transfers are kept until the UE is reached.
+func (ue *AmfUe) AbortN1N2MessagesOnPaging(cause models.N1N2MessageTransferCause) []*N1N2Message {
+	var aborted []*N1N2Message
+	if ue.N1N2Message != nil && ue.N1N2Message.Status == models.N1N2MessageTransferCause_ATTEMPTING_TO_REACH_UE {
+		aborted = append(aborted, ue.N1N2Message)
+		ue.N1N2Message = nil
+	}
+	queue := ue.N1N2MessageQueue[:0]
+	for _, message := range ue.N1N2MessageQueue {
+		if message.Status == models.N1N2MessageTransferCause_ATTEMPTING_TO_REACH_UE {
+			aborted = append(aborted, message)
+		} else {
+			queue = append(queue, message)
+		}
+	}
+	ue.N1N2MessageQueue = queue
+	for _, message := range aborted {
+		message.Status = cause
+	}
+	return aborted
+}
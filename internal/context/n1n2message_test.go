@@ -0,0 +1,43 @@
+package context
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/free5gc/openapi/models"
+)
+
+func TestAbortN1N2MessagesOnPaging(t *testing.T) {
+	ue := &AmfUe{}
+	paging := &N1N2Message{
+		Status:      models.N1N2MessageTransferCause_ATTEMPTING_TO_REACH_UE,
+		ResourceUri: "http://amf.example/ue-contexts/1/n1-n2-messages/2",
+	}
+	preempted := &N1N2Message{
+		Status:      models.N1N2MessageTransferCause_ATTEMPTING_TO_REACH_UE,
+		ResourceUri: "http://amf.example/ue-contexts/1/n1-n2-messages/1",
+	}
+	async := &N1N2Message{
+		Status:      models.N1N2MessageTransferCause_WAITING_FOR_ASYNCHRONOUS_TRANSFER,
+		ResourceUri: "http://amf.example/ue-contexts/1/n1-n2-messages/3",
+	}
+	ue.N1N2Message = paging
+	ue.QueueN1N2Message(preempted)
+	ue.QueueN1N2Message(async)
+
+	message, ok := ue.FindN1N2Message(preempted.ResourceUri)
+	require.True(t, ok)
+	require.Equal(t, preempted, message)
+
+	aborted := ue.AbortN1N2MessagesOnPaging(models.N1N2MessageTransferCause_UE_NOT_RESPONDING)
+	require.Equal(t, []*N1N2Message{paging, preempted}, aborted)
+	require.Equal(t, models.N1N2MessageTransferCause_UE_NOT_RESPONDING, preempted.Status)
+	require.Nil(t, ue.N1N2Message)
+
+	// the asynchronous transfer is kept until the UE is reached
+	_, ok = ue.FindN1N2Message(preempted.ResourceUri)
+	require.False(t, ok)
+	require.Equal(t, []*N1N2Message{async}, ue.DequeueN1N2Messages())
+	require.Empty(t, ue.N1N2MessageQueue)
+}
@@ -0,0 +1,126 @@
+package context
+
+import (
+	"github.com/free5gc/openapi"
+	"github.com/free5gc/openapi/models"
+)
+
+// Network slice-specific authentication and authorization (TS 23.502 4.2.9)
+
+// The S-NSSAI and the EAP message of the Network Slice-Specific Authentication Command, Complete and Result
+// (TS 24.501 8.2.31-8.2.33)
+type NssaaMessage struct {
+	Snssai     models.Snssai
+	EapMessage []byte
+}
+
+// The ongoing NSSAA procedure of the UE
+type NssaaProcedure struct {
+	Snssai     models.Snssai
+	AccessType models.AccessType
+	AuthCtxId  string // the authentication context of the NSSAAF, empty before the first EAP response is sent
+}
+
+// Notification types of the Nnssaaf_NSSAA_Re-AuthenticationNotification and RevocationNotification
+// (TS 29.526 6.1.6.3.3)
+const (
+	NssaaNotificationTypeReauth     = "SLICE_RE_AUTH"
+	NssaaNotificationTypeRevocation = "SLICE_REVOCATION"
+)
+
+// TS 29.526 6.1.6.2.6 and 6.1.6.2.7
+type NssaaNotification struct {
+	NotifType string         `json:"notifType"`
+	Gpsi      string         `json:"gpsi,omitempty"`
+	Snssai    *models.Snssai `json:"snssai"`
+	Supi      string         `json:"supi,omitempty"`
+}
+
+// Record the subscribed S-NSSAI subject to NSSAA, the NSSAA status of the recorded S-NSSAI is kept
+func (ue *AmfUe) AddNssaaSnssai(snssai models.Snssai) {
+	if _, ok := ue.NssaaStatus(snssai); ok {
+		return
+	}
+	ue.NssaaStatusList = append(ue.NssaaStatusList, models.NssaaStatus{
+		Snssai: &snssai,
+		Status: models.AuthStatus_PENDING,
+	})
+}
+
+// The NSSAA status of the S-NSSAI, false if the S-NSSAI is not subject to NSSAA
+func (ue *AmfUe) NssaaStatus(snssai models.Snssai) (models.AuthStatus, bool) {
+	for _, nssaaStatus := range ue.NssaaStatusList {
+		if openapi.SnssaiEqualFold(*nssaaStatus.Snssai, snssai) {
+			return nssaaStatus.Status, true
+		}
+	}
+	return "", false
+}
+
+func (ue *AmfUe) SetNssaaStatus(snssai models.Snssai, status models.AuthStatus) {
+	for i := range ue.NssaaStatusList {
+		if openapi.SnssaiEqualFold(*ue.NssaaStatusList[i].Snssai, snssai) {
+			ue.NssaaStatusList[i].Status = status
+			return
+		}
+	}
+}
+
+// The S-NSSAIs of which the NSSAA failed or was revoked
+func (ue *AmfUe) NssaaFailedNssai() (nssai []models.Snssai) {
+	for _, nssaaStatus := range ue.NssaaStatusList {
+		if nssaaStatus.Status == models.AuthStatus_EAP_FAILURE {
+			nssai = append(nssai, *nssaaStatus.Snssai)
+		}
+	}
+	return nssai
+}
+
+func (ue *AmfUe) InPendingNssai(snssai models.Snssai) bool {
+	for _, pendingSnssai := range ue.PendingNssai {
+		if openapi.SnssaiEqualFold(pendingSnssai, snssai) {
+			return true
+		}
+	}
+	return false
+}
+
+func (ue *AmfUe) AddPendingSnssai(snssai models.Snssai) {
+	if !ue.InPendingNssai(snssai) {
+		ue.PendingNssai = append(ue.PendingNssai, snssai)
+	}
+}
+
+func (ue *AmfUe) RemovePendingSnssai(snssai models.Snssai) {
+	for i, pendingSnssai := range ue.PendingNssai {
+		if openapi.SnssaiEqualFold(pendingSnssai, snssai) {
+			ue.PendingNssai = append(ue.PendingNssai[:i], ue.PendingNssai[i+1:]...)
+			return
+		}
+	}
+}
+
+func (ue *AmfUe) RemoveAllowedSnssai(snssai models.Snssai, anType models.AccessType) {
+	for i, allowedSnssai := range ue.AllowedNssai[anType] {
+		if openapi.SnssaiEqualFold(*allowedSnssai.AllowedSnssai, snssai) {
+			ue.AllowedNssai[anType] = append(ue.AllowedNssai[anType][:i], ue.AllowedNssai[anType][i+1:]...)
+			return
+		}
+	}
+}
+
+// The S-NSSAI to be authenticated next, the S-NSSAIs of the pending NSSAI first and then the allowed S-NSSAIs to be
+// re-authenticated
+func (ue *AmfUe) NextNssaaSnssai(anType models.AccessType) (models.Snssai, bool) {
+	for _, snssai := range ue.PendingNssai {
+		if status, ok := ue.NssaaStatus(snssai); ok && status == models.AuthStatus_PENDING {
+			return snssai, true
+		}
+	}
+	for _, allowedSnssai := range ue.AllowedNssai[anType] {
+		if status, ok := ue.NssaaStatus(*allowedSnssai.AllowedSnssai); ok && status == models.AuthStatus_PENDING {
+			return *allowedSnssai.AllowedSnssai, true
+		}
+	}
+	return models.Snssai{}, false
+}
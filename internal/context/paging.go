@@ -0,0 +1,170 @@
+package context
+
+import (
+	"github.com/free5gc/amf/pkg/factory"
+	"github.com/free5gc/aper"
+	"github.com/free5gc/nas/nasMessage"
+	"github.com/free5gc/ngap/ngapType"
+	"github.com/free5gc/openapi/models"
+)
+
+// The RAN to page the UE, and the TAIs for paging in the RAN
+type PagingTarget struct {
+	Ran     *AmfRan
+	TaiList []models.Tai
+}
+
+// The paging areas of the steps to page the UE, see factory.PagingPolicy. The rules are matched by the
+// N1N2MessageTransfer triggering the paging; the registration area is paged if there is no paging policy.
+func (ue *AmfUe) PagingSteps() []string {
+	policy := GetSelf().PagingPolicy
+	if policy == nil {
+		return []string{factory.PagingAreaRegistrationArea}
+	}
+
+	var ppi, var5qi int32
+	var dnn string
+	if ue.N1N2Message != nil && ue.N1N2Message.Request.JsonData != nil {
+		requestData := ue.N1N2Message.Request.JsonData
+		ppi = requestData.Ppi
+		var5qi = requestData.Var5qi
+		if smContext, ok := ue.SmContextFindByPDUSessionID(requestData.PduSessionId); ok {
+			dnn = smContext.Dnn()
+		}
+	}
+	for _, rule := range policy.Rules {
+		if (rule.Ppi == 0 || rule.Ppi == ppi) && (rule.Dnn == "" || rule.Dnn == dnn) &&
+			(rule.Var5qi == 0 || rule.Var5qi == var5qi) {
+			return rule.Steps
+		}
+	}
+	if len(policy.Steps) > 0 {
+		return policy.Steps
+	}
+	return []string{factory.PagingAreaRegistrationArea}
+}
+
+// The RANs to page the UE in the paging area. The registration area is paged instead of the last known RAN
+// if the RAN is unknown or not connected.
+func (ue *AmfUe) PagingTargets(area string) []PagingTarget {
+	amfSelf := GetSelf()
+	registrationArea := ue.RegistrationArea[models.AccessType__3_GPP_ACCESS]
+
+	if area == factory.PagingAreaLastKnownRan {
+		if targets := ue.lastKnownRanPagingTargets(); len(targets) > 0 {
+			return targets
+		}
+		area = factory.PagingAreaRegistrationArea
+	}
+
+	var targets []PagingTarget
+	amfSelf.AmfRanPool.Range(func(key, value interface{}) bool {
+		ran := value.(*AmfRan)
+		var taiList []models.Tai
+		switch area {
+		case factory.PagingAreaRegistrationArea:
+			taiList = ran.supportedTais(registrationArea)
+		case factory.PagingAreaAllTai:
+			taiList = ran.supportedTais(amfSelf.SupportTaiLists)
+		}
+		if len(taiList) > 0 {
+			targets = append(targets, PagingTarget{Ran: ran, TaiList: taiList})
+		}
+		return true
+	})
+	return targets
+}
+
+// The RAN of the last known cell of the UE, and the RANs recommended for paging at the UE context release
+// (TS 38.413 9.3.1.100)
+func (ue *AmfUe) lastKnownRanPagingTargets() []PagingTarget {
+	amfSelf := GetSelf()
+	registrationArea := ue.RegistrationArea[models.AccessType__3_GPP_ACCESS]
+
+	var targets []PagingTarget
+	addTarget := func(ran *AmfRan, taiList []models.Tai) {
+		for i := range targets {
+			if targets[i].Ran == ran {
+				for _, tai := range taiList {
+					if !InTaiList(tai, targets[i].TaiList) {
+						targets[i].TaiList = append(targets[i].TaiList, tai)
+					}
+				}
+				return
+			}
+		}
+		if len(taiList) > 0 {
+			targets = append(targets, PagingTarget{Ran: ran, TaiList: taiList})
+		}
+	}
+
+	var lastKnownRanId *models.GlobalRanNodeId
+	if location := ue.GetLocation(); location.NrLocation != nil {
+		lastKnownRanId = location.NrLocation.GlobalGnbId
+	} else if location.EutraLocation != nil {
+		lastKnownRanId = location.EutraLocation.GlobalNgenbId
+	}
+	if lastKnownRanId != nil {
+		if ran, ok := amfSelf.AmfRanFindByRanID(*lastKnownRanId); ok {
+			addTarget(ran, ran.supportedTais([]models.Tai{ue.Tai}))
+		}
+	}
+
+	if ue.InfoOnRecommendedCellsAndRanNodesForPaging != nil {
+		for _, ranNode := range ue.InfoOnRecommendedCellsAndRanNodesForPaging.RecommendedRanNodes {
+			switch ranNode.Present {
+			case RecommendRanNodePresentRanNode:
+				if ranNode.GlobalRanNodeId == nil {
+					continue
+				}
+				if ran, ok := amfSelf.AmfRanFindByRanID(*ranNode.GlobalRanNodeId); ok {
+					addTarget(ran, ran.supportedTais(registrationArea))
+				}
+			case RecommendRanNodePresentTAI:
+				if ranNode.Tai == nil || !InTaiList(*ranNode.Tai, registrationArea) {
+					continue
+				}
+				amfSelf.AmfRanPool.Range(func(key, value interface{}) bool {
+					ran := value.(*AmfRan)
+					addTarget(ran, ran.supportedTais([]models.Tai{*ranNode.Tai}))
+					return true
+				})
+			}
+		}
+	}
+	return targets
+}
+
+// The TAIs of taiList supported by the RAN
+func (ran *AmfRan) supportedTais(taiList []models.Tai) []models.Tai {
+	var tais []models.Tai
+	for _, item := range ran.SupportedTAList {
+		if InTaiList(item.Tai, taiList) && !InTaiList(item.Tai, tais) {
+			tais = append(tais, item.Tai)
+		}
+	}
+	return tais
+}
+
+// The Paging DRX of the UE sent to the RAN, nil if the UE specific DRX is not negotiated. The RAN pages the UE with
+// the shorter one of the UE specific DRX and its default paging DRX (TS 38.304 7.1), so the default paging DRX is
+// sent if it is shorter.
+func (ran *AmfRan) PagingDRX(ue *AmfUe) *ngapType.PagingDRX {
+	var value aper.Enumerated
+	switch ue.UESpecificDRX {
+	case nasMessage.DRXcycleParameterT32:
+		value = ngapType.PagingDRXPresentV32
+	case nasMessage.DRXcycleParameterT64:
+		value = ngapType.PagingDRXPresentV64
+	case nasMessage.DRXcycleParameterT128:
+		value = ngapType.PagingDRXPresentV128
+	case nasMessage.DRXcycleParameterT256:
+		value = ngapType.PagingDRXPresentV256
+	default:
+		return nil
+	}
+	if ran.DefaultPagingDRX != nil && ran.DefaultPagingDRX.Value < value {
+		value = ran.DefaultPagingDRX.Value
+	}
+	return &ngapType.PagingDRX{Value: value}
+}
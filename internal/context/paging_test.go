@@ -0,0 +1,114 @@
+package context
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/free5gc/amf/pkg/factory"
+	"github.com/free5gc/nas/nasMessage"
+	"github.com/free5gc/ngap/ngapType"
+	"github.com/free5gc/openapi/models"
+)
+
+func TestPagingSteps(t *testing.T) {
+	self := GetSelf()
+	defer func(policy *factory.PagingPolicy) { self.PagingPolicy = policy }(self.PagingPolicy)
+
+	ue := &AmfUe{}
+	self.PagingPolicy = nil
+	require.Equal(t, []string{factory.PagingAreaRegistrationArea}, ue.PagingSteps())
+
+	self.PagingPolicy = &factory.PagingPolicy{
+		Steps: []string{factory.PagingAreaLastKnownRan, factory.PagingAreaRegistrationArea},
+		Rules: []factory.PagingPolicyRule{
+			{Ppi: 1, Steps: []string{factory.PagingAreaLastKnownRan, factory.PagingAreaAllTai}},
+		},
+	}
+	require.Equal(t, self.PagingPolicy.Steps, ue.PagingSteps())
+
+	ue.N1N2Message = &N1N2Message{
+		Request: models.N1N2MessageTransferRequest{
+			JsonData: &models.N1N2MessageTransferReqData{Ppi: 1},
+		},
+	}
+	require.Equal(t, self.PagingPolicy.Rules[0].Steps, ue.PagingSteps())
+}
+
+func TestPagingTargets(t *testing.T) {
+	self := GetSelf()
+	plmnId := &models.PlmnId{Mcc: "208", Mnc: "93"}
+	tai1 := models.Tai{PlmnId: plmnId, Tac: "000001"}
+	tai2 := models.Tai{PlmnId: plmnId, Tac: "000002"}
+	tai3 := models.Tai{PlmnId: plmnId, Tac: "000003"}
+
+	defer func(taiList []models.Tai) { self.SupportTaiLists = taiList }(self.SupportTaiLists)
+	self.SupportTaiLists = []models.Tai{tai1, tai2, tai3}
+
+	gnb1Id := models.GlobalRanNodeId{PlmnId: plmnId, GNbId: &models.GNbId{BitLength: 22, GNBValue: "000001"}}
+	gnb1 := &AmfRan{
+		RanPresent:      RanPresentGNbId,
+		RanId:           &gnb1Id,
+		SupportedTAList: []SupportedTAI{{Tai: tai1}},
+	}
+	gnb2 := &AmfRan{
+		RanPresent: RanPresentGNbId,
+		RanId: &models.GlobalRanNodeId{
+			PlmnId: plmnId,
+			GNbId:  &models.GNbId{BitLength: 22, GNBValue: "000002"},
+		},
+		SupportedTAList: []SupportedTAI{{Tai: tai2}, {Tai: tai3}},
+	}
+	self.AmfRanPool.Store("gnb1", gnb1)
+	self.AmfRanPool.Store("gnb2", gnb2)
+	defer self.AmfRanPool.Delete("gnb1")
+	defer self.AmfRanPool.Delete("gnb2")
+
+	ue := &AmfUe{
+		Tai: tai1,
+		Location: models.UserLocation{
+			NrLocation: &models.NrLocation{Tai: &tai1, GlobalGnbId: &gnb1Id},
+		},
+		RegistrationArea: map[models.AccessType][]models.Tai{
+			models.AccessType__3_GPP_ACCESS: {tai1, tai2},
+		},
+	}
+
+	require.Equal(t, []PagingTarget{{Ran: gnb1, TaiList: []models.Tai{tai1}}},
+		ue.PagingTargets(factory.PagingAreaLastKnownRan))
+	require.ElementsMatch(t, []PagingTarget{
+		{Ran: gnb1, TaiList: []models.Tai{tai1}},
+		{Ran: gnb2, TaiList: []models.Tai{tai2}},
+	}, ue.PagingTargets(factory.PagingAreaRegistrationArea))
+	require.ElementsMatch(t, []PagingTarget{
+		{Ran: gnb1, TaiList: []models.Tai{tai1}},
+		{Ran: gnb2, TaiList: []models.Tai{tai2, tai3}},
+	}, ue.PagingTargets(factory.PagingAreaAllTai))
+
+	// the RAN recommended by the NG-RAN is paged along with the last known RAN
+	ue.InfoOnRecommendedCellsAndRanNodesForPaging = &InfoOnRecommendedCellsAndRanNodesForPaging{
+		RecommendedRanNodes: []RecommendRanNode{
+			{Present: RecommendRanNodePresentTAI, Tai: &tai2},
+		},
+	}
+	require.ElementsMatch(t, []PagingTarget{
+		{Ran: gnb1, TaiList: []models.Tai{tai1}},
+		{Ran: gnb2, TaiList: []models.Tai{tai2}},
+	}, ue.PagingTargets(factory.PagingAreaLastKnownRan))
+}
+
+func TestPagingDRX(t *testing.T) {
+	ran := &AmfRan{}
+	ue := &AmfUe{UESpecificDRX: nasMessage.DRXValueNotSpecified}
+	require.Nil(t, ran.PagingDRX(ue))
+
+	ue.UESpecificDRX = nasMessage.DRXcycleParameterT128
+	require.Equal(t, &ngapType.PagingDRX{Value: ngapType.PagingDRXPresentV128}, ran.PagingDRX(ue))
+
+	// the shorter default paging DRX of the RAN is sent
+	ran.DefaultPagingDRX = &ngapType.PagingDRX{Value: ngapType.PagingDRXPresentV64}
+	require.Equal(t, &ngapType.PagingDRX{Value: ngapType.PagingDRXPresentV64}, ran.PagingDRX(ue))
+
+	ran.DefaultPagingDRX = &ngapType.PagingDRX{Value: ngapType.PagingDRXPresentV256}
+	require.Equal(t, &ngapType.PagingDRX{Value: ngapType.PagingDRXPresentV128}, ran.PagingDRX(ue))
+}
@@ -0,0 +1,91 @@
+package context
+
+import (
+	"time"
+
+	"github.com/free5gc/ngap/ngapType"
+)
+
+// TS 23.041 9.1.3.5, a Write-Replace-Warning or PWS Cancel procedure initiated by the CBCF
+// via Namf_Communication_NonUeN2MessageTransfer. The responses of the NG-RAN nodes are
+// collected until all of them are received or TimePwsResponse expires.
+type PwsTransaction struct {
+	ProcedureCode     int64
+	MessageIdentifier int32
+	SerialNumber      int32
+
+	responses chan *AmfRan
+}
+
+type pwsTransactionKey struct {
+	procedureCode     int64
+	messageIdentifier int32
+	serialNumber      int32
+}
+
+func PwsIdentifiersToInt(messageIdentifier ngapType.MessageIdentifier,
+	serialNumber ngapType.SerialNumber,
+) (int32, int32) {
+	var msgId, serial int32
+	for _, b := range messageIdentifier.Value.Bytes {
+		msgId = msgId<<8 | int32(b)
+	}
+	for _, b := range serialNumber.Value.Bytes {
+		serial = serial<<8 | int32(b)
+	}
+	return msgId, serial
+}
+
+func (context *AMFContext) NewPwsTransaction(procedureCode int64, messageIdentifier, serialNumber int32,
+	numOfRan int,
+) *PwsTransaction {
+	transaction := &PwsTransaction{
+		ProcedureCode:     procedureCode,
+		MessageIdentifier: messageIdentifier,
+		SerialNumber:      serialNumber,
+		responses:         make(chan *AmfRan, numOfRan),
+	}
+	context.PwsTransactions.Store(pwsTransactionKey{procedureCode, messageIdentifier, serialNumber}, transaction)
+	return transaction
+}
+
+func (context *AMFContext) PwsTransactionFind(procedureCode int64, messageIdentifier, serialNumber int32,
+) (*PwsTransaction, bool) {
+	if value, ok := context.PwsTransactions.Load(
+		pwsTransactionKey{procedureCode, messageIdentifier, serialNumber}); ok {
+		return value.(*PwsTransaction), true
+	}
+	return nil, false
+}
+
+func (context *AMFContext) DeletePwsTransaction(transaction *PwsTransaction) {
+	context.PwsTransactions.Delete(pwsTransactionKey{
+		transaction.ProcedureCode, transaction.MessageIdentifier, transaction.SerialNumber,
+	})
+}
+
+// Return false if the response is not expected (e.g. more responses than the requested NG-RAN nodes)
+func (transaction *PwsTransaction) AddResponse(ran *AmfRan) bool {
+	select {
+	case transaction.responses <- ran:
+		return true
+	default:
+		return false
+	}
+}
+
+// Return the NG-RAN nodes which have responded before the timeout
+func (transaction *PwsTransaction) WaitResponses(numOfRan int, timeout time.Duration) []*AmfRan {
+	var respondedRans []*AmfRan
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for len(respondedRans) < numOfRan {
+		select {
+		case ran := <-transaction.responses:
+			respondedRans = append(respondedRans, ran)
+		case <-timer.C:
+			return respondedRans
+		}
+	}
+	return respondedRans
+}
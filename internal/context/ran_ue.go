@@ -55,7 +55,8 @@ type RanUe struct {
 	/* Routing ID */
 	RoutingID string
 	/* Trace Recording Session Reference */
-	Trsr string
+	Trsr   string
+	trsrId int64 // allocated by AMF for the trace activated in the NG-RAN, 0 if not allocated
 	/* Ue Context Release Action */
 	ReleaseAction RelAction
 	/* context used for AMF Re-allocation procedure */
@@ -89,6 +90,7 @@ func (ranUe *RanUe) Remove() error {
 	self := GetSelf()
 	self.RanUePool.Delete(ranUe.AmfUeNgapId)
 	amfUeNGAPIDGenerator.FreeID(ranUe.AmfUeNgapId)
+	ranUe.FreeTrsr()
 	return nil
 }
 
@@ -191,8 +193,8 @@ func (ranUe *RanUe) UpdateLocation(userLocationInformation *ngapType.UserLocatio
 			if ranUe.AmfUe.Tai != ranUe.Tai {
 				ranUe.AmfUe.LocationChanged = true
 			}
-			ranUe.AmfUe.Location = deepcopy.Copy(ranUe.Location).(models.UserLocation)
-			ranUe.AmfUe.Tai = deepcopy.Copy(*ranUe.AmfUe.Location.EutraLocation.Tai).(models.Tai)
+			ranUe.AmfUe.SetLocation(deepcopy.Copy(ranUe.Location).(models.UserLocation))
+			ranUe.AmfUe.Tai = deepcopy.Copy(*ranUe.AmfUe.GetLocation().EutraLocation.Tai).(models.Tai)
 		}
 	case ngapType.UserLocationInformationPresentUserLocationInformationNR:
 		locationInfoNR := userLocationInformation.UserLocationInformationNR
@@ -228,8 +230,8 @@ func (ranUe *RanUe) UpdateLocation(userLocationInformation *ngapType.UserLocatio
 			if ranUe.AmfUe.Tai != ranUe.Tai {
 				ranUe.AmfUe.LocationChanged = true
 			}
-			ranUe.AmfUe.Location = deepcopy.Copy(ranUe.Location).(models.UserLocation)
-			ranUe.AmfUe.Tai = deepcopy.Copy(*ranUe.AmfUe.Location.NrLocation.Tai).(models.Tai)
+			ranUe.AmfUe.SetLocation(deepcopy.Copy(ranUe.Location).(models.UserLocation))
+			ranUe.AmfUe.Tai = deepcopy.Copy(*ranUe.AmfUe.GetLocation().NrLocation.Tai).(models.Tai)
 		}
 	case ngapType.UserLocationInformationPresentUserLocationInformationN3IWF:
 		locationInfoN3IWF := userLocationInformation.UserLocationInformationN3IWF
@@ -254,7 +256,7 @@ func (ranUe *RanUe) UpdateLocation(userLocationInformation *ngapType.UserLocatio
 		ranUe.Tai = deepcopy.Copy(*ranUe.Location.N3gaLocation.N3gppTai).(models.Tai)
 
 		if ranUe.AmfUe != nil {
-			ranUe.AmfUe.Location = deepcopy.Copy(ranUe.Location).(models.UserLocation)
+			ranUe.AmfUe.SetLocation(deepcopy.Copy(ranUe.Location).(models.UserLocation))
 			ranUe.AmfUe.Tai = *ranUe.Location.N3gaLocation.N3gppTai
 		}
 	case ngapType.UserLocationInformationPresentChoiceExtensions:
@@ -284,7 +286,7 @@ func (ranUe *RanUe) UpdateLocation(userLocationInformation *ngapType.UserLocatio
 			ranUe.Tai = deepcopy.Copy(*ranUe.Location.N3gaLocation.N3gppTai).(models.Tai)
 
 			if ranUe.AmfUe != nil {
-				ranUe.AmfUe.Location = deepcopy.Copy(ranUe.Location).(models.UserLocation)
+				ranUe.AmfUe.SetLocation(deepcopy.Copy(ranUe.Location).(models.UserLocation))
 				ranUe.AmfUe.Tai = *ranUe.Location.N3gaLocation.N3gppTai
 			}
 		case ngapType.ProtocolIEIDUserLocationInformationTWIF:
@@ -313,7 +315,7 @@ func (ranUe *RanUe) UpdateLocation(userLocationInformation *ngapType.UserLocatio
 			ranUe.Tai = deepcopy.Copy(*ranUe.Location.N3gaLocation.N3gppTai).(models.Tai)
 
 			if ranUe.AmfUe != nil {
-				ranUe.AmfUe.Location = deepcopy.Copy(ranUe.Location).(models.UserLocation)
+				ranUe.AmfUe.SetLocation(deepcopy.Copy(ranUe.Location).(models.UserLocation))
 				ranUe.AmfUe.Tai = *ranUe.Location.N3gaLocation.N3gppTai
 			}
 
@@ -324,3 +326,20 @@ func (ranUe *RanUe) UpdateLocation(userLocationInformation *ngapType.UserLocatio
 	case ngapType.UserLocationInformationPresentNothing:
 	}
 }
+
+// The Routing ID of NRPPa transport identifies the LMF, and the NF Instance ID of the LMF is used as it
+func RoutingIDFromNfId(nfId string) string {
+	return hex.EncodeToString([]byte(nfId))
+}
+
+func NfIdFromRoutingID(routingID []byte) string {
+	return string(routingID)
+}
+
+// The routing information of LPP in the Additional information IE of NAS transport identifies the LMF likewise
+func LppRoutingInformation(nfId string) []byte {
+	if nfId == "" {
+		return nil
+	}
+	return []byte(nfId)
+}
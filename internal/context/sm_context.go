@@ -29,6 +29,9 @@ type SmContext struct {
 	// for duplicate pdu session id handling
 	ulNASTransport *nasMessage.ULNASTransport
 	duplicated     bool
+
+	// the Secondary RAT Data Usage Report Transfers buffered during the N2 handover
+	secondaryRatUsageReports [][]byte
 }
 
 func NewSmContext(pduSessionID int32) *SmContext {
@@ -209,3 +212,18 @@ func (c *SmContext) DeleteULNASTransport() {
 	defer c.mu.Unlock()
 	c.ulNASTransport = nil
 }
+
+func (c *SmContext) StoreSecondaryRatUsageReport(report []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.secondaryRatUsageReports = append(c.secondaryRatUsageReports, report)
+}
+
+// The buffered reports are returned and deleted
+func (c *SmContext) PopSecondaryRatUsageReports() [][]byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	reports := c.secondaryRatUsageReports
+	c.secondaryRatUsageReports = nil
+	return reports
+}
@@ -0,0 +1,88 @@
+package context
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net"
+	"regexp"
+
+	"github.com/free5gc/openapi/models"
+)
+
+// Subscriber and equipment trace (TS 32.422)
+
+// MCC and MNC followed by '-' and the trace ID, the trace ID is 3 octets in hexadecimal (TS 29.571 5.6.2.3)
+var traceRefRegexp = regexp.MustCompile("^[0-9]{5,6}-[A-Fa-f0-9]{6}$")
+
+// Validate the trace data to be activated in the NG-RAN, the trace reference, interface list and trace collection
+// entity address are encoded to the Trace Activation IE
+func ValidateTraceData(traceData *models.TraceData) error {
+	if !traceRefRegexp.MatchString(traceData.TraceRef) {
+		return fmt.Errorf("invalid trace reference: %s", traceData.TraceRef)
+	}
+	if traceData.InterfaceList != "" {
+		if interfaceList, err := hex.DecodeString(traceData.InterfaceList); err != nil || len(interfaceList) != 1 {
+			return fmt.Errorf("invalid interface list: %s", traceData.InterfaceList)
+		}
+	}
+	if traceData.CollectionEntityIpv4Addr == "" && traceData.CollectionEntityIpv6Addr == "" {
+		return fmt.Errorf("trace collection entity address is absent")
+	}
+	if traceData.CollectionEntityIpv4Addr != "" && net.ParseIP(traceData.CollectionEntityIpv4Addr).To4() == nil {
+		return fmt.Errorf("invalid trace collection entity IPv4 address: %s", traceData.CollectionEntityIpv4Addr)
+	}
+	if traceData.CollectionEntityIpv6Addr != "" && net.ParseIP(traceData.CollectionEntityIpv6Addr) == nil {
+		return fmt.Errorf("invalid trace collection entity IPv6 address: %s", traceData.CollectionEntityIpv6Addr)
+	}
+	return nil
+}
+
+// Allocate the Trace Recording Session Reference (TS 32.422 5.7) for the trace of the UE activated in the NG-RAN,
+// the NG-RAN trace ID is the trace reference followed by the TRSR. The allocated TRSR is kept until freed.
+func (ranUe *RanUe) AllocateTrsr() error {
+	if ranUe.trsrId != 0 {
+		return nil
+	}
+	trsrId, err := trsrGenerator.Allocate()
+	if err != nil {
+		return fmt.Errorf("allocate TRSR error: %+v", err)
+	}
+	ranUe.trsrId = trsrId
+	ranUe.Trsr = fmt.Sprintf("%04x", trsrId)
+	return nil
+}
+
+func (ranUe *RanUe) FreeTrsr() {
+	if ranUe.trsrId != 0 {
+		trsrGenerator.FreeID(ranUe.trsrId)
+		ranUe.trsrId = 0
+	}
+	ranUe.Trsr = ""
+}
+
+// The trace of the UE is activated in the NG-RAN by the Initial Context Setup, Handover Request or Trace Start
+func (ranUe *RanUe) TraceActivated() bool {
+	return ranUe.trsrId != 0
+}
+
+// Types of the trace events reported to the trace collection entity
+const (
+	TraceEventCellTrafficTrace = "CELL_TRAFFIC_TRACE"
+	TraceEventTraceFailure     = "TRACE_FAILURE"
+)
+
+// The trace event reported to the trace collection entity, the AMF to TCE interface is not standardized and the
+// report is sent in JSON. For the cell traffic trace, the SUPI and IMEI(SV) of the UE are sent together with the
+// trace reference and TRSR (TS 32.422 4.2.2.10).
+type TraceReport struct {
+	Event       string       `json:"event"`
+	Supi        string       `json:"supi,omitempty"`
+	Pei         string       `json:"pei,omitempty"`
+	TraceRef    string       `json:"traceRef"`
+	Trsr        string       `json:"trsr"`
+	NrCgi       *models.Ncgi `json:"nrCgi,omitempty"`
+	EutraCgi    *models.Ecgi `json:"eutraCgi,omitempty"`
+	TceIpv4Addr string       `json:"tceIpv4Addr,omitempty"`
+	TceIpv6Addr string       `json:"tceIpv6Addr,omitempty"`
+	Cause       string       `json:"cause,omitempty"`
+}
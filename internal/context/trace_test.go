@@ -0,0 +1,79 @@
+package context
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/free5gc/openapi/models"
+)
+
+func TestValidateTraceData(t *testing.T) {
+	testCases := []struct {
+		name      string
+		traceData models.TraceData
+		err       bool
+	}{
+		{
+			name: "valid trace data",
+			traceData: models.TraceData{
+				TraceRef:                 "20893-4a5b6c",
+				TraceDepth:               models.TraceDepth_MAXIMUM,
+				InterfaceList:            "e0",
+				CollectionEntityIpv4Addr: "10.0.0.1",
+			},
+		},
+		{
+			name: "invalid trace reference",
+			traceData: models.TraceData{
+				TraceRef:                 "20893-4a5b",
+				CollectionEntityIpv4Addr: "10.0.0.1",
+			},
+			err: true,
+		},
+		{
+			name: "invalid TCE address",
+			traceData: models.TraceData{
+				TraceRef:                 "208093-4a5b6c",
+				CollectionEntityIpv4Addr: "2001:db8::1",
+			},
+			err: true,
+		},
+		{
+			name: "TCE address is absent",
+			traceData: models.TraceData{
+				TraceRef: "208093-4a5b6c",
+			},
+			err: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateTraceData(&tc.traceData)
+			if tc.err {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestTrsr(t *testing.T) {
+	ranUe := new(RanUe)
+	require.False(t, ranUe.TraceActivated())
+
+	require.NoError(t, ranUe.AllocateTrsr())
+	require.True(t, ranUe.TraceActivated())
+	require.Len(t, ranUe.Trsr, 4)
+
+	// the allocated TRSR is kept
+	trsr := ranUe.Trsr
+	require.NoError(t, ranUe.AllocateTrsr())
+	require.Equal(t, trsr, ranUe.Trsr)
+
+	ranUe.FreeTrsr()
+	require.False(t, ranUe.TraceActivated())
+	require.Empty(t, ranUe.Trsr)
+}
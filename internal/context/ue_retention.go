@@ -0,0 +1,103 @@
+package context
+
+import (
+	"time"
+)
+
+// UE context retention over the loss of the NG connection (TS 38.413 8.7.1.2)
+
+// The default grace period to retain the UE contexts if not configured
+const defaultUeRetentionGracePeriod = 30 * time.Second
+
+type retainedRan struct {
+	ran   *AmfRan
+	timer *time.Timer
+}
+
+func (c *AMFContext) ueRetentionGracePeriod() time.Duration {
+	if c.UeRetention.GracePeriod > 0 {
+		return time.Duration(c.UeRetention.GracePeriod) * time.Second
+	}
+	return defaultUeRetentionGracePeriod
+}
+
+// The UE retention requested by the RAN in the NG Setup is accepted
+func (c *AMFContext) UeRetentionEnabled() bool {
+	return c.UeRetention != nil && c.UeRetention.Enable
+}
+
+// Retain the UE contexts of the RAN which lost the NG connection, the RAN is removed from the connected RANs and
+// kept for the grace period. If not resumed within the grace period, release is called with the RAN. The RAN is not
+// retained if the UE retention is not enabled or the RAN has not set up the NG interface.
+func (c *AMFContext) RetainAmfRan(ran *AmfRan, release func(ran *AmfRan)) bool {
+	if !c.UeRetentionEnabled() || ran.RanId == nil {
+		return false
+	}
+	ranId := ran.RanID()
+	if ranId == "" {
+		return false
+	}
+
+	ran.stopAmfConfigUpdateRetry()
+	c.DeleteAmfRan(ran.Conn)
+
+	gracePeriod := c.ueRetentionGracePeriod()
+	retained := &retainedRan{ran: ran}
+	c.retainedRansMutex.Lock()
+	if c.retainedRans == nil {
+		c.retainedRans = make(map[string]*retainedRan)
+	}
+	old := c.retainedRans[ranId]
+	c.retainedRans[ranId] = retained
+	retained.timer = time.AfterFunc(gracePeriod, func() {
+		c.retainedRansMutex.Lock()
+		if c.retainedRans[ranId] != retained {
+			c.retainedRansMutex.Unlock()
+			return
+		}
+		delete(c.retainedRans, ranId)
+		c.retainedRansMutex.Unlock()
+
+		ran.Log.Infof("UE retention of RAN%s expired after %s", ranId, gracePeriod)
+		release(ran)
+	})
+	c.retainedRansMutex.Unlock()
+
+	ran.Log.Infof("Retain UE contexts of RAN%s for %s", ranId, gracePeriod)
+	// the RAN retained before is not resumed, it's released here instead of after its grace period
+	if old != nil {
+		old.timer.Stop()
+		release(old.ran)
+	}
+	return true
+}
+
+// Take the RAN retained with the Global RAN Node ID, it's no longer released after the grace period
+func (c *AMFContext) TakeRetainedAmfRan(ranId string) (*AmfRan, bool) {
+	c.retainedRansMutex.Lock()
+	defer c.retainedRansMutex.Unlock()
+	retained, ok := c.retainedRans[ranId]
+	if !ok {
+		return nil, false
+	}
+	retained.timer.Stop()
+	delete(c.retainedRans, ranId)
+	return retained.ran, true
+}
+
+// Resume the UE-associated logical NG-connections retained by the RAN over the new NG connection, the RanUes are
+// moved to this RAN and keep their NGAP IDs. The retained RanUe is kept in the retained RAN if its RAN UE NGAP ID is
+// taken, it's released by the caller along with the retained RAN.
+func (ran *AmfRan) ResumeRanUes(retainedRan *AmfRan) {
+	retainedRan.RanUeList.Range(func(key, value interface{}) bool {
+		ranUe := value.(*RanUe)
+		if _, loaded := ran.RanUeList.LoadOrStore(key, ranUe); loaded {
+			ran.Log.Warnf("RanUeNgapID[%d] is taken, the retained RanUe is not resumed", ranUe.RanUeNgapId)
+			return true
+		}
+		retainedRan.RanUeList.Delete(key)
+		ranUe.Ran = ran
+		ranUe.UpdateLogFields()
+		return true
+	})
+}
@@ -0,0 +1,80 @@
+package context
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/free5gc/amf/internal/logger"
+	"github.com/free5gc/amf/pkg/factory"
+	"github.com/free5gc/openapi/models"
+)
+
+func TestUeRetention(t *testing.T) {
+	self := GetSelf()
+	defer func(ueRetention *factory.UeRetention) { self.UeRetention = ueRetention }(self.UeRetention)
+
+	newRan := func(gnbId string) *AmfRan {
+		return &AmfRan{
+			RanPresent: RanPresentGNbId,
+			RanId: &models.GlobalRanNodeId{
+				PlmnId: &models.PlmnId{Mcc: "208", Mnc: "93"},
+				GNbId:  &models.GNbId{BitLength: 24, GNBValue: gnbId},
+			},
+			Log: logger.NgapLog.WithField("", ""),
+		}
+	}
+	released := make(chan *AmfRan, 1)
+	release := func(ran *AmfRan) { released <- ran }
+
+	// the RAN is not retained if the UE retention is not enabled
+	self.UeRetention = nil
+	require.False(t, self.RetainAmfRan(newRan("000001"), release))
+
+	self.UeRetention = &factory.UeRetention{Enable: true, GracePeriod: 1}
+
+	// the RAN is resumed within the grace period
+	oldRan := newRan("000001")
+	ranUe, err := oldRan.NewRanUe(1)
+	require.NoError(t, err)
+	require.True(t, self.RetainAmfRan(oldRan, release))
+
+	ran := newRan("000001")
+	retainedRan, ok := self.TakeRetainedAmfRan(ran.RanID())
+	require.True(t, ok)
+	require.Equal(t, oldRan, retainedRan)
+	ran.ResumeRanUes(retainedRan)
+	require.Equal(t, ranUe, ran.RanUeFindByRanUeNgapID(1))
+	require.Equal(t, ran, ranUe.Ran)
+	require.Nil(t, retainedRan.RanUeFindByRanUeNgapID(1))
+	_, ok = self.TakeRetainedAmfRan(ran.RanID())
+	require.False(t, ok)
+	require.NoError(t, ranUe.Remove())
+
+	// the retained RanUe with the RAN UE NGAP ID taken is kept in the retained RAN
+	oldRan = newRan("000001")
+	ranUe, err = oldRan.NewRanUe(1)
+	require.NoError(t, err)
+	ran = newRan("000001")
+	takenRanUe, err := ran.NewRanUe(1)
+	require.NoError(t, err)
+	ran.ResumeRanUes(oldRan)
+	require.Equal(t, takenRanUe, ran.RanUeFindByRanUeNgapID(1))
+	require.Equal(t, ranUe, oldRan.RanUeFindByRanUeNgapID(1))
+	require.Equal(t, oldRan, ranUe.Ran)
+	require.NoError(t, ranUe.Remove())
+	require.NoError(t, takenRanUe.Remove())
+
+	// the RAN is released after the grace period
+	oldRan = newRan("000002")
+	require.True(t, self.RetainAmfRan(oldRan, release))
+	select {
+	case ran := <-released:
+		require.Equal(t, oldRan, ran)
+	case <-time.After(2 * time.Second):
+		t.Fatal("retained RAN is not released after the grace period")
+	}
+	_, ok = self.TakeRetainedAmfRan(oldRan.RanID())
+	require.False(t, ok)
+}
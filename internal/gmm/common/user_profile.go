@@ -6,6 +6,7 @@ import (
 	business_metrics "github.com/free5gc/amf/internal/metrics/business"
 	ngap_message "github.com/free5gc/amf/internal/ngap/message"
 	"github.com/free5gc/amf/internal/sbi/consumer"
+	callback "github.com/free5gc/amf/internal/sbi/processor/notifier"
 	"github.com/free5gc/ngap/ngapType"
 	"github.com/free5gc/openapi/models"
 )
@@ -55,6 +56,7 @@ func PurgeAmfUeSubscriberData(ue *context.AmfUe) {
 	}
 }
 
+// The caller shall hold amfUe.Lock.
 func AttachRanUeToAmfUeAndReleaseOldIfAny(amfUe *context.AmfUe, ranUe *context.RanUe) {
 	if oldRanUe := amfUe.RanUe[ranUe.Ran.AnType]; oldRanUe != nil {
 		oldRanUe.Log.Infof("Implicit Deregistration - RanUeNgapID[%d]", oldRanUe.RanUeNgapId)
@@ -76,6 +78,7 @@ func AttachRanUeToAmfUeAndReleaseOldIfAny(amfUe *context.AmfUe, ranUe *context.R
 	}
 
 	amfUe.AttachRanUe(ranUe)
+	callback.SendAmfEventReport(amfUe, models.AmfEventType_CONNECTIVITY_STATE_REPORT)
 }
 
 func AttachRanUeToAmfUeAndReleaseOldHandover(amfUe *context.AmfUe, sourceRanUe, targetRanUe *context.RanUe) {
@@ -112,6 +115,10 @@ func ClearHoldingRanUe(ranUe *context.RanUe) {
 func PurgeSubscriberData(ue *context.AmfUe, accessType models.AccessType) error {
 	logger.GmmLog.Debugln("PurgeSubscriberData")
 
+	if ue.SmsOverNasActivated[accessType] {
+		DeactivateSmsOverNas(ue, accessType)
+	}
+
 	if !ue.ContextValid {
 		return nil
 	}
@@ -137,3 +144,46 @@ func PurgeSubscriberData(ue *context.AmfUe, accessType models.AccessType) error
 	}
 	return nil
 }
+
+// The SMS over NAS is no longer allowed over the access, and the SMSF is deactivated by
+// Nsmsf_SMService_Deactivate once the SMS over NAS is not allowed over any access (TS 23.502 4.13.2.2)
+func DeactivateSmsOverNas(ue *context.AmfUe, accessType models.AccessType) {
+	delete(ue.SmsOverNasActivated, accessType)
+	if len(ue.SmsOverNasActivated) > 0 {
+		return
+	}
+
+	problemDetails, err := consumer.GetConsumer().SmServiceDeactivate(ue)
+	if problemDetails != nil {
+		ue.GmmLog.Errorf("SMService Deactivate Failed Problem[%+v]", problemDetails)
+	} else if err != nil {
+		ue.GmmLog.Errorf("SMService Deactivate Error[%+v]", err)
+	}
+}
+
+// Activate the trace of the UE in the NG-RAN (TS 32.422 4.2.2.9), the trace session activated before is deactivated
+// first. The trace data is sent by the Trace Start if the UE context is set up in the NG-RAN, otherwise it is sent
+// by the next Initial Context Setup or Handover Request.
+func ActivateTrace(ue *context.AmfUe, traceData *models.TraceData) {
+	DeactivateTrace(ue)
+	ue.TraceData = traceData
+	for _, ranUe := range ue.RanUe {
+		if ranUe.InitialContextSetup {
+			ngap_message.SendTraceStart(ranUe)
+		}
+	}
+}
+
+// Deactivate the trace of the UE in the NG-RAN nodes in which the trace is activated (TS 32.422 4.2.3.9)
+func DeactivateTrace(ue *context.AmfUe) {
+	if ue.TraceData == nil {
+		return
+	}
+	for anType, ranUe := range ue.RanUe {
+		if ranUe.TraceActivated() {
+			ngap_message.SendDeactivateTrace(ue, anType)
+			ranUe.FreeTrsr()
+		}
+	}
+	ue.TraceData = nil
+}
@@ -19,6 +19,7 @@ import (
 	gmm_common "github.com/free5gc/amf/internal/gmm/common"
 	gmm_message "github.com/free5gc/amf/internal/gmm/message"
 	"github.com/free5gc/amf/internal/logger"
+	"github.com/free5gc/amf/internal/nas/nas_security"
 	ngap_message "github.com/free5gc/amf/internal/ngap/message"
 	"github.com/free5gc/amf/internal/sbi/consumer"
 	callback "github.com/free5gc/amf/internal/sbi/processor/notifier"
@@ -31,6 +32,7 @@ import (
 	"github.com/free5gc/nas/security"
 	"github.com/free5gc/ngap/ngapConvert"
 	"github.com/free5gc/ngap/ngapType"
+	"github.com/free5gc/openapi"
 	"github.com/free5gc/openapi/models"
 	Nnrf_NFDiscovery "github.com/free5gc/openapi/nrf/NFDiscovery"
 	"github.com/free5gc/util/fsm"
@@ -39,6 +41,9 @@ import (
 
 const psiArraySize = 16
 
+// SMS requested bit of the 5GS update type, TS 24.501 9.11.3.9A
+const smsOverNasSupported uint8 = 1
+
 func HandleULNASTransport(ue *context.AmfUe, anType models.AccessType,
 	ulNasTransport *nasMessage.ULNASTransport,
 ) error {
@@ -53,14 +58,14 @@ func HandleULNASTransport(ue *context.AmfUe, anType models.AccessType,
 	case nasMessage.PayloadContainerTypeN1SMInfo:
 		return transport5GSMMessage(ue, anType, ulNasTransport)
 	case nasMessage.PayloadContainerTypeSMS:
-		return fmt.Errorf("PayloadContainerTypeSMS has not been implemented yet in UL NAS TRANSPORT")
+		return transportSMSMessage(ue, anType, ulNasTransport)
 	case nasMessage.PayloadContainerTypeLPP:
-		return fmt.Errorf("PayloadContainerTypeLPP has not been implemented yet in UL NAS TRANSPORT")
+		return transportLPPMessage(ue, anType, ulNasTransport)
 	case nasMessage.PayloadContainerTypeSOR:
 		return fmt.Errorf("PayloadContainerTypeSOR has not been implemented yet in UL NAS TRANSPORT")
 	case nasMessage.PayloadContainerTypeUEPolicy:
 		ue.GmmLog.Infoln("AMF Transfer UEPolicy To PCF")
-		callback.SendN1MessageNotify(ue, models.N1MessageClass_UPDP,
+		callback.SendN1MessageNotify(ue, models.N1MessageClass_UPDP, "",
 			ulNasTransport.PayloadContainer.GetPayloadContainerContents(), nil)
 	case nasMessage.PayloadContainerTypeUEParameterUpdate:
 		ue.GmmLog.Infoln("AMF Transfer UEParameterUpdate To UDM")
@@ -79,6 +84,52 @@ func HandleULNASTransport(ue *context.AmfUe, anType models.AccessType,
 	return nil
 }
 
+// TS 24.501 5.4.5.2.3 case c), the LPP message is forwarded to the LMF of the routing information
+func transportLPPMessage(ue *context.AmfUe, anType models.AccessType,
+	ulNasTransport *nasMessage.ULNASTransport,
+) error {
+	lppMessage := ulNasTransport.PayloadContainer.GetPayloadContainerContents()
+
+	if ulNasTransport.AdditionalInformation == nil {
+		ue.GmmLog.Warnln("Routing information of LPP message is missing")
+		gmm_message.SendDLNASTransport(ue.RanUe[anType], nasMessage.PayloadContainerTypeLPP,
+			lppMessage, 0, nasMessage.Cause5GMMPayloadWasNotForwarded, nil, 0, nil)
+		return nil
+	}
+
+	lmfID := context.NfIdFromRoutingID(ulNasTransport.AdditionalInformation.GetAdditionalInformationValue())
+	ue.GmmLog.Infof("AMF Transfer LPP To LMF[%s]", lmfID)
+	callback.SendN1MessageNotify(ue, models.N1MessageClass_LPP, lmfID, lppMessage, nil)
+	return nil
+}
+
+// TS 23.502 4.13.3.3, the MO SMS is forwarded to the SMSF by Nsmsf_SMService_UplinkSMS
+func transportSMSMessage(ue *context.AmfUe, anType models.AccessType,
+	ulNasTransport *nasMessage.ULNASTransport,
+) error {
+	smsMessage := ulNasTransport.PayloadContainer.GetPayloadContainerContents()
+
+	if !ue.SmsOverNasActivated[anType] {
+		ue.GmmLog.Warnln("SMS over NAS is not allowed")
+		gmm_message.SendDLNASTransport(ue.RanUe[anType], nasMessage.PayloadContainerTypeSMS,
+			smsMessage, 0, nasMessage.Cause5GMMPayloadWasNotForwarded, nil, 0, nil)
+		return nil
+	}
+
+	ue.GmmLog.Infof("AMF Transfer SMS To SMSF[%s]", ue.SmsfId)
+	problemDetails, err := consumer.GetConsumer().SmServiceUplinkSms(ue, anType, smsMessage)
+	if problemDetails != nil {
+		ue.GmmLog.Errorf("SMService UplinkSMS Failed Problem[%+v]", problemDetails)
+	} else if err != nil {
+		ue.GmmLog.Errorf("SMService UplinkSMS Error[%+v]", err)
+	} else {
+		return nil
+	}
+	gmm_message.SendDLNASTransport(ue.RanUe[anType], nasMessage.PayloadContainerTypeSMS,
+		smsMessage, 0, nasMessage.Cause5GMMPayloadWasNotForwarded, nil, 0, nil)
+	return nil
+}
+
 func transport5GSMMessage(ue *context.AmfUe, anType models.AccessType,
 	ulNasTransport *nasMessage.ULNASTransport,
 ) error {
@@ -105,10 +156,12 @@ func transport5GSMMessage(ue *context.AmfUe, anType models.AccessType,
 			case nasMessage.ULNASTransportRequestTypeInitialEmergencyRequest:
 				fallthrough
 			case nasMessage.ULNASTransportRequestTypeExistingEmergencyPduSession:
-				ue.GmmLog.Warnf("Emergency PDU Session is not supported")
-				gmm_message.SendDLNASTransport(ue.RanUe[anType], nasMessage.PayloadContainerTypeN1SMInfo,
-					smMessage, pduSessionID, nasMessage.Cause5GMMPayloadWasNotForwarded, nil, 0)
-				return nil
+				if context.GetSelf().Emergency == nil {
+					ue.GmmLog.Warnf("Emergency PDU Session is not supported")
+					gmm_message.SendDLNASTransport(ue.RanUe[anType], nasMessage.PayloadContainerTypeN1SMInfo,
+						smMessage, pduSessionID, nasMessage.Cause5GMMPayloadWasNotForwarded, nil, 0, nil)
+					return nil
+				}
 			}
 		}
 
@@ -120,7 +173,8 @@ func transport5GSMMessage(ue *context.AmfUe, anType models.AccessType,
 			}
 
 			switch requestType.GetRequestTypeValue() {
-			case nasMessage.ULNASTransportRequestTypeInitialRequest:
+			case nasMessage.ULNASTransportRequestTypeInitialRequest,
+				nasMessage.ULNASTransportRequestTypeInitialEmergencyRequest:
 				smContext.StoreULNASTransport(ulNasTransport)
 				//  perform a local release of the PDU session identified by the PDU session ID and shall request
 				// the SMF to perform a local release of the PDU session
@@ -162,8 +216,13 @@ func transport5GSMMessage(ue *context.AmfUe, anType models.AccessType,
 					ue.GmmLog.Errorf("S-NSSAI[%v] is not allowed for access type[%s] (PDU Session ID: %d)",
 						smContext.Snssai(), anType, pduSessionID)
 					gmm_message.SendDLNASTransport(ue.RanUe[anType], nasMessage.PayloadContainerTypeN1SMInfo,
-						smMessage, pduSessionID, nasMessage.Cause5GMMPayloadWasNotForwarded, nil, 0)
+						smMessage, pduSessionID, nasMessage.Cause5GMMPayloadWasNotForwarded, nil, 0, nil)
+				}
+			case nasMessage.ULNASTransportRequestTypeModificationRequest:
+				if rejectCongestedSmMessage(ue, anType, pduSessionID, smContext.Snssai(), smContext.Dnn(), smMessage) {
+					return nil
 				}
+				return forward5GSMMessageToSMF(ue, anType, pduSessionID, smContext, smMessage)
 			// other requestType: AMF forward the 5GSM message, and the PDU session ID IE towards the SMF identified
 			// by the SMF ID of the PDU session routing context
 			default:
@@ -173,15 +232,20 @@ func transport5GSMMessage(ue *context.AmfUe, anType models.AccessType,
 			if requestType == nil {
 				ue.GmmLog.Warnf("Request type is nil")
 				gmm_message.SendDLNASTransport(ue.RanUe[anType], nasMessage.PayloadContainerTypeN1SMInfo,
-					smMessage, pduSessionID, nasMessage.Cause5GMMPayloadWasNotForwarded, nil, 0)
+					smMessage, pduSessionID, nasMessage.Cause5GMMPayloadWasNotForwarded, nil, 0, nil)
 				return nil
 			}
 			switch requestType.GetRequestTypeValue() {
 			// case iii) if the AMF does not have a PDU session routing context for the PDU session ID and the UE
-			// and the Request type IE is included and is set to "initial request"
-			case nasMessage.ULNASTransportRequestTypeInitialRequest:
+			// and the Request type IE is included and is set to "initial request" or "initial emergency request"
+			case nasMessage.ULNASTransportRequestTypeInitialRequest,
+				nasMessage.ULNASTransportRequestTypeInitialEmergencyRequest:
 				_, err := CreatePDUSession(ulNasTransport, ue, anType, pduSessionID, smMessage)
 				return err
+			case nasMessage.ULNASTransportRequestTypeExistingEmergencyPduSession:
+				ue.GmmLog.Warnf("Emergency PDU Session[%d] does not exist", pduSessionID)
+				gmm_message.SendDLNASTransport(ue.RanUe[anType], nasMessage.PayloadContainerTypeN1SMInfo,
+					smMessage, pduSessionID, nasMessage.Cause5GMMPayloadWasNotForwarded, nil, 0, nil)
 			case nasMessage.ULNASTransportRequestTypeModificationRequest:
 				fallthrough
 			case nasMessage.ULNASTransportRequestTypeExistingPduSession:
@@ -190,7 +254,7 @@ func transport5GSMMessage(ue *context.AmfUe, anType models.AccessType,
 					pduSessionIDStr := fmt.Sprintf("%d", pduSessionID)
 					if ueContextInSmf, ok := ue.UeContextInSmfData.PduSessions[pduSessionIDStr]; !ok {
 						gmm_message.SendDLNASTransport(ue.RanUe[anType], nasMessage.PayloadContainerTypeN1SMInfo,
-							smMessage, pduSessionID, nasMessage.Cause5GMMPayloadWasNotForwarded, nil, 0)
+							smMessage, pduSessionID, nasMessage.Cause5GMMPayloadWasNotForwarded, nil, 0, nil)
 					} else {
 						// TS 24.501 5.4.5.2.3 case a) 1) iv)
 						smContext = context.NewSmContext(pduSessionID)
@@ -203,7 +267,7 @@ func transport5GSMMessage(ue *context.AmfUe, anType models.AccessType,
 					}
 				} else {
 					gmm_message.SendDLNASTransport(ue.RanUe[anType], nasMessage.PayloadContainerTypeN1SMInfo,
-						smMessage, pduSessionID, nasMessage.Cause5GMMPayloadWasNotForwarded, nil, 0)
+						smMessage, pduSessionID, nasMessage.Cause5GMMPayloadWasNotForwarded, nil, 0, nil)
 				}
 			default:
 			}
@@ -227,6 +291,13 @@ func CreatePDUSession(ulNasTransport *nasMessage.ULNASTransport,
 	)
 	// A) AMF shall select an SMF
 
+	// The emergency PDU session is established with the emergency configuration of the AMF instead of the S-NSSAI
+	// and DNN requested by the UE (TS 23.501 5.16.4.5)
+	if ulNasTransport.RequestType != nil &&
+		ulNasTransport.RequestType.GetRequestTypeValue() == nasMessage.ULNASTransportRequestTypeInitialEmergencyRequest {
+		return createEmergencyPDUSession(ue, anType, pduSessionID, smMessage)
+	}
+
 	// If the S-NSSAI IE is not included and the user's subscription context obtained from UDM. AMF shall
 	// select a default snssai
 	if ulNasTransport.SNSSAI != nil {
@@ -258,38 +329,86 @@ func CreatePDUSession(ulNasTransport *nasMessage.ULNASTransport,
 		}
 	}
 
-	if newSmContext, cause, errSelectSmf := consumer.GetConsumer().SelectSmf(
-		ue, anType, pduSessionID, snssai, dnn); errSelectSmf != nil {
+	if rejectCongestedSmMessage(ue, anType, pduSessionID, snssai, dnn, smMessage) {
+		return false, nil
+	}
+
+	newSmContext, cause, errSelectSmf := consumer.GetConsumer().SelectSmf(ue, anType, pduSessionID, snssai, dnn)
+	if errSelectSmf != nil {
 		ue.GmmLog.Errorf("Select SMF failed: %+v", errSelectSmf)
 		gmm_message.SendDLNASTransport(ue.RanUe[anType], nasMessage.PayloadContainerTypeN1SMInfo,
-			smMessage, pduSessionID, cause, nil, 0)
-	} else {
-		ue.Lock.Lock()
-		defer ue.Lock.Unlock()
-
-		smContextRef, errResponse, problemDetail, errSendReq := consumer.GetConsumer().SendCreateSmContextRequest(
-			ue, newSmContext, nil, smMessage)
-		if errSendReq != nil {
-			ue.GmmLog.Errorf("CreateSmContextRequest Error: %+v", errSendReq)
-			return false, nil
-		} else if problemDetail != nil {
-			// TODO: error handling
-			return false, fmt.Errorf("failed to Create smContext[pduSessionID: %d], Error[%v]", pduSessionID, problemDetail)
-		} else if errResponse != nil {
-			ue.GmmLog.Warnf("PDU Session Establishment Request is rejected by SMF[pduSessionId:%d]",
-				pduSessionID)
-			gmm_message.SendDLNASTransport(ue.RanUe[anType], nasMessage.PayloadContainerTypeN1SMInfo,
-				errResponse.BinaryDataN1SmMessage, pduSessionID, 0, nil, 0)
-		} else {
-			newSmContext.SetSmContextRef(smContextRef)
-			newSmContext.SetUserLocation(deepcopy.Copy(ue.Location).(models.UserLocation))
-			ue.StoreSmContext(pduSessionID, newSmContext)
-			ue.GmmLog.Infof("create smContext[pduSessionID: %d] Success", pduSessionID)
-			// TODO: handle response(response N2SmInfo to RAN if exists)
-			return true, nil
-		}
+			smMessage, pduSessionID, cause, nil, 0, nil)
+		return false, nil
 	}
-	return false, nil
+	return createSmContext(ue, anType, pduSessionID, newSmContext, nil, smMessage)
+}
+
+// TS 24.501 5.4.5.2.5: the 5GSM message is sent back to the UE with the back-off timer if the DNN or S-NSSAI based
+// congestion control is active, except for the high priority access
+func rejectCongestedSmMessage(ue *context.AmfUe, anType models.AccessType, pduSessionID int32,
+	snssai models.Snssai, dnn string, smMessage []uint8,
+) bool {
+	ranUe := ue.RanUe[anType]
+	if ranUe == nil || ranUe.CongestionControlExempted() {
+		return false
+	}
+	amfSelf := context.GetSelf()
+	cause := amfSelf.SmCongestionCause(snssai, dnn)
+	if cause == 0 {
+		return false
+	}
+
+	ue.GmmLog.Warnf("5GSM message of S-NSSAI[%+v] DNN[%s] is rejected by the congestion control (PDU Session ID: %d)",
+		snssai, dnn, pduSessionID)
+	// the back-off timer value is coded as GPRS timer 3 (TS 24.008 10.5.7.4a)
+	backoffTimer := nasConvert.GPRSTimer3ToNas(amfSelf.SmBackoffTimerValue())
+	backoffTimerUnit := backoffTimer >> 5
+	gmm_message.SendDLNASTransport(ranUe, nasMessage.PayloadContainerTypeN1SMInfo, smMessage, pduSessionID, cause,
+		&backoffTimerUnit, backoffTimer&0x1f, nil)
+	return true
+}
+
+func createEmergencyPDUSession(ue *context.AmfUe, anType models.AccessType, pduSessionID int32,
+	smMessage []uint8,
+) (setNewSmContext bool, err error) {
+	newSmContext, cause, errSelectSmf := consumer.GetConsumer().SelectEmergencySmf(ue, anType, pduSessionID)
+	if errSelectSmf != nil {
+		ue.GmmLog.Errorf("Select emergency SMF failed: %+v", errSelectSmf)
+		gmm_message.SendDLNASTransport(ue.RanUe[anType], nasMessage.PayloadContainerTypeN1SMInfo,
+			smMessage, pduSessionID, cause, nil, 0, nil)
+		return false, nil
+	}
+	requestType := models.RequestType_INITIAL_EMERGENCY_REQUEST
+	return createSmContext(ue, anType, pduSessionID, newSmContext, &requestType, smMessage)
+}
+
+func createSmContext(ue *context.AmfUe, anType models.AccessType, pduSessionID int32,
+	newSmContext *context.SmContext, requestType *models.RequestType, smMessage []uint8,
+) (setNewSmContext bool, err error) {
+	ue.Lock.Lock()
+	defer ue.Lock.Unlock()
+
+	smContextRef, errResponse, problemDetail, errSendReq := consumer.GetConsumer().SendCreateSmContextRequest(
+		ue, newSmContext, requestType, smMessage)
+	if errSendReq != nil {
+		ue.GmmLog.Errorf("CreateSmContextRequest Error: %+v", errSendReq)
+		return false, nil
+	} else if problemDetail != nil {
+		// TODO: error handling
+		return false, fmt.Errorf("failed to Create smContext[pduSessionID: %d], Error[%v]", pduSessionID, problemDetail)
+	} else if errResponse != nil {
+		ue.GmmLog.Warnf("PDU Session Establishment Request is rejected by SMF[pduSessionId:%d]",
+			pduSessionID)
+		gmm_message.SendDLNASTransport(ue.RanUe[anType], nasMessage.PayloadContainerTypeN1SMInfo,
+			errResponse.BinaryDataN1SmMessage, pduSessionID, 0, nil, 0, nil)
+		return false, nil
+	}
+	newSmContext.SetSmContextRef(smContextRef)
+	newSmContext.SetUserLocation(deepcopy.Copy(ue.Location).(models.UserLocation))
+	ue.StoreSmContext(pduSessionID, newSmContext)
+	ue.GmmLog.Infof("create smContext[pduSessionID: %d] Success", pduSessionID)
+	// TODO: handle response(response N2SmInfo to RAN if exists)
+	return true, nil
 }
 
 func forward5GSMMessageToSMF(
@@ -330,7 +449,7 @@ func forward5GSMMessageToSMF(
 			pduSessionID, errJSON.Error.Cause)
 		if n1Msg != nil {
 			gmm_message.SendDLNASTransport(ue.RanUe[accessType], nasMessage.PayloadContainerTypeN1SMInfo,
-				errResponse.BinaryDataN1SmMessage, pduSessionID, 0, nil, 0)
+				errResponse.BinaryDataN1SmMessage, pduSessionID, 0, nil, 0, nil)
 		}
 		// TODO: handle n2 info transfer
 	} else if response != nil {
@@ -344,7 +463,7 @@ func forward5GSMMessageToSMF(
 		if response.BinaryDataN1SmMessage != nil {
 			ue.GmmLog.Debug("Receive N1 SM Message from SMF")
 			n1Msg, err = gmm_message.BuildDLNASTransport(ue, accessType, nasMessage.PayloadContainerTypeN1SMInfo,
-				response.BinaryDataN1SmMessage, uint8(pduSessionID), nil, nil, 0)
+				response.BinaryDataN1SmMessage, uint8(pduSessionID), nil, nil, 0, nil)
 			if err != nil {
 				return err
 			}
@@ -412,7 +531,7 @@ func HandleRegistrationRequest(ue *context.AmfUe, anType models.AccessType, proc
 			ue.SecurityContextAvailable = false
 		} else {
 			m := nas.NewMessage()
-			if errGmmMessageDecode := m.GmmMessageDecode(&contents); errGmmMessageDecode != nil {
+			if errGmmMessageDecode := nas_security.GmmMessageDecode(ue, m, contents); errGmmMessageDecode != nil {
 				return errGmmMessageDecode
 			}
 
@@ -434,6 +553,7 @@ func HandleRegistrationRequest(ue *context.AmfUe, anType models.AccessType, proc
 	case nasMessage.RegistrationType5GSInitialRegistration:
 		ue.GmmLog.Infof("RegistrationType: Initial Registration")
 		ue.SecurityContextAvailable = false // need to start authentication procedure later
+		ue.EmergencyRegistered = false
 	case nasMessage.RegistrationType5GSMobilityRegistrationUpdating:
 		ue.GmmLog.Infof("RegistrationType: Mobility Registration Updating")
 		if ue.State[anType].Is(context.Deregistered) {
@@ -447,7 +567,16 @@ func HandleRegistrationRequest(ue *context.AmfUe, anType models.AccessType, proc
 			return fmt.Errorf("periodic registration updating was sent when the UE state was deregistered")
 		}
 	case nasMessage.RegistrationType5GSEmergencyRegistration:
-		return fmt.Errorf("not supported RegistrationType: Emergency Registration")
+		ue.GmmLog.Infof("RegistrationType: Emergency Registration")
+		if amfSelf.Emergency == nil {
+			// TS 24.501 5.5.1.2.5: the emergency registration not accepted by the network is rejected with
+			// the 5GMM cause #5, so that the UE informs the upper layers of the failure to access emergency services
+			gmm_message.SendRegistrationReject(ue.RanUe[anType], nasMessage.Cause5GMMPEINotAccepted, "")
+			return fmt.Errorf("emergency services are not supported")
+		}
+		// the emergency registration is handled as an initial registration (TS 23.502 4.2.2.2.2)
+		ue.SecurityContextAvailable = false
+		ue.EmergencyRegistered = true
 	case nasMessage.RegistrationType5GSReserved:
 		ue.RegistrationType5GS = nasMessage.RegistrationType5GSInitialRegistration
 		ue.GmmLog.Infof("RegistrationType: Reserved")
@@ -456,6 +585,13 @@ func HandleRegistrationRequest(ue *context.AmfUe, anType models.AccessType, proc
 		ue.RegistrationType5GS = nasMessage.RegistrationType5GSInitialRegistration
 	}
 
+	// TS 24.501 5.5.1.2.8, 5.5.1.3.8: the registration is rejected with the back-off timer T3346 while the general NAS
+	// level mobility management congestion control is active, except for the emergency and high priority access
+	if amfSelf.MmCongestionActive() && !ue.EmergencyRegistered && !ue.RanUe[anType].CongestionControlExempted() {
+		gmm_message.SendRegistrationReject(ue.RanUe[anType], nasMessage.Cause5GMMCongestion, "")
+		return fmt.Errorf("registration is rejected by the congestion control")
+	}
+
 	mobileIdentity5GSContents := registrationRequest.MobileIdentity5GS.GetMobileIdentity5GSContents()
 	if len(mobileIdentity5GSContents) < 1 {
 		return errors.New("broken MobileIdentity5GS")
@@ -484,7 +620,7 @@ func HandleRegistrationRequest(ue *context.AmfUe, anType models.AccessType, proc
 		ue.GmmLog.Infof("MobileIdentity5GS: GUTI[%s]", guti)
 
 		// TODO: support multiple ServedGuami
-		servedGuami := amfSelf.ServedGuamiList[0]
+		servedGuami := amfSelf.GetServedGuamiList()[0]
 		if reflect.DeepEqual(guamiFromUeGuti, servedGuami) {
 			ue.ServingAmfChanged = false
 			// refresh 5G-GUTI according to 6.12.3 Subscription temporary identifier, TS33.501
@@ -533,8 +669,14 @@ func HandleRegistrationRequest(ue *context.AmfUe, anType models.AccessType, proc
 	// TODO: This check due to RanUe may release during the process;it should be a better way to make this procedure
 	// as an atomic operation
 	if ue.RanUe[anType] != nil {
-		ue.Location = ue.RanUe[anType].Location
+		ue.SetLocation(ue.RanUe[anType].Location)
 		ue.Tai = ue.RanUe[anType].Tai
+		callback.SendAmfEventReport(ue, models.AmfEventType_LOCATION_REPORT)
+		// the time zone of the UE is the one of the AMF serving its location
+		if ue.TimeZone != amfSelf.TimeZone {
+			ue.TimeZone = amfSelf.TimeZone
+			callback.SendAmfEventReport(ue, models.AmfEventType_TIMEZONE_REPORT)
+		}
 		if ue.RanUe[anType].Ran != nil {
 			// ue.Ratype TS 23.502 4.2.2.1
 			// The AMF determines Access Type and RAT Type as defined in clause 5.3.2.3 of TS 23.501 .
@@ -586,7 +728,7 @@ func contextTransferFromOldAmf(ue *context.AmfUe, anType models.AccessType, oldA
 
 	var transferReason models.TransferReason
 	switch ue.RegistrationType5GS {
-	case nasMessage.RegistrationType5GSInitialRegistration:
+	case nasMessage.RegistrationType5GSInitialRegistration, nasMessage.RegistrationType5GSEmergencyRegistration:
 		transferReason = models.TransferReason_INIT_REG
 	case nasMessage.RegistrationType5GSMobilityRegistrationUpdating:
 		fallthrough
@@ -629,13 +771,17 @@ func HandleInitialRegistration(ue *context.AmfUe, anType models.AccessType) erro
 	// update Kgnb/Kn3iwf
 	ue.UpdateSecurityContext(anType)
 
-	// Registration with AMF re-allocation (TS 23.502 4.2.2.2.3)
-	if len(ue.SubscribedNssai) == 0 {
-		getSubscribedNssai(ue)
-	}
+	// The subscription of the unauthenticated UE for emergency services is unknown, and the emergency PDU session
+	// is established with the S-NSSAI for emergency services (TS 23.502 4.2.2.2.2)
+	if !ue.IsUnauthenticatedEmergency() {
+		// Registration with AMF re-allocation (TS 23.502 4.2.2.2.3)
+		if len(ue.SubscribedNssai) == 0 {
+			getSubscribedNssai(ue)
+		}
 
-	if err := handleRequestedNssai(ue, anType); err != nil {
-		return err
+		if err := handleRequestedNssai(ue, anType); err != nil {
+			return err
+		}
 	}
 
 	if ue.RegistrationRequest.Capability5GMM != nil {
@@ -647,11 +793,6 @@ func HandleInitialRegistration(ue *context.AmfUe, anType models.AccessType) erro
 
 	storeLastVisitedRegisteredTAI(ue, ue.RegistrationRequest.LastVisitedRegisteredTAI)
 
-	if ue.RegistrationRequest.MICOIndication != nil {
-		ue.GmmLog.Warnf("Receive MICO Indication[RAAI: %d], Not Supported",
-			ue.RegistrationRequest.MICOIndication.GetRAAI())
-	}
-
 	// TODO: Negotiate DRX value if need (TS 23.501 5.4.5)
 	negotiateDRXParameters(ue, ue.RegistrationRequest.RequestedDRXParameters)
 
@@ -680,9 +821,17 @@ func HandleInitialRegistration(ue *context.AmfUe, anType models.AccessType) erro
 	// TODO (step 12 optional): the new AMF initiates ME identity check by invoking the
 	// N5g-eir_EquipmentIdentityCheck_Get service operation
 
+	if ue.IsUnauthenticatedEmergency() {
+		// TS 23.502 4.2.2.2.2 step 14 and 16: the UDM and PCF are not contacted for the unauthenticated UE
+		return acceptInitialRegistration(ue, anType)
+	}
+
 	if ue.ServingAmfChanged || ue.State[models.AccessType_NON_3_GPP_ACCESS].Is(context.Registered) ||
 		!ue.ContextValid {
-		if err := communicateWithUDM(ue, anType); err != nil {
+		if err := communicateWithUDM(ue, anType); err != nil && ue.EmergencyRegistered {
+			// the subscription checks are skipped for emergency services (TS 23.501 5.16.4.3)
+			ue.GmmLog.Warnf("communicateWithUDM error for emergency registration: %v", err)
+		} else if err != nil {
 			ue.GmmLog.Errorf("communicateWithUDM error: %v", err)
 			gmm_message.SendRegistrationReject(ue.RanUe[anType], nasMessage.Cause5GMMPLMNNotAllowed, "")
 			return errors.Wrap(err, "communicateWithUDM failed")
@@ -757,19 +906,36 @@ func HandleInitialRegistration(ue *context.AmfUe, anType models.AccessType) erro
 	// 	TODO: send N2 AMF Mobility Request
 	// }
 
+	return acceptInitialRegistration(ue, anType)
+}
+
+func acceptInitialRegistration(ue *context.AmfUe, anType models.AccessType) error {
+	amfSelf := context.GetSelf()
+
 	amfSelf.AllocateRegistrationArea(ue, anType)
 	ue.GmmLog.Debugf("Use original GUTI[%s]", ue.Guti)
 
 	assignLadnInfo(ue, anType)
 
-	amfSelf.AddAmfUeToUePool(ue, ue.Supi)
+	if ue.Supi != "" {
+		amfSelf.AddAmfUeToUePool(ue, ue.Supi)
+	} else {
+		amfSelf.AddPeiOnlyAmfUeToUePool(ue)
+	}
 	ue.T3502Value = amfSelf.T3502Value
 	if anType == models.AccessType__3_GPP_ACCESS {
 		ue.T3512Value = amfSelf.T3512Value
+		negotiateMicoMode(ue)
+		negotiateEdrxParameters(ue)
 	} else {
 		ue.Non3gppDeregTimerValue = amfSelf.Non3gppDeregTimerValue
 	}
 
+	// only the emergency services are provided to the UE registered for emergency services
+	if !ue.EmergencyRegistered {
+		activateSmsOverNas(ue, anType)
+	}
+
 	gmm_message.SendRegistrationAccept(ue, anType, nil, nil, nil, nil, nil)
 	return nil
 }
@@ -804,11 +970,6 @@ func HandleMobilityAndPeriodicRegistrationUpdating(ue *context.AmfUe, anType mod
 
 	storeLastVisitedRegisteredTAI(ue, ue.RegistrationRequest.LastVisitedRegisteredTAI)
 
-	if ue.RegistrationRequest.MICOIndication != nil {
-		ue.GmmLog.Warnf("Receive MICO Indication[RAAI: %d], Not Supported",
-			ue.RegistrationRequest.MICOIndication.GetRAAI())
-	}
-
 	// TODO: Negotiate DRX value if need (TS 23.501 5.4.5)
 	negotiateDRXParameters(ue, ue.RegistrationRequest.RequestedDRXParameters)
 
@@ -887,24 +1048,13 @@ func HandleMobilityAndPeriodicRegistrationUpdating(ue *context.AmfUe, anType mod
 			// SMF has indicated pending downlink signaling only,
 			// forward the received 5GSM message via 3GPP access to the UE
 			// after the REGISTRATION ACCEPT message is sent
+			appendQueuedN1N2Messages(ue, anType, &cxtList)
 			gmm_message.SendRegistrationAccept(ue, anType, pduSessionStatus,
 				reactivationResult, errPduSessionId, errCause, &cxtList)
 
-			switch requestData.N1MessageContainer.N1MessageClass {
-			case models.N1MessageClass_SM:
-				gmm_message.SendDLNASTransport(ue.RanUe[anType], nasMessage.PayloadContainerTypeN1SMInfo,
-					n1Msg, requestData.PduSessionId, 0, nil, 0)
-			case models.N1MessageClass_LPP:
-				gmm_message.SendDLNASTransport(ue.RanUe[anType], nasMessage.PayloadContainerTypeLPP,
-					n1Msg, 0, 0, nil, 0)
-			case models.N1MessageClass_SMS:
-				gmm_message.SendDLNASTransport(ue.RanUe[anType], nasMessage.PayloadContainerTypeSMS,
-					n1Msg, 0, 0, nil, 0)
-			case models.N1MessageClass_UPDP:
-				gmm_message.SendDLNASTransport(ue.RanUe[anType], nasMessage.PayloadContainerTypeUEPolicy,
-					n1Msg, 0, 0, nil, 0)
-			}
+			sendN1N2MessageN1(ue, anType, requestData, n1Msg)
 			ue.N1N2Message = nil
+			deliverQueuedN1N2Messages(ue, anType)
 			return nil
 		}
 
@@ -945,13 +1095,74 @@ func HandleMobilityAndPeriodicRegistrationUpdating(ue *context.AmfUe, anType mod
 	assignLadnInfo(ue, anType)
 
 	// TODO: GUTI reassignment if need (based on operator poilcy)
-	// TODO: T3512/Non3GPP de-registration timer reassignment if need (based on operator policy)
+	// T3512/Non3GPP de-registration timer is assigned if the UE context is transferred from the old AMF
+	if anType == models.AccessType__3_GPP_ACCESS && ue.T3512Value == 0 {
+		ue.T3512Value = amfSelf.T3512Value
+	} else if anType == models.AccessType_NON_3_GPP_ACCESS && ue.Non3gppDeregTimerValue == 0 {
+		ue.Non3gppDeregTimerValue = amfSelf.Non3gppDeregTimerValue
+	}
+	if anType == models.AccessType__3_GPP_ACCESS {
+		negotiateMicoMode(ue)
+		negotiateEdrxParameters(ue)
+	}
 
+	activateSmsOverNas(ue, anType)
+
+	// the transfers queued while the UE is in CM-IDLE are delivered along with the Registration Accept
+	appendQueuedN1N2Messages(ue, anType, &cxtList)
 	gmm_message.SendRegistrationAccept(ue, anType, pduSessionStatus, reactivationResult,
 		errPduSessionId, errCause, &cxtList)
+	deliverQueuedN1N2Messages(ue, anType)
 	return nil
 }
 
+// TS 23.502 4.13.2.1, the SMS over NAS is activated in the SMSF if the UE requests it in the registration and
+// the SMS is subscribed, and then the SMS over NAS is allowed in the Registration Accept
+func activateSmsOverNas(ue *context.AmfUe, anType models.AccessType) {
+	updateType := ue.RegistrationRequest.UpdateType5GS
+	if updateType == nil || updateType.GetSMSRequested() != smsOverNasSupported {
+		if ue.SmsOverNasActivated[anType] {
+			gmm_common.DeactivateSmsOverNas(ue, anType)
+		}
+		return
+	}
+	if ue.SmsOverNasActivated[anType] {
+		return
+	}
+
+	if ue.SmsSubscriptionData == nil {
+		problemDetails, err := consumer.GetConsumer().SDMGetSmsData(ue)
+		if problemDetails != nil {
+			ue.GmmLog.Errorf("SDM_Get SmsData Failed Problem[%+v]", problemDetails)
+			return
+		} else if err != nil {
+			ue.GmmLog.Errorf("SDM_Get SmsData Error[%+v]", err)
+			return
+		}
+	}
+	if !ue.SmsSubscriptionData.SmsSubscribed {
+		ue.GmmLog.Infoln("SMS over NAS is not subscribed")
+		return
+	}
+
+	if ue.SmsfUri == "" {
+		if err := consumer.GetConsumer().SelectSmsf(ue); err != nil {
+			ue.GmmLog.Errorf("Select SMSF Error[%+v]", err)
+			return
+		}
+	}
+	problemDetails, err := consumer.GetConsumer().SmServiceActivate(ue, anType)
+	if problemDetails != nil {
+		ue.GmmLog.Errorf("SMService Activate Failed Problem[%+v]", problemDetails)
+		return
+	} else if err != nil {
+		ue.GmmLog.Errorf("SMService Activate Error[%+v]", err)
+		return
+	}
+	ue.GmmLog.Infof("SMS over NAS is activated in SMSF[%s]", ue.SmsfId)
+	ue.SmsOverNasActivated[anType] = true
+}
+
 // TS 23.502 4.2.2.2.2 step 1
 // If available, the last visited TAI shall be included in order to help the AMF produce Registration Area for the UE
 func storeLastVisitedRegisteredTAI(ue *context.AmfUe, lastVisitedRegisteredTAI *nasType.LastVisitedRegisteredTAI) {
@@ -997,6 +1208,118 @@ func negotiateDRXParameters(ue *context.AmfUe, requestedDRXParameters *nasType.R
 	}
 }
 
+// TS 23.501 5.4.1.3, the MICO mode is allowed if the UE requests it in the registration, and it is allowed by
+// the subscription and the local policy. The UE in MICO mode is assigned the extended periodic registration
+// timer, and the all PLMN registration area if requested. The MICO mode is renegotiated in every registration.
+func negotiateMicoMode(ue *context.AmfUe) {
+	amfSelf := context.GetSelf()
+	micoIndication := ue.RegistrationRequest.MICOIndication
+
+	micoMode := micoIndication != nil && amfSelf.Mico != nil && amfSelf.Mico.Enable && !ue.EmergencyRegistered &&
+		ue.AccessAndMobilitySubscriptionData != nil && ue.AccessAndMobilitySubscriptionData.MicoAllowed
+	if !micoMode {
+		if micoIndication != nil {
+			ue.GmmLog.Infof("MICO mode is not allowed")
+		}
+		if ue.MicoMode {
+			// the periodic registration timer of the MICO mode is no longer applied
+			ue.T3512Value = amfSelf.T3512Value
+		}
+		ue.MicoMode = false
+		ue.MicoAllPlmnRegistrationArea = false
+		return
+	}
+
+	ue.MicoMode = true
+	ue.MicoAllPlmnRegistrationArea = micoIndication.GetRAAI() == 1 && amfSelf.Mico.AllPlmnRegistrationArea
+	if subsRegTimer := ue.AccessAndMobilitySubscriptionData.SubsRegTimer; subsRegTimer > 0 {
+		ue.T3512Value = int(subsRegTimer)
+	} else if amfSelf.Mico.T3512Value > 0 {
+		ue.T3512Value = amfSelf.Mico.T3512Value
+	}
+	ue.GmmLog.Infof("MICO mode is allowed[RAAI: %t, T3512: %ds]", ue.MicoAllPlmnRegistrationArea, ue.T3512Value)
+}
+
+// TS 24.501 5.4.4.2, the UE in MICO mode is requested to re-negotiate the MICO mode in a registration if it's
+// no longer allowed by the subscription. The UE in CM-IDLE re-negotiates it in its next registration.
+// The caller shall hold ue.Lock.
+func HandleSubscribedMicoChange(ue *context.AmfUe) {
+	if !ue.MicoMode || !ue.State[models.AccessType__3_GPP_ACCESS].Is(context.Registered) ||
+		!ue.CmConnect(models.AccessType__3_GPP_ACCESS) {
+		return
+	}
+	gmm_message.SendConfigurationUpdateCommand(ue, models.AccessType__3_GPP_ACCESS,
+		&context.ConfigurationUpdateCommandFlags{
+			NeedMicoIndication: true,
+		})
+}
+
+// TS 23.501 5.31.7.2, the eDRX is allowed if the UE requests it in the registration and it is allowed by the
+// local policy. The eDRX value and the paging time window of the subscription are used instead of the requested
+// ones, and the eDRX cycle is limited by the local policy. The eDRX is renegotiated in every registration.
+func negotiateEdrxParameters(ue *context.AmfUe) {
+	amfSelf := context.GetSelf()
+	if ue.RequestedEdrx == nil || amfSelf.Edrx == nil || !amfSelf.Edrx.Enable || ue.EmergencyRegistered {
+		if ue.RequestedEdrx != nil {
+			ue.GmmLog.Infof("eDRX is not allowed")
+		}
+		ue.Edrx = nil
+		return
+	}
+
+	edrx := *ue.RequestedEdrx
+	if subscription := ue.AccessAndMobilitySubscriptionData; subscription != nil {
+		for _, edrxParameters := range subscription.EdrxParametersList {
+			if edrxParameters.RatType != ue.RatType {
+				continue
+			}
+			if value, err := strconv.ParseUint(edrxParameters.EdrxValue, 2, 4); err == nil {
+				edrx.Value = uint8(value)
+			}
+		}
+		for _, ptwParameters := range subscription.PtwParametersList {
+			if ptwParameters.OperationMode != edrxOperationMode(ue.RatType) {
+				continue
+			}
+			if value, err := strconv.ParseUint(ptwParameters.PtwValue, 2, 4); err == nil {
+				edrx.PagingTimeWindow = uint8(value)
+			}
+		}
+	}
+
+	if amfSelf.Edrx.MaxCycle > 0 {
+		maxCycle := time.Duration(amfSelf.Edrx.MaxCycle) * time.Second
+		if edrx.Cycle() > maxCycle {
+			value, ok := context.EdrxValueOfMaxCycle(maxCycle)
+			if !ok {
+				ue.GmmLog.Infof("eDRX is not allowed, the maximum eDRX cycle is too short")
+				ue.Edrx = nil
+				return
+			}
+			edrx.Value = value
+		}
+	}
+	if amfSelf.Edrx.PagingTimeWindow > 0 {
+		edrx.PagingTimeWindow = context.PagingTimeWindowOfLength(
+			time.Duration(amfSelf.Edrx.PagingTimeWindow) * time.Second)
+	}
+
+	ue.Edrx = &edrx
+	ue.GmmLog.Infof("eDRX is allowed[cycle: %s, PTW: %s]", edrx.Cycle(), edrx.PagingTimeWindowLength())
+}
+
+// The operation mode of the paging time window subscription (TS 29.503 6.1.6.3.10) for the RAT type
+func edrxOperationMode(ratType models.RatType) models.OperationMode {
+	switch ratType {
+	case models.RatType_EUTRA:
+		return models.OperationMode_WB_N1
+	case models.RatType_NBIOT:
+		return models.OperationMode_NB_N1
+	default:
+		return models.OperationMode_NR_N1
+	}
+}
+
 func communicateWithUDM(ue *context.AmfUe, accessType models.AccessType) error {
 	ue.GmmLog.Debugln("communicateWithUDM")
 	amfSelf := context.GetSelf()
@@ -1060,6 +1383,17 @@ func communicateWithUDM(ue *context.AmfUe, accessType models.AccessType) error {
 		return errors.Wrap(err, "SDM_Get UeContextInSmfData Error")
 	}
 
+	// the UE is registered without the trace if the trace data is not available
+	traceData, problemDetails, err := consumer.GetConsumer().SDMGetTraceData(ue)
+	if problemDetails != nil {
+		ue.GmmLog.Debugf("SDM_Get TraceData Failed Problem[%+v]", problemDetails)
+	} else if err != nil {
+		ue.GmmLog.Warnf("SDM_Get TraceData Error[%+v]", err)
+	} else if traceData != nil && !reflect.DeepEqual(traceData, ue.TraceData) {
+		// the trace session activated in the previous registration is kept if the trace data is not changed
+		gmm_common.ActivateTrace(ue, traceData)
+	}
+
 	problemDetails, err = consumer.GetConsumer().SDMSubscribe(ue)
 	if problemDetails != nil {
 		return errors.Errorf("%s", problemDetails.Cause)
@@ -1099,6 +1433,9 @@ func getSubscribedNssai(ue *context.AmfUe) {
 func handleRequestedNssai(ue *context.AmfUe, anType models.AccessType) error {
 	amfSelf := context.GetSelf()
 
+	// the pending NSSAI is determined in each registration (TS 23.502 4.2.9.1)
+	ue.PendingNssai = nil
+
 	if ue.RegistrationRequest.RequestedNSSAI != nil {
 		logger.GmmLog.Infof("RequestedNssai: %+v", ue.RegistrationRequest.RequestedNSSAI)
 		requestedNssai, err := nasConvert.RequestedNssaiToModels(ue.RegistrationRequest.RequestedNSSAI)
@@ -1118,7 +1455,9 @@ func handleRequestedNssai(ue *context.AmfUe, anType models.AccessType) error {
 					},
 					MappedHomeSnssai: requestedSnssai.HomeSnssai,
 				}
-				if !ue.InAllowedNssai(*allowedSnssai.AllowedSnssai, anType) {
+				if !nssaaAllowed(ue, *allowedSnssai.AllowedSnssai) {
+					addNssaaPendingSnssai(ue, *allowedSnssai.AllowedSnssai)
+				} else if !ue.InAllowedNssai(*allowedSnssai.AllowedSnssai, anType) {
 					ue.AllowedNssai[anType] = append(ue.AllowedNssai[anType], allowedSnssai)
 				}
 			} else {
@@ -1185,7 +1524,7 @@ func handleRequestedNssai(ue *context.AmfUe, anType models.AccessType) error {
 					// TargetAmfSet format: ^[0-9]{3}-[0-9]{2-3}-[A-Fa-f0-9]{2}-[0-3][A-Fa-f0-9]{2}$
 					// mcc-mnc-amfRegionId(8 bit)-AmfSetId(10 bit)
 					targetAmfSetToken := strings.Split(networkSliceInfo.TargetAmfSet, "-")
-					guami := amfSelf.ServedGuamiList[0]
+					guami := amfSelf.GetServedGuamiList()[0]
 					targetAmfPlmnId := models.PlmnId{
 						Mcc: targetAmfSetToken[0],
 						Mnc: targetAmfSetToken[1],
@@ -1216,7 +1555,7 @@ func handleRequestedNssai(ue *context.AmfUe, anType models.AccessType) error {
 					AnType:           anType,
 					AnN2ApId:         int32(ue.RanUe[anType].RanUeNgapId),
 					RanNodeId:        ue.RanUe[anType].Ran.RanId,
-					InitialAmfName:   amfSelf.Name,
+					InitialAmfName:   amfSelf.GetName(),
 					UserLocation:     &ue.Location,
 					RrcEstCause:      ue.RanUe[anType].RRCEstablishmentCause,
 					UeContextRequest: ue.RanUe[anType].UeContextRequest,
@@ -1262,6 +1601,10 @@ func handleRequestedNssai(ue *context.AmfUe, anType models.AccessType) error {
 		for _, snssai := range ue.SubscribedNssai {
 			if snssai.DefaultIndication {
 				if amfSelf.InPlmnSupportList(*snssai.SubscribedSnssai) {
+					if !nssaaAllowed(ue, *snssai.SubscribedSnssai) {
+						addNssaaPendingSnssai(ue, *snssai.SubscribedSnssai)
+						continue
+					}
 					allowedSnssai := models.AllowedSnssai{
 						AllowedSnssai: snssai.SubscribedSnssai,
 					}
@@ -1273,6 +1616,20 @@ func handleRequestedNssai(ue *context.AmfUe, anType models.AccessType) error {
 	return nil
 }
 
+// Whether the S-NSSAI can be in the allowed NSSAI without the NSSAA (TS 23.502 4.2.9.1). The S-NSSAI subject to
+// NSSAA is in the allowed NSSAI once the NSSAA succeeds, and it is rejected if the NSSAA failed or was revoked.
+func nssaaAllowed(ue *context.AmfUe, snssai models.Snssai) bool {
+	status, ok := ue.NssaaStatus(snssai)
+	return !ok || status == models.AuthStatus_EAP_SUCCESS
+}
+
+// The S-NSSAI not allowed until its NSSAA is performed is added to the pending NSSAI (TS 23.502 4.2.9.1)
+func addNssaaPendingSnssai(ue *context.AmfUe, snssai models.Snssai) {
+	if status, ok := ue.NssaaStatus(snssai); ok && status == models.AuthStatus_PENDING {
+		ue.AddPendingSnssai(snssai)
+	}
+}
+
 func assignLadnInfo(ue *context.AmfUe, accessType models.AccessType) {
 	amfSelf := context.GetSelf()
 
@@ -1639,6 +1996,14 @@ func AuthenticationProcedure(ue *context.AmfUe, accessType models.AccessType) (b
 			ue.GmmLog.Debugln("UE has a valid security context - skip the authentication procedure")
 			return true, nil
 		}
+	} else if ue.EmergencyRegistered && ue.Pei != "" {
+		// TS 33.501 10.2.2: the UE registered for emergency services with only the PEI can not be authenticated
+		if !context.GetSelf().Emergency.AllowUnauthenticated {
+			gmm_message.SendRegistrationReject(ue.RanUe[accessType], nasMessage.Cause5GMMPEINotAccepted, "")
+			return false, fmt.Errorf("unauthenticated emergency services are not allowed")
+		}
+		ue.GmmLog.Infoln("UE is registered for emergency services with PEI - skip the authentication procedure")
+		return true, nil
 	} else {
 		// Request UE's SUCI by sending identity request
 		ue.IdentityRequestSendTimes++
@@ -1677,6 +2042,10 @@ func AuthenticationProcedure(ue *context.AmfUe, accessType models.AccessType) (b
 	ue.AusfUri = ausfUri
 
 	response, problemDetails, err := consumer.GetConsumer().SendUEAuthenticationAuthenticateRequest(ue, nil)
+	if (err != nil || problemDetails != nil) && continueUnauthenticatedEmergency(ue) {
+		ue.GmmLog.Warnf("Authentication of emergency services failed - continue without authentication")
+		return true, nil
+	}
 	if err != nil {
 		ue.GmmLog.Errorf("Nausf_UEAU Authenticate Request Error: %+v", err)
 		gmm_message.SendRegistrationReject(ue.RanUe[accessType], nasMessage.Cause5GMMCongestion, "")
@@ -1704,6 +2073,12 @@ func AuthenticationProcedure(ue *context.AmfUe, accessType models.AccessType) (b
 	return false, nil
 }
 
+// TS 33.501 10.2.2: the UE registered for emergency services continues the registration without authentication
+// if the authentication fails and the local regulation allows the unauthenticated emergency services
+func continueUnauthenticatedEmergency(ue *context.AmfUe) bool {
+	return ue.EmergencyRegistered && context.GetSelf().Emergency.AllowUnauthenticated
+}
+
 // TS 24501 5.6.1
 func HandleServiceRequest(ue *context.AmfUe, anType models.AccessType,
 	serviceRequest *nasMessage.ServiceRequest,
@@ -1714,9 +2089,6 @@ func HandleServiceRequest(ue *context.AmfUe, anType models.AccessType,
 
 	ue.GmmLog.Info("Handle Service Request")
 
-	ue.StopT3513()
-	ue.StopT3565()
-
 	// Set No ongoing
 	if procedure := ue.OnGoing(anType).Procedure; procedure == context.OnGoingProcedurePaging {
 		ue.SetOnGoing(anType, &context.OnGoing{
@@ -1780,18 +2152,42 @@ func HandleServiceRequest(ue *context.AmfUe, anType models.AccessType,
 	var dlPduSessionId int32
 	cxtList := ngapType.PDUSessionResourceSetupListCxtReq{}
 
-	if serviceType == nasMessage.ServiceTypeEmergencyServices ||
+	if (serviceType == nasMessage.ServiceTypeEmergencyServices && context.GetSelf().Emergency == nil) ||
 		serviceType == nasMessage.ServiceTypeEmergencyServicesFallback {
-		ue.GmmLog.Warnf("emergency service is not supported")
+		ue.GmmLog.Warnf("emergency service[service type: %d] is not supported", serviceType)
 		gmm_message.SendServiceReject(ue.RanUe[anType], pduStatusResult, nasMessage.Cause5GMM5GSServicesNotAllowed)
 		ngap_message.SendUEContextReleaseCommand(ue.RanUe[anType],
 			context.UeContextN2NormalRelease, ngapType.CausePresentNas, ngapType.CauseNasPresentNormalRelease)
 		return nil
 	}
 
+	// TS 24.501 5.6.1.5: the service request is rejected with the back-off timer T3346 while the general NAS level
+	// mobility management congestion control is active, except for the emergency services, high priority access and
+	// the response to paging
+	if context.GetSelf().MmCongestionActive() && !ue.RanUe[anType].CongestionControlExempted() &&
+		serviceType != nasMessage.ServiceTypeEmergencyServices &&
+		serviceType != nasMessage.ServiceTypeHighPriorityAccess &&
+		serviceType != nasMessage.ServiceTypeMobileTerminatedServices {
+		ue.GmmLog.Warnf("service request[service type: %d] is rejected by the congestion control", serviceType)
+		gmm_message.SendServiceReject(ue.RanUe[anType], pduStatusResult, nasMessage.Cause5GMMCongestion)
+		ngap_message.SendUEContextReleaseCommand(ue.RanUe[anType],
+			context.UeContextN2NormalRelease, ngapType.CausePresentNas, ngapType.CauseNasPresentNormalRelease)
+		return nil
+	}
+
 	if serviceType == nasMessage.ServiceTypeSignalling {
+		appendQueuedN1N2Messages(ue, anType, &cxtList)
 		err := gmm_message.SendServiceAccept(ue, anType, cxtList, pduStatusResult, nil, nil, nil)
-		return err
+		if err != nil {
+			return err
+		}
+		// the paging is stopped once the service request is accepted. If the service request is rejected, the
+		// transfers attempting to reach the UE stay queued until the UE responds to the retransmitted paging,
+		// or fail with UE_NOT_RESPONDING when the paging is aborted
+		ue.StopT3513()
+		ue.StopT3565()
+		deliverQueuedN1N2Messages(ue, anType)
+		return nil
 	}
 
 	var N1N2ReqData *models.N1N2MessageTransferReqData
@@ -1827,6 +2223,9 @@ func HandleServiceRequest(ue *context.AmfUe, anType models.AccessType,
 		releaseInactivePDUSession(ue, anType, &uePduStatus, pduStatusResult)
 	}
 
+	// the transfers queued while the UE is in CM-IDLE are delivered along with the one triggering the paging
+	appendQueuedN1N2Messages(ue, anType, &cxtList)
+
 	switch serviceType {
 	case nasMessage.ServiceTypeMobileTerminatedServices: // Trigger by Network
 		if ue.N1N2Message != nil {
@@ -1837,21 +2236,11 @@ func HandleServiceRequest(ue *context.AmfUe, anType models.AccessType,
 				if err != nil {
 					return err
 				}
-				switch N1N2ReqData.N1MessageContainer.N1MessageClass {
-				case models.N1MessageClass_SM:
-					gmm_message.SendDLNASTransport(ue.RanUe[anType],
-						nasMessage.PayloadContainerTypeN1SMInfo, n1Msg, N1N2ReqData.PduSessionId, 0, nil, 0)
-				case models.N1MessageClass_LPP:
-					gmm_message.SendDLNASTransport(ue.RanUe[anType],
-						nasMessage.PayloadContainerTypeLPP, n1Msg, 0, 0, nil, 0)
-				case models.N1MessageClass_SMS:
-					gmm_message.SendDLNASTransport(ue.RanUe[anType],
-						nasMessage.PayloadContainerTypeSMS, n1Msg, 0, 0, nil, 0)
-				case models.N1MessageClass_UPDP:
-					gmm_message.SendDLNASTransport(ue.RanUe[anType],
-						nasMessage.PayloadContainerTypeUEPolicy, n1Msg, 0, 0, nil, 0)
-				}
+				ue.StopT3513()
+				ue.StopT3565()
+				sendN1N2MessageN1(ue, anType, N1N2ReqData, n1Msg)
 				ue.N1N2Message = nil
+				deliverQueuedN1N2Messages(ue, anType)
 				return nil
 			}
 
@@ -1875,7 +2264,7 @@ func HandleServiceRequest(ue *context.AmfUe, anType models.AccessType,
 				if n1Msg != nil {
 					pduSessionId := uint8(smInfo.PduSessionId)
 					nasPdu, err = gmm_message.BuildDLNASTransport(ue, anType, nasMessage.PayloadContainerTypeN1SMInfo,
-						n1Msg, pduSessionId, nil, nil, 0)
+						n1Msg, pduSessionId, nil, nil, 0, nil)
 					if err != nil {
 						return err
 					}
@@ -1899,6 +2288,13 @@ func HandleServiceRequest(ue *context.AmfUe, anType models.AccessType,
 			}
 			gmm_message.SendConfigurationUpdateCommand(ue, anType, ue.ConfigurationUpdateCommandFlags)
 			ue.ConfigurationUpdateCommandFlags = nil
+		} else if ue.N1N2Message == nil {
+			// paged for the signalling of other NFs, e.g. the LPP of positioning
+			err := gmm_message.SendServiceAccept(ue, anType, cxtList,
+				pduStatusResult, reactivationResult, errPduSessionId, errCause)
+			if err != nil {
+				return err
+			}
 		}
 	case nasMessage.ServiceTypeData:
 		if anType == models.AccessType__3_GPP_ACCESS {
@@ -1922,6 +2318,13 @@ func HandleServiceRequest(ue *context.AmfUe, anType models.AccessType,
 		if err != nil {
 			return err
 		}
+	case nasMessage.ServiceTypeEmergencyServices:
+		// the emergency services are not restricted by the service area restriction (TS 23.501 5.16.4.3)
+		err := gmm_message.SendServiceAccept(ue, anType, cxtList, pduStatusResult,
+			reactivationResult, errPduSessionId, errCause)
+		if err != nil {
+			return err
+		}
 	case nasMessage.ServiceTypeHighPriorityAccess:
 		// TODO: support HighPriorityAccess
 		err := gmm_message.SendServiceAccept(ue, anType, cxtList, pduStatusResult,
@@ -1935,10 +2338,129 @@ func HandleServiceRequest(ue *context.AmfUe, anType models.AccessType,
 	if len(errPduSessionId) != 0 {
 		ue.GmmLog.Info(errPduSessionId, errCause)
 	}
+	ue.StopT3513()
+	ue.StopT3565()
 	ue.N1N2Message = nil
+	deliverQueuedN1N2Messages(ue, anType)
+
+	// the NSSAA re-authentication requested while the UE is in CM-IDLE (TS 23.502 4.2.9.3)
+	ue.Lock.Lock()
+	StartNssaa(ue, anType)
+	ue.Lock.Unlock()
 	return nil
 }
 
+// Forward the N1 message of the N1N2MessageTransfer to the UE in CM-CONNECTED
+func sendN1N2MessageN1(ue *context.AmfUe, anType models.AccessType,
+	requestData *models.N1N2MessageTransferReqData, n1Msg []byte,
+) {
+	if requestData == nil || requestData.N1MessageContainer == nil || n1Msg == nil {
+		return
+	}
+	switch requestData.N1MessageContainer.N1MessageClass {
+	case models.N1MessageClass_SM:
+		gmm_message.SendDLNASTransport(ue.RanUe[anType],
+			nasMessage.PayloadContainerTypeN1SMInfo, n1Msg, requestData.PduSessionId, 0, nil, 0, nil)
+	case models.N1MessageClass_LPP:
+		gmm_message.SendDLNASTransport(ue.RanUe[anType], nasMessage.PayloadContainerTypeLPP,
+			n1Msg, 0, 0, nil, 0, context.LppRoutingInformation(requestData.N1MessageContainer.NfId))
+	case models.N1MessageClass_SMS:
+		gmm_message.SendDLNASTransport(ue.RanUe[anType],
+			nasMessage.PayloadContainerTypeSMS, n1Msg, 0, 0, nil, 0, nil)
+	case models.N1MessageClass_UPDP:
+		gmm_message.SendDLNASTransport(ue.RanUe[anType],
+			nasMessage.PayloadContainerTypeUEPolicy, n1Msg, 0, 0, nil, 0, nil)
+	}
+}
+
+// TS 29.518 5.2.2.3.1.2, the transfers queued while the UE is in CM-IDLE are delivered when the UE is reached.
+// The PDU sessions to set up are included in the Initial Context Setup of the accept; the transfers stay queued
+// until the accept is sent and deliverQueuedN1N2Messages is called.
+func appendQueuedN1N2Messages(ue *context.AmfUe, anType models.AccessType,
+	cxtList *ngapType.PDUSessionResourceSetupListCxtReq,
+) {
+	if anType != models.AccessType__3_GPP_ACCESS {
+		return
+	}
+
+	for _, n1n2Message := range ue.N1N2MessageQueue {
+		requestData := n1n2Message.Request.JsonData
+		if context.N1N2MessageNgapIeType(requestData) != models.AmfCommunicationNgapIeType_PDU_RES_SETUP_REQ {
+			continue
+		}
+
+		smInfo := requestData.N2InfoContainer.SmInfo
+		if pduSessionResourceSetupListCxtReqHas(cxtList, smInfo.PduSessionId) {
+			// the user plane resources have been re-activated for the pending uplink data
+			continue
+		}
+		var nasPdu []byte
+		if n1Msg := n1n2Message.Request.BinaryDataN1Message; n1Msg != nil {
+			var err error
+			nasPdu, err = gmm_message.BuildDLNASTransport(ue, anType, nasMessage.PayloadContainerTypeN1SMInfo,
+				n1Msg, uint8(smInfo.PduSessionId), nil, nil, 0, nil)
+			if err != nil {
+				ue.GmmLog.Errorf("Build DL NAS Transport error: %+v", err)
+				continue
+			}
+		}
+		ngap_message.AppendPDUSessionResourceSetupListCxtReq(cxtList, smInfo.PduSessionId, *smInfo.SNssai,
+			nasPdu, n1n2Message.Request.BinaryDataN2Information)
+	}
+}
+
+// Dequeue the queued transfers after the accept is sent. The PDU sessions to set up have been included in the
+// Initial Context Setup, the N2 information to modify the PDU session is sent with the N1 message in the PDU Session
+// Resource Modify Request, and only the N1 message of the others is forwarded (TS 23.502 4.3.3.2 step 3a).
+func deliverQueuedN1N2Messages(ue *context.AmfUe, anType models.AccessType) {
+	if anType != models.AccessType__3_GPP_ACCESS {
+		return
+	}
+
+	for _, n1n2Message := range ue.DequeueN1N2Messages() {
+		n1n2Message.Status = models.N1N2MessageTransferCause_N1_N2_TRANSFER_INITIATED
+		requestData := n1n2Message.Request.JsonData
+		n1Msg := n1n2Message.Request.BinaryDataN1Message
+		switch context.N1N2MessageNgapIeType(requestData) {
+		case models.AmfCommunicationNgapIeType_PDU_RES_SETUP_REQ:
+			continue
+		case models.AmfCommunicationNgapIeType_PDU_RES_MOD_REQ:
+			smInfo := requestData.N2InfoContainer.SmInfo
+			var nasPdu []byte
+			if n1Msg != nil {
+				var err error
+				nasPdu, err = gmm_message.BuildDLNASTransport(ue, anType, nasMessage.PayloadContainerTypeN1SMInfo,
+					n1Msg, uint8(smInfo.PduSessionId), nil, nil, 0, nil)
+				if err != nil {
+					ue.GmmLog.Errorf("Build DL NAS Transport error: %+v", err)
+					continue
+				}
+			}
+			list := ngapType.PDUSessionResourceModifyListModReq{}
+			ngap_message.AppendPDUSessionResourceModifyListModReq(&list, smInfo.PduSessionId, nasPdu,
+				n1n2Message.Request.BinaryDataN2Information)
+			ngap_message.SendPDUSessionResourceModifyRequest(ue.RanUe[anType], list)
+			continue
+		}
+		if n1Msg == nil {
+			ue.GmmLog.Debugf("Discard the N2 information of N1N2 message transfer[%s]", n1n2Message.ResourceUri)
+			continue
+		}
+		sendN1N2MessageN1(ue, anType, requestData, n1Msg)
+	}
+}
+
+func pduSessionResourceSetupListCxtReqHas(cxtList *ngapType.PDUSessionResourceSetupListCxtReq,
+	pduSessionID int32,
+) bool {
+	for _, item := range cxtList.List {
+		if item.PDUSessionID.Value == int64(pduSessionID) {
+			return true
+		}
+	}
+	return false
+}
+
 // TS 24.501 5.4.1
 func HandleAuthenticationResponse(ue *context.AmfUe, accessType models.AccessType,
 	authenticationResponse *nasMessage.AuthenticationResponse,
@@ -2218,6 +2740,15 @@ func HandleRegistrationComplete(ue *context.AmfUe, accessType models.AccessType,
 	configurationUpdateCommandFlags := &context.ConfigurationUpdateCommandFlags{
 		NeedNITZ: true,
 	}
+	// the configuration update parked while the UE is not reachable, e.g. in MICO mode, is sent along with it,
+	// except the re-registration and the MICO re-negotiation which are done by this registration
+	if accessType == models.AccessType__3_GPP_ACCESS && ue.ConfigurationUpdateCommandFlags != nil {
+		configurationUpdateCommandFlags = ue.ConfigurationUpdateCommandFlags
+		configurationUpdateCommandFlags.NeedNITZ = true
+		configurationUpdateCommandFlags.NeedReRegistration = false
+		configurationUpdateCommandFlags.NeedMicoIndication = false
+		ue.ConfigurationUpdateCommandFlags = nil
+	}
 	gmm_message.SendConfigurationUpdateCommand(ue, accessType, configurationUpdateCommandFlags)
 
 	// if registrationComplete.SORTransparentContainer != nil {
@@ -2230,15 +2761,24 @@ func HandleRegistrationComplete(ue *context.AmfUe, accessType models.AccessType,
 	//	2. AMF determines that it needs to update the Homogeneous Support of IMS Voice over PS Sessions (TS 23.501 5.16.3.3)
 	// Then invoke Nudm_UECM_Update to send "Homogeneous Support of IMS Voice over PS Sessions" indication to udm
 
+	// the NAS signalling connection is kept for the NSSAA of the pending NSSAI (TS 24.501 5.5.1.2.4)
 	if ue.RegistrationRequest.UplinkDataStatus == nil &&
-		ue.RegistrationRequest.GetFOR() == nasMessage.FollowOnRequestNoPending {
+		ue.RegistrationRequest.GetFOR() == nasMessage.FollowOnRequestNoPending && len(ue.PendingNssai) == 0 {
 		ngap_message.SendUEContextReleaseCommand(ue.RanUe[accessType], context.UeContextN2NormalRelease,
 			ngapType.CausePresentNas, ngapType.CauseNasPresentNormalRelease)
 	}
-	return GmmFSM.SendEvent(ue.State[accessType], ContextSetupSuccessEvent, fsm.ArgsType{
+	if err := GmmFSM.SendEvent(ue.State[accessType], ContextSetupSuccessEvent, fsm.ArgsType{
 		ArgAmfUe:      ue,
 		ArgAccessType: accessType,
-	}, logger.GmmLog)
+	}, logger.GmmLog); err != nil {
+		return err
+	}
+
+	// TS 23.502 4.2.9.2, the NSSAA is performed for the pending NSSAI after the registration
+	ue.Lock.Lock()
+	StartNssaa(ue, accessType)
+	ue.Lock.Unlock()
+	return nil
 }
 
 // TS 33.501 6.7.2
@@ -2272,7 +2812,7 @@ func HandleSecurityModeComplete(ue *context.AmfUe, anType models.AccessType, pro
 	if securityModeComplete.NASMessageContainer != nil {
 		contents := securityModeComplete.NASMessageContainer.GetNASMessageContainerContents()
 		m := nas.NewMessage()
-		if err := m.GmmMessageDecode(&contents); err != nil {
+		if err := nas_security.GmmMessageDecode(ue, m, contents); err != nil {
 			return err
 		}
 
@@ -2442,7 +2982,10 @@ func HandleDeregistrationAccept(ue *context.AmfUe, anType models.AccessType,
 	}
 
 	ue.DeregistrationTargetAccessType = 0
-	return nil
+	return GmmFSM.SendEvent(ue.State[anType], DeregistrationAcceptEvent, fsm.ArgsType{
+		ArgAmfUe:      ue,
+		ArgAccessType: anType,
+	}, logger.GmmLog)
 }
 
 func HandleStatus5GMM(ue *context.AmfUe, anType models.AccessType, status5GMM *nasMessage.Status5GMM) error {
@@ -2455,3 +2998,418 @@ func HandleStatus5GMM(ue *context.AmfUe, anType models.AccessType, status5GMM *n
 	ue.GmmLog.Errorf("Error condition [Cause Value: %s]", nasMessage.Cause5GMMToString(cause))
 	return nil
 }
+
+// EAP-Request/Identity (RFC 3748) sent to start the EAP authentication of the NSSAA
+var nssaaEapIdentityRequest = []byte{0x01, 0x00, 0x00, 0x05, 0x01}
+
+// Start the NSSAA procedure (TS 23.502 4.2.9.2) for the next S-NSSAI to be authenticated, the S-NSSAIs are
+// authenticated one at a time. The caller shall hold ue.Lock.
+func StartNssaa(ue *context.AmfUe, anType models.AccessType) {
+	if ue.Nssaa != nil || !ue.CmConnect(anType) {
+		return
+	}
+	snssai, ok := ue.NextNssaaSnssai(anType)
+	if !ok {
+		return
+	}
+
+	if ue.NssaafUri == "" {
+		if err := consumer.GetConsumer().SelectNssaaf(ue); err != nil {
+			ue.GmmLog.Errorf("NSSAA of S-NSSAI[%+v] is not performed: %+v", snssai, err)
+			return
+		}
+	}
+
+	ue.GmmLog.Infof("Start NSSAA of S-NSSAI[%+v]", snssai)
+	ue.Nssaa = &context.NssaaProcedure{
+		Snssai:     snssai,
+		AccessType: anType,
+	}
+	gmm_message.SendNetworkSliceSpecificAuthenticationCommand(ue.RanUe[anType], snssai, nssaaEapIdentityRequest)
+}
+
+// TS 23.502 4.2.9.2 step 4-18, the EAP message of the UE is relayed to the NSSAAF
+func HandleNetworkSliceSpecificAuthenticationComplete(ue *context.AmfUe, anType models.AccessType,
+	complete *context.NssaaMessage,
+) error {
+	ue.GmmLog.Info("Handle Network Slice-Specific Authentication Complete")
+
+	if ue.MacFailed {
+		return fmt.Errorf("NAS message integrity check failed")
+	}
+	// the NSSAA may be revoked by the NSSAAF meanwhile
+	ue.Lock.Lock()
+	defer ue.Lock.Unlock()
+	procedure := ue.Nssaa
+	if complete == nil || procedure == nil || !openapi.SnssaiEqualFold(complete.Snssai, procedure.Snssai) {
+		return fmt.Errorf("no ongoing NSSAA for the Network Slice-Specific Authentication Complete")
+	}
+
+	var eapMessage []byte
+	var authResult models.AuthStatus
+	var problemDetails *models.ProblemDetails
+	var err error
+	if procedure.AuthCtxId == "" {
+		eapMessage, procedure.AuthCtxId, problemDetails, err = consumer.GetConsumer().NssaaAuthenticate(ue,
+			procedure.Snssai, complete.EapMessage)
+	} else {
+		eapMessage, authResult, problemDetails, err = consumer.GetConsumer().NssaaConfirm(ue, procedure.AuthCtxId,
+			procedure.Snssai, complete.EapMessage)
+	}
+	if problemDetails != nil || err != nil {
+		// the NSSAA is considered failed if the NSSAAF can not complete it
+		ue.GmmLog.Errorf("NSSAA of S-NSSAI[%+v] failed: Problem[%+v] Error[%+v]", procedure.Snssai, problemDetails, err)
+		completeNssaa(ue, models.AuthStatus_EAP_FAILURE)
+		return nil
+	}
+
+	if authResult == "" {
+		// the EAP authentication continues
+		gmm_message.SendNetworkSliceSpecificAuthenticationCommand(ue.RanUe[anType], procedure.Snssai, eapMessage)
+		return nil
+	}
+	gmm_message.SendNetworkSliceSpecificAuthenticationResult(ue.RanUe[anType], procedure.Snssai, eapMessage)
+	completeNssaa(ue, authResult)
+	return nil
+}
+
+// Store the result of the ongoing NSSAA, and update the allowed NSSAI once no S-NSSAI is left to be authenticated
+// (TS 23.502 4.2.9.2 step 19-21). The caller shall hold ue.Lock.
+func completeNssaa(ue *context.AmfUe, authResult models.AuthStatus) {
+	procedure := ue.Nssaa
+	ue.Nssaa = nil
+	ue.GmmLog.Infof("NSSAA of S-NSSAI[%+v]: %s", procedure.Snssai, authResult)
+
+	ue.SetNssaaStatus(procedure.Snssai, authResult)
+	ue.RemovePendingSnssai(procedure.Snssai)
+	if authResult == models.AuthStatus_EAP_SUCCESS {
+		if !ue.InAllowedNssai(procedure.Snssai, procedure.AccessType) {
+			snssai := procedure.Snssai
+			ue.AllowedNssai[procedure.AccessType] = append(ue.AllowedNssai[procedure.AccessType],
+				models.AllowedSnssai{AllowedSnssai: &snssai})
+		}
+	} else {
+		ue.RemoveAllowedSnssai(procedure.Snssai, procedure.AccessType)
+		releaseSnssaiPduSessions(ue, procedure.Snssai)
+	}
+
+	if _, ok := ue.NextNssaaSnssai(procedure.AccessType); ok {
+		StartNssaa(ue, procedure.AccessType)
+		return
+	}
+	UpdateAllowedNssaiAfterNssaa(ue, procedure.AccessType)
+}
+
+// Send the allowed NSSAI updated by the NSSAA to the UE in CM-CONNECTED, or start the network-initiated
+// deregistration of the UE if no S-NSSAI is allowed (TS 23.502 4.2.9.2 step 20-21). The caller shall hold ue.Lock.
+func UpdateAllowedNssaiAfterNssaa(ue *context.AmfUe, anType models.AccessType) {
+	if len(ue.AllowedNssai[anType]) > 0 {
+		gmm_message.SendConfigurationUpdateCommand(ue, anType, &context.ConfigurationUpdateCommandFlags{
+			NeedAllowedNSSAI: true,
+			NeedRejectNSSAI:  true,
+		})
+		return
+	}
+
+	ue.GmmLog.Infof("No S-NSSAI is allowed after NSSAA, deregister the UE over %q", anType)
+	ue.SmContextList.Range(func(key, value interface{}) bool {
+		smContext := value.(*context.SmContext)
+		if smContext.AccessType() != anType {
+			return true
+		}
+		problemDetail, err := consumer.GetConsumer().SendReleaseSmContextRequest(ue, smContext, nil, "", nil)
+		if problemDetail != nil {
+			ue.GmmLog.Errorf("Release SmContext Failed Problem[%+v]", problemDetail)
+		} else if err != nil {
+			ue.GmmLog.Errorf("Release SmContext Error[%v]", err.Error())
+		}
+		ue.SmContextList.Delete(key)
+		return true
+	})
+
+	ue.DeregistrationTargetAccessType = nasMessage.AccessType3GPP
+	if anType == models.AccessType_NON_3_GPP_ACCESS {
+		ue.DeregistrationTargetAccessType = nasMessage.AccessTypeNon3GPP
+	}
+	if err := GmmFSM.SendEvent(ue.State[anType], InitDeregistrationEvent, fsm.ArgsType{
+		ArgAmfUe:      ue,
+		ArgAccessType: anType,
+		ArgCause5GMM:  nas_security.Cause5GMMNoNetworkSlicesAvailable,
+	}, logger.GmmLog); err != nil {
+		ue.GmmLog.Errorln(err)
+	}
+}
+
+// The PDU sessions of the S-NSSAI are released, e.g. the NSSAA of the S-NSSAI fails or is revoked
+func releaseSnssaiPduSessions(ue *context.AmfUe, snssai models.Snssai) {
+	ue.SmContextList.Range(func(key, value interface{}) bool {
+		smContext := value.(*context.SmContext)
+		if !openapi.SnssaiEqualFold(smContext.Snssai(), snssai) {
+			return true
+		}
+		problemDetail, err := consumer.GetConsumer().SendReleaseSmContextRequest(ue, smContext, nil, "", nil)
+		if problemDetail != nil {
+			ue.GmmLog.Errorf("Release SmContext Failed Problem[%+v]", problemDetail)
+		} else if err != nil {
+			ue.GmmLog.Errorf("Release SmContext Error[%v]", err.Error())
+		}
+		ue.SmContextList.Delete(key)
+		return true
+	})
+}
+
+// NSSAAF triggered slice-specific re-authentication and re-authorization (TS 23.502 4.2.9.3). The UE in CM-IDLE
+// is paged, and the NSSAA is started after the Service Request. The caller shall hold ue.Lock.
+func HandleNssaaReauthentication(ue *context.AmfUe, snssai models.Snssai) {
+	if _, ok := ue.NssaaStatus(snssai); !ok {
+		ue.GmmLog.Warnf("S-NSSAI[%+v] of the NSSAA re-authentication is not subject to NSSAA", snssai)
+		return
+	}
+	ue.SetNssaaStatus(snssai, models.AuthStatus_PENDING)
+
+	for _, anType := range []models.AccessType{models.AccessType__3_GPP_ACCESS, models.AccessType_NON_3_GPP_ACCESS} {
+		if !ue.State[anType].Is(context.Registered) || !ue.InAllowedNssai(snssai, anType) {
+			continue
+		}
+		if ue.CmConnect(anType) {
+			StartNssaa(ue, anType)
+		} else if anType == models.AccessType__3_GPP_ACCESS && !ue.MicoMode {
+			// the UE in MICO mode is re-authenticated in the next registration
+			pageForSignalling(ue)
+		}
+	}
+}
+
+// NSSAAF triggered slice-specific authorization revocation (TS 23.502 4.2.9.4). The caller shall hold ue.Lock.
+func HandleNssaaRevocation(ue *context.AmfUe, snssai models.Snssai) {
+	if _, ok := ue.NssaaStatus(snssai); !ok {
+		ue.GmmLog.Warnf("S-NSSAI[%+v] of the NSSAA revocation is not subject to NSSAA", snssai)
+		return
+	}
+	ue.SetNssaaStatus(snssai, models.AuthStatus_EAP_FAILURE)
+	ue.RemovePendingSnssai(snssai)
+	releaseSnssaiPduSessions(ue, snssai)
+	if ue.Nssaa != nil && openapi.SnssaiEqualFold(ue.Nssaa.Snssai, snssai) {
+		ue.Nssaa = nil
+	}
+
+	for _, anType := range []models.AccessType{models.AccessType__3_GPP_ACCESS, models.AccessType_NON_3_GPP_ACCESS} {
+		if !ue.State[anType].Is(context.Registered) || !ue.InAllowedNssai(snssai, anType) {
+			continue
+		}
+		ue.RemoveAllowedSnssai(snssai, anType)
+		switch {
+		case ue.CmConnect(anType):
+			UpdateAllowedNssaiAfterNssaa(ue, anType)
+		case len(ue.AllowedNssai[anType]) == 0:
+			// the UE is de-registered once ue.Lock is released
+			go ImplicitDeregistration(ue, anType)
+		default:
+			// the allowed NSSAI is updated once the UE is reachable
+			ue.ConfigurationUpdateCommandFlags = &context.ConfigurationUpdateCommandFlags{
+				NeedAllowedNSSAI: true,
+				NeedRejectNSSAI:  true,
+			}
+			if anType == models.AccessType__3_GPP_ACCESS && !ue.MicoMode {
+				pageForSignalling(ue)
+			}
+		}
+	}
+}
+
+func pageForSignalling(ue *context.AmfUe) {
+	if ue.OnGoing(models.AccessType__3_GPP_ACCESS).Procedure == context.OnGoingProcedurePaging {
+		return
+	}
+	ue.SetOnGoing(models.AccessType__3_GPP_ACCESS, &context.OnGoing{
+		Procedure: context.OnGoingProcedurePaging,
+	})
+	if err := ngap_message.SendPaging(ue, nil, false); err != nil {
+		ue.GmmLog.Errorf("Send Paging failed: %+v", err)
+		ue.SetOnGoing(models.AccessType__3_GPP_ACCESS, &context.OnGoing{
+			Procedure: context.OnGoingProcedureNothing,
+		})
+	}
+}
+
+// The network slicing subscription of the UE is changed in the UDM (TS 23.502 4.2.4.2). The S-NSSAIs not subscribed
+// anymore are removed from the allowed NSSAI, and the UE is requested to re-register if no allowed S-NSSAI is left.
+// The caller shall hold ue.Lock.
+func HandleSubscribedNssaiChange(ue *context.AmfUe) {
+	ue.NetworkSlicingSubscriptionChanged = true
+	updateConfiguredNssai(ue)
+
+	for _, anType := range []models.AccessType{models.AccessType__3_GPP_ACCESS, models.AccessType_NON_3_GPP_ACCESS} {
+		if !ue.State[anType].Is(context.Registered) {
+			continue
+		}
+		for _, allowedSnssai := range append([]models.AllowedSnssai{}, ue.AllowedNssai[anType]...) {
+			snssai := *allowedSnssai.AllowedSnssai
+			if allowedSnssai.MappedHomeSnssai != nil {
+				snssai = *allowedSnssai.MappedHomeSnssai
+			}
+			if !ue.InSubscribedNssai(snssai) {
+				ue.GmmLog.Infof("S-NSSAI[%+v] is not subscribed anymore", snssai)
+				ue.RemoveAllowedSnssai(*allowedSnssai.AllowedSnssai, anType)
+				releaseSnssaiPduSessions(ue, *allowedSnssai.AllowedSnssai)
+			}
+		}
+
+		configurationUpdateCommandFlags := &context.ConfigurationUpdateCommandFlags{
+			NeedAllowedNSSAI:             true,
+			NeedConfiguredNSSAI:          true,
+			NeedNetworkSlicingIndication: true,
+			NeedReRegistration:           len(ue.AllowedNssai[anType]) == 0,
+		}
+		if ue.CmConnect(anType) {
+			gmm_message.SendConfigurationUpdateCommand(ue, anType, configurationUpdateCommandFlags)
+		} else {
+			// the UE is updated once it is reachable
+			ue.ConfigurationUpdateCommandFlags = configurationUpdateCommandFlags
+			if anType == models.AccessType__3_GPP_ACCESS && !ue.MicoMode {
+				pageForSignalling(ue)
+			}
+		}
+	}
+}
+
+// The configured NSSAI follows the subscribed S-NSSAIs supported by the AMF
+func updateConfiguredNssai(ue *context.AmfUe) {
+	amfSelf := context.GetSelf()
+
+	var configuredNssai []models.ConfiguredSnssai
+	inConfiguredNssai := func(snssai models.Snssai) bool {
+		for _, configuredSnssai := range configuredNssai {
+			if openapi.SnssaiEqualFold(*configuredSnssai.ConfiguredSnssai, snssai) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, configuredSnssai := range ue.ConfiguredNssai {
+		homeSnssai := configuredSnssai.ConfiguredSnssai
+		if configuredSnssai.MappedHomeSnssai != nil {
+			homeSnssai = configuredSnssai.MappedHomeSnssai
+		}
+		if ue.InSubscribedNssai(*homeSnssai) {
+			configuredNssai = append(configuredNssai, configuredSnssai)
+		}
+	}
+	for _, subscribedSnssai := range ue.SubscribedNssai {
+		snssai := *subscribedSnssai.SubscribedSnssai
+		if amfSelf.InPlmnSupportList(snssai) && !inConfiguredNssai(snssai) {
+			configuredNssai = append(configuredNssai, models.ConfiguredSnssai{
+				ConfiguredSnssai: &snssai,
+			})
+		}
+	}
+	ue.ConfiguredNssai = configuredNssai
+}
+
+// TS 24.501 5.3.7, the mobile reachable timer is started when the registered UE enters 5GMM-IDLE over 3GPP
+// access. When it expires, the UE is considered unreachable and the implicit de-registration timer is started.
+// Over non-3GPP access, the non-3GPP implicit de-registration timer is started when the N1 NAS signalling
+// connection is released. The UE is implicitly de-registered when the implicit de-registration timer expires.
+func StartMobileReachableTimer(ue *context.AmfUe, anType models.AccessType) {
+	if ue.State[anType] == nil || !ue.State[anType].Is(context.Registered) || ue.CmConnect(anType) {
+		return
+	}
+	ue.StopMobileReachableTimers(anType)
+
+	if anType == models.AccessType_NON_3_GPP_ACCESS {
+		deregTimerValue := ue.Non3gppDeregTimerValue
+		if deregTimerValue == 0 {
+			deregTimerValue = context.GetSelf().Non3gppDeregTimerValue
+		}
+		d := time.Duration(deregTimerValue)*time.Second + context.TimeNon3gppImplicitDeregOffset
+		ue.GmmLog.Infof("Start Non-3GPP Implicit Deregistration timer[%s]", d)
+		ue.StartNon3gppImplicitDeregistrationTimer(d, func() {
+			ImplicitDeregistration(ue, anType)
+		})
+		return
+	}
+
+	// the periodic registration update is deactivated if T3512 is zero, and the UE is not expected to be reachable
+	if ue.T3512Value == 0 {
+		return
+	}
+	d := time.Duration(ue.T3512Value)*time.Second + context.TimeMobileReachableOffset
+	ue.GmmLog.Infof("Start Mobile Reachable timer[%s]", d)
+	ue.StartMobileReachableTimer(d, func() {
+		ue.GmmLog.Warnf("Mobile Reachable timer expires, UE is unreachable")
+		callback.SendAmfEventReport(ue, models.AmfEventType_REACHABILITY_REPORT)
+
+		ue.GmmLog.Infof("Start Implicit Deregistration timer[%s]", context.TimeImplicitDeregistration)
+		ue.StartImplicitDeregistrationTimer(context.TimeImplicitDeregistration, func() {
+			ImplicitDeregistration(ue, anType)
+		})
+	})
+}
+
+// The UE is de-registered over the access locally without NAS signalling (TS 23.502 4.2.2.3.3). The PDU sessions
+// of the access are released, the UE is de-registered in the UDM, and the UE context is removed once the UE is
+// de-registered over both accesses. The caller shall not hold ue.Lock, which is released before the requests to
+// the other NFs are sent.
+func ImplicitDeregistration(ue *context.AmfUe, anType models.AccessType) {
+	ue.Lock.Lock()
+	if ue.CmConnect(anType) || !ue.State[anType].Is(context.Registered) {
+		ue.Lock.Unlock()
+		return
+	}
+	ue.GmmLog.Infof("Implicit Deregistration over %q", anType)
+
+	var smContexts []*context.SmContext
+	ue.SmContextList.Range(func(key, value interface{}) bool {
+		smContext := value.(*context.SmContext)
+		if smContext.AccessType() == anType {
+			smContexts = append(smContexts, smContext)
+			ue.SmContextList.Delete(key)
+		}
+		return true
+	})
+
+	if err := GmmFSM.SendEvent(ue.State[anType], ImplicitDeregistrationEvent, fsm.ArgsType{
+		ArgAmfUe:      ue,
+		ArgAccessType: anType,
+	}, logger.GmmLog); err != nil {
+		ue.GmmLog.Errorln(err)
+	}
+
+	otherAnType := models.AccessType_NON_3_GPP_ACCESS
+	if anType == models.AccessType_NON_3_GPP_ACCESS {
+		otherAnType = models.AccessType__3_GPP_ACCESS
+	}
+	otherRegistered := ue.State[otherAnType].Is(context.Registered)
+	ue.Lock.Unlock()
+
+	for _, smContext := range smContexts {
+		problemDetail, err := consumer.GetConsumer().SendReleaseSmContextRequest(ue, smContext, nil, "", nil)
+		if problemDetail != nil {
+			ue.GmmLog.Errorf("Release SmContext Failed Problem[%+v]", problemDetail)
+		} else if err != nil {
+			ue.GmmLog.Errorf("Release SmContext Error[%v]", err.Error())
+		}
+	}
+
+	if otherRegistered {
+		// the subscriber data is kept for the access which is still registered
+		if ue.SmsOverNasActivated[anType] {
+			gmm_common.DeactivateSmsOverNas(ue, anType)
+		}
+		if ue.UeCmRegistered[anType] {
+			problemDetails, err := consumer.GetConsumer().UeCmDeregistration(ue, anType)
+			if problemDetails != nil {
+				ue.GmmLog.Errorf("UECM Deregistration Failed Problem[%+v]", problemDetails)
+			} else if err != nil {
+				ue.GmmLog.Errorf("UECM Deregistration Error[%+v]", err)
+			}
+			ue.UeCmRegistered[anType] = false
+		}
+		return
+	}
+
+	if err := gmm_common.PurgeSubscriberData(ue, anType); err != nil {
+		ue.GmmLog.Errorf("Purge subscriber data Error[%v]", err.Error())
+	}
+	gmm_common.RemoveAmfUe(ue, true)
+}
@@ -8,18 +8,19 @@ import (
 )
 
 const (
-	GmmMessageEvent           fsm.EventType = "Gmm Message"
-	StartAuthEvent            fsm.EventType = "Start Authentication"
-	AuthSuccessEvent          fsm.EventType = "Authentication Success"
-	AuthErrorEvent            fsm.EventType = "Authentication Error"
-	AuthRestartEvent          fsm.EventType = "Authentication Restart"
-	AuthFailEvent             fsm.EventType = "Authentication Fail"
-	SecurityModeSuccessEvent  fsm.EventType = "SecurityMode Success"
-	SecurityModeFailEvent     fsm.EventType = "SecurityMode Fail"
-	ContextSetupSuccessEvent  fsm.EventType = "ContextSetup Success"
-	ContextSetupFailEvent     fsm.EventType = "ContextSetup Fail"
-	InitDeregistrationEvent   fsm.EventType = "Initialize Deregistration"
-	DeregistrationAcceptEvent fsm.EventType = "Deregistration Accept"
+	GmmMessageEvent             fsm.EventType = "Gmm Message"
+	StartAuthEvent              fsm.EventType = "Start Authentication"
+	AuthSuccessEvent            fsm.EventType = "Authentication Success"
+	AuthErrorEvent              fsm.EventType = "Authentication Error"
+	AuthRestartEvent            fsm.EventType = "Authentication Restart"
+	AuthFailEvent               fsm.EventType = "Authentication Fail"
+	SecurityModeSuccessEvent    fsm.EventType = "SecurityMode Success"
+	SecurityModeFailEvent       fsm.EventType = "SecurityMode Fail"
+	ContextSetupSuccessEvent    fsm.EventType = "ContextSetup Success"
+	ContextSetupFailEvent       fsm.EventType = "ContextSetup Fail"
+	InitDeregistrationEvent     fsm.EventType = "Initialize Deregistration"
+	DeregistrationAcceptEvent   fsm.EventType = "Deregistration Accept"
+	ImplicitDeregistrationEvent fsm.EventType = "Implicit Deregistration"
 )
 
 const (
@@ -31,6 +32,8 @@ const (
 	ArgEAPMessage          string = "EAP Message"
 	Arg3GPPDeregistered    string = "3GPP Deregistered"
 	ArgNon3GPPDeregistered string = "Non3GPP Deregistered"
+	ArgNssaaMessage        string = "NSSAA Message"
+	ArgCause5GMM           string = "5GMM Cause"
 )
 
 var transitions = fsm.Transitions{
@@ -51,6 +54,7 @@ var transitions = fsm.Transitions{
 	{Event: ContextSetupFailEvent, From: context.ContextSetup, To: context.Deregistered},
 	{Event: InitDeregistrationEvent, From: context.Registered, To: context.DeregistrationInitiated},
 	{Event: DeregistrationAcceptEvent, From: context.DeregistrationInitiated, To: context.Deregistered},
+	{Event: ImplicitDeregistrationEvent, From: context.Registered, To: context.Deregistered},
 }
 
 var callbacks = fsm.Callbacks{
@@ -20,7 +20,7 @@ import (
 )
 
 func BuildDLNASTransport(ue *context.AmfUe, accessType models.AccessType, payloadContainerType uint8, nasPdu []byte,
-	pduSessionId uint8, cause *uint8, backoffTimerUint *uint8, backoffTimer uint8,
+	pduSessionId uint8, cause *uint8, backoffTimerUint *uint8, backoffTimer uint8, additionalInformation []byte,
 ) ([]byte, error) {
 	m := nas.NewMessage()
 	m.GmmMessage = nas.NewGmmMessage()
@@ -56,6 +56,12 @@ func BuildDLNASTransport(ue *context.AmfUe, accessType models.AccessType, payloa
 		dLNASTransport.BackoffTimerValue.SetUnitTimerValue(*backoffTimerUint)
 		dLNASTransport.BackoffTimerValue.SetTimerValue(backoffTimer)
 	}
+	if additionalInformation != nil {
+		dLNASTransport.AdditionalInformation = new(nasType.AdditionalInformation)
+		dLNASTransport.AdditionalInformation.SetIei(nasMessage.DLNASTransportAdditionalInformationType)
+		dLNASTransport.AdditionalInformation.SetLen(uint8(len(additionalInformation)))
+		dLNASTransport.AdditionalInformation.SetAdditionalInformationValue(additionalInformation)
+	}
 
 	m.GmmMessage.DLNASTransport = dLNASTransport
 
@@ -278,7 +284,7 @@ func BuildAuthenticationResult(ue *context.AmfUe, accessType models.AccessType,
 	return nas_security.Encode(ue, m, accessType)
 }
 
-// T3346 Timer and EAP are not Supported
+// T3346 is included if rejected with 5GMM cause #22 by the congestion control, EAP is not supported
 func BuildServiceReject(ue *context.AmfUe, accessType models.AccessType, pDUSessionStatus *[16]bool, cause uint8,
 ) ([]byte, error) {
 	m := nas.NewMessage()
@@ -296,6 +302,13 @@ func BuildServiceReject(ue *context.AmfUe, accessType models.AccessType, pDUSess
 		serviceReject.PDUSessionStatus.SetLen(2)
 		serviceReject.PDUSessionStatus.Buffer = nasConvert.PSIToBuf(*pDUSessionStatus)
 	}
+	if cause == nasMessage.Cause5GMMCongestion {
+		if t3346Val := context.GetSelf().T3346Value(); t3346Val != 0 {
+			serviceReject.T3346Value = nasType.NewT3346Value(nasMessage.ServiceRejectT3346ValueType)
+			serviceReject.T3346Value.SetLen(1)
+			serviceReject.T3346Value.SetGPRSTimer2Value(nasConvert.GPRSTimer2ToNas(t3346Val))
+		}
+	}
 
 	m.GmmMessage.ServiceReject = serviceReject
 
@@ -306,7 +319,7 @@ func BuildServiceReject(ue *context.AmfUe, accessType models.AccessType, pDUSess
 	return nas_security.Encode(ue, m, accessType)
 }
 
-// T3346 timer are not supported
+// T3346 is included if rejected with 5GMM cause #22 by the congestion control
 func BuildRegistrationReject(ue *context.AmfUe, accessType models.AccessType, cause5GMM uint8, eapMessage string,
 ) ([]byte, error) {
 	m := nas.NewMessage()
@@ -320,6 +333,14 @@ func BuildRegistrationReject(ue *context.AmfUe, accessType models.AccessType, ca
 	registrationReject.RegistrationRejectMessageIdentity.SetMessageType(nas.MsgTypeRegistrationReject)
 	registrationReject.Cause5GMM.SetCauseValue(cause5GMM)
 
+	if cause5GMM == nasMessage.Cause5GMMCongestion {
+		if t3346Val := context.GetSelf().T3346Value(); t3346Val != 0 {
+			registrationReject.T3346Value = nasType.NewT3346Value(nasMessage.RegistrationRejectT3346ValueType)
+			registrationReject.T3346Value.SetLen(1)
+			registrationReject.T3346Value.SetGPRSTimer2Value(nasConvert.GPRSTimer2ToNas(t3346Val))
+		}
+	}
+
 	t3502Val := context.GetSelf().T3502Value
 	if ue != nil {
 		t3502Val = ue.T3502Value
@@ -495,6 +516,36 @@ func BuildDeregistrationAccept(ue *context.AmfUe, accessType models.AccessType)
 	return nas_security.Encode(ue, m, accessType)
 }
 
+// Bits of the 5GS registration result (TS 24.501 9.11.3.6), not provided by nasType
+const (
+	registrationResultNssaaToBePerformed  uint8 = 0x10
+	registrationResultEmergencyRegistered uint8 = 0x20
+)
+
+// Cause value of the rejected S-NSSAI (TS 24.501 9.11.3.46), not provided by nasMessage
+const rejectedSnssaiCauseNssaaFailedOrRevoked uint8 = 0x03
+
+// The Rejected NSSAI IE of the S-NSSAIs rejected by the NSSF and the S-NSSAIs of which the network slice-specific
+// authentication and authorization failed or was revoked, nil if no S-NSSAI is rejected
+func buildRejectedNssai(ue *context.AmfUe, iei uint8) *nasType.RejectedNSSAI {
+	var buf []uint8
+	if ue.NetworkSliceInfo != nil {
+		rejectedNssaiNas := nasConvert.RejectedNssaiToNas(
+			ue.NetworkSliceInfo.RejectedNssaiInPlmn, ue.NetworkSliceInfo.RejectedNssaiInTa)
+		buf = append(buf, rejectedNssaiNas.GetRejectedNSSAIContents()...)
+	}
+	for _, snssai := range ue.NssaaFailedNssai() {
+		buf = append(buf, nasConvert.RejectedSnssaiToNas(snssai, rejectedSnssaiCauseNssaaFailedOrRevoked)...)
+	}
+	if len(buf) == 0 {
+		return nil
+	}
+	rejectedNssai := nasType.NewRejectedNSSAI(iei)
+	rejectedNssai.SetLen(uint8(len(buf)))
+	rejectedNssai.SetRejectedNSSAIContents(buf)
+	return rejectedNssai
+}
+
 func BuildRegistrationAccept(
 	ue *context.AmfUe,
 	anType models.AccessType,
@@ -531,7 +582,15 @@ func BuildRegistrationAccept(
 		}
 	}
 	registrationAccept.RegistrationResult5GS.SetRegistrationResultValue5GS(registrationResult)
-	// TODO: set smsAllowed value of RegistrationResult5GS if need
+	if ue.SmsOverNasActivated[anType] {
+		registrationAccept.RegistrationResult5GS.SetSMSAllowed(nasMessage.SMSOverNasAllowed)
+	}
+	if ue.EmergencyRegistered {
+		registrationAccept.RegistrationResult5GS.Octet |= registrationResultEmergencyRegistered
+	}
+	if len(ue.PendingNssai) > 0 {
+		registrationAccept.RegistrationResult5GS.Octet |= registrationResultNssaaToBePerformed
+	}
 
 	if ue.Guti != "" {
 		gutiNas, err := nasConvert.GutiToNasWithError(ue.Guti)
@@ -543,10 +602,10 @@ func BuildRegistrationAccept(
 	}
 
 	amfSelf := context.GetSelf()
-	if len(amfSelf.PlmnSupportList) > 1 {
+	if plmnSupportList := amfSelf.GetPlmnSupportList(); len(plmnSupportList) > 1 {
 		registrationAccept.EquivalentPlmns = nasType.NewEquivalentPlmns(nasMessage.RegistrationAcceptEquivalentPlmnsType)
 		var buf []uint8
-		for _, plmnSupportItem := range amfSelf.PlmnSupportList {
+		for _, plmnSupportItem := range plmnSupportList {
 			buf = append(buf, nasConvert.PlmnIDToNas(*plmnSupportItem.PlmnId)...)
 		}
 		registrationAccept.EquivalentPlmns.SetLen(uint8(len(buf)))
@@ -570,14 +629,7 @@ func BuildRegistrationAccept(
 		registrationAccept.AllowedNSSAI.SetSNSSAIValue(buf)
 	}
 
-	if ue.NetworkSliceInfo != nil {
-		if len(ue.NetworkSliceInfo.RejectedNssaiInPlmn) != 0 || len(ue.NetworkSliceInfo.RejectedNssaiInTa) != 0 {
-			rejectedNssaiNas := nasConvert.RejectedNssaiToNas(
-				ue.NetworkSliceInfo.RejectedNssaiInPlmn, ue.NetworkSliceInfo.RejectedNssaiInTa)
-			registrationAccept.RejectedNSSAI = &rejectedNssaiNas
-			registrationAccept.RejectedNSSAI.SetIei(nasMessage.RegistrationAcceptRejectedNSSAIType)
-		}
-	}
+	registrationAccept.RejectedNSSAI = buildRejectedNssai(ue, nasMessage.RegistrationAcceptRejectedNSSAIType)
 
 	if includeConfiguredNssaiCheck(ue) {
 		registrationAccept.ConfiguredNSSAI = nasType.NewConfiguredNSSAI(nasMessage.RegistrationAcceptConfiguredNSSAIType)
@@ -655,6 +707,13 @@ func BuildRegistrationAccept(
 		registrationAccept.ServiceAreaList.SetPartialServiceAreaList(partialServiceAreaList)
 	}
 
+	if anType == models.AccessType__3_GPP_ACCESS && ue.MicoMode {
+		registrationAccept.MICOIndication = nasType.NewMICOIndication(nasMessage.RegistrationAcceptMICOIndicationType)
+		if ue.MicoAllPlmnRegistrationArea {
+			registrationAccept.MICOIndication.SetRAAI(1)
+		}
+	}
+
 	if anType == models.AccessType__3_GPP_ACCESS && ue.T3512Value != 0 {
 		registrationAccept.T3512Value = nasType.NewT3512Value(nasMessage.RegistrationAcceptT3512ValueType)
 		registrationAccept.T3512Value.SetLen(1)
@@ -727,6 +786,22 @@ func BuildStatus5GMM(ue *context.AmfUe, accessType models.AccessType, cause uint
 	return nas_security.Encode(ue, m, accessType)
 }
 
+// TS 24.501 8.2.31
+func BuildNetworkSliceSpecificAuthenticationCommand(ue *context.AmfUe, anType models.AccessType,
+	snssai models.Snssai, eapMessage []byte,
+) ([]byte, error) {
+	return nas_security.EncodeNssaaMessage(ue, nas_security.MsgTypeNetworkSliceSpecificAuthenticationCommand,
+		&context.NssaaMessage{Snssai: snssai, EapMessage: eapMessage}, anType)
+}
+
+// TS 24.501 8.2.33
+func BuildNetworkSliceSpecificAuthenticationResult(ue *context.AmfUe, anType models.AccessType,
+	snssai models.Snssai, eapMessage []byte,
+) ([]byte, error) {
+	return nas_security.EncodeNssaaMessage(ue, nas_security.MsgTypeNetworkSliceSpecificAuthenticationResult,
+		&context.NssaaMessage{Snssai: snssai, EapMessage: eapMessage}, anType)
+}
+
 // Fllowed by TS 24.501 - 5.4.4 Generic UE configuration update procedure - 5.4.4.1 General
 func BuildConfigurationUpdateCommand(ue *context.AmfUe, anType models.AccessType,
 	flags *context.ConfigurationUpdateCommandFlags,
@@ -746,6 +821,7 @@ func BuildConfigurationUpdateCommand(ue *context.AmfUe, anType models.AccessType
 		configurationUpdateCommand.NetworkSlicingIndication = nasType.
 			NewNetworkSlicingIndication(nasMessage.ConfigurationUpdateCommandNetworkSlicingIndicationType)
 		configurationUpdateCommand.NetworkSlicingIndication.SetNSSCI(0x01)
+		ue.NetworkSlicingSubscriptionChanged = false // reset the value
 	}
 
 	if flags.NeedGUTI {
@@ -794,13 +870,9 @@ func BuildConfigurationUpdateCommand(ue *context.AmfUe, anType models.AccessType
 	}
 
 	if flags.NeedRejectNSSAI {
-		if ue.NetworkSliceInfo != nil &&
-			(len(ue.NetworkSliceInfo.RejectedNssaiInPlmn) != 0 || len(ue.NetworkSliceInfo.RejectedNssaiInTa) != 0) {
-			rejectedNssaiNas := nasConvert.RejectedNssaiToNas(
-				ue.NetworkSliceInfo.RejectedNssaiInPlmn, ue.NetworkSliceInfo.RejectedNssaiInTa)
-			configurationUpdateCommand.RejectedNSSAI = &rejectedNssaiNas
-			configurationUpdateCommand.RejectedNSSAI.SetIei(nasMessage.ConfigurationUpdateCommandRejectedNSSAIType)
-		} else {
+		configurationUpdateCommand.RejectedNSSAI = buildRejectedNssai(ue,
+			nasMessage.ConfigurationUpdateCommandRejectedNSSAIType)
+		if configurationUpdateCommand.RejectedNSSAI == nil {
 			logger.GmmLog.Warnf("Require Rejected NSSAI, but got nothing.")
 		}
 	}
@@ -845,6 +917,14 @@ func BuildConfigurationUpdateCommand(ue *context.AmfUe, anType models.AccessType
 		}
 	}
 
+	if flags.NeedMicoIndication && anType == models.AccessType__3_GPP_ACCESS {
+		configurationUpdateCommand.MICOIndication = nasType.
+			NewMICOIndication(nasMessage.ConfigurationUpdateCommandMICOIndicationType)
+		if ue.MicoAllPlmnRegistrationArea {
+			configurationUpdateCommand.MICOIndication.SetRAAI(1)
+		}
+	}
+
 	amfSelf := context.GetSelf()
 
 	if flags.NeedNITZ {
@@ -870,8 +950,7 @@ func BuildConfigurationUpdateCommand(ue *context.AmfUe, anType models.AccessType
 		universalTimeAndLocalTimeZone.SetIei(nasMessage.ConfigurationUpdateCommandUniversalTimeAndLocalTimeZoneType)
 		configurationUpdateCommand.UniversalTimeAndLocalTimeZone = &universalTimeAndLocalTimeZone
 
-		if ue.TimeZone != amfSelf.TimeZone {
-			ue.TimeZone = amfSelf.TimeZone
+		if ue.TimeZone != "" {
 			// Local Time Zone
 			localTimeZone := nasConvert.EncodeLocalTimeZoneToNas(ue.TimeZone)
 			localTimeZone.SetIei(nasMessage.ConfigurationUpdateCommandLocalTimeZoneType)
@@ -909,6 +988,10 @@ func BuildConfigurationUpdateCommand(ue *context.AmfUe, anType models.AccessType
 		// Allowed NSSAI and Configured NSSAI are optional to request to perform the registration procedure
 		configurationUpdateCommand.ConfigurationUpdateIndication.SetRED(uint8(1))
 	}
+	if flags.NeedReRegistration {
+		// e.g. no S-NSSAI of the allowed NSSAI is subscribed anymore (TS 23.502 4.2.4.2)
+		configurationUpdateCommand.ConfigurationUpdateIndication.SetRED(uint8(1))
+	}
 
 	// Check if the Configuration Update Command is vaild
 	if configurationUpdateCommand.ConfigurationUpdateIndication.GetACK() == uint8(0) &&
@@ -16,7 +16,7 @@ import (
 
 // backOffTimerUint = 7 means backoffTimer is null
 func SendDLNASTransport(ue *context.RanUe, payloadContainerType uint8, nasPdu []byte,
-	pduSessionId int32, cause uint8, backOffTimerUint *uint8, backOffTimer uint8,
+	pduSessionId int32, cause uint8, backOffTimerUint *uint8, backOffTimer uint8, additionalInformation []byte,
 ) {
 	isNasMsgSent := false
 	additionalCause := ""
@@ -40,7 +40,7 @@ func SendDLNASTransport(ue *context.RanUe, payloadContainerType uint8, nasPdu []
 		causePtr = &cause
 	}
 	nasMsg, err := BuildDLNASTransport(amfUe, ue.Ran.AnType, payloadContainerType, nasPdu,
-		uint8(pduSessionId), causePtr, backOffTimerUint, backOffTimer)
+		uint8(pduSessionId), causePtr, backOffTimerUint, backOffTimer, additionalInformation)
 	if err != nil {
 		additionalCause = nasMetrics.NAS_MSG_BUILD_ERR
 		amfUe.GmmLog.Error(err.Error())
@@ -350,6 +350,70 @@ func SendAuthenticationResult(ue *context.RanUe, eapSuccess bool, eapMsg string)
 	ngap_message.SendDownlinkNasTransport(ue, nasMsg, nil)
 }
 
+// NAS message names of the metrics, not provided by the metrics module
+const (
+	nssaaCommandMetricName = "NetworkSliceSpecificAuthenticationCommand"
+	nssaaResultMetricName  = "NetworkSliceSpecificAuthenticationResult"
+)
+
+func SendNetworkSliceSpecificAuthenticationCommand(ue *context.RanUe, snssai models.Snssai, eapMessage []byte) {
+	isNasMsgSent := false
+	additionalCause := ""
+	defer nasMetrics.IncrMetricsSentNasMsgs(nssaaCommandMetricName, &isNasMsgSent, 0, &additionalCause)
+
+	if ue == nil {
+		additionalCause = nasMetrics.RAN_UE_NIL_ERR
+		logger.GmmLog.Error("SendNetworkSliceSpecificAuthenticationCommand: RanUe is nil")
+		return
+	}
+	if ue.AmfUe == nil {
+		additionalCause = nasMetrics.AMF_UE_NIL_ERR
+		logger.GmmLog.Error("SendNetworkSliceSpecificAuthenticationCommand: AmfUe is nil")
+		return
+	}
+	amfUe := ue.AmfUe
+	amfUe.GmmLog.Infof("Send Network Slice-Specific Authentication Command for S-NSSAI[%+v]", snssai)
+
+	nasMsg, err := BuildNetworkSliceSpecificAuthenticationCommand(amfUe, ue.Ran.AnType, snssai, eapMessage)
+	if err != nil {
+		additionalCause = nasMetrics.NAS_MSG_BUILD_ERR
+		amfUe.GmmLog.Error(err.Error())
+		return
+	}
+
+	isNasMsgSent = true
+	ngap_message.SendDownlinkNasTransport(ue, nasMsg, nil)
+}
+
+func SendNetworkSliceSpecificAuthenticationResult(ue *context.RanUe, snssai models.Snssai, eapMessage []byte) {
+	isNasMsgSent := false
+	additionalCause := ""
+	defer nasMetrics.IncrMetricsSentNasMsgs(nssaaResultMetricName, &isNasMsgSent, 0, &additionalCause)
+
+	if ue == nil {
+		additionalCause = nasMetrics.RAN_UE_NIL_ERR
+		logger.GmmLog.Error("SendNetworkSliceSpecificAuthenticationResult: RanUe is nil")
+		return
+	}
+	if ue.AmfUe == nil {
+		additionalCause = nasMetrics.AMF_UE_NIL_ERR
+		logger.GmmLog.Error("SendNetworkSliceSpecificAuthenticationResult: AmfUe is nil")
+		return
+	}
+	amfUe := ue.AmfUe
+	amfUe.GmmLog.Infof("Send Network Slice-Specific Authentication Result for S-NSSAI[%+v]", snssai)
+
+	nasMsg, err := BuildNetworkSliceSpecificAuthenticationResult(amfUe, ue.Ran.AnType, snssai, eapMessage)
+	if err != nil {
+		additionalCause = nasMetrics.NAS_MSG_BUILD_ERR
+		amfUe.GmmLog.Error(err.Error())
+		return
+	}
+
+	isNasMsgSent = true
+	ngap_message.SendDownlinkNasTransport(ue, nasMsg, nil)
+}
+
 func SendServiceReject(ue *context.RanUe, pDUSessionStatus *[16]bool, cause uint8) {
 	isNasMsgSent := false
 	additionalCause := ""
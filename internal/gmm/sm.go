@@ -8,11 +8,14 @@ import (
 	gmm_message "github.com/free5gc/amf/internal/gmm/message"
 	"github.com/free5gc/amf/internal/logger"
 	business_metrics "github.com/free5gc/amf/internal/metrics/business"
+	"github.com/free5gc/amf/internal/nas/nas_security"
 	ngap_message "github.com/free5gc/amf/internal/ngap/message"
 	"github.com/free5gc/amf/internal/sbi/consumer"
+	callback "github.com/free5gc/amf/internal/sbi/processor/notifier"
 	"github.com/free5gc/nas"
 	"github.com/free5gc/nas/nasConvert"
 	"github.com/free5gc/nas/nasMessage"
+	"github.com/free5gc/nas/security"
 	"github.com/free5gc/ngap/ngapType"
 	"github.com/free5gc/openapi/models"
 	"github.com/free5gc/util/fsm"
@@ -25,6 +28,8 @@ func DeRegistered(state *fsm.State, event fsm.EventType, args fsm.ArgsType) {
 		accessType := args[ArgAccessType].(models.AccessType)
 		amfUe.ClearRegistrationRequestData(accessType)
 		amfUe.GmmLog.Debugln("EntryEvent at GMM State[DeRegistered]")
+		callback.SendAmfEventReport(amfUe, models.AmfEventType_REGISTRATION_STATE_REPORT)
+		callback.SendAmfEventReport(amfUe, models.AmfEventType_ACCESS_TYPE_REPORT)
 	case GmmMessageEvent:
 		amfUe := args[ArgAmfUe].(*context.AmfUe)
 		procedureCode := args[ArgProcedureCode].(int64)
@@ -76,6 +81,8 @@ func Registered(state *fsm.State, event fsm.EventType, args fsm.ArgsType) {
 		if amfUe.CmConnect(accessType) {
 			business_metrics.IncrUeConnectivityGauge(accessType)
 		}
+		callback.SendAmfEventReport(amfUe, models.AmfEventType_REGISTRATION_STATE_REPORT)
+		callback.SendAmfEventReport(amfUe, models.AmfEventType_ACCESS_TYPE_REPORT)
 
 	case GmmMessageEvent:
 		amfUe := args[ArgAmfUe].(*context.AmfUe)
@@ -113,6 +120,11 @@ func Registered(state *fsm.State, event fsm.EventType, args fsm.ArgsType) {
 			if err := HandleNotificationResponse(amfUe, gmmMessage.NotificationResponse); err != nil {
 				logger.GmmLog.Errorln(err)
 			}
+		case nas_security.MsgTypeNetworkSliceSpecificAuthenticationComplete:
+			nssaaComplete, _ := args[ArgNssaaMessage].(*context.NssaaMessage)
+			if err := HandleNetworkSliceSpecificAuthenticationComplete(amfUe, accessType, nssaaComplete); err != nil {
+				logger.GmmLog.Errorln(err)
+			}
 		case nas.MsgTypeDeregistrationRequestUEOriginatingDeregistration:
 			if err := GmmFSM.SendEvent(state, InitDeregistrationEvent, fsm.ArgsType{
 				ArgAmfUe:      amfUe,
@@ -133,6 +145,8 @@ func Registered(state *fsm.State, event fsm.EventType, args fsm.ArgsType) {
 		logger.GmmLog.Debugln(event)
 	case InitDeregistrationEvent:
 		logger.GmmLog.Debugln(event)
+	case ImplicitDeregistrationEvent:
+		logger.GmmLog.Debugln(event)
 	case fsm.ExitEvent:
 		amfUe := args[ArgAmfUe].(*context.AmfUe)
 		entryTime := amfUe.GmmStateEnterTime
@@ -174,6 +188,8 @@ func Authentication(state *fsm.State, event fsm.EventType, args fsm.ArgsType) {
 			if errSendEvent := GmmFSM.SendEvent(state, AuthSuccessEvent, fsm.ArgsType{
 				ArgAmfUe:      amfUe,
 				ArgAccessType: accessType,
+				ArgEAPSuccess: false,
+				ArgEAPMessage: "",
 			}, logger.GmmLog); errSendEvent != nil {
 				logger.GmmLog.Errorln(errSendEvent)
 			}
@@ -281,9 +297,13 @@ func SecurityMode(state *fsm.State, event fsm.EventType, args fsm.ArgsType) {
 			eapSuccess := args[ArgEAPSuccess].(bool)
 			eapMessage := args[ArgEAPMessage].(string)
 			// Select enc/int algorithm based on ue security capability & amf's policy,
+			// the null algorithms are used for the unauthenticated emergency services (TS 33.501 10.2.2)
 			amfSelf := context.GetSelf()
-			if err := amfUe.SelectSecurityAlg(amfSelf.SecurityAlgorithm.IntegrityOrder,
-				amfSelf.SecurityAlgorithm.CipheringOrder); err != nil {
+			intOrder, encOrder := amfSelf.SecurityAlgorithm.IntegrityOrder, amfSelf.SecurityAlgorithm.CipheringOrder
+			if amfUe.IsUnauthenticatedEmergency() {
+				intOrder, encOrder = []uint8{security.AlgIntegrity128NIA0}, []uint8{security.AlgCiphering128NEA0}
+			}
+			if err := amfUe.SelectSecurityAlg(intOrder, encOrder); err != nil {
 				amfUe.GmmLog.Errorf("Select security algorithm failed: %s", err)
 				gmm_message.SendRegistrationReject(amfUe.RanUe[accessType], nasMessage.Cause5GMMUESecurityCapabilitiesMismatch, "")
 				err = GmmFSM.SendEvent(state, SecurityModeFailEvent, fsm.ArgsType{
@@ -356,7 +376,7 @@ func ContextSetup(state *fsm.State, event fsm.EventType, args fsm.ArgsType) {
 		case *nasMessage.RegistrationRequest:
 			amfUe.RegistrationRequest = message
 			switch amfUe.RegistrationType5GS {
-			case nasMessage.RegistrationType5GSInitialRegistration:
+			case nasMessage.RegistrationType5GSInitialRegistration, nasMessage.RegistrationType5GSEmergencyRegistration:
 				if err := HandleInitialRegistration(amfUe, accessType); err != nil {
 					logger.GmmLog.Errorln(err)
 					err = GmmFSM.SendEvent(state, ContextSetupFailEvent, fsm.ArgsType{
@@ -398,7 +418,7 @@ func ContextSetup(state *fsm.State, event fsm.EventType, args fsm.ArgsType) {
 				logger.GmmLog.Errorln(err)
 			} else {
 				switch amfUe.RegistrationType5GS {
-				case nasMessage.RegistrationType5GSInitialRegistration:
+				case nasMessage.RegistrationType5GSInitialRegistration, nasMessage.RegistrationType5GSEmergencyRegistration:
 					if err2 := HandleInitialRegistration(amfUe, accessType); err2 != nil {
 						logger.GmmLog.Errorln(err2)
 						err2 = GmmFSM.SendEvent(state, ContextSetupFailEvent, fsm.ArgsType{
@@ -466,11 +486,16 @@ func DeregisteredInitiated(state *fsm.State, event fsm.EventType, args fsm.ArgsT
 	case fsm.EntryEvent:
 		business_metrics.IncrGmmStateGauge(string(accessType), string(state.Current()))
 		amfUe := args[ArgAmfUe].(*context.AmfUe)
-		gmmMessage := args[ArgNASMessage].(*nas.GmmMessage)
 		amfUe.GmmLog.Debugln("EntryEvent at GMM State[DeregisteredInitiated]")
-		if err := HandleDeregistrationRequest(amfUe, accessType,
-			gmmMessage.DeregistrationRequestUEOriginatingDeregistration); err != nil {
-			logger.GmmLog.Errorln(err)
+		if gmmMessage, ok := args[ArgNASMessage].(*nas.GmmMessage); ok {
+			if err := HandleDeregistrationRequest(amfUe, accessType,
+				gmmMessage.DeregistrationRequestUEOriginatingDeregistration); err != nil {
+				logger.GmmLog.Errorln(err)
+			}
+		} else {
+			// network-initiated deregistration (TS 24.501 5.5.2.3), T3522 is started with the Deregistration Request
+			gmm_message.SendDeregistrationRequest(amfUe.RanUe[accessType], amfUe.DeregistrationTargetAccessType,
+				false, args[ArgCause5GMM].(uint8))
 		}
 	case GmmMessageEvent:
 		amfUe := args[ArgAmfUe].(*context.AmfUe)
@@ -12,7 +12,10 @@ import (
 	"github.com/free5gc/util/fsm"
 )
 
-func Dispatch(ue *context.AmfUe, accessType models.AccessType, procedureCode int64, msg *nas.Message) error {
+// nssaaComplete is the Network Slice-Specific Authentication Complete decoded along with msg, if any
+func Dispatch(ue *context.AmfUe, accessType models.AccessType, procedureCode int64, msg *nas.Message,
+	nssaaComplete *context.NssaaMessage,
+) error {
 	if msg.GmmMessage == nil {
 		return errors.New("gmm Message is nil")
 	}
@@ -30,5 +33,6 @@ func Dispatch(ue *context.AmfUe, accessType models.AccessType, procedureCode int
 		gmm.ArgAccessType:    accessType,
 		gmm.ArgNASMessage:    msg.GmmMessage,
 		gmm.ArgProcedureCode: procedureCode,
+		gmm.ArgNssaaMessage:  nssaaComplete,
 	}, logger.GmmLog)
 }
@@ -37,19 +37,24 @@ func HandleNAS(ranUe *amf_context.RanUe, procedureCode int64, nasPdu []byte, ini
 	if ranUe.AmfUe == nil {
 		// Only the New created RanUE will have no AmfUe in it
 
-		if ranUe.HoldingAmfUe != nil && !ranUe.HoldingAmfUe.CmConnect(ranUe.Ran.AnType) {
+		if amfUe := ranUe.HoldingAmfUe; amfUe != nil && !amfUe.CmConnect(ranUe.Ran.AnType) {
 			// If the UE is CM-IDLE, there is no RanUE in AmfUe, so here we attach new RanUe to AmfUe.
-			gmm_common.AttachRanUeToAmfUeAndReleaseOldIfAny(ranUe.HoldingAmfUe, ranUe)
+			amfUe.Lock.Lock()
+			gmm_common.AttachRanUeToAmfUeAndReleaseOldIfAny(amfUe, ranUe)
+			amfUe.Lock.Unlock()
 			ranUe.HoldingAmfUe = nil
 		} else {
 			// Assume we have an existing UE context in CM-CONNECTED state. (RanUe <-> AmfUe)
 			// We will release it if the new UE context has a valid security context(Authenticated) in line 50.
-			ranUe.AmfUe = amfSelf.NewAmfUe("")
-			gmm_common.AttachRanUeToAmfUeAndReleaseOldIfAny(ranUe.AmfUe, ranUe)
+			amfUe = amfSelf.NewAmfUe("")
+			amfUe.Lock.Lock()
+			gmm_common.AttachRanUeToAmfUeAndReleaseOldIfAny(amfUe, ranUe)
+			amfUe.Lock.Unlock()
 		}
 	}
 
-	msg, integrityProtected, err := nas_security.Decode(ranUe.AmfUe, ranUe.Ran.AnType, nasPdu, initialMessage)
+	msg, nssaaComplete, integrityProtected, err := nas_security.DecodeUplink(ranUe.AmfUe, ranUe.Ran.AnType, nasPdu,
+		initialMessage)
 	if err != nil {
 		metricCause = nas_metrics.DECODE_NAS_MSG_ERR
 		ranUe.AmfUe.NASLog.Errorln(err)
@@ -68,7 +73,7 @@ func HandleNAS(ranUe *amf_context.RanUe, procedureCode int64, nasPdu []byte, ini
 
 	isNasMsgRcv = true
 
-	if errDispatch := Dispatch(ranUe.AmfUe, ranUe.Ran.AnType, procedureCode, msg); errDispatch != nil {
+	if errDispatch := Dispatch(ranUe.AmfUe, ranUe.Ran.AnType, procedureCode, msg, nssaaComplete); errDispatch != nil {
 		ranUe.AmfUe.NASLog.Errorf("Handle NAS Error: %v", errDispatch)
 		isNasMsgRcv = false
 	}
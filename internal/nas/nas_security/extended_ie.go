@@ -0,0 +1,151 @@
+package nas_security
+
+import (
+	"encoding/binary"
+
+	"github.com/free5gc/amf/internal/context"
+	"github.com/free5gc/nas"
+	"github.com/free5gc/nas/nasConvert"
+	"github.com/free5gc/nas/nasMessage"
+	"github.com/free5gc/openapi/models"
+)
+
+// The nas module encodes and decodes the IEs of the Registration Request and the Registration Accept up to the
+// EPS bearer context status IE, and the IEs following an IE unknown to it are misparsed. The IEs introduced
+// later are handled here on the plain 5GMM messages.
+
+// TS 24.501 8.2.6.1 and 8.2.7.1
+const (
+	registrationRequestRequestedExtendedDRXParametersType uint8 = 0x6E
+	registrationAcceptNegotiatedExtendedDRXParametersType uint8 = 0x6E
+	registrationAcceptPendingNSSAIType                    uint8 = 0x39
+)
+
+// The IEIs of the optional IEs of the Registration Request decoded by the nas module
+var knownRegistrationRequestIeis = map[uint8]bool{
+	nasMessage.RegistrationRequestNoncurrentNativeNASKeySetIdentifierType: true,
+	nasMessage.RegistrationRequestCapability5GMMType:                      true,
+	nasMessage.RegistrationRequestUESecurityCapabilityType:                true,
+	nasMessage.RegistrationRequestRequestedNSSAIType:                      true,
+	nasMessage.RegistrationRequestLastVisitedRegisteredTAIType:            true,
+	nasMessage.RegistrationRequestS1UENetworkCapabilityType:               true,
+	nasMessage.RegistrationRequestUplinkDataStatusType:                    true,
+	nasMessage.RegistrationRequestPDUSessionStatusType:                    true,
+	nasMessage.RegistrationRequestMICOIndicationType:                      true,
+	nasMessage.RegistrationRequestUEStatusType:                            true,
+	nasMessage.RegistrationRequestAdditionalGUTIType:                      true,
+	nasMessage.RegistrationRequestAllowedPDUSessionStatusType:             true,
+	nasMessage.RegistrationRequestUesUsageSettingType:                     true,
+	nasMessage.RegistrationRequestRequestedDRXParametersType:              true,
+	nasMessage.RegistrationRequestEPSNASMessageContainerType:              true,
+	nasMessage.RegistrationRequestLADNIndicationType:                      true,
+	nasMessage.RegistrationRequestPayloadContainerType:                    true,
+	nasMessage.RegistrationRequestNetworkSlicingIndicationType:            true,
+	nasMessage.RegistrationRequestUpdateType5GSType:                       true,
+	nasMessage.RegistrationRequestNASMessageContainerType:                 true,
+	nasMessage.RegistrationRequestEPSBearerContextStatusType:              true,
+}
+
+// Decode the plain 5GMM message, e.g. the NAS message container. The extended IEs of the Registration Request
+// are stored to the UE if the UE is not nil.
+func GmmMessageDecode(ue *context.AmfUe, msg *nas.Message, payload []byte) error {
+	payload = decodeRegistrationRequestExtendedIEs(ue, payload)
+	return msg.GmmMessageDecode(&payload)
+}
+
+// The Network Slice-Specific Authentication Complete unknown to the nas module is returned if decoded
+func plainNasDecode(ue *context.AmfUe, msg *nas.Message, payload []byte) (*context.NssaaMessage, error) {
+	if len(payload) >= 3 && payload[0] == nasMessage.Epd5GSMobilityManagementMessage &&
+		payload[2] == MsgTypeNetworkSliceSpecificAuthenticationComplete {
+		return nssaaCompleteDecode(ue, msg, payload)
+	}
+	payload = decodeRegistrationRequestExtendedIEs(ue, payload)
+	return nil, msg.PlainNasDecode(&payload)
+}
+
+func plainNasEncode(ue *context.AmfUe, msg *nas.Message, accessType models.AccessType) ([]byte, error) {
+	payload, err := msg.PlainNasEncode()
+	if err != nil {
+		return nil, err
+	}
+	if ue == nil || msg.GmmMessage == nil || msg.GmmHeader.GetMessageType() != nas.MsgTypeRegistrationAccept {
+		return payload, nil
+	}
+
+	// the extended IEs are appended in the order of TS 24.501 8.2.7.1
+	if accessType == models.AccessType__3_GPP_ACCESS && ue.Edrx != nil {
+		payload = append(payload, registrationAcceptNegotiatedExtendedDRXParametersType, 1, ue.Edrx.Octet())
+	}
+	if len(ue.PendingNssai) > 0 {
+		var buf []uint8
+		for _, snssai := range ue.PendingNssai {
+			buf = append(buf, nasConvert.SnssaiToNas(snssai)...)
+		}
+		payload = append(payload, registrationAcceptPendingNSSAIType, uint8(len(buf)))
+		payload = append(payload, buf...)
+	}
+	return payload, nil
+}
+
+// Remove the IEs unknown to the nas module from the plain Registration Request, and decode the Requested
+// extended DRX parameters IE to the UE. Other messages and the malformed messages are returned as they are,
+// then the nas module reports the errors.
+func decodeRegistrationRequestExtendedIEs(ue *context.AmfUe, payload []byte) []byte {
+	// Extended protocol discriminator, security header type, message type, 5GS registration type and ngKSI,
+	// and the length of the 5GS mobile identity
+	if len(payload) < 6 || payload[0] != nasMessage.Epd5GSMobilityManagementMessage ||
+		payload[2] != nas.MsgTypeRegistrationRequest {
+		return payload
+	}
+
+	var requestedEdrx *context.EdrxParameters
+	offset := 6 + int(binary.BigEndian.Uint16(payload[4:6]))
+	if offset > len(payload) {
+		return payload
+	}
+	stripped := append([]byte{}, payload[:offset]...)
+
+	for offset < len(payload) {
+		iei := payload[offset]
+		var ieLen int
+		switch {
+		case iei >= 0x80:
+			// type 1 IE, TV
+			ieLen = 1
+		case iei == nasMessage.RegistrationRequestLastVisitedRegisteredTAIType:
+			// type 3 IE, TV
+			ieLen = 7
+		case iei&0xf0 == 0x70:
+			// type 6 IE, TLV-E (TS 24.007 11.2.4)
+			if offset+3 > len(payload) {
+				return payload
+			}
+			ieLen = 3 + int(binary.BigEndian.Uint16(payload[offset+1:offset+3]))
+		default:
+			// type 4 IE, TLV
+			if offset+2 > len(payload) {
+				return payload
+			}
+			ieLen = 2 + int(payload[offset+1])
+		}
+		if offset+ieLen > len(payload) {
+			return payload
+		}
+
+		ie := payload[offset : offset+ieLen]
+		switch {
+		case iei >= 0x80 && knownRegistrationRequestIeis[iei>>4]:
+			stripped = append(stripped, ie...)
+		case iei < 0x80 && knownRegistrationRequestIeis[iei]:
+			stripped = append(stripped, ie...)
+		case iei == registrationRequestRequestedExtendedDRXParametersType && ieLen >= 3:
+			requestedEdrx = context.NewEdrxParameters(ie[2])
+		}
+		offset += ieLen
+	}
+
+	if ue != nil {
+		ue.RequestedEdrx = requestedEdrx
+	}
+	return stripped
+}
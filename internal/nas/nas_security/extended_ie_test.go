@@ -0,0 +1,43 @@
+package nas_security_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	amf_context "github.com/free5gc/amf/internal/context"
+	"github.com/free5gc/amf/internal/nas/nas_security"
+	nastesting "github.com/free5gc/amf/internal/nas/testing"
+	"github.com/free5gc/nas"
+	"github.com/free5gc/nas/nasMessage"
+	"github.com/free5gc/nas/nasType"
+)
+
+func TestGmmMessageDecodeRegistrationRequestExtendedIEs(t *testing.T) {
+	payload := nastesting.GetRegistrationRequest(
+		nasMessage.RegistrationType5GSInitialRegistration,
+		nasType.MobileIdentity5GS{
+			Len:    12, // suci
+			Buffer: []uint8{0x01, 0x02, 0xf8, 0x39, 0xf0, 0xff, 0x00, 0x00, 0x00, 0x00, 0x47, 0x78},
+		},
+		nil, nil, nil, nil, nil)
+	payload = append(payload,
+		0x6e, 0x01, 0x53, // Requested extended DRX parameters
+		0x6a, 0x01, 0x21, // T3324 value
+		0xa1,             // N5GC indication
+		0x51, 0x01, 0x02, // Requested DRX parameters
+	)
+
+	ue := new(amf_context.AmfUe)
+	m := nas.NewMessage()
+	require.NoError(t, nas_security.GmmMessageDecode(ue, m, payload))
+	require.NotNil(t, m.GmmMessage.RegistrationRequest.RequestedDRXParameters)
+	require.Equal(t, nasMessage.DRXcycleParameterT64,
+		m.GmmMessage.RegistrationRequest.RequestedDRXParameters.GetDRXValue())
+	require.Equal(t, &amf_context.EdrxParameters{Value: 0x3, PagingTimeWindow: 0x5}, ue.RequestedEdrx)
+
+	// the requested extended DRX parameters are cleared by the registration request without them
+	payload = payload[:len(payload)-10]
+	require.NoError(t, nas_security.GmmMessageDecode(ue, nas.NewMessage(), payload))
+	require.Nil(t, ue.RequestedEdrx)
+}
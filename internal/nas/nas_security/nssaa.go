@@ -0,0 +1,99 @@
+package nas_security
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/free5gc/amf/internal/context"
+	"github.com/free5gc/nas"
+	"github.com/free5gc/nas/nasConvert"
+	"github.com/free5gc/nas/nasMessage"
+	"github.com/free5gc/openapi/models"
+)
+
+// The nas module does not provide the network slice-specific authentication messages (TS 24.501 8.2.31-8.2.33),
+// they are encoded and decoded here. The three messages consist of the S-NSSAI and the EAP message IEs.
+
+// TS 24.501 9.7
+const (
+	MsgTypeNetworkSliceSpecificAuthenticationCommand  uint8 = 80
+	MsgTypeNetworkSliceSpecificAuthenticationComplete uint8 = 81
+	MsgTypeNetworkSliceSpecificAuthenticationResult   uint8 = 82
+)
+
+// TS 24.501 9.11.3.2, the 5GMM cause of the UE without any allowed S-NSSAI after the NSSAA
+const Cause5GMMNoNetworkSlicesAvailable uint8 = 0x3e
+
+// Encode the Network Slice-Specific Authentication Command or Result, integrity protected and ciphered with the
+// current 5G NAS security context
+func EncodeNssaaMessage(ue *context.AmfUe, msgType uint8, message *context.NssaaMessage,
+	accessType models.AccessType,
+) ([]byte, error) {
+	if ue == nil || !ue.SecurityContextAvailable {
+		return nil, fmt.Errorf("NAS message type %d is requierd security, but security context is not available", msgType)
+	}
+	securityHeader := nas.SecurityHeader{
+		ProtocolDiscriminator: nasMessage.Epd5GSMobilityManagementMessage,
+		SecurityHeaderType:    nas.SecurityHeaderTypeIntegrityProtectedAndCiphered,
+	}
+	return securityProtect(ue, securityHeader, encodeNssaaMessage(msgType, message), accessType)
+}
+
+func encodeNssaaMessage(msgType uint8, message *context.NssaaMessage) []byte {
+	payload := []byte{nasMessage.Epd5GSMobilityManagementMessage, nas.SecurityHeaderTypePlainNas, msgType}
+	// S-NSSAI, LV
+	payload = append(payload, nasConvert.SnssaiToNas(message.Snssai)...)
+	// EAP message, LV-E
+	payload = binary.BigEndian.AppendUint16(payload, uint16(len(message.EapMessage)))
+	return append(payload, message.EapMessage...)
+}
+
+// Decode the plain Network Slice-Specific Authentication Command, Complete or Result
+func decodeNssaaMessage(payload []byte) (*context.NssaaMessage, error) {
+	if len(payload) < 4 {
+		return nil, fmt.Errorf("NSSAA message is too short")
+	}
+	offset := 3
+	snssaiLen := int(payload[offset])
+	if offset+1+snssaiLen+2 > len(payload) {
+		return nil, fmt.Errorf("NSSAA message is too short")
+	}
+	value := payload[offset+1 : offset+1+snssaiLen]
+	message := new(context.NssaaMessage)
+	switch snssaiLen {
+	case 1, 2:
+		// SST and mapped HPLMN SST
+		message.Snssai.Sst = int32(value[0])
+	case 4, 5, 8:
+		// SST, SD and mapped HPLMN S-NSSAI
+		message.Snssai.Sst = int32(value[0])
+		message.Snssai.Sd = hex.EncodeToString(value[1:4])
+	default:
+		return nil, fmt.Errorf("invalid length of S-NSSAI contents: %d", snssaiLen)
+	}
+	offset += 1 + snssaiLen
+
+	eapLen := int(binary.BigEndian.Uint16(payload[offset : offset+2]))
+	offset += 2
+	if offset+eapLen > len(payload) {
+		return nil, fmt.Errorf("NSSAA message is too short")
+	}
+	message.EapMessage = append([]byte{}, payload[offset:offset+eapLen]...)
+	return message, nil
+}
+
+// The Network Slice-Specific Authentication Complete is returned, and only the GMM header is decoded to the message
+// for the GMM state machine
+func nssaaCompleteDecode(ue *context.AmfUe, msg *nas.Message, payload []byte) (*context.NssaaMessage, error) {
+	if ue == nil {
+		return nil, fmt.Errorf("network slice-specific authentication complete is not allowed here")
+	}
+	message, err := decodeNssaaMessage(payload)
+	if err != nil {
+		return nil, err
+	}
+	msg.GmmMessage = nas.NewGmmMessage()
+	copy(msg.GmmHeader.Octet[:], payload[:3])
+	return message, nil
+}
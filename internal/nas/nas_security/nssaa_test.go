@@ -0,0 +1,57 @@
+package nas_security_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	amf_context "github.com/free5gc/amf/internal/context"
+	"github.com/free5gc/amf/internal/nas/nas_security"
+	"github.com/free5gc/nas"
+	"github.com/free5gc/nas/security"
+	"github.com/free5gc/openapi/models"
+)
+
+func TestNetworkSliceSpecificAuthenticationMessages(t *testing.T) {
+	ue := newFuzzTestAmfUe()
+	ue.SecurityContextAvailable = true
+	ue.IntegrityAlg = security.AlgIntegrity128NIA0
+	ue.CipheringAlg = security.AlgCiphering128NEA0
+
+	eapIdentityRequest := []byte{0x01, 0x00, 0x00, 0x05, 0x01}
+	payload, err := nas_security.EncodeNssaaMessage(ue,
+		nas_security.MsgTypeNetworkSliceSpecificAuthenticationCommand,
+		&amf_context.NssaaMessage{
+			Snssai:     models.Snssai{Sst: 1, Sd: "010203"},
+			EapMessage: eapIdentityRequest,
+		}, models.AccessType__3_GPP_ACCESS)
+	require.NoError(t, err)
+	// security header, then the plain message with the S-NSSAI and the EAP message
+	require.Equal(t, []byte{
+		0x7e, 0x00, 0x50,
+		0x04, 0x01, 0x01, 0x02, 0x03,
+		0x00, 0x05, 0x01, 0x00, 0x00, 0x05, 0x01,
+	}, payload[7:])
+
+	complete := []byte{
+		0x7e, nas.SecurityHeaderTypeIntegrityProtectedAndCiphered, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x7e, 0x00, 0x51,
+		0x04, 0x01, 0x01, 0x02, 0x03,
+		0x00, 0x06, 0x02, 0x00, 0x00, 0x06, 0x01, 0x41,
+	}
+	msg, nssaaComplete, integrityProtected, err := nas_security.DecodeUplink(ue, models.AccessType__3_GPP_ACCESS,
+		complete, false)
+	require.NoError(t, err)
+	require.True(t, integrityProtected)
+	require.Equal(t, nas_security.MsgTypeNetworkSliceSpecificAuthenticationComplete,
+		msg.GmmMessage.GetMessageType())
+	require.Equal(t, &amf_context.NssaaMessage{
+		Snssai:     models.Snssai{Sst: 1, Sd: "010203"},
+		EapMessage: []byte{0x02, 0x00, 0x00, 0x06, 0x01, 0x41},
+	}, nssaaComplete)
+
+	// the EAP message exceeding the message is rejected
+	complete[16] = 0x07
+	_, _, _, err = nas_security.DecodeUplink(ue, models.AccessType__3_GPP_ACCESS, complete, false)
+	require.EqualError(t, err, "NSSAA message is too short")
+}
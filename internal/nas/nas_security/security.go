@@ -44,73 +44,78 @@ func Encode(ue *context.AmfUe, msg *nas.Message, accessType models.AccessType) (
 		default:
 			return nil, fmt.Errorf("NAS message type %d is requierd security, but security context is not available", msgType)
 		}
-		pdu, err := msg.PlainNasEncode()
+		pdu, err := plainNasEncode(ue, msg, accessType)
 		return pdu, err
 	} else {
-		// Security protected NAS Message
-		// a security protected NAS message must be integrity protected, and ciphering is optional
-		needCiphering := false
-		switch msg.SecurityHeader.SecurityHeaderType {
-		case nas.SecurityHeaderTypeIntegrityProtected:
-			ue.NASLog.Debugln("Security header type: Integrity Protected")
-		case nas.SecurityHeaderTypeIntegrityProtectedAndCiphered:
-			ue.NASLog.Debugln("Security header type: Integrity Protected And Ciphered")
-			needCiphering = true
-		case nas.SecurityHeaderTypeIntegrityProtectedWithNew5gNasSecurityContext:
-			ue.NASLog.Debugln("Security header type: Integrity Protected With New 5G Security Context")
-			ue.ULCount.Set(0, 0)
-			ue.DLCount.Set(0, 0)
-		default:
-			return nil, fmt.Errorf("wrong security header type: 0x%0x", msg.SecurityHeader.SecurityHeaderType)
-		}
-
 		// encode plain nas first
-		payload, err := msg.PlainNasEncode()
+		payload, err := plainNasEncode(ue, msg, accessType)
 		if err != nil {
 			return nil, fmt.Errorf("plain NAS encode error: %+v", err)
 		}
+		return securityProtect(ue, msg.SecurityHeader, payload, accessType)
+	}
+}
 
-		ue.NASLog.Tracef("plain payload:\n%+v", hex.Dump(payload))
-		if needCiphering {
-			ue.NASLog.Debugf("Encrypt NAS message (algorithm: %+v, DLCount: 0x%0x)", ue.CipheringAlg, ue.DLCount.Get())
-			ue.NASLog.Tracef("NAS ciphering key: %0x", ue.KnasEnc)
-			if err = security.NASEncrypt(ue.CipheringAlg, ue.KnasEnc, ue.DLCount.Get(),
-				GetBearerType(accessType), security.DirectionDownlink, payload); err != nil {
-				return nil, fmt.Errorf("encrypt error: %+v", err)
-			}
+// Security protected NAS Message of the plain payload
+func securityProtect(ue *context.AmfUe, securityHeader nas.SecurityHeader, payload []byte,
+	accessType models.AccessType,
+) ([]byte, error) {
+	// a security protected NAS message must be integrity protected, and ciphering is optional
+	needCiphering := false
+	switch securityHeader.SecurityHeaderType {
+	case nas.SecurityHeaderTypeIntegrityProtected:
+		ue.NASLog.Debugln("Security header type: Integrity Protected")
+	case nas.SecurityHeaderTypeIntegrityProtectedAndCiphered:
+		ue.NASLog.Debugln("Security header type: Integrity Protected And Ciphered")
+		needCiphering = true
+	case nas.SecurityHeaderTypeIntegrityProtectedWithNew5gNasSecurityContext:
+		ue.NASLog.Debugln("Security header type: Integrity Protected With New 5G Security Context")
+		ue.ULCount.Set(0, 0)
+		ue.DLCount.Set(0, 0)
+	default:
+		return nil, fmt.Errorf("wrong security header type: 0x%0x", securityHeader.SecurityHeaderType)
+	}
+
+	ue.NASLog.Tracef("plain payload:\n%+v", hex.Dump(payload))
+	if needCiphering {
+		ue.NASLog.Debugf("Encrypt NAS message (algorithm: %+v, DLCount: 0x%0x)", ue.CipheringAlg, ue.DLCount.Get())
+		ue.NASLog.Tracef("NAS ciphering key: %0x", ue.KnasEnc)
+		if err := security.NASEncrypt(ue.CipheringAlg, ue.KnasEnc, ue.DLCount.Get(),
+			GetBearerType(accessType), security.DirectionDownlink, payload); err != nil {
+			return nil, fmt.Errorf("encrypt error: %+v", err)
 		}
+	}
 
-		// add sequece number
-		addsqn := []byte{}
-		addsqn = append(addsqn, []byte{ue.DLCount.SQN()}...)
-		addsqn = append(addsqn, payload...)
-		payload = addsqn
+	// add sequece number
+	addsqn := []byte{}
+	addsqn = append(addsqn, []byte{ue.DLCount.SQN()}...)
+	addsqn = append(addsqn, payload...)
+	payload = addsqn
 
-		ue.NASLog.Debugf("Calculate NAS MAC (algorithm: %+v, DLCount: 0x%0x)", ue.IntegrityAlg, ue.DLCount.Get())
-		ue.NASLog.Tracef("NAS integrity key: %0x", ue.KnasInt)
-		mac32, err := security.NASMacCalculate(ue.IntegrityAlg, ue.KnasInt, ue.DLCount.Get(),
-			GetBearerType(accessType), security.DirectionDownlink, payload)
-		if err != nil {
-			return nil, fmt.Errorf("MAC calcuate error: %+v", err)
-		}
-		// Add mac value
-		ue.NASLog.Tracef("MAC: 0x%08x", mac32)
-		addmac := []byte{}
-		addmac = append(addmac, mac32...)
-		addmac = append(addmac, payload...)
-		payload = addmac
-
-		// Add EPD and Security Type
-		msgSecurityHeader := []byte{msg.SecurityHeader.ProtocolDiscriminator, msg.SecurityHeader.SecurityHeaderType}
-		encodepayload := []byte{}
-		encodepayload = append(encodepayload, msgSecurityHeader...)
-		encodepayload = append(encodepayload, payload...)
-		payload = encodepayload
-
-		// Increase DL Count
-		ue.DLCount.AddOne()
-		return payload, nil
+	ue.NASLog.Debugf("Calculate NAS MAC (algorithm: %+v, DLCount: 0x%0x)", ue.IntegrityAlg, ue.DLCount.Get())
+	ue.NASLog.Tracef("NAS integrity key: %0x", ue.KnasInt)
+	mac32, err := security.NASMacCalculate(ue.IntegrityAlg, ue.KnasInt, ue.DLCount.Get(),
+		GetBearerType(accessType), security.DirectionDownlink, payload)
+	if err != nil {
+		return nil, fmt.Errorf("MAC calcuate error: %+v", err)
 	}
+	// Add mac value
+	ue.NASLog.Tracef("MAC: 0x%08x", mac32)
+	addmac := []byte{}
+	addmac = append(addmac, mac32...)
+	addmac = append(addmac, payload...)
+	payload = addmac
+
+	// Add EPD and Security Type
+	msgSecurityHeader := []byte{securityHeader.ProtocolDiscriminator, securityHeader.SecurityHeaderType}
+	encodepayload := []byte{}
+	encodepayload = append(encodepayload, msgSecurityHeader...)
+	encodepayload = append(encodepayload, payload...)
+	payload = encodepayload
+
+	// Increase DL Count
+	ue.DLCount.AddOne()
+	return payload, nil
 }
 
 /*
@@ -120,14 +125,24 @@ format is followed TS 24.501 9.1.1
 func Decode(ue *context.AmfUe, accessType models.AccessType, payload []byte,
 	initialMessage bool,
 ) (msg *nas.Message, integrityProtected bool, err error) {
+	msg, _, integrityProtected, err = DecodeUplink(ue, accessType, payload, initialMessage)
+	return msg, integrityProtected, err
+}
+
+// Decode the uplink NAS message of the UE to be dispatched to the GMM state machine. The message unknown to the nas
+// module, i.e. the Network Slice-Specific Authentication Complete, is decoded to the GMM header of msg and to
+// nssaaComplete.
+func DecodeUplink(ue *context.AmfUe, accessType models.AccessType, payload []byte,
+	initialMessage bool,
+) (msg *nas.Message, nssaaComplete *context.NssaaMessage, integrityProtected bool, err error) {
 	if ue == nil {
-		return nil, false, fmt.Errorf("amfUe is nil")
+		return nil, nil, false, fmt.Errorf("amfUe is nil")
 	}
 	if payload == nil {
-		return nil, false, fmt.Errorf("NAS payload is empty")
+		return nil, nil, false, fmt.Errorf("NAS payload is empty")
 	}
 	if len(payload) < 2 {
-		return nil, false, fmt.Errorf("NAS payload is too short")
+		return nil, nil, false, fmt.Errorf("NAS payload is too short")
 	}
 
 	ulCountNew := ue.ULCount
@@ -144,7 +159,7 @@ func Decode(ue *context.AmfUe, accessType models.AccessType, payload []byte,
 		// Sequence number					V 1
 		// Plain 5GS NAS message			V 3-n
 		if len(payload) < (1 + 1 + 4 + 1 + 3) {
-			return nil, false, fmt.Errorf("NAS payload is too short")
+			return nil, nil, false, fmt.Errorf("NAS payload is too short")
 		}
 		securityHeader := payload[0:6]
 		ue.NASLog.Traceln("securityHeader is ", securityHeader)
@@ -169,11 +184,11 @@ func Decode(ue *context.AmfUe, accessType models.AccessType, payload []byte,
 			ciphered = true
 			ulCountNew.Set(0, 0)
 		default:
-			return nil, false, fmt.Errorf("wrong security header type: 0x%0x", msg.SecurityHeader.SecurityHeaderType)
+			return nil, nil, false, fmt.Errorf("wrong security header type: 0x%0x", msg.SecurityHeader.SecurityHeaderType)
 		}
 
 		if ciphered && !ue.SecurityContextAvailable {
-			return nil, false, fmt.Errorf("NAS message is ciphered, but UE Security Context is not Available")
+			return nil, nil, false, fmt.Errorf("NAS message is ciphered, but UE Security Context is not Available")
 		}
 
 		if ue.SecurityContextAvailable {
@@ -189,7 +204,7 @@ func Decode(ue *context.AmfUe, accessType models.AccessType, payload []byte,
 			mac32, err = security.NASMacCalculate(ue.IntegrityAlg, ue.KnasInt, ulCountNew.Get(),
 				GetBearerType(accessType), security.DirectionUplink, payload)
 			if err != nil {
-				return nil, false, fmt.Errorf("MAC calcuate error: %+v", err)
+				return nil, nil, false, fmt.Errorf("MAC calcuate error: %+v", err)
 			}
 
 			if !reflect.DeepEqual(mac32, receivedMac32) {
@@ -204,14 +219,14 @@ func Decode(ue *context.AmfUe, accessType models.AccessType, payload []byte,
 
 		if ciphered {
 			if !integrityProtected {
-				return nil, false, fmt.Errorf("NAS message is ciphered, but MAC verification failed")
+				return nil, nil, false, fmt.Errorf("NAS message is ciphered, but MAC verification failed")
 			}
 			ue.NASLog.Debugf("Decrypt NAS message (algorithm: %+v, ULCount: 0x%0x)", ue.CipheringAlg, ulCountNew.Get())
 			ue.NASLog.Tracef("NAS ciphering key: %0x", ue.KnasEnc)
 			// decrypt payload without sequence number (payload[1])
 			if err = security.NASEncrypt(ue.CipheringAlg, ue.KnasEnc, ulCountNew.Get(), GetBearerType(accessType),
 				security.DirectionUplink, payload[1:]); err != nil {
-				return nil, false, fmt.Errorf("decrypt error: %+v", err)
+				return nil, nil, false, fmt.Errorf("decrypt error: %+v", err)
 			}
 		}
 
@@ -219,9 +234,9 @@ func Decode(ue *context.AmfUe, accessType models.AccessType, payload []byte,
 		payload = payload[1:]
 	}
 
-	err = msg.PlainNasDecode(&payload)
+	nssaaComplete, err = plainNasDecode(ue, msg, payload)
 	if err != nil {
-		return nil, false, err
+		return nil, nil, false, err
 	}
 
 	msgTypeText := func() string {
@@ -243,13 +258,13 @@ func Decode(ue *context.AmfUe, accessType models.AccessType, payload []byte,
 
 	if msg.GmmMessage == nil {
 		if !ue.SecurityContextAvailable {
-			return nil, false, errNoSecurityContext()
+			return nil, nil, false, errNoSecurityContext()
 		}
 		if msg.SecurityHeaderType != nas.SecurityHeaderTypeIntegrityProtectedAndCiphered {
-			return nil, false, errWrongSecurityHeader()
+			return nil, nil, false, errWrongSecurityHeader()
 		}
 		if !integrityProtected {
-			return nil, false, errMacVerificationFailed()
+			return nil, nil, false, errMacVerificationFailed()
 		}
 	} else {
 		switch msg.GmmHeader.GetMessageType() {
@@ -257,32 +272,32 @@ func Decode(ue *context.AmfUe, accessType models.AccessType, payload []byte,
 			if initialMessage {
 				if msg.SecurityHeaderType == nas.SecurityHeaderTypeIntegrityProtectedAndCiphered ||
 					msg.SecurityHeaderType == nas.SecurityHeaderTypeIntegrityProtectedAndCipheredWithNew5gNasSecurityContext {
-					return nil, false, errWrongSecurityHeader()
+					return nil, nil, false, errWrongSecurityHeader()
 				}
 			} else {
 				if ue.SecurityContextAvailable {
 					if msg.SecurityHeaderType != nas.SecurityHeaderTypeIntegrityProtectedAndCiphered {
-						return nil, false, errWrongSecurityHeader()
+						return nil, nil, false, errWrongSecurityHeader()
 					}
 					if !integrityProtected {
-						return nil, false, errMacVerificationFailed()
+						return nil, nil, false, errMacVerificationFailed()
 					}
 				}
 			}
 		case nas.MsgTypeServiceRequest:
 			if initialMessage {
 				if msg.SecurityHeaderType != nas.SecurityHeaderTypeIntegrityProtected {
-					return nil, false, errWrongSecurityHeader()
+					return nil, nil, false, errWrongSecurityHeader()
 				}
 			} else {
 				if !ue.SecurityContextAvailable {
-					return nil, false, errNoSecurityContext()
+					return nil, nil, false, errNoSecurityContext()
 				}
 				if msg.SecurityHeaderType != nas.SecurityHeaderTypeIntegrityProtectedAndCiphered {
-					return nil, false, errWrongSecurityHeader()
+					return nil, nil, false, errWrongSecurityHeader()
 				}
 				if !integrityProtected {
-					return nil, false, errMacVerificationFailed()
+					return nil, nil, false, errMacVerificationFailed()
 				}
 			}
 		case nas.MsgTypeIdentityResponse:
@@ -292,22 +307,22 @@ func Decode(ue *context.AmfUe, accessType models.AccessType, payload []byte,
 				// Identity is SUCI
 				if ue.SecurityContextAvailable {
 					if msg.SecurityHeaderType != nas.SecurityHeaderTypeIntegrityProtectedAndCiphered {
-						return nil, false, errWrongSecurityHeader()
+						return nil, nil, false, errWrongSecurityHeader()
 					}
 					if !integrityProtected {
-						return nil, false, errMacVerificationFailed()
+						return nil, nil, false, errMacVerificationFailed()
 					}
 				}
 			} else {
 				// Identity is not SUCI
 				if !ue.SecurityContextAvailable {
-					return nil, false, errNoSecurityContext()
+					return nil, nil, false, errNoSecurityContext()
 				}
 				if msg.SecurityHeaderType != nas.SecurityHeaderTypeIntegrityProtectedAndCiphered {
-					return nil, false, errWrongSecurityHeader()
+					return nil, nil, false, errWrongSecurityHeader()
 				}
 				if !integrityProtected {
-					return nil, false, errMacVerificationFailed()
+					return nil, nil, false, errMacVerificationFailed()
 				}
 			}
 		case nas.MsgTypeAuthenticationResponse,
@@ -316,31 +331,31 @@ func Decode(ue *context.AmfUe, accessType models.AccessType, payload []byte,
 			nas.MsgTypeDeregistrationAcceptUETerminatedDeregistration:
 			if ue.SecurityContextAvailable {
 				if msg.SecurityHeaderType != nas.SecurityHeaderTypeIntegrityProtectedAndCiphered {
-					return nil, false, errWrongSecurityHeader()
+					return nil, nil, false, errWrongSecurityHeader()
 				}
 				if !integrityProtected {
-					return nil, false, errMacVerificationFailed()
+					return nil, nil, false, errMacVerificationFailed()
 				}
 			}
 		case nas.MsgTypeSecurityModeComplete:
 			if !ue.SecurityContextAvailable {
-				return nil, false, errNoSecurityContext()
+				return nil, nil, false, errNoSecurityContext()
 			}
 			if msg.SecurityHeaderType != nas.SecurityHeaderTypeIntegrityProtectedAndCipheredWithNew5gNasSecurityContext {
-				return nil, false, errWrongSecurityHeader()
+				return nil, nil, false, errWrongSecurityHeader()
 			}
 			if !integrityProtected {
-				return nil, false, errMacVerificationFailed()
+				return nil, nil, false, errMacVerificationFailed()
 			}
 		default:
 			if !ue.SecurityContextAvailable {
-				return nil, false, errNoSecurityContext()
+				return nil, nil, false, errNoSecurityContext()
 			}
 			if msg.SecurityHeaderType != nas.SecurityHeaderTypeIntegrityProtectedAndCiphered {
-				return nil, false, errWrongSecurityHeader()
+				return nil, nil, false, errWrongSecurityHeader()
 			}
 			if !integrityProtected {
-				return nil, false, errMacVerificationFailed()
+				return nil, nil, false, errMacVerificationFailed()
 			}
 		}
 	}
@@ -348,7 +363,7 @@ func Decode(ue *context.AmfUe, accessType models.AccessType, payload []byte,
 	if integrityProtected {
 		ue.ULCount = ulCountNew
 	}
-	return msg, integrityProtected, nil
+	return msg, nssaaComplete, integrityProtected, nil
 }
 
 // DecodePlainNas is used to decode plain nas.
@@ -382,7 +397,7 @@ func DecodePlainNasNoIntegrityCheck(payload []byte) (*nas.Message, error) {
 		payload = payload[7:]
 	}
 
-	err := msg.PlainNasDecode(&payload)
+	_, err := plainNasDecode(nil, msg, payload)
 	return msg, err
 }
 
@@ -0,0 +1,48 @@
+package ngap
+
+import (
+	"time"
+
+	"github.com/free5gc/amf/internal/context"
+	"github.com/free5gc/amf/internal/logger"
+	ngap_message "github.com/free5gc/amf/internal/ngap/message"
+	"github.com/free5gc/ngap/ngapType"
+)
+
+// Push the AMF name, served GUAMIs, relative capacity and PLMN support list changed at runtime to all the NG-RAN
+// nodes set up with the AMF by AMF Configuration Update (TS 38.413 8.7.3)
+func UpdateAMFConfiguration() {
+	logger.NgapLog.Info("AMF configuration is changed, send AMF Configuration Update to the NG-RAN nodes")
+	context.GetSelf().AmfRanPool.Range(func(key, value interface{}) bool {
+		ran := value.(*context.AmfRan)
+		if ran.RanId != nil {
+			sendAMFConfigurationUpdate(ran)
+		}
+		return true
+	})
+}
+
+func sendAMFConfigurationUpdate(ran *context.AmfRan) {
+	ran.AmfConfigUpdateSent()
+	ngap_message.SendAMFConfigurationUpdate(ran)
+}
+
+// TS 38.413 9.3.1.56
+func timeToWaitDuration(timeToWait *ngapType.TimeToWait) time.Duration {
+	switch timeToWait.Value {
+	case ngapType.TimeToWaitPresentV1s:
+		return time.Second
+	case ngapType.TimeToWaitPresentV2s:
+		return 2 * time.Second
+	case ngapType.TimeToWaitPresentV5s:
+		return 5 * time.Second
+	case ngapType.TimeToWaitPresentV10s:
+		return 10 * time.Second
+	case ngapType.TimeToWaitPresentV20s:
+		return 20 * time.Second
+	case ngapType.TimeToWaitPresentV60s:
+		return 60 * time.Second
+	default:
+		return 0
+	}
+}
@@ -2,6 +2,7 @@ package ngap
 
 import (
 	"net"
+	"time"
 
 	"github.com/free5gc/amf/internal/context"
 	"github.com/free5gc/amf/internal/logger"
@@ -26,7 +27,7 @@ func Dispatch(conn net.Conn, msg []byte) {
 
 	if len(msg) == 0 {
 		ran.Log.Infof("RAN close the connection.")
-		ran.Remove()
+		removeRan(ran)
 		return
 	}
 
@@ -46,7 +47,9 @@ func Dispatch(conn net.Conn, msg []byte) {
 		return
 	}
 
+	start := time.Now()
 	dispatchMain(ran, pdu)
+	amfSelf.Load.NgapMessageDispatched(time.Since(start))
 }
 
 func HandleSCTPNotification(conn net.Conn, notification sctp.Notification) {
@@ -67,16 +70,16 @@ func HandleSCTPNotification(conn net.Conn, notification sctp.Notification) {
 		switch event.State() {
 		case sctp.SCTP_COMM_LOST:
 			ran.Log.Infof("SCTP state is SCTP_COMM_LOST, close the connection")
-			ran.Remove()
+			removeRan(ran)
 		case sctp.SCTP_SHUTDOWN_COMP:
 			ran.Log.Infof("SCTP state is SCTP_SHUTDOWN_COMP, close the connection")
-			ran.Remove()
+			removeRan(ran)
 		default:
 			ran.Log.Warnf("SCTP state[%+v] is not handled", event.State())
 		}
 	case sctp.SCTP_SHUTDOWN_EVENT:
 		ran.Log.Infof("SCTP_SHUTDOWN_EVENT notification, close the connection")
-		ran.Remove()
+		removeRan(ran)
 	default:
 		ran.Log.Warnf("Non handled notification type: 0x%x", notification.Type())
 	}
@@ -92,5 +95,15 @@ func HandleSCTPConnError(conn net.Conn) {
 		logger.NgapLog.Warnf("RAN context has been removed[addr: %+v]", conn.RemoteAddr())
 		return
 	}
-	ran.Remove()
+	removeRan(ran)
+}
+
+// The RAN lost the NG connection, its UE contexts are retained for the grace period if the UE retention is enabled
+func removeRan(ran *context.AmfRan) {
+	release := func(ran *context.AmfRan) {
+		removeRanUes(ran, ran.Remove)
+	}
+	if !context.GetSelf().RetainAmfRan(ran, release) {
+		release(ran)
+	}
 }
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/free5gc/amf/internal/context"
+	"github.com/free5gc/amf/internal/gmm"
 	gmm_common "github.com/free5gc/amf/internal/gmm/common"
 	gmm_message "github.com/free5gc/amf/internal/gmm/message"
 	business_metrics "github.com/free5gc/amf/internal/metrics/business"
@@ -14,6 +15,8 @@ import (
 	"github.com/free5gc/amf/internal/nas/nas_security"
 	ngap_message "github.com/free5gc/amf/internal/ngap/message"
 	"github.com/free5gc/amf/internal/sbi/consumer"
+	callback "github.com/free5gc/amf/internal/sbi/processor/notifier"
+	"github.com/free5gc/amf/internal/util"
 	"github.com/free5gc/amf/pkg/factory"
 	"github.com/free5gc/aper"
 	"github.com/free5gc/nas"
@@ -22,6 +25,7 @@ import (
 	"github.com/free5gc/ngap/ngapConvert"
 	"github.com/free5gc/ngap/ngapType"
 	"github.com/free5gc/openapi/models"
+	Nnrf_NFDiscovery "github.com/free5gc/openapi/nrf/NFDiscovery"
 	"github.com/free5gc/util/metrics/ngap"
 	"github.com/free5gc/util/metrics/utils"
 )
@@ -31,6 +35,7 @@ func handleNGSetupRequestMain(ran *context.AmfRan,
 	rANNodeName *ngapType.RANNodeName,
 	supportedTAList *ngapType.SupportedTAList,
 	pagingDRX *ngapType.PagingDRX,
+	uERetentionInformation *ngapType.UERetentionInformation,
 ) {
 	var cause ngapType.Cause
 
@@ -40,35 +45,10 @@ func handleNGSetupRequestMain(ran *context.AmfRan,
 	}
 	if pagingDRX != nil {
 		ran.Log.Tracef("PagingDRX[%d]", pagingDRX.Value)
+		ran.DefaultPagingDRX = pagingDRX
 	}
 
-	for i := 0; i < len(supportedTAList.List); i++ {
-		supportedTAItem := supportedTAList.List[i]
-		tac := hex.EncodeToString(supportedTAItem.TAC.Value)
-		capOfSupportTai := cap(ran.SupportedTAList)
-		for j := 0; j < len(supportedTAItem.BroadcastPLMNList.List); j++ {
-			supportedTAI := context.NewSupportedTAI()
-			supportedTAI.Tai.Tac = tac
-			broadcastPLMNItem := supportedTAItem.BroadcastPLMNList.List[j]
-			plmnId := ngapConvert.PlmnIdToModels(broadcastPLMNItem.PLMNIdentity)
-			supportedTAI.Tai.PlmnId = &plmnId
-			capOfSNssaiList := cap(supportedTAI.SNssaiList)
-			for k := 0; k < len(broadcastPLMNItem.TAISliceSupportList.List); k++ {
-				tAISliceSupportItem := broadcastPLMNItem.TAISliceSupportList.List[k]
-				if len(supportedTAI.SNssaiList) < capOfSNssaiList {
-					supportedTAI.SNssaiList = append(supportedTAI.SNssaiList, ngapConvert.SNssaiToModels(tAISliceSupportItem.SNSSAI))
-				} else {
-					break
-				}
-			}
-			ran.Log.Tracef("PLMN_ID[MCC:%s MNC:%s] TAC[%s]", plmnId.Mcc, plmnId.Mnc, tac)
-			if len(ran.SupportedTAList) < capOfSupportTai {
-				ran.SupportedTAList = append(ran.SupportedTAList, supportedTAI)
-			} else {
-				break
-			}
-		}
-	}
+	ran.SupportedTAList = buildSupportedTAList(ran, supportedTAList)
 
 	if len(ran.SupportedTAList) == 0 {
 		ran.Log.Warn("NG-Setup failure: No supported TA exist in NG-Setup request")
@@ -95,12 +75,33 @@ func handleNGSetupRequestMain(ran *context.AmfRan,
 	}
 
 	if cause.Present == ngapType.CausePresentNothing {
-		ngap_message.SendNGSetupResponse(ran)
+		ueRetained := uERetentionInformation != nil && context.GetSelf().UeRetentionEnabled()
+		resumeRetainedRan(ran, ueRetained)
+		ngap_message.SendNGSetupResponse(ran, ueRetained)
+		// the RAN set up during the overload is requested to reduce the load as well
+		if context.GetSelf().Load.Overloaded() {
+			SendOverloadStart(ran)
+		}
 	} else {
 		ngap_message.SendNGSetupFailure(ran, cause)
 	}
 }
 
+// The UE contexts retained on the loss of the previous NG connection of the RAN are resumed if the UE retention is
+// accepted, or released otherwise (TS 38.413 8.7.1.2)
+func resumeRetainedRan(ran *context.AmfRan, ueRetained bool) {
+	retainedRan, ok := context.GetSelf().TakeRetainedAmfRan(ran.RanID())
+	if !ok {
+		return
+	}
+	if ueRetained {
+		ran.Log.Infof("Resume UE contexts retained by RAN%s", ran.RanID())
+		ran.ResumeRanUes(retainedRan)
+	}
+	// the RanUes not resumed are released, the mobile reachable timer is started for their UEs
+	removeRanUes(retainedRan, retainedRan.Remove)
+}
+
 func handleUplinkNASTransportMain(ran *context.AmfRan,
 	ranUe *context.RanUe,
 	nASPDU *ngapType.NASPDU,
@@ -135,7 +136,7 @@ func handleNGResetMain(ran *context.AmfRan,
 	switch resetType.Present {
 	case ngapType.ResetTypePresentNGInterface:
 		ran.Log.Trace("ResetType Present: NG Interface")
-		ran.RemoveAllRanUe(false)
+		removeRanUes(ran, func() { ran.RemoveAllRanUe(false) })
 		ngap_message.SendNGResetAcknowledge(ran, nil, nil)
 	case ngapType.ResetTypePresentPartOfNGInterface:
 		ran.Log.Trace("ResetType Present: Part of NG Interface")
@@ -167,10 +168,17 @@ func handleNGResetMain(ran *context.AmfRan,
 				}
 			}
 
+			var amfUe *context.AmfUe
+			if ranUe != nil && ranUe.AmfUe != nil && ranUe.AmfUe.RanUe[ran.AnType] == ranUe {
+				amfUe = ranUe.AmfUe
+			}
 			err := ranUe.Remove()
 			if err != nil {
 				ran.Log.Error(err.Error())
 			}
+			if amfUe != nil {
+				gmm.StartMobileReachableTimer(amfUe, ran.AnType)
+			}
 		}
 		ngap_message.SendNGResetAcknowledge(ran, partOfNGInterface, nil)
 	default:
@@ -228,7 +236,7 @@ func handleUEContextReleaseCompleteMain(ran *context.AmfRan,
 		}
 		return
 	}
-	// TODO: AMF shall, if supported, store it and may use it for subsequent paging
+	// AMF shall, if supported, store it and may use it for subsequent paging
 	if infoOnRecommendedCellsAndRANNodesForPaging != nil {
 		amfUe.InfoOnRecommendedCellsAndRanNodesForPaging = new(context.InfoOnRecommendedCellsAndRanNodesForPaging)
 
@@ -268,8 +276,8 @@ func handleUEContextReleaseCompleteMain(ran *context.AmfRan,
 			switch item.AMFPagingTarget.Present {
 			case ngapType.AMFPagingTargetPresentGlobalRANNodeID:
 				recommendedRanNode.Present = context.RecommendRanNodePresentRanNode
-				recommendedRanNode.GlobalRanNodeId = new(models.GlobalRanNodeId)
-				// TODO: recommendedRanNode.GlobalRanNodeId = ngapConvert.RanIdToModels(item.AMFPagingTarget.GlobalRANNodeID)
+				ranNodeId := ngapConvert.RanIdToModels(*item.AMFPagingTarget.GlobalRANNodeID)
+				recommendedRanNode.GlobalRanNodeId = &ranNodeId
 			case ngapType.AMFPagingTargetPresentTAI:
 				recommendedRanNode.Present = context.RecommendRanNodePresentTAI
 				tai := ngapConvert.TaiToModels(*item.AMFPagingTarget.TAI)
@@ -306,6 +314,10 @@ func handleUEContextReleaseCompleteMain(ran *context.AmfRan,
 		}
 	}
 
+	if isCommunicationFailure(cause.NgapCause) {
+		callback.SendCommunicationFailureReport(amfUe, cause.NgapCause)
+	}
+
 	// TODO: stop timer and release RanUe context
 	// Remove UE N2 Connection
 	delete(amfUe.ReleaseCause, ran.AnType)
@@ -317,6 +329,8 @@ func handleUEContextReleaseCompleteMain(ran *context.AmfRan,
 		if err != nil {
 			ran.Log.Errorln(err.Error())
 		}
+		gmm.StartMobileReachableTimer(amfUe, ran.AnType)
+		callback.SendAmfEventReport(amfUe, models.AmfEventType_CONNECTIVITY_STATE_REPORT)
 	case context.UeContextReleaseUeContext:
 		ran.Log.Infof("Release UE[%s] Context : Release Ue Context", amfUe.Supi)
 		amfUe.Lock.Lock()
@@ -332,7 +346,9 @@ func handleUEContextReleaseCompleteMain(ran *context.AmfRan,
 		if err != nil {
 			ran.Log.Errorln(err.Error())
 		}
+		amfUe.Lock.Lock()
 		gmm_common.AttachRanUeToAmfUeAndReleaseOldIfAny(amfUe, targetRanUe)
+		amfUe.Lock.Unlock()
 		// Todo: remove indirect tunnel
 	default:
 		ran.Log.Errorf("Invalid Release Action[%d]", ranUe.ReleaseAction)
@@ -472,9 +488,10 @@ func handleInitialUEMessageMain(ran *context.AmfRan,
 		id = amfSetPtrID + tmsi
 		idType = "5G-S-TMSI"
 		ranUe.Log.Infof("Find 5G-S-TMSI [%q] in InitialUEMessage", id)
-	} else if regReqType == nasMessage.RegistrationType5GSInitialRegistration {
-		// NGAP 5G-S-TMSI IE might not be present in InitialUEMessage carrying Initial Registration.
-		// Need to get 5GSMobileIdentity from Initial Registration.
+	} else if regReqType == nasMessage.RegistrationType5GSInitialRegistration ||
+		regReqType == nasMessage.RegistrationType5GSEmergencyRegistration {
+		// NGAP 5G-S-TMSI IE might not be present in InitialUEMessage carrying Initial/Emergency Registration.
+		// Need to get 5GSMobileIdentity from Initial/Emergency Registration.
 
 		id, idType, err = amf_nas.GetNas5GSMobileIdentity(gmmMessage)
 		ran.Log.Infof("5GSMobileIdentity [%q:%q, err: %v]", idType, id, err)
@@ -503,7 +520,8 @@ func handleInitialUEMessageMain(ran *context.AmfRan,
 		ranUe.Log.Infof("find AmfUe [%q:%q]", idType, id)
 		ranUe.Log.Debugf("AmfUe Attach RanUe [RanUeNgapID: %d]", ranUe.RanUeNgapId)
 		ranUe.HoldingAmfUe = amfUe
-	} else if regReqType != nasMessage.RegistrationType5GSInitialRegistration {
+	} else if regReqType != nasMessage.RegistrationType5GSInitialRegistration &&
+		regReqType != nasMessage.RegistrationType5GSEmergencyRegistration {
 		if regReqType == nasMessage.RegistrationType5GSPeriodicRegistrationUpdating ||
 			regReqType == nasMessage.RegistrationType5GSMobilityRegistrationUpdating {
 			gmm_message.SendRegistrationReject(
@@ -562,7 +580,7 @@ func findAmfUe(ran *context.AmfRan, id, idType string) (*context.AmfUe, bool) {
 	var ok bool
 
 	amfSelf := context.GetSelf()
-	servedGuami := amfSelf.ServedGuamiList[0]
+	servedGuami := amfSelf.GetServedGuamiList()[0]
 	tmpRegionID, _, _ := ngapConvert.AmfIdToNgap(servedGuami.AmfId)
 
 	switch idType {
@@ -579,6 +597,10 @@ func findAmfUe(ran *context.AmfRan, id, idType string) (*context.AmfUe, bool) {
 		id = servedGuami.PlmnId.Mcc + servedGuami.PlmnId.Mnc + ngapConvert.BitStringToHex(&tmpRegionID) + id
 		ran.Log.Debugf("5G-S-TMSI %s", id)
 		amfUe, ok = amfSelf.AmfUeFindByGuti(id)
+	case "IMEI", "IMEISV":
+		// emergency registration with PEI
+		ran.Log.Debugf("PEI %s", id)
+		amfUe, ok = amfSelf.AmfUeFindByPei(id)
 	}
 	return amfUe, ok
 }
@@ -787,7 +809,7 @@ func handlePDUSessionResourceNotifyMain(ran *context.AmfRan,
 						if n1Msg != nil {
 							pduSessionId := uint8(pduSessionID)
 							nasPdu, err = gmm_message.BuildDLNASTransport(amfUe, ran.AnType, nasMessage.PayloadContainerTypeN1SMInfo,
-								n1Msg, pduSessionId, nil, nil, 0)
+								n1Msg, pduSessionId, nil, nil, 0, nil)
 							if err != nil {
 								ranUe.Log.Warnf("GMM Message build DL NAS Transport filaed: %v", err)
 							}
@@ -805,7 +827,7 @@ func handlePDUSessionResourceNotifyMain(ran *context.AmfRan,
 					pduSessionID, errJSON.Error.Cause)
 				if n1Msg != nil {
 					gmm_message.SendDLNASTransport(
-						ranUe, nasMessage.PayloadContainerTypeN1SMInfo, errResponse.BinaryDataN1SmMessage, pduSessionID, 0, nil, 0)
+						ranUe, nasMessage.PayloadContainerTypeN1SMInfo, errResponse.BinaryDataN1SmMessage, pduSessionID, 0, nil, 0, nil)
 				}
 				// TODO: handle n2 info transfer
 			} else if err != nil {
@@ -845,7 +867,7 @@ func handlePDUSessionResourceNotifyMain(ran *context.AmfRan,
 						if n1Msg != nil {
 							nasPdu, err = gmm_message.BuildDLNASTransport(
 								amfUe, ran.AnType, nasMessage.PayloadContainerTypeN1SMInfo, n1Msg,
-								uint8(pduSessionID), nil, nil, 0)
+								uint8(pduSessionID), nil, nil, 0, nil)
 							if err != nil {
 								ranUe.Log.Warnf("GMM Message build DL NAS Transport filaed: %v", err)
 							}
@@ -862,7 +884,7 @@ func handlePDUSessionResourceNotifyMain(ran *context.AmfRan,
 					pduSessionID, errJSON.Error.Cause)
 				if n1Msg != nil {
 					gmm_message.SendDLNASTransport(
-						ranUe, nasMessage.PayloadContainerTypeN1SMInfo, errResponse.BinaryDataN1SmMessage, pduSessionID, 0, nil, 0)
+						ranUe, nasMessage.PayloadContainerTypeN1SMInfo, errResponse.BinaryDataN1SmMessage, pduSessionID, 0, nil, 0, nil)
 				}
 			} else if err != nil {
 				return
@@ -1198,13 +1220,38 @@ func handleHandoverNotifyMain(ran *context.AmfRan,
 		ran.Log.Error("AmfUe is nil")
 		return
 	}
+	callback.SendAmfEventReport(amfUe, models.AmfEventType_LOCATION_REPORT)
 	sourceUe := targetUe.SourceUe
 	if sourceUe == nil {
-		// TODO: Send to S-AMF
-		// Desciibed in (23.502 4.9.1.3.3) [conditional] 6a.Namf_Communication_N2InfoNotify.
-		ran.Log.Error("N2 Handover between AMF has not been implemented yet")
-		business_metrics.IncrHoEventCounter(business_metrics.HANDOVER_TYPE_NGAP_VALUE, utils.FailureMetric,
-			business_metrics.HANDOVER_NOT_YET_IMPLEMENT_N2_HANDOVER_BETWEEN_AMF, targetUe.HandOverStartTime)
+		// N2 Handover between AMF
+		// Described in (23.502 4.9.1.3.3) step 2-3 and [conditional] 6a.Namf_Communication_N2InfoNotify.
+		amfSelf := context.GetSelf()
+		for _, pduSessionID := range targetUe.SuccessPduSessionId {
+			smContext, ok := amfUe.SmContextFindByPDUSessionID(pduSessionID)
+			if !ok {
+				targetUe.Log.Warnf("SmContext[PDU Session ID:%d] not found", pduSessionID)
+				continue
+			}
+			_, _, _, err := consumer.GetConsumer().SendUpdateSmContextN2HandoverComplete(amfUe, smContext,
+				amfSelf.NfId, &amfSelf.GetServedGuamiList()[0])
+			if err != nil {
+				ran.Log.Errorf("Send UpdateSmContextN2HandoverComplete Error[%s]", err.Error())
+			}
+		}
+		consumer.GetConsumer().SendBufferedSecondaryRatUsageReports(amfUe)
+		// no notification is subscribed if the UE context is relocated from EPS
+		if amfUe.HandoverNotifyUri != "" {
+			if err := callback.SendN2InfoNotifyN2Handover(amfUe, nil); err != nil {
+				ran.Log.Errorf("Send N2InfoNotify to S-AMF Error[%s]", err.Error())
+			}
+		}
+
+		business_metrics.IncrHoEventCounter(business_metrics.HANDOVER_TYPE_NGAP_VALUE,
+			utils.SuccessMetric,
+			business_metrics.HANDOVER_EMPTY_CAUSE, targetUe.HandOverStartTime)
+		amfUe.SetOnGoing(ran.AnType, &context.OnGoing{
+			Procedure: context.OnGoingProcedureNothing,
+		})
 	} else {
 		ran.Log.Info("Handle Handover notification Finshed")
 		for _, pduSessionID := range targetUe.SuccessPduSessionId {
@@ -1219,6 +1266,7 @@ func handleHandoverNotifyMain(ran *context.AmfRan,
 				ran.Log.Errorf("Send UpdateSmContextN2HandoverComplete Error[%s]", err.Error())
 			}
 		}
+		consumer.GetConsumer().SendBufferedSecondaryRatUsageReports(amfUe)
 
 		business_metrics.IncrHoEventCounter(business_metrics.HANDOVER_TYPE_NGAP_VALUE,
 			utils.SuccessMetric,
@@ -1288,6 +1336,7 @@ func handlePathSwitchRequestMain(ran *context.AmfRan,
 	}
 
 	ranUe.UpdateLocation(userLocationInformation)
+	callback.SendAmfEventReport(amfUe, models.AmfEventType_LOCATION_REPORT)
 
 	var pduSessionResourceSwitchedList ngapType.PDUSessionResourceSwitchedList
 	var pduSessionResourceReleasedListPSAck ngapType.PDUSessionResourceReleasedListPSAck
@@ -1488,8 +1537,54 @@ func handleHandoverRequestAcknowledgeMain(ran *context.AmfRan,
 
 	sourceUe := targetUe.SourceUe
 	if sourceUe == nil {
-		// TODO: Send Namf_Communication_CreateUEContext Response to S-AMF
-		ran.Log.Error("handover between different Ue has not been implement yet")
+		// N2 Handover between AMF, send Namf_Communication_CreateUEContext Response to S-AMF
+		// Described in (23.502 4.9.1.3.2) step 12
+		if len(pduSessionResourceHandoverList.List) == 0 || targetToSourceTransparentContainer == nil {
+			targetUe.Log.Info("Handle Handover Preparation Failure [HoFailure In Target5GC NgranNode Or TargetSystem]")
+			cause := &ngapType.Cause{
+				Present: ngapType.CausePresentRadioNetwork,
+				RadioNetwork: &ngapType.CauseRadioNetwork{
+					Value: ngapType.CauseRadioNetworkPresentHoFailureInTarget5GCNgranNodeOrTargetSystem,
+				},
+			}
+			// the UE context is rolled back by Namf_Communication_CreateUEContext waiting for the result
+			if !amfUe.SendN2HandoverResult(&context.N2HandoverResult{
+				NgapCause: &models.NgApCause{
+					Group: int32(cause.Present),
+					Value: int32(cause.RadioNetwork.Value),
+				},
+			}) {
+				ngap_message.SendUEContextReleaseCommand(targetUe, context.UeContextReleaseUeContext,
+					cause.Present, cause.RadioNetwork.Value)
+			}
+			hoFailCause = ngap.GetCauseErrorStr(cause)
+			return
+		}
+		binaryParts := make(util.NgapBinaryParts)
+		ueContextCreatedData := &models.UeContextCreatedData{
+			TargetToSourceData: binaryParts.N2InfoContent(models.AmfCommunicationNgapIeType_TAR_TO_SRC_CONTAINER,
+				targetToSourceTransparentContainer.Value),
+		}
+		for _, item := range pduSessionResourceHandoverList.List {
+			ueContextCreatedData.PduSessionList = append(ueContextCreatedData.PduSessionList, models.N2SmInformation{
+				PduSessionId: int32(item.PDUSessionID.Value),
+				N2InfoContent: binaryParts.N2InfoContent(models.AmfCommunicationNgapIeType_HANDOVER_CMD,
+					item.HandoverCommandTransfer),
+			})
+		}
+		for _, item := range pduSessionResourceToReleaseList.List {
+			ueContextCreatedData.FailedSessionList = append(ueContextCreatedData.FailedSessionList, models.N2SmInformation{
+				PduSessionId: int32(item.PDUSessionID.Value),
+				N2InfoContent: binaryParts.N2InfoContent(models.AmfCommunicationNgapIeType_HANDOVER_PREP_FAIL,
+					item.HandoverPreparationUnsuccessfulTransfer),
+			})
+		}
+		if !amfUe.SendN2HandoverResult(&context.N2HandoverResult{
+			UeContextCreatedData: ueContextCreatedData,
+			BinaryParts:          binaryParts,
+		}) {
+			targetUe.Log.Warn("Namf_Communication_CreateUEContext is not waiting for the handover result")
+		}
 	} else {
 		ran.Log.Tracef("Source: RanUeNgapID[%d] AmfUeNgapID[%d]", sourceUe.RanUeNgapId, sourceUe.AmfUeNgapId)
 		ran.Log.Tracef("Target: RanUeNgapID[%d] AmfUeNgapID[%d]", targetUe.RanUeNgapId, targetUe.AmfUeNgapId)
@@ -1539,8 +1634,17 @@ func handleHandoverFailureMain(ran *context.AmfRan,
 
 	sourceUe := targetUe.SourceUe
 	if sourceUe == nil {
-		// TODO: handle N2 Handover between AMF
-		ran.Log.Error("N2 Handover between AMF has not been implemented yet")
+		// N2 Handover between AMF, the UE context is rolled back by Namf_Communication_CreateUEContext
+		// Described in (23.502 4.9.1.3.2) step 12
+		if amfUe := targetUe.AmfUe; amfUe == nil || !amfUe.SendN2HandoverResult(&context.N2HandoverResult{
+			NgapCause: &models.NgApCause{
+				Group: int32(causePresent),
+				Value: int32(causeValue),
+			},
+		}) {
+			ngap_message.SendUEContextReleaseCommand(targetUe, context.UeContextReleaseUeContext, causePresent,
+				causeValue)
+		}
 	} else {
 		amfUe := targetUe.AmfUe
 		if amfUe != nil {
@@ -1570,9 +1674,8 @@ func handleHandoverFailureMain(ran *context.AmfRan,
 			}
 		}
 		ngap_message.SendHandoverPreparationFailure(sourceUe, *sendCause, criticalityDiagnostics)
+		ngap_message.SendUEContextReleaseCommand(targetUe, context.UeContextReleaseHandover, causePresent, causeValue)
 	}
-
-	ngap_message.SendUEContextReleaseCommand(targetUe, context.UeContextReleaseHandover, causePresent, causeValue)
 }
 
 func handleHandoverRequiredMain(ran *context.AmfRan,
@@ -1629,14 +1732,11 @@ func handleHandoverRequiredMain(ran *context.AmfRan,
 	targetRanNodeId := ngapConvert.RanIdToModels(targetID.TargetRANNodeID.GlobalRANNodeID)
 	targetRan, ok := aMFSelf.AmfRanFindByRanID(targetRanNodeId)
 	if !ok {
-		// [todo] add metric for different amf
 		// handover between different AMF
-		sourceUe.Log.Warnf("Handover required : cannot find target Ran Node Id[%+v] in this AMF", targetRanNodeId)
-		sourceUe.Log.Error("Handover between different AMF has not been implemented yet")
-		hoFailCause = business_metrics.HANDOVER_BETWEEN_DIFFERENT_AMF_NOT_SUPPORTED
+		sourceUe.Log.Infof("Handover required : cannot find target Ran Node Id[%+v] in this AMF", targetRanNodeId)
+		hoFailCause = handleInterAmfHandoverRequired(sourceUe, handoverType, cause, targetRanNodeId,
+			targetID.TargetRANNodeID.SelectedTAI, pDUSessionResourceListHORqd, sourceToTargetTransparentContainer)
 		return
-		// TODO: Send to T-AMF
-		// Described in (23.502 4.9.1.3.2) step 3.Namf_Communication_CreateUEContext Request
 	} else {
 		// Handover in same AMF
 		sourceUe.HandOverType.Value = handoverType.Value
@@ -1701,6 +1801,178 @@ func handleHandoverRequiredMain(ran *context.AmfRan,
 	}
 }
 
+// TS 23.502 4.9.1.3.2 step 2-3 and 12, the S-AMF forwards the handover to the T-AMF.
+// It returns the failure cause for the handover metrics, or an empty string once the
+// handover is forwarded, the failure afterwards is counted when the T-AMF responds.
+func handleInterAmfHandoverRequired(sourceUe *context.RanUe,
+	handoverType *ngapType.HandoverType,
+	cause *ngapType.Cause,
+	targetRanNodeId models.GlobalRanNodeId,
+	selectedTAI ngapType.TAI,
+	pDUSessionResourceListHORqd *ngapType.PDUSessionResourceListHORqd,
+	sourceToTargetTransparentContainer *ngapType.SourceToTargetTransparentContainer,
+) string {
+	amfUe := sourceUe.AmfUe
+	sendFailure := func(failureCause ngapType.Cause) string {
+		ngap_message.SendHandoverPreparationFailure(sourceUe, failureCause, nil)
+		return ngap.GetCauseErrorStr(&failureCause)
+	}
+	hoFailureCause := ngapType.Cause{
+		Present: ngapType.CausePresentRadioNetwork,
+		RadioNetwork: &ngapType.CauseRadioNetwork{
+			Value: ngapType.CauseRadioNetworkPresentHoFailureInTarget5GCNgranNodeOrTargetSystem,
+		},
+	}
+
+	sourceUe.HandOverType.Value = handoverType.Value
+	tai := ngapConvert.TaiToModels(selectedTAI)
+	targetId := models.NgRanTargetId{
+		RanNodeId: &targetRanNodeId,
+		Tai:       &tai,
+	}
+
+	binaryParts := make(util.NgapBinaryParts)
+	var pduSessionList []models.N2SmInformation
+	if pDUSessionResourceListHORqd != nil {
+		for _, pDUSessionResourceHoItem := range pDUSessionResourceListHORqd.List {
+			pduSessionID := int32(pDUSessionResourceHoItem.PDUSessionID.Value)
+			smContext, ok := amfUe.SmContextFindByPDUSessionID(pduSessionID)
+			if !ok {
+				sourceUe.Log.Warnf("SmContext[PDU Session ID:%d] not found", pduSessionID)
+				continue
+			}
+			snssai := smContext.Snssai()
+			pduSessionList = append(pduSessionList, models.N2SmInformation{
+				PduSessionId: pduSessionID,
+				SNssai:       &snssai,
+				N2InfoContent: binaryParts.N2InfoContent(models.AmfCommunicationNgapIeType_HANDOVER_REQUIRED,
+					pDUSessionResourceHoItem.HandoverRequiredTransfer),
+			})
+		}
+	}
+	if len(pduSessionList) == 0 {
+		sourceUe.Log.Info("Handle Handover Preparation Failure [HoFailure In Target5GC NgranNode Or TargetSystem]")
+		return sendFailure(hoFailureCause)
+	}
+
+	// Update NH
+	amfUe.UpdateNH()
+
+	ngapCause := models.NgApCause{
+		Group: int32(ngapType.CausePresentMisc),
+		Value: int32(ngapType.CauseMiscPresentUnspecified),
+	}
+	if cause != nil {
+		present, value := printAndGetCause(sourceUe.Ran, cause)
+		ngapCause.Group = int32(present)
+		ngapCause.Value = int32(value)
+	}
+	sourceToTargetData := binaryParts.N2InfoContent(models.AmfCommunicationNgapIeType_SRC_TO_TAR_CONTAINER,
+		sourceToTargetTransparentContainer.Value)
+	n2NotifyUri := fmt.Sprintf("%s%s/handover-complete/%s", context.GetSelf().GetIPv4Uri(),
+		factory.AmfCallbackResUriPrefix, amfUe.Supi)
+
+	ueContextCreateData := consumer.GetConsumer().BuildUeContextCreateData(amfUe, targetId, *sourceToTargetData,
+		pduSessionList, n2NotifyUri, &ngapCause, binaryParts)
+
+	// the T-AMF responds after the handover resource allocation in target NG-RAN, the Handover Required is completed
+	// when the response arrives, not to hold the NGAP messages of the other UEs of the RAN
+	go func() {
+		hoFailCause := createUeContextInTargetAmf(sourceUe, tai, ueContextCreateData, binaryParts)
+		if hoFailCause != "" {
+			business_metrics.IncrHoEventCounter(business_metrics.HANDOVER_TYPE_NGAP_VALUE, utils.FailureMetric,
+				hoFailCause, sourceUe.HandOverStartTime)
+		}
+	}()
+	return ""
+}
+
+// TS 23.502 4.9.1.3.2 step 3 and 12-13, the failure cause is returned if the Handover Preparation Failure is sent
+func createUeContextInTargetAmf(sourceUe *context.RanUe, tai models.Tai,
+	ueContextCreateData models.UeContextCreateData, binaryParts util.NgapBinaryParts,
+) string {
+	amfUe := sourceUe.AmfUe
+	sendFailure := func(failureCause ngapType.Cause) string {
+		ngap_message.SendHandoverPreparationFailure(sourceUe, failureCause, nil)
+		return ngap.GetCauseErrorStr(&failureCause)
+	}
+	hoFailureCause := ngapType.Cause{
+		Present: ngapType.CausePresentRadioNetwork,
+		RadioNetwork: &ngapType.CauseRadioNetwork{
+			Value: ngapType.CauseRadioNetworkPresentHoFailureInTarget5GCNgranNodeOrTargetSystem,
+		},
+	}
+
+	// select the T-AMF serving the target TAI
+	param := Nnrf_NFDiscovery.SearchNFInstancesRequest{
+		Tai:            &tai,
+		TargetPlmnList: []models.PlmnId{*tai.PlmnId},
+	}
+	err := consumer.GetConsumer().SearchAmfCommunicationInstance(amfUe, amfUe.ServingAMF().NrfUri,
+		models.NrfNfManagementNfType_AMF, models.NrfNfManagementNfType_AMF, &param)
+	if err != nil {
+		sourceUe.Log.Errorf("Search target AMF error: %+v", err)
+		return sendFailure(ngapType.Cause{
+			Present: ngapType.CausePresentRadioNetwork,
+			RadioNetwork: &ngapType.CauseRadioNetwork{
+				Value: ngapType.CauseRadioNetworkPresentUnknownTargetID,
+			},
+		})
+	}
+
+	ueContextCreatedData, createdBinaryParts, problemDetails, err := consumer.GetConsumer().CreateUEContextRequest(amfUe,
+		ueContextCreateData, binaryParts)
+	if err != nil {
+		sourceUe.Log.Errorf("Namf_Communication_CreateUEContext error: %+v", err)
+		return sendFailure(hoFailureCause)
+	}
+	if problemDetails != nil {
+		sourceUe.Log.Warnf("ProblemDetails[status: %d, Cause: %s]", problemDetails.Status, problemDetails.Cause)
+		return sendFailure(hoFailureCause)
+	}
+	if amfUe.RanUe[sourceUe.Ran.AnType] != sourceUe ||
+		amfUe.OnGoing(sourceUe.Ran.AnType).Procedure != context.OnGoingProcedureN2Handover {
+		sourceUe.Log.Warn("Handover is not ongoing when Namf_Communication_CreateUEContext responds")
+		return ""
+	}
+
+	// TS 23.502 4.9.1.3.2 step 12-13
+	var pduSessionResourceHandoverList ngapType.PDUSessionResourceHandoverList
+	var pduSessionResourceToReleaseList ngapType.PDUSessionResourceToReleaseListHOCmd
+	for _, item := range ueContextCreatedData.PduSessionList {
+		transfer, err := createdBinaryParts.NgapData(item.N2InfoContent)
+		if err != nil {
+			sourceUe.Log.Warnf("HandoverCommandTransfer of PDU Session[%d] error: %+v", item.PduSessionId, err)
+			continue
+		}
+		handoverItem := ngapType.PDUSessionResourceHandoverItem{}
+		handoverItem.PDUSessionID.Value = int64(item.PduSessionId)
+		handoverItem.HandoverCommandTransfer = transfer
+		pduSessionResourceHandoverList.List = append(pduSessionResourceHandoverList.List, handoverItem)
+	}
+	for _, item := range ueContextCreatedData.FailedSessionList {
+		transfer, err := createdBinaryParts.NgapData(item.N2InfoContent)
+		if err != nil {
+			sourceUe.Log.Warnf("HandoverPreparationUnsuccessfulTransfer of PDU Session[%d] error: %+v",
+				item.PduSessionId, err)
+			continue
+		}
+		releaseItem := ngapType.PDUSessionResourceToReleaseItemHOCmd{}
+		releaseItem.PDUSessionID.Value = int64(item.PduSessionId)
+		releaseItem.HandoverPreparationUnsuccessfulTransfer = transfer
+		pduSessionResourceToReleaseList.List = append(pduSessionResourceToReleaseList.List, releaseItem)
+	}
+	targetToSourceData, err := createdBinaryParts.NgapData(ueContextCreatedData.TargetToSourceData)
+	if err != nil || len(pduSessionResourceHandoverList.List) == 0 {
+		sourceUe.Log.Info("Handle Handover Preparation Failure [HoFailure In Target5GC NgranNode Or TargetSystem]")
+		return sendFailure(hoFailureCause)
+	}
+
+	ngap_message.SendHandoverCommand(sourceUe, pduSessionResourceHandoverList, pduSessionResourceToReleaseList,
+		ngapType.TargetToSourceTransparentContainer{Value: targetToSourceData}, nil)
+	return ""
+}
+
 func handleHandoverCancelMain(ran *context.AmfRan,
 	sourceUe *context.RanUe,
 	cause *ngapType.Cause,
@@ -1734,6 +2006,7 @@ func handleHandoverCancelMain(ran *context.AmfRan,
 				}
 				return true
 			})
+			consumer.GetConsumer().SendBufferedSecondaryRatUsageReports(amfUe)
 		}
 		ngap_message.SendUEContextReleaseCommand(targetUe, context.UeContextReleaseHandover, causePresent, causeValue)
 		ngap_message.SendHandoverCancelAcknowledge(sourceUe, nil)
@@ -1765,72 +2038,143 @@ func handleNASNonDeliveryIndicationMain(ran *context.AmfRan,
 	}
 }
 
+// The RAN configuration is updated only if the update is acknowledged, the supported TA list replaces the whole
+// list provided before (TS 38.413 8.7.2.2)
 func handleRANConfigurationUpdateMain(ran *context.AmfRan,
+	rANNodeName *ngapType.RANNodeName,
 	supportedTAList *ngapType.SupportedTAList,
+	defaultPagingDRX *ngapType.PagingDRX,
+	globalRANNodeID *ngapType.GlobalRANNodeID,
 ) {
 	var cause ngapType.Cause
+	amfSelf := context.GetSelf()
+
+	if globalRANNodeID != nil {
+		ranId := ngapConvert.RanIdToModels(*globalRANNodeID)
+		if otherRan, ok := amfSelf.AmfRanFindByRanID(ranId); ok && otherRan != ran {
+			ran.Log.Warnf("RanConfigurationUpdate failure: Global RAN Node ID is used by RAN[%s]", otherRan.RanID())
+			cause.Present = ngapType.CausePresentMisc
+			cause.Misc = &ngapType.CauseMisc{
+				Value: ngapType.CauseMiscPresentUnspecified,
+			}
+		}
+	}
+
+	var newSupportedTAList []context.SupportedTAI
+	if supportedTAList != nil && cause.Present == ngapType.CausePresentNothing {
+		newSupportedTAList = buildSupportedTAList(ran, supportedTAList)
+		cause = checkSupportedTAList(ran, newSupportedTAList)
+	}
+
+	if cause.Present != ngapType.CausePresentNothing {
+		ran.Log.Info("Handle RanConfigurationUpdateAcknowledgeFailure")
+		ngap_message.SendRanConfigurationUpdateFailure(ran, cause, nil)
+		return
+	}
 
+	if rANNodeName != nil {
+		ran.Name = rANNodeName.Value
+	}
+	if defaultPagingDRX != nil {
+		ran.Log.Tracef("PagingDRX[%d]", defaultPagingDRX.Value)
+		ran.DefaultPagingDRX = defaultPagingDRX
+	}
+	if globalRANNodeID != nil {
+		oldRanId := ran.RanID()
+		ran.SetRanId(globalRANNodeID)
+		if newRanId := ran.RanID(); newRanId != oldRanId {
+			ran.Log.Infof("Global RAN Node ID is changed from %s to %s", oldRanId, newRanId)
+		}
+	}
 	if supportedTAList != nil {
-		for i := 0; i < len(supportedTAList.List); i++ {
-			supportedTAItem := supportedTAList.List[i]
-			tac := hex.EncodeToString(supportedTAItem.TAC.Value)
-			capOfSupportTai := cap(ran.SupportedTAList)
-			for j := 0; j < len(supportedTAItem.BroadcastPLMNList.List); j++ {
-				supportedTAI := context.NewSupportedTAI()
-				supportedTAI.Tai.Tac = tac
-				broadcastPLMNItem := supportedTAItem.BroadcastPLMNList.List[j]
-				plmnId := ngapConvert.PlmnIdToModels(broadcastPLMNItem.PLMNIdentity)
-				supportedTAI.Tai.PlmnId = &plmnId
-				capOfSNssaiList := cap(supportedTAI.SNssaiList)
-				for k := 0; k < len(broadcastPLMNItem.TAISliceSupportList.List); k++ {
-					tAISliceSupportItem := broadcastPLMNItem.TAISliceSupportList.List[k]
-					if len(supportedTAI.SNssaiList) < capOfSNssaiList {
-						supportedTAI.SNssaiList = append(supportedTAI.SNssaiList, ngapConvert.SNssaiToModels(tAISliceSupportItem.SNSSAI))
-					} else {
-						break
-					}
-				}
-				ran.Log.Tracef("PLMN_ID[MCC:%s MNC:%s] TAC[%s]", plmnId.Mcc, plmnId.Mnc, tac)
-				if len(ran.SupportedTAList) < capOfSupportTai {
-					ran.SupportedTAList = append(ran.SupportedTAList, supportedTAI)
+		ran.SupportedTAList = newSupportedTAList
+	}
+
+	ran.Log.Info("Handle RanConfigurationUpdateAcknowledge")
+	ngap_message.SendRanConfigurationUpdateAcknowledge(ran, nil)
+}
+
+func buildSupportedTAList(ran *context.AmfRan, supportedTAList *ngapType.SupportedTAList) []context.SupportedTAI {
+	taList := make([]context.SupportedTAI, 0, context.MaxNumOfTAI*context.MaxNumOfBroadcastPLMNs)
+	for i := 0; i < len(supportedTAList.List); i++ {
+		supportedTAItem := supportedTAList.List[i]
+		tac := hex.EncodeToString(supportedTAItem.TAC.Value)
+		capOfSupportTai := cap(taList)
+		for j := 0; j < len(supportedTAItem.BroadcastPLMNList.List); j++ {
+			supportedTAI := context.NewSupportedTAI()
+			supportedTAI.Tai.Tac = tac
+			broadcastPLMNItem := supportedTAItem.BroadcastPLMNList.List[j]
+			plmnId := ngapConvert.PlmnIdToModels(broadcastPLMNItem.PLMNIdentity)
+			supportedTAI.Tai.PlmnId = &plmnId
+			capOfSNssaiList := cap(supportedTAI.SNssaiList)
+			for k := 0; k < len(broadcastPLMNItem.TAISliceSupportList.List); k++ {
+				tAISliceSupportItem := broadcastPLMNItem.TAISliceSupportList.List[k]
+				if len(supportedTAI.SNssaiList) < capOfSNssaiList {
+					supportedTAI.SNssaiList = append(supportedTAI.SNssaiList, ngapConvert.SNssaiToModels(tAISliceSupportItem.SNSSAI))
 				} else {
 					break
 				}
 			}
+			ran.Log.Tracef("PLMN_ID[MCC:%s MNC:%s] TAC[%s]", plmnId.Mcc, plmnId.Mnc, tac)
+			if len(taList) < capOfSupportTai {
+				taList = append(taList, supportedTAI)
+			} else {
+				break
+			}
 		}
 	}
+	return taList
+}
 
-	if len(ran.SupportedTAList) == 0 {
+// Check the supported TA list updated by the RAN, at least one TA is served by the AMF, and the served TA with the
+// S-NSSAIs changed has at least one S-NSSAI supported by the AMF in the PLMN of the TA, as accepted by the NG Setup
+func checkSupportedTAList(ran *context.AmfRan, supportedTAList []context.SupportedTAI) (cause ngapType.Cause) {
+	if len(supportedTAList) == 0 {
 		ran.Log.Warn("RanConfigurationUpdate failure: No supported TA exist in RanConfigurationUpdate")
 		cause.Present = ngapType.CausePresentMisc
 		cause.Misc = &ngapType.CauseMisc{
 			Value: ngapType.CauseMiscPresentUnspecified,
 		}
-	} else {
-		var found bool
-		for i, tai := range ran.SupportedTAList {
-			if context.InTaiList(tai.Tai, context.GetSelf().SupportTaiLists) {
-				ran.Log.Tracef("SERVED_TAI_INDEX[%d]", i)
-				found = true
-				break
+		return cause
+	}
+
+	amfSelf := context.GetSelf()
+	var found bool
+	for i, tai := range supportedTAList {
+		if !context.InTaiList(tai.Tai, amfSelf.SupportTaiLists) {
+			continue
+		}
+		ran.Log.Tracef("SERVED_TAI_INDEX[%d]", i)
+		found = true
+		if !ran.TaiSliceSupportChanged(tai) {
+			continue
+		}
+		var snssaiSupported bool
+		for _, snssai := range tai.SNssaiList {
+			if amfSelf.InPlmnSupportListOfPlmn(*tai.Tai.PlmnId, snssai) {
+				snssaiSupported = true
+			} else {
+				ran.Log.Warnf("S-NSSAI[SST:%d SD:%s] of TAI[%s] is not supported in AMF", snssai.Sst, snssai.Sd,
+					tai.Tai.Tac)
 			}
 		}
-		if !found {
-			ran.Log.Warn("RanConfigurationUpdate failure: Cannot find Served TAI in AMF")
-			cause.Present = ngapType.CausePresentMisc
-			cause.Misc = &ngapType.CauseMisc{
-				Value: ngapType.CauseMiscPresentUnknownPLMN,
+		if !snssaiSupported {
+			ran.Log.Warnf("RanConfigurationUpdate failure: No S-NSSAI of TAI[%s] is supported in AMF", tai.Tai.Tac)
+			cause.Present = ngapType.CausePresentRadioNetwork
+			cause.RadioNetwork = &ngapType.CauseRadioNetwork{
+				Value: ngapType.CauseRadioNetworkPresentSliceNotSupported,
 			}
+			return cause
 		}
 	}
-
-	if cause.Present == ngapType.CausePresentNothing {
-		ran.Log.Info("Handle RanConfigurationUpdateAcknowledge")
-		ngap_message.SendRanConfigurationUpdateAcknowledge(ran, nil)
-	} else {
-		ran.Log.Info("Handle RanConfigurationUpdateAcknowledgeFailure")
-		ngap_message.SendRanConfigurationUpdateFailure(ran, cause, nil)
+	if !found {
+		ran.Log.Warn("RanConfigurationUpdate failure: Cannot find Served TAI in AMF")
+		cause.Present = ngapType.CausePresentMisc
+		cause.Misc = &ngapType.CauseMisc{
+			Value: ngapType.CauseMiscPresentUnknownPLMN,
+		}
 	}
+	return cause
 }
 
 func handleUplinkRANConfigurationTransferMain(ran *context.AmfRan,
@@ -1857,10 +2201,18 @@ func handleUplinkRANConfigurationTransferMain(ran *context.AmfRan,
 func handleUplinkUEAssociatedNRPPaTransportMain(ran *context.AmfRan,
 	ranUe *context.RanUe,
 	routingID *ngapType.RoutingID,
+	nRPPaPDU *ngapType.NRPPaPDU,
 ) {
 	ranUe.RoutingID = hex.EncodeToString(routingID.Value)
 
-	// TODO: Forward NRPPaPDU to LMF
+	amfUe := ranUe.AmfUe
+	if amfUe == nil {
+		ranUe.Log.Error("AmfUe is nil")
+		return
+	}
+	// Described in (23.273 6.5.3 and 6.11.1), the Routing ID identifies the LMF
+	callback.SendN2InfoNotify(amfUe, models.N2InformationClass_NRP_PA, context.NfIdFromRoutingID(routingID.Value),
+		nil, nRPPaPDU.Value)
 }
 
 func handleUplinkNonUEAssociatedNRPPaTransportMain(ran *context.AmfRan,
@@ -1869,8 +2221,8 @@ func handleUplinkNonUEAssociatedNRPPaTransportMain(ran *context.AmfRan,
 ) {
 	// Forward routingID to LMF
 	// Described in (23.502 4.13.5.6)
-
-	// TODO: Forward NRPPaPDU to LMF
+	callback.SendNonUeN2InfoNotify(ran, models.N2InformationClass_NRP_PA, context.NfIdFromRoutingID(routingID.Value),
+		nRPPaPDU.Value)
 }
 
 func handleLocationReportMain(ran *context.AmfRan,
@@ -1880,6 +2232,9 @@ func handleLocationReportMain(ran *context.AmfRan,
 	locationReportingRequestType *ngapType.LocationReportingRequestType,
 ) {
 	ranUe.UpdateLocation(userLocationInformation)
+	if amfUe := ranUe.AmfUe; amfUe != nil {
+		callback.SendAmfEventReport(amfUe, models.AmfEventType_LOCATION_REPORT)
+	}
 
 	if locationReportingRequestType != nil {
 		ranUe.Log.Tracef("Report Area[%d]", locationReportingRequestType.ReportArea.Value)
@@ -1924,6 +2279,48 @@ func handleLocationReportMain(ran *context.AmfRan,
 	}
 }
 
+// TS 23.502 4.2.3.2 and 4.9.1.3.3, the Secondary RAT usage data of the dual connectivity is forwarded to the SMFs.
+// The reports with the handover flag are buffered during the N2 handover, and forwarded after it ends.
+func handleSecondaryRATDataUsageReportMain(ran *context.AmfRan,
+	ranUe *context.RanUe,
+	pDUSessionResourceSecondaryRATUsageList *ngapType.PDUSessionResourceSecondaryRATUsageList,
+	handoverFlag *ngapType.HandoverFlag,
+) {
+	if ranUe == nil || pDUSessionResourceSecondaryRATUsageList == nil {
+		return
+	}
+	amfUe := ranUe.AmfUe
+	if amfUe == nil {
+		ranUe.Log.Error("AmfUe is nil")
+		return
+	}
+
+	buffered := handoverFlag != nil &&
+		handoverFlag.Value == ngapType.HandoverFlagPresentHandoverPreparation &&
+		(ranUe.TargetUe != nil || amfUe.OnGoing(ran.AnType).Procedure == context.OnGoingProcedureN2Handover)
+	for _, item := range pDUSessionResourceSecondaryRATUsageList.List {
+		pduSessionID := int32(item.PDUSessionID.Value)
+		smContext, ok := amfUe.SmContextFindByPDUSessionID(pduSessionID)
+		if !ok {
+			ranUe.Log.Warnf("SmContext[PDU Session ID:%d] not found", pduSessionID)
+			continue
+		}
+		if buffered {
+			ranUe.Log.Debugf("Buffer Secondary RAT usage of PDU Session[%d] during handover", pduSessionID)
+			smContext.StoreSecondaryRatUsageReport(item.SecondaryRATDataUsageReportTransfer)
+			continue
+		}
+		_, _, problemDetails, err := consumer.GetConsumer().SendUpdateSmContextN2Info(amfUe, smContext,
+			models.N2SmInfoType_SECONDARY_RAT_USAGE, item.SecondaryRATDataUsageReportTransfer)
+		if problemDetails != nil {
+			ranUe.Log.Errorf("Send Secondary RAT usage of PDU Session[%d] Failed Problem[%+v]",
+				pduSessionID, problemDetails)
+		} else if err != nil {
+			ranUe.Log.Errorf("Send Secondary RAT usage of PDU Session[%d] Error[%v]", pduSessionID, err)
+		}
+	}
+}
+
 func handleUERadioCapabilityInfoIndicationMain(ran *context.AmfRan,
 	ranUe *context.RanUe,
 	uERadioCapability *ngapType.UERadioCapability,
@@ -1956,25 +2353,60 @@ func handleUERadioCapabilityInfoIndicationMain(ran *context.AmfRan,
 
 func handleAMFConfigurationUpdateFailureMain(ran *context.AmfRan,
 	cause *ngapType.Cause,
+	timeToWait *ngapType.TimeToWait,
 	criticalityDiagnostics *ngapType.CriticalityDiagnostics,
 ) {
 	if cause != nil {
 		printAndGetCause(ran, cause)
 	}
 
-	//	TODO: Time To Wait
-
 	if criticalityDiagnostics != nil {
 		printCriticalityDiagnostics(ran, criticalityDiagnostics)
 	}
+
+	// TS 38.413 8.7.3.3: the AMF shall wait at least for the time to wait before reinitiating the procedure
+	var wait time.Duration
+	if timeToWait != nil {
+		wait = timeToWaitDuration(timeToWait)
+		ran.Log.Warnf("AMF Configuration Update failed, retry after %s", wait)
+	} else {
+		ran.Log.Warn("AMF Configuration Update failed")
+	}
+	ran.AmfConfigUpdateFailed(wait, func() {
+		sendAMFConfigurationUpdate(ran)
+	})
 }
 
 func handleAMFConfigurationUpdateAcknowledgeMain(ran *context.AmfRan,
+	aMFTNLAssociationSetupList *ngapType.AMFTNLAssociationSetupList,
+	aMFTNLAssociationFailedToSetupList *ngapType.TNLAssociationList,
 	criticalityDiagnostics *ngapType.CriticalityDiagnostics,
 ) {
+	// the AMF does not request to add TNL associations, the TNL associations set up by the RAN are only logged
+	if aMFTNLAssociationSetupList != nil {
+		for _, item := range aMFTNLAssociationSetupList.List {
+			if item.AMFTNLAssociationAddress.EndpointIPAddress != nil {
+				ipv4, ipv6 := ngapConvert.IPAddressToString(*item.AMFTNLAssociationAddress.EndpointIPAddress)
+				ran.Log.Infof("AMF TNL association is set up[IPv4: %s, IPv6: %s]", ipv4, ipv6)
+			}
+		}
+	}
+	if aMFTNLAssociationFailedToSetupList != nil {
+		for _, item := range aMFTNLAssociationFailedToSetupList.List {
+			if item.TNLAssociationAddress.EndpointIPAddress != nil {
+				ipv4, ipv6 := ngapConvert.IPAddressToString(*item.TNLAssociationAddress.EndpointIPAddress)
+				ran.Log.Warnf("AMF TNL association failed to set up[IPv4: %s, IPv6: %s]", ipv4, ipv6)
+			}
+			printAndGetCause(ran, &item.Cause)
+		}
+	}
+
 	if criticalityDiagnostics != nil {
 		printCriticalityDiagnostics(ran, criticalityDiagnostics)
 	}
+
+	ran.Log.Info("AMF Configuration Update is acknowledged")
+	ran.AmfConfigUpdateAcknowledged()
 }
 
 func handleErrorIndicationMain(ran *context.AmfRan,
@@ -2031,10 +2463,27 @@ func handleCellTrafficTraceMain(ran *context.AmfRan,
 	nGRANCGI *ngapType.NGRANCGI,
 	traceCollectionEntityIPAddress *ngapType.TransportLayerAddress,
 ) {
-	if nGRANTraceID != nil {
-		ranUe.Trsr = hex.EncodeToString(nGRANTraceID.Value[6:])
+	// TS 32.422 4.2.2.10
+	// When AMF receives this new NG signaling message containing the Trace Recording Session Reference (TRSR)
+	// and Trace Reference (TR), the AMF shall look up the SUPI/IMEI(SV) of the given call from its database and
+	// shall send the SUPI/IMEI(SV) numbers together with the Trace Recording Session Reference and Trace Reference
+	// to the Trace Collection Entity.
+	report := &context.TraceReport{
+		Event: context.TraceEventCellTrafficTrace,
+	}
+	if amfUe := ranUe.AmfUe; amfUe != nil {
+		report.Supi = amfUe.Supi
+		report.Pei = amfUe.Pei
+	}
 
-		ranUe.Log.Tracef("TRSR[%s]", ranUe.Trsr)
+	if nGRANTraceID != nil {
+		traceRef, trsr, err := ngranTraceIDToModels(nGRANTraceID)
+		if err != nil {
+			ranUe.Log.Errorf("Invalid NG-RAN Trace ID: %+v", err)
+			return
+		}
+		report.TraceRef, report.Trsr = traceRef, trsr
+		ranUe.Log.Tracef("TraceRef[%s] TRSR[%s]", traceRef, trsr)
 	}
 
 	if nGRANCGI != nil {
@@ -2043,10 +2492,18 @@ func handleCellTrafficTraceMain(ran *context.AmfRan,
 			plmnID := ngapConvert.PlmnIdToModels(nGRANCGI.NRCGI.PLMNIdentity)
 			cellID := ngapConvert.BitStringToHex(&nGRANCGI.NRCGI.NRCellIdentity.Value)
 			ranUe.Log.Debugf("NRCGI[plmn: %s, cellID: %s]", plmnID, cellID)
+			report.NrCgi = &models.Ncgi{
+				PlmnId:   &plmnID,
+				NrCellId: cellID,
+			}
 		case ngapType.NGRANCGIPresentEUTRACGI:
 			plmnID := ngapConvert.PlmnIdToModels(nGRANCGI.EUTRACGI.PLMNIdentity)
 			cellID := ngapConvert.BitStringToHex(&nGRANCGI.EUTRACGI.EUTRACellIdentity.Value)
 			ranUe.Log.Debugf("EUTRACGI[plmn: %s, cellID: %s]", plmnID, cellID)
+			report.EutraCgi = &models.Ecgi{
+				PlmnId:      &plmnID,
+				EutraCellId: cellID,
+			}
 		}
 	}
 
@@ -2058,13 +2515,159 @@ func handleCellTrafficTraceMain(ran *context.AmfRan,
 		if tceIpv6 != "" {
 			ranUe.Log.Debugf("TCE IP Address[v6: %s]", tceIpv6)
 		}
+		report.TceIpv4Addr, report.TceIpv6Addr = tceIpv4, tceIpv6
 	}
 
-	// TODO: TS 32.422 4.2.2.10
-	// When AMF receives this new NG signaling message containing the Trace Recording Session Reference (TRSR)
-	// and Trace Reference (TR), the AMF shall look up the SUPI/IMEI(SV) of the given call from its database and
-	// shall send the SUPI/IMEI(SV) numbers together with the Trace Recording Session Reference and Trace Reference
-	// to the Trace Collection Entity.
+	if err := consumer.GetConsumer().QueueTraceReport(report); err != nil {
+		ranUe.Log.Errorf("Report cell traffic trace to TCE error: %+v", err)
+	}
+}
+
+// The Trace Start or Deactivate Trace is failed in the NG-RAN node, the trace session is no longer active in the
+// NG-RAN and the failure is reported to the TCE
+func handleTraceFailureIndicationMain(ran *context.AmfRan,
+	ranUe *context.RanUe,
+	nGRANTraceID *ngapType.NGRANTraceID,
+	cause *ngapType.Cause,
+) {
+	report := &context.TraceReport{
+		Event: context.TraceEventTraceFailure,
+	}
+	if amfUe := ranUe.AmfUe; amfUe != nil {
+		report.Supi = amfUe.Supi
+		report.Pei = amfUe.Pei
+	}
+	if cause != nil {
+		printAndGetCause(ran, cause)
+		report.Cause = ngap.GetCauseErrorStr(cause)
+	}
+
+	if nGRANTraceID != nil {
+		traceRef, trsr, err := ngranTraceIDToModels(nGRANTraceID)
+		if err != nil {
+			ranUe.Log.Errorf("Invalid NG-RAN Trace ID: %+v", err)
+			return
+		}
+		report.TraceRef, report.Trsr = traceRef, trsr
+		ranUe.Log.Warnf("Trace failure of TraceRef[%s] TRSR[%s]", traceRef, trsr)
+
+		if ranUe.TraceActivated() && trsr == ranUe.Trsr {
+			ranUe.FreeTrsr()
+		}
+	}
+
+	if err := consumer.GetConsumer().QueueTraceReport(report); err != nil {
+		ranUe.Log.Errorf("Report trace failure to TCE error: %+v", err)
+	}
+}
+
+// The NG-RAN trace ID is the trace reference of PLMN ID and trace ID followed by the TRSR (TS 38.413 9.3.1.88),
+// they are returned in the format of the trace data (TS 29.571 5.6.2.3)
+func ngranTraceIDToModels(nGRANTraceID *ngapType.NGRANTraceID) (traceRef, trsr string, err error) {
+	if len(nGRANTraceID.Value) != 8 {
+		return "", "", fmt.Errorf("length of NG-RAN Trace ID is %d", len(nGRANTraceID.Value))
+	}
+	plmnID := ngapConvert.PlmnIdToModels(ngapType.PLMNIdentity{Value: nGRANTraceID.Value[:3]})
+	traceRef = plmnID.Mcc + plmnID.Mnc + "-" + hex.EncodeToString(nGRANTraceID.Value[3:6])
+	trsr = hex.EncodeToString(nGRANTraceID.Value[6:])
+	return traceRef, trsr, nil
+}
+
+func handleWriteReplaceWarningResponseMain(ran *context.AmfRan,
+	messageIdentifier *ngapType.MessageIdentifier,
+	serialNumber *ngapType.SerialNumber,
+	broadcastCompletedAreaList *ngapType.BroadcastCompletedAreaList,
+	criticalityDiagnostics *ngapType.CriticalityDiagnostics,
+) {
+	if criticalityDiagnostics != nil {
+		printCriticalityDiagnostics(ran, criticalityDiagnostics)
+	}
+	if broadcastCompletedAreaList == nil {
+		ran.Log.Warn("Broadcast of the warning message is not completed in any area")
+	}
+	handlePwsResponse(ran, ngapType.ProcedureCodeWriteReplaceWarning, messageIdentifier, serialNumber)
+}
+
+func handlePWSCancelResponseMain(ran *context.AmfRan,
+	messageIdentifier *ngapType.MessageIdentifier,
+	serialNumber *ngapType.SerialNumber,
+	broadcastCancelledAreaList *ngapType.BroadcastCancelledAreaList,
+	criticalityDiagnostics *ngapType.CriticalityDiagnostics,
+) {
+	if criticalityDiagnostics != nil {
+		printCriticalityDiagnostics(ran, criticalityDiagnostics)
+	}
+	if broadcastCancelledAreaList == nil {
+		ran.Log.Warn("Broadcast of the warning message is not cancelled in any area")
+	}
+	handlePwsResponse(ran, ngapType.ProcedureCodePWSCancel, messageIdentifier, serialNumber)
+}
+
+// the responses are collected by Namf_Communication_NonUeN2MessageTransfer
+func handlePwsResponse(ran *context.AmfRan, procedureCode int64,
+	messageIdentifier *ngapType.MessageIdentifier,
+	serialNumber *ngapType.SerialNumber,
+) {
+	msgId, serial := context.PwsIdentifiersToInt(*messageIdentifier, *serialNumber)
+	transaction, ok := context.GetSelf().PwsTransactionFind(procedureCode, msgId, serial)
+	if !ok {
+		ran.Log.Warnf("No ongoing PWS procedure for Message Identifier[%d] Serial Number[%d]", msgId, serial)
+		return
+	}
+	if !transaction.AddResponse(ran) {
+		ran.Log.Warnf("Unexpected PWS response for Message Identifier[%d] Serial Number[%d]", msgId, serial)
+	}
+}
+
+// TS 23.041 9.1.3.5.3, the CBCF is informed of the restart to reload the warning messages
+func handlePWSRestartIndicationMain(ran *context.AmfRan,
+	cellIDListForRestart *ngapType.CellIDListForRestart,
+	globalRANNodeID *ngapType.GlobalRANNodeID,
+	tAIListForRestart *ngapType.TAIListForRestart,
+	emergencyAreaIDListForRestart *ngapType.EmergencyAreaIDListForRestart,
+) {
+	pwsContainer, err := ngap_message.BuildPWSRestartIndication(cellIDListForRestart, globalRANNodeID,
+		tAIListForRestart, emergencyAreaIDListForRestart)
+	if err != nil {
+		ran.Log.Errorf("Build PWSRestartIndication failed : %s", err.Error())
+		return
+	}
+	callback.SendNonUeN2InfoNotify(ran, models.N2InformationClass_PWS_RF, "", pwsContainer)
+}
+
+// TS 23.041 9.1.3.5.4, the CBCF is informed of the cells where the warning messages are no longer broadcast
+func handlePWSFailureIndicationMain(ran *context.AmfRan,
+	pWSFailedCellIDList *ngapType.PWSFailedCellIDList,
+	globalRANNodeID *ngapType.GlobalRANNodeID,
+) {
+	pwsContainer, err := ngap_message.BuildPWSFailureIndication(pWSFailedCellIDList, globalRANNodeID)
+	if err != nil {
+		ran.Log.Errorf("Build PWSFailureIndication failed : %s", err.Error())
+		return
+	}
+	callback.SendNonUeN2InfoNotify(ran, models.N2InformationClass_PWS_RF, "", pwsContainer)
+}
+
+// The UE context release is a communication failure of the UE unless it is released normally
+func isCommunicationFailure(ngapCause *models.NgApCause) bool {
+	if ngapCause == nil {
+		return false
+	}
+	switch ngapCause.Group {
+	case int32(ngapType.CausePresentRadioNetwork):
+		switch aper.Enumerated(ngapCause.Value) {
+		case ngapType.CauseRadioNetworkPresentUserInactivity,
+			ngapType.CauseRadioNetworkPresentSuccessfulHandover,
+			ngapType.CauseRadioNetworkPresentReleaseDueTo5gcGeneratedReason:
+			return false
+		}
+	case int32(ngapType.CausePresentNas):
+		switch aper.Enumerated(ngapCause.Value) {
+		case ngapType.CauseNasPresentNormalRelease, ngapType.CauseNasPresentDeregister:
+			return false
+		}
+	}
+	return true
 }
 
 func printAndGetCause(ran *context.AmfRan, cause *ngapType.Cause) (present int, value aper.Enumerated) {
@@ -2195,6 +2798,8 @@ func removeRanUeByInvalidId(ran *context.AmfRan, ranUe *context.RanUe, reason st
 		}
 		gmm_common.StopAll5GSMMTimers(amfUe)
 		amfUe.DetachRanUe(ran.AnType)
+		callback.SendAmfEventReport(amfUe, models.AmfEventType_CONNECTIVITY_STATE_REPORT)
+		gmm.StartMobileReachableTimer(amfUe, ran.AnType)
 	}
 	ranUe.DetachAmfUe()
 	if err := ranUe.Remove(); err != nil {
@@ -2202,6 +2807,23 @@ func removeRanUeByInvalidId(ran *context.AmfRan, ranUe *context.RanUe, reason st
 	}
 }
 
+// Remove the UE-associated NG connections of the RAN with remove, and start the mobile reachable timers of
+// the UEs which enter CM-IDLE
+func removeRanUes(ran *context.AmfRan, remove func()) {
+	var amfUes []*context.AmfUe
+	ran.RanUeList.Range(func(key, value interface{}) bool {
+		ranUe := value.(*context.RanUe)
+		if amfUe := ranUe.AmfUe; amfUe != nil && amfUe.RanUe[ran.AnType] == ranUe {
+			amfUes = append(amfUes, amfUe)
+		}
+		return true
+	})
+	remove()
+	for _, amfUe := range amfUes {
+		gmm.StartMobileReachableTimer(amfUe, ran.AnType)
+	}
+}
+
 // Implementation "10.6 Handling of AP ID" in TS 38.413
 // This function is implementation of these sentences.
 //
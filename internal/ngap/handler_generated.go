@@ -277,18 +277,13 @@ func handlerAMFConfigurationUpdateAcknowledge(ran *context.AmfRan, successfulOut
 		return
 	}
 
-	if aMFTNLAssociationSetupList != nil {
-		ran.Log.Warn("IE AMF-TNLAssociationSetupList is not implemented")
-	}
-	if aMFTNLAssociationFailedToSetupList != nil {
-		ran.Log.Warn("IE TNLAssociationList is not implemented")
-	}
-
 	metricStatusOk = true
 
 	// func handleAMFConfigurationUpdateAcknowledgeMain(ran *context.AmfRan,
+	//	aMFTNLAssociationSetupList *ngapType.AMFTNLAssociationSetupList,
+	//	aMFTNLAssociationFailedToSetupList *ngapType.TNLAssociationList,
 	//	criticalityDiagnostics *ngapType.CriticalityDiagnostics) {
-	handleAMFConfigurationUpdateAcknowledgeMain(ran, criticalityDiagnostics /* may be nil */)
+	handleAMFConfigurationUpdateAcknowledgeMain(ran, aMFTNLAssociationSetupList /* may be nil */, aMFTNLAssociationFailedToSetupList /* may be nil */, criticalityDiagnostics /* may be nil */)
 }
 
 func handlerAMFConfigurationUpdateFailure(ran *context.AmfRan, unsuccessfulOutcome *ngapType.UnsuccessfulOutcome) {
@@ -372,16 +367,14 @@ func handlerAMFConfigurationUpdateFailure(ran *context.AmfRan, unsuccessfulOutco
 	if cause == nil {
 		ran.Log.Warn("Missing IE Cause")
 	}
-	if timeToWait != nil {
-		ran.Log.Warn("IE TimeToWait is not implemented")
-	}
 
 	metricStatusOk = true
 
 	// func handleAMFConfigurationUpdateFailureMain(ran *context.AmfRan,
 	//	cause *ngapType.Cause,
+	//	timeToWait *ngapType.TimeToWait,
 	//	criticalityDiagnostics *ngapType.CriticalityDiagnostics) {
-	handleAMFConfigurationUpdateFailureMain(ran, cause /* may be nil */, criticalityDiagnostics /* may be nil */)
+	handleAMFConfigurationUpdateFailureMain(ran, cause /* may be nil */, timeToWait /* may be nil */, criticalityDiagnostics /* may be nil */)
 }
 
 func handlerAMFStatusIndication(ran *context.AmfRan, initiatingMessage *ngapType.InitiatingMessage) {
@@ -5629,9 +5622,6 @@ func handlerNGSetupRequest(ran *context.AmfRan, initiatingMessage *ngapType.Init
 	if defaultPagingDRX == nil {
 		ran.Log.Warn("Missing IE PagingDRX")
 	}
-	if uERetentionInformation != nil {
-		ran.Log.Warn("IE UERetentionInformation is not implemented")
-	}
 
 	metricStatusOk = true
 
@@ -5639,8 +5629,9 @@ func handlerNGSetupRequest(ran *context.AmfRan, initiatingMessage *ngapType.Init
 	//	globalRANNodeID *ngapType.GlobalRANNodeID,
 	//	rANNodeName *ngapType.RANNodeName,
 	//	supportedTAList *ngapType.SupportedTAList,
-	//	defaultPagingDRX *ngapType.PagingDRX) {
-	handleNGSetupRequestMain(ran, globalRANNodeID, rANNodeName /* may be nil */, supportedTAList, defaultPagingDRX /* may be nil */)
+	//	defaultPagingDRX *ngapType.PagingDRX,
+	//	uERetentionInformation *ngapType.UERetentionInformation) {
+	handleNGSetupRequestMain(ran, globalRANNodeID, rANNodeName /* may be nil */, supportedTAList, defaultPagingDRX /* may be nil */, uERetentionInformation /* may be nil */)
 }
 
 func handlerNGSetupResponse(ran *context.AmfRan, successfulOutcome *ngapType.SuccessfulOutcome) {
@@ -7731,10 +7722,6 @@ func handlerPWSCancelResponse(ran *context.AmfRan, successfulOutcome *ngapType.S
 	handlePWSCancelResponseMain(ran, messageIdentifier, serialNumber, broadcastCancelledAreaList /* may be nil */, criticalityDiagnostics /* may be nil */)
 }
 
-func handlePWSCancelResponseMain(ran *context.AmfRan, messageIdentifier *ngapType.MessageIdentifier, serialNumber *ngapType.SerialNumber, broadcastCancelledAreaList *ngapType.BroadcastCancelledAreaList, criticalityDiagnostics *ngapType.CriticalityDiagnostics) {
-	ran.Log.Error("Handle PWSCancelResponse: not implemented")
-}
-
 func handlerPWSFailureIndication(ran *context.AmfRan, initiatingMessage *ngapType.InitiatingMessage) {
 	var pWSFailedCellIDList *ngapType.PWSFailedCellIDList
 	var globalRANNodeID *ngapType.GlobalRANNodeID
@@ -7851,21 +7838,6 @@ func handlerPWSFailureIndication(ran *context.AmfRan, initiatingMessage *ngapTyp
 	handlePWSFailureIndicationMain(ran, pWSFailedCellIDList, globalRANNodeID)
 }
 
-func handlePWSFailureIndicationMain(ran *context.AmfRan, pWSFailedCellIDList *ngapType.PWSFailedCellIDList, globalRANNodeID *ngapType.GlobalRANNodeID) {
-	ran.Log.Error("Handle PWSFailureIndication: not implemented")
-	procedureCode := ngapType.ProcedureCodePWSFailureIndication
-	triggeringMessage := ngapType.TriggeringMessagePresentInitiatingMessage
-	procedureCriticality := ngapType.CriticalityPresentIgnore
-	notImplementedCriticalityDiagnostics := buildCriticalityDiagnostics(&procedureCode, &triggeringMessage, &procedureCriticality, nil)
-	notImplementedCause := &ngapType.Cause{
-		Present: ngapType.CausePresentProtocol,
-		Protocol: &ngapType.CauseProtocol{
-			Value: ngapType.CauseProtocolPresentUnspecified,
-		},
-	}
-	ngap_message.SendErrorIndication(ran, nil, nil, notImplementedCause, &notImplementedCriticalityDiagnostics)
-}
-
 func handlerPWSRestartIndication(ran *context.AmfRan, initiatingMessage *ngapType.InitiatingMessage) {
 	var cellIDListForRestart *ngapType.CellIDListForRestart
 	var globalRANNodeID *ngapType.GlobalRANNodeID
@@ -8024,21 +7996,6 @@ func handlerPWSRestartIndication(ran *context.AmfRan, initiatingMessage *ngapTyp
 	handlePWSRestartIndicationMain(ran, cellIDListForRestart, globalRANNodeID, tAIListForRestart, emergencyAreaIDListForRestart /* may be nil */)
 }
 
-func handlePWSRestartIndicationMain(ran *context.AmfRan, cellIDListForRestart *ngapType.CellIDListForRestart, globalRANNodeID *ngapType.GlobalRANNodeID, tAIListForRestart *ngapType.TAIListForRestart, emergencyAreaIDListForRestart *ngapType.EmergencyAreaIDListForRestart) {
-	ran.Log.Error("Handle PWSRestartIndication: not implemented")
-	procedureCode := ngapType.ProcedureCodePWSRestartIndication
-	triggeringMessage := ngapType.TriggeringMessagePresentInitiatingMessage
-	procedureCriticality := ngapType.CriticalityPresentIgnore
-	notImplementedCriticalityDiagnostics := buildCriticalityDiagnostics(&procedureCode, &triggeringMessage, &procedureCriticality, nil)
-	notImplementedCause := &ngapType.Cause{
-		Present: ngapType.CausePresentProtocol,
-		Protocol: &ngapType.CauseProtocol{
-			Value: ngapType.CauseProtocolPresentUnspecified,
-		},
-	}
-	ngap_message.SendErrorIndication(ran, nil, nil, notImplementedCause, &notImplementedCriticalityDiagnostics)
-}
-
 func handlerPaging(ran *context.AmfRan, initiatingMessage *ngapType.InitiatingMessage) {
 	var uEPagingIdentity *ngapType.UEPagingIdentity
 	var pagingDRX *ngapType.PagingDRX
@@ -8897,21 +8854,14 @@ func handlerRANConfigurationUpdate(ran *context.AmfRan, initiatingMessage *ngapT
 		return
 	}
 
-	if rANNodeName != nil {
-		ran.Log.Warn("IE RANNodeName is not implemented")
-	}
-	if defaultPagingDRX != nil {
-		ran.Log.Warn("IE PagingDRX is not implemented")
-	}
-	if globalRANNodeID != nil {
-		ran.Log.Warn("IE GlobalRANNodeID is not implemented")
-	}
-
 	metricStatusOk = true
 
 	// func handleRANConfigurationUpdateMain(ran *context.AmfRan,
-	//	supportedTAList *ngapType.SupportedTAList) {
-	handleRANConfigurationUpdateMain(ran, supportedTAList /* may be nil */)
+	//	rANNodeName *ngapType.RANNodeName,
+	//	supportedTAList *ngapType.SupportedTAList,
+	//	defaultPagingDRX *ngapType.PagingDRX,
+	//	globalRANNodeID *ngapType.GlobalRANNodeID) {
+	handleRANConfigurationUpdateMain(ran, rANNodeName /* may be nil */, supportedTAList /* may be nil */, defaultPagingDRX /* may be nil */, globalRANNodeID /* may be nil */)
 }
 
 func handlerRANConfigurationUpdateAcknowledge(ran *context.AmfRan, successfulOutcome *ngapType.SuccessfulOutcome) {
@@ -9579,21 +9529,6 @@ func handlerSecondaryRATDataUsageReport(ran *context.AmfRan, initiatingMessage *
 	handleSecondaryRATDataUsageReportMain(ran, ranUe /* may be nil */, pDUSessionResourceSecondaryRATUsageList /* may be nil */, handoverFlag /* may be nil */)
 }
 
-func handleSecondaryRATDataUsageReportMain(ran *context.AmfRan, ranUe *context.RanUe, pDUSessionResourceSecondaryRATUsageList *ngapType.PDUSessionResourceSecondaryRATUsageList, handoverFlag *ngapType.HandoverFlag) {
-	ran.Log.Error("Handle SecondaryRATDataUsageReport: not implemented")
-	procedureCode := ngapType.ProcedureCodeSecondaryRATDataUsageReport
-	triggeringMessage := ngapType.TriggeringMessagePresentInitiatingMessage
-	procedureCriticality := ngapType.CriticalityPresentIgnore
-	notImplementedCriticalityDiagnostics := buildCriticalityDiagnostics(&procedureCode, &triggeringMessage, &procedureCriticality, nil)
-	notImplementedCause := &ngapType.Cause{
-		Present: ngapType.CausePresentProtocol,
-		Protocol: &ngapType.CauseProtocol{
-			Value: ngapType.CauseProtocolPresentUnspecified,
-		},
-	}
-	ngap_message.SendErrorIndication(ran, nil, nil, notImplementedCause, &notImplementedCriticalityDiagnostics)
-}
-
 func handlerTraceFailureIndication(ran *context.AmfRan, initiatingMessage *ngapType.InitiatingMessage) {
 	var aMFUENGAPID *ngapType.AMFUENGAPID
 	var rANUENGAPID *ngapType.RANUENGAPID
@@ -9762,21 +9697,6 @@ func handlerTraceFailureIndication(ran *context.AmfRan, initiatingMessage *ngapT
 	handleTraceFailureIndicationMain(ran, ranUe, nGRANTraceID /* may be nil */, cause /* may be nil */)
 }
 
-func handleTraceFailureIndicationMain(ran *context.AmfRan, ranUe *context.RanUe, nGRANTraceID *ngapType.NGRANTraceID, cause *ngapType.Cause) {
-	ran.Log.Error("Handle TraceFailureIndication: not implemented")
-	procedureCode := ngapType.ProcedureCodeTraceFailureIndication
-	triggeringMessage := ngapType.TriggeringMessagePresentInitiatingMessage
-	procedureCriticality := ngapType.CriticalityPresentIgnore
-	notImplementedCriticalityDiagnostics := buildCriticalityDiagnostics(&procedureCode, &triggeringMessage, &procedureCriticality, nil)
-	notImplementedCause := &ngapType.Cause{
-		Present: ngapType.CausePresentProtocol,
-		Protocol: &ngapType.CauseProtocol{
-			Value: ngapType.CauseProtocolPresentUnspecified,
-		},
-	}
-	ngap_message.SendErrorIndication(ran, nil, nil, notImplementedCause, &notImplementedCriticalityDiagnostics)
-}
-
 func handlerTraceStart(ran *context.AmfRan, initiatingMessage *ngapType.InitiatingMessage) {
 	var aMFUENGAPID *ngapType.AMFUENGAPID
 	var rANUENGAPID *ngapType.RANUENGAPID
@@ -12212,9 +12132,6 @@ func handlerUplinkUEAssociatedNRPPaTransport(ran *context.AmfRan, initiatingMess
 		ran.Log.Error("Missing IE NRPPa-PDU")
 		return
 	}
-	if nRPPaPDU != nil {
-		ran.Log.Warn("IE NRPPa-PDU is not implemented")
-	}
 
 	// AMF: mandatory, reject
 	// RAN: mandatory, reject
@@ -12235,8 +12152,9 @@ func handlerUplinkUEAssociatedNRPPaTransport(ran *context.AmfRan, initiatingMess
 
 	// func handleUplinkUEAssociatedNRPPaTransportMain(ran *context.AmfRan,
 	//	ranUe *context.RanUe,
-	//	routingID *ngapType.RoutingID) {
-	handleUplinkUEAssociatedNRPPaTransportMain(ran, ranUe, routingID)
+	//	routingID *ngapType.RoutingID,
+	//	nRPPaPDU *ngapType.NRPPaPDU) {
+	handleUplinkUEAssociatedNRPPaTransportMain(ran, ranUe, routingID, nRPPaPDU)
 }
 
 func handlerWriteReplaceWarningRequest(ran *context.AmfRan, initiatingMessage *ngapType.InitiatingMessage) {
@@ -12640,10 +12558,6 @@ func handlerWriteReplaceWarningResponse(ran *context.AmfRan, successfulOutcome *
 	handleWriteReplaceWarningResponseMain(ran, messageIdentifier, serialNumber, broadcastCompletedAreaList /* may be nil */, criticalityDiagnostics /* may be nil */)
 }
 
-func handleWriteReplaceWarningResponseMain(ran *context.AmfRan, messageIdentifier *ngapType.MessageIdentifier, serialNumber *ngapType.SerialNumber, broadcastCompletedAreaList *ngapType.BroadcastCompletedAreaList, criticalityDiagnostics *ngapType.CriticalityDiagnostics) {
-	ran.Log.Error("Handle WriteReplaceWarningResponse: not implemented")
-}
-
 func rawBuildHandoverPreparationFailure(aMFUENGAPID *ngapType.AMFUENGAPID, rANUENGAPID *ngapType.RANUENGAPID, cause *ngapType.Cause, criticalityDiagnostics *ngapType.CriticalityDiagnostics) ([]byte, error) {
 	var pdu ngapType.NGAPPDU
 
@@ -431,3 +431,30 @@ func TestHandleInitialUEMessage(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckSupportedTAList(t *testing.T) {
+	NewAmfContext(amf_context.GetSelf())
+	ran := NewAmfRan(new(ngaptesting.SctpConnStub))
+
+	newSupportedTAList := func(snssaiList ...models.Snssai) []amf_context.SupportedTAI {
+		return []amf_context.SupportedTAI{
+			{
+				Tai: models.Tai{
+					PlmnId: &models.PlmnId{Mcc: "208", Mnc: "93"},
+					Tac:    "000001",
+				},
+				SNssaiList: snssaiList,
+			},
+		}
+	}
+
+	// the served TA is accepted with an S-NSSAI not supported, as long as another one is supported
+	cause := checkSupportedTAList(ran, newSupportedTAList(
+		models.Snssai{Sst: 1, Sd: "112233"}, models.Snssai{Sst: 2, Sd: "000001"}))
+	require.Equal(t, ngapType.CausePresentNothing, cause.Present)
+
+	// the served TA is rejected if none of its S-NSSAIs is supported
+	cause = checkSupportedTAList(ran, newSupportedTAList(models.Snssai{Sst: 2, Sd: "000001"}))
+	require.Equal(t, ngapType.CausePresentRadioNetwork, cause.Present)
+	require.Equal(t, ngapType.CauseRadioNetworkPresentSliceNotSupported, cause.RadioNetwork.Value)
+}
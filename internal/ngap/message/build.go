@@ -80,7 +80,8 @@ func BuildPDUSessionResourceReleaseCommand(ue *context.RanUe, nasPdu []byte,
 	return ngap.Encoder(pdu)
 }
 
-func BuildNGSetupResponse() ([]byte, error) {
+// ueRetained: the UE retention requested by the RAN is accepted (TS 38.413 8.7.1.2)
+func BuildNGSetupResponse(ueRetained bool) ([]byte, error) {
 	amfSelf := context.GetSelf()
 	var pdu ngapType.NGAPPDU
 	pdu.Present = ngapType.NGAPPDUPresentSuccessfulOutcome
@@ -103,7 +104,7 @@ func BuildNGSetupResponse() ([]byte, error) {
 	ie.Value.AMFName = new(ngapType.AMFName)
 
 	aMFName := ie.Value.AMFName
-	aMFName.Value = amfSelf.Name
+	aMFName.Value = amfSelf.GetName()
 
 	nGSetupResponseIEs.List = append(nGSetupResponseIEs.List, ie)
 
@@ -115,7 +116,7 @@ func BuildNGSetupResponse() ([]byte, error) {
 	ie.Value.ServedGUAMIList = new(ngapType.ServedGUAMIList)
 
 	servedGUAMIList := ie.Value.ServedGUAMIList
-	for _, guami := range amfSelf.ServedGuamiList {
+	for _, guami := range amfSelf.GetServedGuamiList() {
 		servedGUAMIItem := ngapType.ServedGUAMIItem{}
 		servedGUAMIItem.GUAMI.PLMNIdentity = ngapConvert.PlmnIdToNgap(util.PlmnIdNidToModelsPlmnId(*guami.PlmnId))
 		regionId, setId, prtId := ngapConvert.AmfIdToNgap(guami.AmfId)
@@ -134,7 +135,7 @@ func BuildNGSetupResponse() ([]byte, error) {
 	ie.Value.Present = ngapType.NGSetupResponseIEsPresentRelativeAMFCapacity
 	ie.Value.RelativeAMFCapacity = new(ngapType.RelativeAMFCapacity)
 	relativeAMFCapacity := ie.Value.RelativeAMFCapacity
-	relativeAMFCapacity.Value = amfSelf.RelativeCapacity
+	relativeAMFCapacity.Value = amfSelf.GetRelativeCapacity()
 
 	nGSetupResponseIEs.List = append(nGSetupResponseIEs.List, ie)
 
@@ -146,7 +147,7 @@ func BuildNGSetupResponse() ([]byte, error) {
 	ie.Value.PLMNSupportList = new(ngapType.PLMNSupportList)
 
 	pLMNSupportList := ie.Value.PLMNSupportList
-	for _, plmnItem := range amfSelf.PlmnSupportList {
+	for _, plmnItem := range amfSelf.GetPlmnSupportList() {
 		pLMNSupportItem := ngapType.PLMNSupportItem{}
 		pLMNSupportItem.PLMNIdentity = ngapConvert.PlmnIdToNgap(*plmnItem.PlmnId)
 		for _, snssai := range plmnItem.SNssaiList {
@@ -159,6 +160,18 @@ func BuildNGSetupResponse() ([]byte, error) {
 
 	nGSetupResponseIEs.List = append(nGSetupResponseIEs.List, ie)
 
+	// UE Retention Information (optional)
+	if ueRetained {
+		ie = ngapType.NGSetupResponseIEs{}
+		ie.Id.Value = ngapType.ProtocolIEIDUERetentionInformation
+		ie.Criticality.Value = ngapType.CriticalityPresentIgnore
+		ie.Value.Present = ngapType.NGSetupResponseIEsPresentUERetentionInformation
+		ie.Value.UERetentionInformation = &ngapType.UERetentionInformation{
+			Value: ngapType.UERetentionInformationPresentUesRetained,
+		}
+		nGSetupResponseIEs.List = append(nGSetupResponseIEs.List, ie)
+	}
+
 	return ngap.Encoder(pdu)
 }
 
@@ -1003,7 +1016,7 @@ func BuildInitialContextSetupRequest(
 	amfSetID := &guami.AMFSetID
 	amfPtrID := &guami.AMFPointer
 
-	servedGuami := amfSelf.ServedGuamiList[0]
+	servedGuami := amfSelf.GetServedGuamiList()[0]
 
 	*plmnID = ngapConvert.PlmnIdToNgap(util.PlmnIdNidToModelsPlmnId(*servedGuami.PlmnId))
 	amfRegionID.Value, amfSetID.Value, amfPtrID.Value = ngapConvert.AmfIdToNgap(servedGuami.AmfId)
@@ -1090,16 +1103,17 @@ func BuildInitialContextSetupRequest(
 
 	// Trace Activation (optional)
 	if amfUe.TraceData != nil {
-		ie = ngapType.InitialContextSetupRequestIEs{}
-		ie.Id.Value = ngapType.ProtocolIEIDTraceActivation
-		ie.Criticality.Value = ngapType.CriticalityPresentIgnore
-		ie.Value.Present = ngapType.InitialContextSetupRequestIEsPresentTraceActivation
-		ie.Value.TraceActivation = new(ngapType.TraceActivation)
 		// TS 32.422 4.2.2.9
-		// TODO: AMF allocate Trace Recording Session Reference
-		traceActivation := ngapConvert.TraceDataToNgap(*amfUe.TraceData, ranUe.Trsr)
-		ie.Value.TraceActivation = &traceActivation
-		initialContextSetupRequestIEs.List = append(initialContextSetupRequestIEs.List, ie)
+		if traceActivation, err := buildTraceActivation(ranUe, amfUe.TraceData); err != nil {
+			ranUe.Log.Warnf("Trace is not activated: %+v", err)
+		} else {
+			ie = ngapType.InitialContextSetupRequestIEs{}
+			ie.Id.Value = ngapType.ProtocolIEIDTraceActivation
+			ie.Criticality.Value = ngapType.CriticalityPresentIgnore
+			ie.Value.Present = ngapType.InitialContextSetupRequestIEsPresentTraceActivation
+			ie.Value.TraceActivation = traceActivation
+			initialContextSetupRequestIEs.List = append(initialContextSetupRequestIEs.List, ie)
+		}
 	}
 
 	// Mobility Restriction List (optional)
@@ -1723,7 +1737,7 @@ func BuildHandoverRequest(ue *context.RanUe, cause ngapType.Cause,
 	ie.Value.AllowedNSSAI = new(ngapType.AllowedNSSAI)
 
 	allowedNSSAI := ie.Value.AllowedNSSAI
-	for _, snssaiItem := range amfSelf.PlmnSupportList[0].SNssaiList {
+	for _, snssaiItem := range amfSelf.GetPlmnSupportList()[0].SNssaiList {
 		allowedNSSAIItem := ngapType.AllowedNSSAIItem{}
 
 		ngapSnssai := ngapConvert.SNssaiToNgap(snssaiItem)
@@ -1800,7 +1814,7 @@ func BuildHandoverRequest(ue *context.RanUe, cause ngapType.Cause,
 	amfSetID := &guami.AMFSetID
 	amfPtrID := &guami.AMFPointer
 
-	servedGuami := amfSelf.ServedGuamiList[0]
+	servedGuami := amfSelf.GetServedGuamiList()[0]
 
 	*plmnID = ngapConvert.PlmnIdToNgap(util.PlmnIdNidToModelsPlmnId(*servedGuami.PlmnId))
 	amfRegionID.Value, amfSetID.Value, amfPtrID.Value = ngapConvert.AmfIdToNgap(servedGuami.AmfId)
@@ -1836,6 +1850,19 @@ func BuildHandoverRequest(ue *context.RanUe, cause ngapType.Cause,
 	// handoverRequestIEs.List = append(handoverRequestIEs.List, ie)
 
 	// Trace Activation(optional)
+	if amfUe.TraceData != nil {
+		if traceActivation, err := buildTraceActivation(ue, amfUe.TraceData); err != nil {
+			ue.Log.Warnf("Trace is not activated: %+v", err)
+		} else {
+			ie = ngapType.HandoverRequestIEs{}
+			ie.Id.Value = ngapType.ProtocolIEIDTraceActivation
+			ie.Criticality.Value = ngapType.CriticalityPresentIgnore
+			ie.Value.Present = ngapType.HandoverRequestIEsPresentTraceActivation
+			ie.Value.TraceActivation = traceActivation
+			handoverRequestIEs.List = append(handoverRequestIEs.List, ie)
+		}
+	}
+
 	// Masked IMEISV(optional)
 	// Mobility Restriction List(optional)
 	// Location Reporting Request Type(optional)
@@ -1995,7 +2022,7 @@ func BuildPathSwitchRequestAcknowledge(
 
 	allowedNSSAI := ie.Value.AllowedNSSAI
 	// plmnSupportList[0] is serving plmn
-	for _, modelSnssai := range amfSelf.PlmnSupportList[0].SNssaiList {
+	for _, modelSnssai := range amfSelf.GetPlmnSupportList()[0].SNssaiList {
 		allowedNSSAIItem := ngapType.AllowedNSSAIItem{}
 
 		ngapSnssai := ngapConvert.SNssaiToNgap(modelSnssai)
@@ -2181,11 +2208,13 @@ func BuildDownlinkRanStatusTransfer(ue *context.RanUe,
 // is associated with non-3GPP access, the AMF sends a Paging message with associated access "non-3GPP" to
 // NG-RAN node(s) via 3GPP access.
 // more paging policy with 3gpp/non-3gpp access is described in TS 23.501 5.6.8
+// ran: the RAN the message is sent to, the Paging DRX is sent according to its default paging DRX
+// taiList: TAIs for paging in the RAN the message is sent to
+// pagingAttemptInfo: the attempt of the escalating paging (TS 38.413 9.3.1.72)
 func BuildPaging(
-	ue *context.AmfUe, pagingPriority *ngapType.PagingPriority, pagingOriginNon3GPP bool,
+	ue *context.AmfUe, ran *context.AmfRan, pagingPriority *ngapType.PagingPriority, pagingOriginNon3GPP bool,
+	taiList []models.Tai, pagingAttemptInfo *ngapType.PagingAttemptInformation,
 ) ([]byte, error) {
-	// TODO: Paging DRX (optional)
-
 	var pdu ngapType.NGAPPDU
 	pdu.Present = ngapType.NGAPPDUPresentInitiatingMessage
 	pdu.InitiatingMessage = new(ngapType.InitiatingMessage)
@@ -2233,6 +2262,14 @@ func BuildPaging(
 	pagingIEs.List = append(pagingIEs.List, ie)
 
 	// Paging DRX (optional)
+	if pagingDRX := ran.PagingDRX(ue); pagingDRX != nil {
+		ie = ngapType.PagingIEs{}
+		ie.Id.Value = ngapType.ProtocolIEIDPagingDRX
+		ie.Criticality.Value = ngapType.CriticalityPresentIgnore
+		ie.Value.Present = ngapType.PagingIEsPresentPagingDRX
+		ie.Value.PagingDRX = pagingDRX
+		pagingIEs.List = append(pagingIEs.List, ie)
+	}
 
 	// TAI List for Paging
 	ie = ngapType.PagingIEs{}
@@ -2242,11 +2279,14 @@ func BuildPaging(
 	ie.Value.TAIListForPaging = new(ngapType.TAIListForPaging)
 
 	taiListForPaging := ie.Value.TAIListForPaging
-	if ue.RegistrationArea[models.AccessType__3_GPP_ACCESS] == nil {
-		err = fmt.Errorf("registration area of Ue[%s] is empty", ue.Supi)
+	if len(taiList) == 0 {
+		err = fmt.Errorf("TAI list for paging Ue[%s] is empty", ue.Supi)
 		return nil, err
 	} else {
-		for _, tai := range ue.RegistrationArea[models.AccessType__3_GPP_ACCESS] {
+		if len(taiList) > context.MaxNumOfTAI {
+			taiList = taiList[:context.MaxNumOfTAI]
+		}
+		for _, tai := range taiList {
 			var tac []byte
 			taiListforPagingItem := ngapType.TAIListForPagingItem{}
 			taiListforPagingItem.TAI.PLMNIdentity = ngapConvert.PlmnIdToNgap(*tai.PlmnId)
@@ -2297,14 +2337,23 @@ func BuildPaging(
 		pagingIEs.List = append(pagingIEs.List, ie)
 	}
 
+	// Paging eDRX Information (optional) is not provided by the ngap module, the UE in eDRX is paged only within
+	// its estimated paging time window instead
+
 	// Assistance Data for Paing (optional)
-	if ue.InfoOnRecommendedCellsAndRanNodesForPaging != nil {
+	hasRecommendedCells := ue.InfoOnRecommendedCellsAndRanNodesForPaging != nil &&
+		len(ue.InfoOnRecommendedCellsAndRanNodesForPaging.RecommendedCells) > 0
+	if hasRecommendedCells || pagingAttemptInfo != nil {
 		ie = ngapType.PagingIEs{}
 		ie.Id.Value = ngapType.ProtocolIEIDAssistanceDataForPaging
 		ie.Criticality.Value = ngapType.CriticalityPresentIgnore
 		ie.Value.Present = ngapType.PagingIEsPresentAssistanceDataForPaging
 		ie.Value.AssistanceDataForPaging = new(ngapType.AssistanceDataForPaging)
 
+		assistanceDataForPaging := ie.Value.AssistanceDataForPaging
+		assistanceDataForPaging.PagingAttemptInformation = pagingAttemptInfo
+	}
+	if hasRecommendedCells {
 		assistanceDataForPaging := ie.Value.AssistanceDataForPaging
 		assistanceDataForPaging.AssistanceDataForRecommendedCells = new(ngapType.AssistanceDataForRecommendedCells)
 		recommendedCellList := &assistanceDataForPaging.
@@ -2332,8 +2381,8 @@ func BuildPaging(
 			}
 			recommendedCellList.List = append(recommendedCellList.List, recommendedCellItem)
 		}
-
-		// TODO: Paging Attempt Information (optional): provided by AMF (TS 23.502 4.2.3.3, TS 38.300 9.2.5)
+	}
+	if hasRecommendedCells || pagingAttemptInfo != nil {
 		pagingIEs.List = append(pagingIEs.List, ie)
 	}
 
@@ -2683,7 +2732,7 @@ func BuildDownlinkRanConfigurationTransfer(
 }
 
 func BuildDownlinkNonUEAssociatedNRPPATransport(
-	ue *context.RanUe, nRPPaPDU ngapType.NRPPaPDU,
+	routingIDHex string, nRPPaPDU ngapType.NRPPaPDU,
 ) ([]byte, error) {
 	// NRPPa PDU is by pass
 	// NRPPa PDU is from LMF define in 4.13.5.6
@@ -2703,7 +2752,6 @@ func BuildDownlinkNonUEAssociatedNRPPATransport(
 	downlinkNonUEAssociatedNRPPaTransportIEs := &downlinkNonUEAssociatedNRPPaTransport.ProtocolIEs
 
 	// Routing ID
-	// Routing id in the ran context
 	ie := ngapType.DownlinkNonUEAssociatedNRPPaTransportIEs{}
 	ie.Id.Value = ngapType.ProtocolIEIDRoutingID
 	ie.Criticality.Value = ngapType.CriticalityPresentReject
@@ -2712,9 +2760,9 @@ func BuildDownlinkNonUEAssociatedNRPPATransport(
 
 	var err error
 	routingID := ie.Value.RoutingID
-	routingID.Value, err = hex.DecodeString(ue.RoutingID)
+	routingID.Value, err = hex.DecodeString(routingIDHex)
 	if err != nil {
-		logger.NgapLog.Errorf("[Build Error] DecodeString ue.RoutingID error: %+v", err)
+		logger.NgapLog.Errorf("[Build Error] DecodeString routingID error: %+v", err)
 	}
 
 	downlinkNonUEAssociatedNRPPaTransportIEs.List = append(downlinkNonUEAssociatedNRPPaTransportIEs.List, ie)
@@ -2732,11 +2780,83 @@ func BuildDownlinkNonUEAssociatedNRPPATransport(
 	return ngap.Encoder(pdu)
 }
 
-func BuildTraceStart() ([]byte, error) {
+func BuildTraceStart(ranUe *context.RanUe) ([]byte, error) {
+	amfUe := ranUe.AmfUe
+	if amfUe == nil {
+		return nil, fmt.Errorf("AmfUe is nil")
+	}
+	if amfUe.TraceData == nil {
+		return nil, fmt.Errorf("TraceData is nil")
+	}
+
 	var pdu ngapType.NGAPPDU
+
+	pdu.Present = ngapType.NGAPPDUPresentInitiatingMessage
+	pdu.InitiatingMessage = new(ngapType.InitiatingMessage)
+
+	initiatingMessage := pdu.InitiatingMessage
+	initiatingMessage.ProcedureCode.Value = ngapType.ProcedureCodeTraceStart
+	initiatingMessage.Criticality.Value = ngapType.CriticalityPresentIgnore
+
+	initiatingMessage.Value.Present = ngapType.InitiatingMessagePresentTraceStart
+	initiatingMessage.Value.TraceStart = new(ngapType.TraceStart)
+
+	traceStart := initiatingMessage.Value.TraceStart
+	traceStartIEs := &traceStart.ProtocolIEs
+
+	// AMF UE NGAP ID
+	ie := ngapType.TraceStartIEs{}
+	ie.Id.Value = ngapType.ProtocolIEIDAMFUENGAPID
+	ie.Criticality.Value = ngapType.CriticalityPresentReject
+	ie.Value.Present = ngapType.TraceStartIEsPresentAMFUENGAPID
+	ie.Value.AMFUENGAPID = new(ngapType.AMFUENGAPID)
+	ie.Value.AMFUENGAPID.Value = ranUe.AmfUeNgapId
+
+	traceStartIEs.List = append(traceStartIEs.List, ie)
+
+	// RAN UE NGAP ID
+	ie = ngapType.TraceStartIEs{}
+	ie.Id.Value = ngapType.ProtocolIEIDRANUENGAPID
+	ie.Criticality.Value = ngapType.CriticalityPresentReject
+	ie.Value.Present = ngapType.TraceStartIEsPresentRANUENGAPID
+	ie.Value.RANUENGAPID = new(ngapType.RANUENGAPID)
+	ie.Value.RANUENGAPID.Value = ranUe.RanUeNgapId
+
+	traceStartIEs.List = append(traceStartIEs.List, ie)
+
+	// Trace Activation
+	traceActivation, err := buildTraceActivation(ranUe, amfUe.TraceData)
+	if err != nil {
+		return nil, err
+	}
+	ie = ngapType.TraceStartIEs{}
+	ie.Id.Value = ngapType.ProtocolIEIDTraceActivation
+	ie.Criticality.Value = ngapType.CriticalityPresentIgnore
+	ie.Value.Present = ngapType.TraceStartIEsPresentTraceActivation
+	ie.Value.TraceActivation = traceActivation
+
+	traceStartIEs.List = append(traceStartIEs.List, ie)
+
 	return ngap.Encoder(pdu)
 }
 
+// The Trace Activation IE of the trace data, the NG-RAN trace ID is composed of the trace reference and the Trace
+// Recording Session Reference allocated to the RanUe (TS 32.422 4.2.2.9)
+func buildTraceActivation(ranUe *context.RanUe, traceData *models.TraceData) (*ngapType.TraceActivation, error) {
+	if err := context.ValidateTraceData(traceData); err != nil {
+		return nil, err
+	}
+	if err := ranUe.AllocateTrsr(); err != nil {
+		return nil, err
+	}
+	traceActivation := ngapConvert.TraceDataToNgap(*traceData, ranUe.Trsr)
+	if traceData.InterfaceList == "" {
+		// all the NG-RAN interfaces (NG-C, Xn-C, Uu, F1-C and E1) are traced if the interface list is absent
+		traceActivation.InterfacesToTrace.Value.Bytes = []byte{0xf8}
+	}
+	return &traceActivation, nil
+}
+
 func BuildDeactivateTrace(amfUe *context.AmfUe, anType models.AccessType) ([]byte, error) {
 	var pdu ngapType.NGAPPDU
 
@@ -2956,10 +3076,9 @@ func BuildUETNLABindingReleaseRequest(ue *context.RanUe) ([]byte, error) {
 	return ngap.Encoder(pdu)
 }
 
-// Weight Factor associated with each of the TNL association within the AMF
-func BuildAMFConfigurationUpdate(tNLassociationUsage ngapType.TNLAssociationUsage,
-	tNLAddressWeightFactor ngapType.TNLAddressWeightFactor,
-) ([]byte, error) {
+// The AMF name, served GUAMIs, relative capacity and PLMN support list of the AMF are sent to the RAN, the TNL
+// associations of the AMF are not changed
+func BuildAMFConfigurationUpdate() ([]byte, error) {
 	amfSelf := context.GetSelf()
 	var pdu ngapType.NGAPPDU
 
@@ -2983,7 +3102,7 @@ func BuildAMFConfigurationUpdate(tNLassociationUsage ngapType.TNLAssociationUsag
 	ie.Value.AMFName = new(ngapType.AMFName)
 
 	aMFName := ie.Value.AMFName
-	aMFName.Value = amfSelf.Name
+	aMFName.Value = amfSelf.GetName()
 
 	aMFConfigurationUpdateIEs.List = append(aMFConfigurationUpdateIEs.List, ie)
 
@@ -2995,7 +3114,7 @@ func BuildAMFConfigurationUpdate(tNLassociationUsage ngapType.TNLAssociationUsag
 	ie.Value.ServedGUAMIList = new(ngapType.ServedGUAMIList)
 
 	servedGUAMIList := ie.Value.ServedGUAMIList
-	for _, guami := range amfSelf.ServedGuamiList {
+	for _, guami := range amfSelf.GetServedGuamiList() {
 		servedGUAMIItem := ngapType.ServedGUAMIItem{}
 		servedGUAMIItem.GUAMI.PLMNIdentity = ngapConvert.PlmnIdToNgap(util.PlmnIdNidToModelsPlmnId(*guami.PlmnId))
 		regionId, setId, prtId := ngapConvert.AmfIdToNgap(guami.AmfId)
@@ -3011,10 +3130,10 @@ func BuildAMFConfigurationUpdate(tNLassociationUsage ngapType.TNLAssociationUsag
 	ie = ngapType.AMFConfigurationUpdateIEs{}
 	ie.Id.Value = ngapType.ProtocolIEIDRelativeAMFCapacity
 	ie.Criticality.Value = ngapType.CriticalityPresentIgnore
-	ie.Value.Present = ngapType.NGSetupResponseIEsPresentRelativeAMFCapacity
+	ie.Value.Present = ngapType.AMFConfigurationUpdateIEsPresentRelativeAMFCapacity
 	ie.Value.RelativeAMFCapacity = new(ngapType.RelativeAMFCapacity)
 	relativeAMFCapacity := ie.Value.RelativeAMFCapacity
-	relativeAMFCapacity.Value = amfSelf.RelativeCapacity
+	relativeAMFCapacity.Value = amfSelf.GetRelativeCapacity()
 
 	aMFConfigurationUpdateIEs.List = append(aMFConfigurationUpdateIEs.List, ie)
 
@@ -3026,7 +3145,7 @@ func BuildAMFConfigurationUpdate(tNLassociationUsage ngapType.TNLAssociationUsag
 	ie.Value.PLMNSupportList = new(ngapType.PLMNSupportList)
 
 	pLMNSupportList := ie.Value.PLMNSupportList
-	for _, plmnItem := range amfSelf.PlmnSupportList {
+	for _, plmnItem := range amfSelf.GetPlmnSupportList() {
 		pLMNSupportItem := ngapType.PLMNSupportItem{}
 		pLMNSupportItem.PLMNIdentity = ngapConvert.PlmnIdToNgap(*plmnItem.PlmnId)
 		for _, snssai := range plmnItem.SNssaiList {
@@ -3039,85 +3158,6 @@ func BuildAMFConfigurationUpdate(tNLassociationUsage ngapType.TNLAssociationUsag
 
 	aMFConfigurationUpdateIEs.List = append(aMFConfigurationUpdateIEs.List, ie)
 
-	//	AMF TNL Association to Add List
-	ie = ngapType.AMFConfigurationUpdateIEs{}
-	ie.Id.Value = ngapType.ProtocolIEIDAMFTNLAssociationToAddList
-	ie.Criticality.Value = ngapType.CriticalityPresentIgnore
-	ie.Value.Present = ngapType.AMFConfigurationUpdateIEsPresentAMFTNLAssociationToAddList
-	ie.Value.AMFTNLAssociationToAddList = new(ngapType.AMFTNLAssociationToAddList)
-
-	aMFTNLAssociationToAddList := ie.Value.AMFTNLAssociationToAddList
-
-	//	AMFTNLAssociationToAddItem in AMFTNLAssociationToAddList
-	aMFTNLAssociationToAddItem := ngapType.AMFTNLAssociationToAddItem{}
-	aMFTNLAssociationToAddItem.AMFTNLAssociationAddress.Present = ngapType.
-		CPTransportLayerInformationPresentEndpointIPAddress
-	aMFTNLAssociationToAddItem.AMFTNLAssociationAddress.EndpointIPAddress = new(ngapType.TransportLayerAddress)
-	*aMFTNLAssociationToAddItem.AMFTNLAssociationAddress.EndpointIPAddress = ngapConvert.
-		IPAddressToNgap(amfSelf.RegisterIPv4, amfSelf.HttpIPv6Address)
-
-	//	AMF TNL Association Usage[optional]
-	if aMFTNLAssociationToAddItem.TNLAssociationUsage != nil {
-		aMFTNLAssociationToAddItem.TNLAssociationUsage = new(ngapType.TNLAssociationUsage)
-		aMFTNLAssociationToAddItem.TNLAssociationUsage = &tNLassociationUsage
-	}
-
-	//	AMF TNL Address Weight Factor
-	aMFTNLAssociationToAddItem.TNLAddressWeightFactor = tNLAddressWeightFactor
-
-	aMFTNLAssociationToAddList.List = append(aMFTNLAssociationToAddList.List, aMFTNLAssociationToAddItem)
-	aMFConfigurationUpdateIEs.List = append(aMFConfigurationUpdateIEs.List, ie)
-
-	//	AMF TNL Association to Remove List
-	ie = ngapType.AMFConfigurationUpdateIEs{}
-	ie.Id.Value = ngapType.ProtocolIEIDAMFTNLAssociationToRemoveList
-	ie.Criticality.Value = ngapType.CriticalityPresentIgnore
-	ie.Value.Present = ngapType.AMFConfigurationUpdateIEsPresentAMFTNLAssociationToRemoveList
-	ie.Value.AMFTNLAssociationToRemoveList = new(ngapType.AMFTNLAssociationToRemoveList)
-
-	aMFTNLAssociationToRemoveList := ie.Value.AMFTNLAssociationToRemoveList
-
-	//	AMFTNLAssociationToRemoveItem
-	aMFTNLAssociationToRemoveItem := ngapType.AMFTNLAssociationToRemoveItem{}
-	aMFTNLAssociationToRemoveItem.AMFTNLAssociationAddress.Present = ngapType.
-		CPTransportLayerInformationPresentEndpointIPAddress
-	aMFTNLAssociationToRemoveItem.AMFTNLAssociationAddress.EndpointIPAddress = new(ngapType.TransportLayerAddress)
-	*aMFTNLAssociationToRemoveItem.AMFTNLAssociationAddress.EndpointIPAddress = ngapConvert.
-		IPAddressToNgap(amfSelf.RegisterIPv4, amfSelf.HttpIPv6Address)
-
-	aMFTNLAssociationToRemoveList.List = append(aMFTNLAssociationToRemoveList.List, aMFTNLAssociationToRemoveItem)
-	aMFConfigurationUpdateIEs.List = append(aMFConfigurationUpdateIEs.List, ie)
-
-	//	AMFTNLAssociationToUpdateList
-	ie = ngapType.AMFConfigurationUpdateIEs{}
-	ie.Id.Value = ngapType.ProtocolIEIDAMFTNLAssociationToUpdateList
-	ie.Criticality.Value = ngapType.CriticalityPresentIgnore
-	ie.Value.Present = ngapType.AMFConfigurationUpdateIEsPresentAMFTNLAssociationToUpdateList
-	ie.Value.AMFTNLAssociationToUpdateList = new(ngapType.AMFTNLAssociationToUpdateList)
-
-	aMFTNLAssociationToUpdateList := ie.Value.AMFTNLAssociationToUpdateList
-
-	//	AMFTNLAssociationAddress in AMFTNLAssociationtoUpdateItem
-	aMFTNLAssociationToUpdateItem := ngapType.AMFTNLAssociationToUpdateItem{}
-	aMFTNLAssociationToUpdateItem.AMFTNLAssociationAddress.Present = ngapType.
-		CPTransportLayerInformationPresentEndpointIPAddress
-	aMFTNLAssociationToUpdateItem.AMFTNLAssociationAddress.EndpointIPAddress = new(ngapType.TransportLayerAddress)
-	*aMFTNLAssociationToUpdateItem.AMFTNLAssociationAddress.EndpointIPAddress = ngapConvert.
-		IPAddressToNgap(amfSelf.RegisterIPv4, amfSelf.HttpIPv6Address)
-
-	//	TNLAssociationUsage in AMFTNLAssociationtoUpdateItem [optional]
-	if aMFTNLAssociationToUpdateItem.TNLAssociationUsage != nil {
-		aMFTNLAssociationToUpdateItem.TNLAssociationUsage = new(ngapType.TNLAssociationUsage)
-		aMFTNLAssociationToUpdateItem.TNLAssociationUsage = &tNLassociationUsage
-	}
-	//	TNLAddressWeightFactor in AMFTNLAssociationtoUpdateItem [optional]
-	if aMFTNLAssociationToUpdateItem.TNLAddressWeightFactor != nil {
-		aMFTNLAssociationToUpdateItem.TNLAddressWeightFactor = new(ngapType.TNLAddressWeightFactor)
-		aMFTNLAssociationToUpdateItem.TNLAddressWeightFactor = &tNLAddressWeightFactor
-	}
-	aMFTNLAssociationToUpdateList.List = append(aMFTNLAssociationToUpdateList.List, aMFTNLAssociationToUpdateItem)
-	aMFConfigurationUpdateIEs.List = append(aMFConfigurationUpdateIEs.List, ie)
-
 	return ngap.Encoder(pdu)
 }
 
@@ -3191,3 +3231,94 @@ func BuildDownlinkUEAssociatedNRPPaTransport(ue *context.RanUe, nRPPaPDU ngapTyp
 
 	return ngap.Encoder(pdu)
 }
+
+// The PWS indications received from the NG-RAN node are rebuilt to be forwarded to the CBCF
+// in the PWS container of N2InfoNotify (TS 23.041 9.1.3.5.3 and 9.1.3.5.4)
+func BuildPWSRestartIndication(cellIDListForRestart *ngapType.CellIDListForRestart,
+	globalRANNodeID *ngapType.GlobalRANNodeID, tAIListForRestart *ngapType.TAIListForRestart,
+	emergencyAreaIDListForRestart *ngapType.EmergencyAreaIDListForRestart,
+) ([]byte, error) {
+	var pdu ngapType.NGAPPDU
+	pdu.Present = ngapType.NGAPPDUPresentInitiatingMessage
+	pdu.InitiatingMessage = new(ngapType.InitiatingMessage)
+
+	initiatingMessage := pdu.InitiatingMessage
+	initiatingMessage.ProcedureCode.Value = ngapType.ProcedureCodePWSRestartIndication
+	initiatingMessage.Criticality.Value = ngapType.CriticalityPresentIgnore
+
+	initiatingMessage.Value.Present = ngapType.InitiatingMessagePresentPWSRestartIndication
+	initiatingMessage.Value.PWSRestartIndication = new(ngapType.PWSRestartIndication)
+
+	pWSRestartIndicationIEs := &initiatingMessage.Value.PWSRestartIndication.ProtocolIEs
+
+	// Cell ID List For Restart
+	ie := ngapType.PWSRestartIndicationIEs{}
+	ie.Id.Value = ngapType.ProtocolIEIDCellIDListForRestart
+	ie.Criticality.Value = ngapType.CriticalityPresentReject
+	ie.Value.Present = ngapType.PWSRestartIndicationIEsPresentCellIDListForRestart
+	ie.Value.CellIDListForRestart = cellIDListForRestart
+	pWSRestartIndicationIEs.List = append(pWSRestartIndicationIEs.List, ie)
+
+	// Global RAN Node ID
+	ie = ngapType.PWSRestartIndicationIEs{}
+	ie.Id.Value = ngapType.ProtocolIEIDGlobalRANNodeID
+	ie.Criticality.Value = ngapType.CriticalityPresentReject
+	ie.Value.Present = ngapType.PWSRestartIndicationIEsPresentGlobalRANNodeID
+	ie.Value.GlobalRANNodeID = globalRANNodeID
+	pWSRestartIndicationIEs.List = append(pWSRestartIndicationIEs.List, ie)
+
+	// TAI List For Restart
+	ie = ngapType.PWSRestartIndicationIEs{}
+	ie.Id.Value = ngapType.ProtocolIEIDTAIListForRestart
+	ie.Criticality.Value = ngapType.CriticalityPresentReject
+	ie.Value.Present = ngapType.PWSRestartIndicationIEsPresentTAIListForRestart
+	ie.Value.TAIListForRestart = tAIListForRestart
+	pWSRestartIndicationIEs.List = append(pWSRestartIndicationIEs.List, ie)
+
+	// Emergency Area ID List For Restart (optional)
+	if emergencyAreaIDListForRestart != nil {
+		ie = ngapType.PWSRestartIndicationIEs{}
+		ie.Id.Value = ngapType.ProtocolIEIDEmergencyAreaIDListForRestart
+		ie.Criticality.Value = ngapType.CriticalityPresentReject
+		ie.Value.Present = ngapType.PWSRestartIndicationIEsPresentEmergencyAreaIDListForRestart
+		ie.Value.EmergencyAreaIDListForRestart = emergencyAreaIDListForRestart
+		pWSRestartIndicationIEs.List = append(pWSRestartIndicationIEs.List, ie)
+	}
+
+	return ngap.Encoder(pdu)
+}
+
+func BuildPWSFailureIndication(pWSFailedCellIDList *ngapType.PWSFailedCellIDList,
+	globalRANNodeID *ngapType.GlobalRANNodeID,
+) ([]byte, error) {
+	var pdu ngapType.NGAPPDU
+	pdu.Present = ngapType.NGAPPDUPresentInitiatingMessage
+	pdu.InitiatingMessage = new(ngapType.InitiatingMessage)
+
+	initiatingMessage := pdu.InitiatingMessage
+	initiatingMessage.ProcedureCode.Value = ngapType.ProcedureCodePWSFailureIndication
+	initiatingMessage.Criticality.Value = ngapType.CriticalityPresentIgnore
+
+	initiatingMessage.Value.Present = ngapType.InitiatingMessagePresentPWSFailureIndication
+	initiatingMessage.Value.PWSFailureIndication = new(ngapType.PWSFailureIndication)
+
+	pWSFailureIndicationIEs := &initiatingMessage.Value.PWSFailureIndication.ProtocolIEs
+
+	// PWS Failed Cell ID List
+	ie := ngapType.PWSFailureIndicationIEs{}
+	ie.Id.Value = ngapType.ProtocolIEIDPWSFailedCellIDList
+	ie.Criticality.Value = ngapType.CriticalityPresentReject
+	ie.Value.Present = ngapType.PWSFailureIndicationIEsPresentPWSFailedCellIDList
+	ie.Value.PWSFailedCellIDList = pWSFailedCellIDList
+	pWSFailureIndicationIEs.List = append(pWSFailureIndicationIEs.List, ie)
+
+	// Global RAN Node ID
+	ie = ngapType.PWSFailureIndicationIEs{}
+	ie.Id.Value = ngapType.ProtocolIEIDGlobalRANNodeID
+	ie.Criticality.Value = ngapType.CriticalityPresentReject
+	ie.Value.Present = ngapType.PWSFailureIndicationIEsPresentGlobalRANNodeID
+	ie.Value.GlobalRANNodeID = globalRANNodeID
+	pWSFailureIndicationIEs.List = append(pWSFailureIndicationIEs.List, ie)
+
+	return ngap.Encoder(pdu)
+}
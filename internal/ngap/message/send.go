@@ -1,6 +1,7 @@
 package message
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/free5gc/amf/internal/context"
@@ -16,6 +17,11 @@ import (
 
 var emptyCause = ngapType.Cause{Present: 0}
 
+// NGAP message names of the metrics, not provided by the metrics module
+const (
+	traceStartMetricName = "TraceStart"
+)
+
 func SendToRan(ran *context.AmfRan, packet []byte) (bool, string) {
 	defer func() {
 		// This is workaround.
@@ -92,14 +98,14 @@ func NasSendToRan(ue *context.AmfUe, accessType models.AccessType, packet []byte
 	return SendToRanUe(ranUe, packet)
 }
 
-func SendNGSetupResponse(ran *context.AmfRan) {
+func SendNGSetupResponse(ran *context.AmfRan, ueRetained bool) {
 	isNGSetupRespSent := false
 	additionalCause := ""
 	defer ngap_metrics.IncrMetricsSentMsg(ngap_metrics.NG_SETUP_RESPONSE, &isNGSetupRespSent, emptyCause, &additionalCause)
 
 	ran.Log.Info("Send NG-Setup response")
 
-	pkt, err := BuildNGSetupResponse()
+	pkt, err := BuildNGSetupResponse(ueRetained)
 	if err != nil {
 		additionalCause = ngap_metrics.NGAP_MSG_BUILD_ERR
 		ran.Log.Errorf("Build NGSetupResponse failed : %s", err.Error())
@@ -701,6 +707,54 @@ func SendHandoverRequest(sourceUe *context.RanUe, targetRan *context.AmfRan, cau
 	isHoReqSent, additionalCause = SendToRanUe(targetUe, pkt)
 }
 
+// Used by target AMF of the N2 handover between AMFs (TS 23.502 4.9.1.3.2 step 9),
+// targetUe is attached to the AmfUe created by Namf_Communication_CreateUEContext
+// and there is no source UE in this AMF
+func SendHandoverRequestToTargetUe(targetUe *context.RanUe, cause ngapType.Cause,
+	pduSessionResourceSetupListHOReq ngapType.PDUSessionResourceSetupListHOReq,
+	sourceToTargetTransparentContainer ngapType.SourceToTargetTransparentContainer, nsci bool,
+) bool {
+	isHoReqSent := false
+	additionalCause := ""
+	defer ngap_metrics.IncrMetricsSentMsg(ngap_metrics.HANDOVER_REQUEST, &isHoReqSent, cause, &additionalCause)
+
+	if targetUe == nil {
+		additionalCause = ngap_metrics.RAN_UE_NIL_ERR
+		logger.NgapLog.Error("targetUe is nil")
+		return false
+	}
+
+	targetUe.Log.Info("Send Handover Request")
+
+	if targetUe.AmfUe == nil {
+		additionalCause = ngap_metrics.AMF_UE_NIL_ERR
+		targetUe.Log.Error("amfUe is nil")
+		return false
+	}
+
+	if len(pduSessionResourceSetupListHOReq.List) > context.MaxNumOfPDUSessions {
+		additionalCause = ngap_metrics.PDU_LIST_OOR_ERR
+		targetUe.Log.Error("Pdu List out of range")
+		return false
+	}
+
+	if len(sourceToTargetTransparentContainer.Value) == 0 {
+		additionalCause = ngap_metrics.SRC_TO_TARGET_TRANSPARENT_CONTAINER_NIL_ERR
+		targetUe.Log.Error("Source To Target TransparentContainer is nil")
+		return false
+	}
+
+	pkt, err := BuildHandoverRequest(targetUe, cause, pduSessionResourceSetupListHOReq,
+		sourceToTargetTransparentContainer, nsci)
+	if err != nil {
+		additionalCause = ngap_metrics.NGAP_MSG_BUILD_ERR
+		targetUe.Log.Errorf("Build HandoverRequest failed : %s", err.Error())
+		return false
+	}
+	isHoReqSent, additionalCause = SendToRanUe(targetUe, pkt)
+	return isHoReqSent
+}
+
 // pduSessionResourceSwitchedList: provided by AMF, and the transfer data is from SMF
 // pduSessionResourceReleasedList: provided by AMF, and the transfer data is from SMF
 // newSecurityContextIndicator: if AMF has activated a new 5G NAS security context, set it to true,
@@ -836,62 +890,108 @@ func SendDownlinkRanStatusTransfer(ue *context.RanUe, container ngapType.RANStat
 // is associated with non-3GPP access, the AMF sends a Paging message with associated access "non-3GPP" to
 // NG-RAN node(s) via 3GPP access.
 // more paging policy with 3gpp/non-3gpp access is described in TS 23.501 5.6.8
-func SendPaging(ue *context.AmfUe, ngapBuf []byte) {
+// The paging area is escalated on each T3513 expiry by the steps of the paging policy, see factory.PagingPolicy
+// The caller shall hold ue.Lock.
+func SendPaging(ue *context.AmfUe, pagingPriority *ngapType.PagingPriority, pagingOriginNon3GPP bool) error {
 	isPagingSent := false
 	additionalCause := ""
 	defer ngap_metrics.IncrMetricsSentMsg(ngap_metrics.PAGING, &isPagingSent, emptyCause, &additionalCause)
 
-	// var pagingPriority *ngapType.PagingPriority
 	if ue == nil {
 		additionalCause = ngap_metrics.AMF_UE_NIL_ERR
-		logger.NgapLog.Error("AmfUe is nil")
-		return
+		return fmt.Errorf("AmfUe is nil")
 	}
 
-	// if ppi != nil {
-	// pagingPriority = new(ngapType.PagingPriority)
-	// pagingPriority.Value = aper.Enumerated(*ppi)
-	// }
-	// pkt, err := BuildPaging(ue, pagingPriority, pagingOriginNon3GPP)
-	// if err != nil {
-	// 	ngaplog.Errorf("Build Paging failed : %s", err.Error())
-	// }
-	taiList := ue.RegistrationArea[models.AccessType__3_GPP_ACCESS]
-	context.GetSelf().AmfRanPool.Range(func(key, value interface{}) bool {
-		ran := value.(*context.AmfRan)
-		for _, item := range ran.SupportedTAList {
-			if context.InTaiList(item.Tai, taiList) {
-				ue.GmmLog.Infof("Send Paging to TAI(%+v, Tac:%+v)", item.Tai.PlmnId, item.Tai.Tac)
-				isPagingSent, additionalCause = SendToRan(ran, ngapBuf)
-				break
-			}
-		}
-		return true
-	})
+	cfg := context.GetSelf().T3513Cfg
+	steps := ue.PagingSteps()
+	intendedAttempts := 1
+	if cfg.Enable {
+		intendedAttempts += cfg.MaxRetryTimes
+	}
+
+	var err error
+	isPagingSent, additionalCause, err = sendPagingAttempt(ue, pagingPriority, pagingOriginNon3GPP,
+		steps, 0, intendedAttempts)
+	if err != nil {
+		return err
+	}
 
-	if context.GetSelf().T3513Cfg.Enable {
-		cfg := context.GetSelf().T3513Cfg
+	if cfg.Enable {
 		ue.GmmLog.Infof("Start T3513 timer")
-		ue.T3513 = context.NewTimer(cfg.ExpireTime, cfg.MaxRetryTimes, func(expireTimes int32) {
+		// the paging of the UE context is rebuilt under ue.Lock on expiry, and the expiry of the timer stopped
+		// while waiting for the lock is ignored
+		var t3513 *context.Timer
+		t3513 = context.NewTimer(cfg.ExpireTime, cfg.MaxRetryTimes, func(expireTimes int32) {
+			ue.Lock.Lock()
+			defer ue.Lock.Unlock()
+			if ue.T3513 != t3513 {
+				return
+			}
 			ue.GmmLog.Warnf("T3513 expires, retransmit Paging (retry: %d)", expireTimes)
-			context.GetSelf().AmfRanPool.Range(func(key, value interface{}) bool {
-				ran := value.(*context.AmfRan)
-				for _, item := range ran.SupportedTAList {
-					if context.InTaiList(item.Tai, taiList) {
-						isPagingSent, additionalCause = SendToRan(ran, ngapBuf)
-						break
-					}
-				}
-				return true
-			})
+			if _, _, errAttempt := sendPagingAttempt(ue, pagingPriority, pagingOriginNon3GPP,
+				steps, int(expireTimes), intendedAttempts); errAttempt != nil {
+				ue.GmmLog.Errorf("Retransmit Paging failed: %+v", errAttempt)
+			}
 		}, func() {
+			ue.Lock.Lock()
+			defer ue.Lock.Unlock()
+			if ue.T3513 != t3513 {
+				return
+			}
 			ue.GmmLog.Warnf("T3513 expires %d times, abort paging procedure", cfg.MaxRetryTimes)
 			ue.T3513 = nil // clear the timer
+			if ue.OnGoing(models.AccessType__3_GPP_ACCESS).Procedure == context.OnGoingProcedurePaging {
+				ue.SetOnGoing(models.AccessType__3_GPP_ACCESS, &context.OnGoing{
+					Procedure: context.OnGoingProcedureNothing,
+				})
+			}
 			if ue.OnGoing(models.AccessType__3_GPP_ACCESS).Procedure != context.OnGoingProcedureN2Handover {
 				callback.SendN1N2TransferFailureNotification(ue, models.N1N2MessageTransferCause_UE_NOT_RESPONDING)
 			}
 		})
+		ue.T3513 = t3513
+	}
+	return nil
+}
+
+// The paging area of the attempt is the one of the step, and the area of the last step is used for the
+// remaining attempts. The NG-RAN is informed whether the area of the next attempt is changed, TS 38.413 9.3.1.72.
+func sendPagingAttempt(ue *context.AmfUe, pagingPriority *ngapType.PagingPriority, pagingOriginNon3GPP bool,
+	steps []string, attempt int, intendedAttempts int,
+) (bool, string, error) {
+	stepArea := func(attempt int) string {
+		return steps[min(attempt, len(steps)-1)]
+	}
+	area := stepArea(attempt)
+
+	pagingAttemptInfo := &ngapType.PagingAttemptInformation{
+		PagingAttemptCount:             ngapType.PagingAttemptCount{Value: int64(min(attempt+1, 16))},
+		IntendedNumberOfPagingAttempts: ngapType.IntendedNumberOfPagingAttempts{Value: int64(min(intendedAttempts, 16))},
+	}
+	if attempt+1 < intendedAttempts {
+		pagingAttemptInfo.NextPagingAreaScope = &ngapType.NextPagingAreaScope{
+			Value: ngapType.NextPagingAreaScopePresentSame,
+		}
+		if stepArea(attempt+1) != area {
+			pagingAttemptInfo.NextPagingAreaScope.Value = ngapType.NextPagingAreaScopePresentChanged
+		}
+	}
+
+	targets := ue.PagingTargets(area)
+	if len(targets) == 0 {
+		return false, ngap_metrics.RAN_NIL_ERR, fmt.Errorf("no RAN to page Ue[%s] in %s", ue.Supi, area)
 	}
+
+	isPagingSent, additionalCause := false, ""
+	for _, target := range targets {
+		pkt, err := BuildPaging(ue, target.Ran, pagingPriority, pagingOriginNon3GPP, target.TaiList, pagingAttemptInfo)
+		if err != nil {
+			return false, ngap_metrics.NGAP_MSG_BUILD_ERR, fmt.Errorf("build Paging failed: %w", err)
+		}
+		ue.GmmLog.Infof("Send Paging to RAN[%s] of %s (attempt: %d)", target.Ran.RanID(), area, attempt+1)
+		isPagingSent, additionalCause = SendToRan(target.Ran, pkt)
+	}
+	return isPagingSent, additionalCause, nil
 }
 
 // TS 23.502 4.2.2.2.3
@@ -1090,6 +1190,25 @@ func SendOverloadStop(ran *context.AmfRan) {
 	isOverleadStopSent, additionalCause = SendToRan(ran, pkt)
 }
 
+// The Write-Replace-Warning Request and PWS Cancel Request are built by the CBCF (TS 23.041 9.1.3.5),
+// AMF forwards them to the NG-RAN node without modification
+func SendPwsRequest(ran *context.AmfRan, msgType string, packet []byte) bool {
+	isPwsRequestSent := false
+	additionalCause := ""
+	defer ngap_metrics.IncrMetricsSentMsg(msgType, &isPwsRequestSent, emptyCause, &additionalCause)
+
+	if ran == nil {
+		additionalCause = ngap_metrics.RAN_NIL_ERR
+		logger.NgapLog.Error("Ran is nil")
+		return false
+	}
+
+	ran.Log.Infof("Send %s", msgType)
+
+	isPwsRequestSent, additionalCause = SendToRan(ran, packet)
+	return isPwsRequestSent
+}
+
 // SONConfigurationTransfer = sONConfigurationTransfer from uplink Ran Configuration Transfer
 func SendDownlinkRanConfigurationTransfer(ran *context.AmfRan, transfer *ngapType.SONConfigurationTransfer) {
 	isDLRandConfigurationTransferSent := false
@@ -1117,34 +1236,58 @@ func SendDownlinkRanConfigurationTransfer(ran *context.AmfRan, transfer *ngapTyp
 
 // NRPPa PDU is by pass
 // NRPPa PDU is from LMF define in 4.13.5.6
-func SendDownlinkNonUEAssociatedNRPPATransport(ue *context.RanUe, nRPPaPDU ngapType.NRPPaPDU) {
+func SendDownlinkNonUEAssociatedNRPPATransport(ran *context.AmfRan, routingID string, nRPPaPDU ngapType.NRPPaPDU) {
 	metricsStatus := false
 	additionalCause := ""
 	defer ngap_metrics.IncrMetricsSentMsg(
 		ngap_metrics.DOWNLINK_NON_UE_ASSOCIATED_NRPPA_TRANSPORT, &metricsStatus, emptyCause, &additionalCause)
 
-	if ue == nil {
+	if ran == nil {
 		additionalCause = ngap_metrics.RAN_NIL_ERR
-		logger.NgapLog.Error("RanUe is nil")
+		logger.NgapLog.Error("Ran is nil")
 		return
 	}
 
-	ue.Log.Info("Send Downlink Non UE Associated NRPPA Transport")
+	ran.Log.Info("Send Downlink Non UE Associated NRPPA Transport")
 
 	if len(nRPPaPDU.Value) == 0 {
 		additionalCause = ngap_metrics.NRPPA_LEN_ZERO_ERR
-		ue.Log.Error("length of NRPPA-PDU is 0")
+		ran.Log.Error("length of NRPPA-PDU is 0")
+		return
+	}
+
+	pkt, err := BuildDownlinkNonUEAssociatedNRPPATransport(routingID, nRPPaPDU)
+	if err != nil {
+		additionalCause = ngap_metrics.NGAP_MSG_BUILD_ERR
+		ran.Log.Errorf("Build DownlinkNonUEAssociatedNRPPATransport failed : %s", err.Error())
+		return
+	}
+
+	metricsStatus, additionalCause = SendToRan(ran, pkt)
+}
+
+// Activate the trace data of the UE in the NG-RAN node of which the UE context is set up (TS 32.422 4.2.2.9)
+func SendTraceStart(ranUe *context.RanUe) {
+	isTraceStartSent := false
+	additionalCause := ""
+	defer ngap_metrics.IncrMetricsSentMsg(traceStartMetricName, &isTraceStartSent, emptyCause, &additionalCause)
+
+	if ranUe == nil {
+		additionalCause = ngap_metrics.RAN_UE_NIL_ERR
+		logger.NgapLog.Error("RanUe is nil")
 		return
 	}
 
-	pkt, err := BuildDownlinkNonUEAssociatedNRPPATransport(ue, nRPPaPDU)
+	ranUe.Log.Info("Send Trace Start")
+
+	pkt, err := BuildTraceStart(ranUe)
 	if err != nil {
 		additionalCause = ngap_metrics.NGAP_MSG_BUILD_ERR
-		ue.Log.Errorf("Build DownlinkNonUEAssociatedNRPPATransport failed : %s", err.Error())
+		ranUe.Log.Errorf("Build TraceStart failed : %s", err.Error())
 		return
 	}
 
-	metricsStatus, additionalCause = SendToRanUe(ue, pkt)
+	isTraceStartSent, additionalCause = SendToRanUe(ranUe, pkt)
 }
 
 func SendDeactivateTrace(amfUe *context.AmfUe, anType models.AccessType) {
@@ -1254,10 +1397,7 @@ func SendUETNLABindingReleaseRequest(ue *context.RanUe) {
 	isUETNLABindingRelReqSent, additionalCause = SendToRanUe(ue, pkt)
 }
 
-// Weight Factor associated with each of the TNL association within the AMF
-func SendAMFConfigurationUpdate(ran *context.AmfRan, usage ngapType.TNLAssociationUsage,
-	weightfactor ngapType.TNLAddressWeightFactor,
-) {
+func SendAMFConfigurationUpdate(ran *context.AmfRan) {
 	isAMFConfigurationUpdateSent := false
 	additionalCause := ""
 	defer ngap_metrics.IncrMetricsSentMsg(
@@ -1271,7 +1411,7 @@ func SendAMFConfigurationUpdate(ran *context.AmfRan, usage ngapType.TNLAssociati
 
 	ran.Log.Info("Send AMF Configuration Update")
 
-	pkt, err := BuildAMFConfigurationUpdate(usage, weightfactor)
+	pkt, err := BuildAMFConfigurationUpdate()
 	if err != nil {
 		additionalCause = ngap_metrics.NGAP_MSG_BUILD_ERR
 		ran.Log.Errorf("Build AMFConfigurationUpdate failed : %s", err.Error())
@@ -234,22 +234,14 @@ func readASN1() {
 
 func fixIEs() {
 	// Not implemented IEs
-	MsgTable["AMFConfigurationUpdateAcknowledge"].IEs["id-AMF-TNLAssociationSetupList"].Unimplemented = true
-	MsgTable["AMFConfigurationUpdateAcknowledge"].IEs["id-AMF-TNLAssociationFailedToSetupList"].Unimplemented = true
-	MsgTable["AMFConfigurationUpdateFailure"].IEs["id-TimeToWait"].Unimplemented = true
 	MsgTable["HandoverRequired"].IEs["id-DirectForwardingPathAvailability"].Unimplemented = true
 	MsgTable["InitialUEMessage"].IEs["id-AMFSetID"].Unimplemented = true
 	MsgTable["InitialUEMessage"].IEs["id-AllowedNSSAI"].Unimplemented = true
-	MsgTable["NGSetupRequest"].IEs["id-UERetentionInformation"].Unimplemented = true
-	MsgTable["RANConfigurationUpdate"].IEs["id-RANNodeName"].Unimplemented = true
-	MsgTable["RANConfigurationUpdate"].IEs["id-DefaultPagingDRX"].Unimplemented = true
-	MsgTable["RANConfigurationUpdate"].IEs["id-GlobalRANNodeID"].Unimplemented = true
 	// MsgTable["UERadioCapabilityCheckResponse"].IEs["id-AMF-UE-NGAP-ID"].Unimplemented = true
 	// MsgTable["UERadioCapabilityCheckResponse"].IEs["id-RAN-UE-NGAP-ID"].Unimplemented = true
 	MsgTable["UERadioCapabilityCheckResponse"].IEs["id-IMSVoiceSupportIndicator"].Unimplemented = true
 	MsgTable["UplinkRANConfigurationTransfer"].IEs["id-ENDC-SONConfigurationTransferUL"].Unimplemented = true
 	MsgTable["UplinkRANStatusTransfer"].IEs["id-RANStatusTransfer-TransparentContainer"].Unimplemented = true
-	MsgTable["UplinkUEAssociatedNRPPaTransport"].IEs["id-NRPPa-PDU"].Unimplemented = true
 }
 
 // generate NGAP handler file
@@ -542,13 +534,7 @@ syntaxCause = &ngapType.Cause{
 		fmt.Fprintf(fOut, "handle%sMain(%s)\n", msgName, strings.Join(mainFuncArgs, ","))
 		fmt.Fprintf(fOut, "}\n\n")
 
-		if !isRANtoAMFMessage(msgName) ||
-			msgName == "PWSCancelResponse" || // XXX not implemented
-			msgName == "PWSFailureIndication" || // XXX not implemented
-			msgName == "PWSRestartIndication" || // XXX not implemented
-			msgName == "SecondaryRATDataUsageReport" || // XXX not implemented
-			msgName == "TraceFailureIndication" || // XXX not implemented
-			msgName == "WriteReplaceWarningResponse" { // XXX not implemented
+		if !isRANtoAMFMessage(msgName) {
 			stubCause := "CauseProtocolPresentUnspecified"
 			stubMessage := "not implemented"
 			if isAMFtoRANMessage(msgName) {
@@ -0,0 +1,157 @@
+package ngap
+
+import (
+	"time"
+
+	"github.com/free5gc/amf/internal/context"
+	"github.com/free5gc/amf/internal/logger"
+	ngap_message "github.com/free5gc/amf/internal/ngap/message"
+	"github.com/free5gc/amf/pkg/factory"
+	"github.com/free5gc/aper"
+	"github.com/free5gc/ngap/ngapConvert"
+	"github.com/free5gc/ngap/ngapType"
+)
+
+// The default interval to sample the load of the AMF
+const defaultOverloadControlInterval = time.Second
+
+// Run the overload control of the NG-RAN nodes (TS 23.501 5.19.5.2) until done, the load of the AMF is sampled
+// every interval and the NG-RAN nodes are requested to reduce the signalling load once the AMF is overloaded
+func RunOverloadControl(done <-chan struct{}) {
+	overloadControl := context.GetSelf().OverloadControl
+	if overloadControl == nil || !overloadControl.Enable {
+		return
+	}
+
+	interval := defaultOverloadControlInterval
+	if overloadControl.Interval > 0 {
+		interval = time.Duration(overloadControl.Interval) * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	logger.NgapLog.Infof("Overload control started, sampling interval[%s]", interval)
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			checkOverload(overloadControl, interval)
+		}
+	}
+}
+
+func checkOverload(overloadControl *factory.OverloadControl, interval time.Duration) {
+	amfSelf := context.GetSelf()
+	sample := amfSelf.Load.Sample(interval)
+
+	if !amfSelf.Load.Overloaded() {
+		if !overloadStarted(overloadControl, sample) {
+			return
+		}
+		logger.NgapLog.Warnf("AMF is overloaded: %s", sample)
+		amfSelf.Load.SetOverloaded(true)
+		// the NG-RAN nodes not set up yet are requested once their NG Setup is accepted
+		amfSelf.AmfRanPool.Range(func(key, value interface{}) bool {
+			if ran := value.(*context.AmfRan); ran.RanId != nil {
+				SendOverloadStart(ran)
+			}
+			return true
+		})
+		return
+	}
+
+	if !overloadStopped(overloadControl, sample) {
+		return
+	}
+	logger.NgapLog.Infof("AMF is no longer overloaded: %s", sample)
+	amfSelf.Load.SetOverloaded(false)
+	amfSelf.AmfRanPool.Range(func(key, value interface{}) bool {
+		if ran := value.(*context.AmfRan); ran.RanId != nil {
+			ngap_message.SendOverloadStop(ran)
+		}
+		return true
+	})
+}
+
+// Any load reaches its start threshold
+func overloadStarted(overloadControl *factory.OverloadControl, sample context.LoadSample) bool {
+	return reachThreshold(overloadControl.UeNum, float64(sample.UeNum), true) ||
+		reachThreshold(overloadControl.NgapMessageRate, sample.NgapMessageRate, true) ||
+		reachThreshold(overloadControl.DispatchLatency,
+			float64(sample.DispatchLatency)/float64(time.Millisecond), true) ||
+		reachThreshold(overloadControl.SbiBacklog, float64(sample.SbiBacklog), true)
+}
+
+// All the loads fall below their stop thresholds
+func overloadStopped(overloadControl *factory.OverloadControl, sample context.LoadSample) bool {
+	return !reachThreshold(overloadControl.UeNum, float64(sample.UeNum), false) &&
+		!reachThreshold(overloadControl.NgapMessageRate, sample.NgapMessageRate, false) &&
+		!reachThreshold(overloadControl.DispatchLatency,
+			float64(sample.DispatchLatency)/float64(time.Millisecond), false) &&
+		!reachThreshold(overloadControl.SbiBacklog, float64(sample.SbiBacklog), false)
+}
+
+func reachThreshold(threshold *factory.OverloadThreshold, load float64, start bool) bool {
+	if threshold == nil {
+		return false
+	}
+	if start || threshold.Stop == 0 {
+		return load >= float64(threshold.Start)
+	}
+	return load >= float64(threshold.Stop)
+}
+
+// Send Overload Start with the configured overload response and traffic load reduction to the RAN
+func SendOverloadStart(ran *context.AmfRan) {
+	overloadControl := context.GetSelf().OverloadControl
+	if overloadControl == nil {
+		return
+	}
+
+	var overloadStartNSSAIList *ngapType.OverloadStartNSSAIList
+	if len(overloadControl.SliceOverloadList) > 0 {
+		overloadStartNSSAIList = new(ngapType.OverloadStartNSSAIList)
+		for _, sliceOverload := range overloadControl.SliceOverloadList {
+			var item ngapType.OverloadStartNSSAIItem
+			for _, snssai := range sliceOverload.SnssaiList {
+				item.SliceOverloadList.List = append(item.SliceOverloadList.List, ngapType.SliceOverloadItem{
+					SNSSAI: ngapConvert.SNssaiToNgap(snssai),
+				})
+			}
+			item.SliceOverloadResponse = buildOverloadResponse(sliceOverload.OverloadAction)
+			if sliceOverload.TrafficLoadReduction != 0 {
+				item.SliceTrafficLoadReductionIndication = &ngapType.TrafficLoadReductionIndication{
+					Value: int64(sliceOverload.TrafficLoadReduction),
+				}
+			}
+			overloadStartNSSAIList.List = append(overloadStartNSSAIList.List, item)
+		}
+	}
+
+	ngap_message.SendOverloadStart(ran, buildOverloadResponse(overloadControl.OverloadAction),
+		int64(overloadControl.TrafficLoadReduction), overloadStartNSSAIList)
+}
+
+// The overload response is not sent if the overload action is not configured
+func buildOverloadResponse(overloadAction string) *ngapType.OverloadResponse {
+	var value aper.Enumerated
+	switch overloadAction {
+	case factory.OverloadActionRejectNonEmergencyMoDt:
+		value = ngapType.OverloadActionPresentRejectNonEmergencyMoDt
+	case factory.OverloadActionRejectRrcCrSignalling:
+		value = ngapType.OverloadActionPresentRejectRrcCrSignalling
+	case factory.OverloadActionPermitEmergencyAndMt:
+		value = ngapType.OverloadActionPresentPermitEmergencySessionsAndMobileTerminatedServicesOnly
+	case factory.OverloadActionPermitHighPriorityAndMt:
+		value = ngapType.OverloadActionPresentPermitHighPrioritySessionsAndMobileTerminatedServicesOnly
+	default:
+		return nil
+	}
+	return &ngapType.OverloadResponse{
+		Present: ngapType.OverloadResponsePresentOverloadAction,
+		OverloadAction: &ngapType.OverloadAction{
+			Value: value,
+		},
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"github.com/free5gc/amf/internal/logger"
+	"github.com/free5gc/amf/internal/util"
 	"github.com/free5gc/openapi"
 	"github.com/free5gc/openapi/models"
 	"github.com/free5gc/util/metrics/sbi"
@@ -188,13 +189,14 @@ func (s *Server) HTTPCreateUEContext(c *gin.Context) {
 		return
 	}
 
+	var binaryParts util.NgapBinaryParts
 	contentType := c.GetHeader("Content-Type")
 	str := strings.Split(contentType, ";")
 	switch str[0] {
 	case applicationjson:
 		err = openapi.Deserialize(createUeContextRequest.JsonData, requestBody, contentType)
 	case multipartrelate:
-		err = openapi.Deserialize(&createUeContextRequest, requestBody, contentType)
+		binaryParts, err = util.MultipartRelatedDecode(createUeContextRequest.JsonData, requestBody, contentType)
 	default:
 		err = fmt.Errorf("wrong content type")
 	}
@@ -211,7 +213,7 @@ func (s *Server) HTTPCreateUEContext(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, rsp)
 		return
 	}
-	s.Processor().HandleCreateUEContextRequest(c, createUeContextRequest)
+	s.Processor().HandleCreateUEContextRequest(c, createUeContextRequest, binaryParts)
 }
 
 // EBIAssignment - Namf_Communication EBI Assignment service Operation
@@ -360,11 +362,92 @@ func (s *Server) HTTPUEContextTransfer(c *gin.Context) {
 }
 
 func (s *Server) HTTPRelocateUEContext(c *gin.Context) {
-	c.JSON(http.StatusNotImplemented, gin.H{})
+	var relocateUeContextRequest models.RelocateUeContextRequest
+	relocateUeContextRequest.JsonData = new(models.UeContextRelocateData)
+
+	requestBody, err := c.GetRawData()
+	if err != nil {
+		logger.CommLog.Errorf("Get Request Body error: %+v", err)
+		problemDetail := models.ProblemDetails{
+			Title:  "System failure",
+			Status: http.StatusInternalServerError,
+			Detail: err.Error(),
+			Cause:  "SYSTEM_FAILURE",
+		}
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetail)
+		c.JSON(http.StatusInternalServerError, problemDetail)
+		return
+	}
+
+	var binaryParts util.NgapBinaryParts
+	contentType := c.GetHeader("Content-Type")
+	str := strings.Split(contentType, ";")
+	switch str[0] {
+	case applicationjson:
+		err = openapi.Deserialize(relocateUeContextRequest.JsonData, requestBody, contentType)
+	case multipartrelate:
+		binaryParts, err = util.MultipartRelatedDecode(relocateUeContextRequest.JsonData, requestBody, contentType)
+	default:
+		err = fmt.Errorf("wrong content type")
+	}
+
+	if err != nil {
+		problemDetail := reqbody + err.Error()
+		rsp := models.ProblemDetails{
+			Title:  "Malformed request syntax",
+			Status: http.StatusBadRequest,
+			Detail: problemDetail,
+		}
+		logger.CommLog.Errorln(problemDetail)
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, http.StatusText((http.StatusBadRequest)))
+		c.JSON(http.StatusBadRequest, rsp)
+		return
+	}
+	s.Processor().HandleRelocateUEContextRequest(c, relocateUeContextRequest, binaryParts)
 }
 
 func (s *Server) HTTPCancelRelocateUEContext(c *gin.Context) {
-	c.JSON(http.StatusNotImplemented, gin.H{})
+	var cancelRelocateUeContextRequest models.CancelRelocateUeContextRequest
+	cancelRelocateUeContextRequest.JsonData = new(models.UeContextCancelRelocateData)
+
+	requestBody, err := c.GetRawData()
+	if err != nil {
+		logger.CommLog.Errorf("Get Request Body error: %+v", err)
+		problemDetail := models.ProblemDetails{
+			Title:  "System failure",
+			Status: http.StatusInternalServerError,
+			Detail: err.Error(),
+			Cause:  "SYSTEM_FAILURE",
+		}
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetail)
+		c.JSON(http.StatusInternalServerError, problemDetail)
+		return
+	}
+
+	contentType := c.GetHeader("Content-Type")
+	str := strings.Split(contentType, ";")
+	switch str[0] {
+	case applicationjson:
+		err = openapi.Deserialize(cancelRelocateUeContextRequest.JsonData, requestBody, contentType)
+	case multipartrelate:
+		_, err = util.MultipartRelatedDecode(cancelRelocateUeContextRequest.JsonData, requestBody, contentType)
+	default:
+		err = fmt.Errorf("wrong content type")
+	}
+
+	if err != nil {
+		problemDetail := reqbody + err.Error()
+		rsp := models.ProblemDetails{
+			Title:  "Malformed request syntax",
+			Status: http.StatusBadRequest,
+			Detail: problemDetail,
+		}
+		logger.CommLog.Errorln(problemDetail)
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, http.StatusText((http.StatusBadRequest)))
+		c.JSON(http.StatusBadRequest, rsp)
+		return
+	}
+	s.Processor().HandleCancelRelocateUEContextRequest(c, cancelRelocateUeContextRequest)
 }
 
 func (s *Server) HTTPN1N2MessageUnSubscribe(c *gin.Context) {
@@ -453,18 +536,120 @@ func (s *Server) HTTPN1N2MessageSubscribe(c *gin.Context) {
 }
 
 func (s *Server) HTTPNonUeN2InfoUnSubscribe(c *gin.Context) {
-	logger.CommLog.Warnf("Handle Non Ue N2 Info UnSubscribe is not implemented.")
-	c.JSON(http.StatusNotImplemented, gin.H{})
+	s.Processor().HandleNonUeN2InfoUnSubscribeRequest(c)
 }
 
 func (s *Server) HTTPNonUeN2MessageTransfer(c *gin.Context) {
-	logger.CommLog.Warnf("Handle Non Ue N2 Message Transfer is not implemented.")
-	c.JSON(http.StatusNotImplemented, gin.H{})
+	var nonUeN2MessageTransferRequest models.NonUeN2MessageTransferRequest
+	nonUeN2MessageTransferRequest.JsonData = new(models.N2InformationTransferReqData)
+
+	requestBody, err := c.GetRawData()
+	if err != nil {
+		logger.CommLog.Errorf("Get Request Body error: %+v", err)
+		problemDetail := models.ProblemDetails{
+			Title:  "System failure",
+			Status: http.StatusInternalServerError,
+			Detail: err.Error(),
+			Cause:  "SYSTEM_FAILURE",
+		}
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetail)
+		c.JSON(http.StatusInternalServerError, problemDetail)
+		return
+	}
+
+	contentType := c.GetHeader("Content-Type")
+	str := strings.Split(contentType, ";")
+	switch str[0] {
+	case applicationjson:
+		err = fmt.Errorf("N2 information is missing in NonUeN2MessageTransfer")
+	case multipartrelate:
+		var binaryParts util.NgapBinaryParts
+		binaryParts, err = util.MultipartRelatedDecode(nonUeN2MessageTransferRequest.JsonData, requestBody, contentType)
+		if err == nil {
+			nonUeN2MessageTransferRequest.BinaryDataN2Information = n2InfoContainerBinaryData(
+				nonUeN2MessageTransferRequest.JsonData.N2Information, binaryParts)
+		}
+	default:
+		err = fmt.Errorf("wrong content type")
+	}
+
+	if err != nil {
+		problemDetail := reqbody + err.Error()
+		rsp := models.ProblemDetails{
+			Title:  "Malformed request syntax",
+			Status: http.StatusBadRequest,
+			Detail: problemDetail,
+		}
+		logger.CommLog.Errorln(problemDetail)
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, http.StatusText(http.StatusBadRequest))
+		c.JSON(http.StatusBadRequest, rsp)
+		return
+	}
+	s.Processor().HandleNonUeN2MessageTransferRequest(c, nonUeN2MessageTransferRequest)
+}
+
+// The binary part of N2InfoContainer is referenced by the N2 information of its class
+func n2InfoContainerBinaryData(n2InfoContainer *models.N2InfoContainer, binaryParts util.NgapBinaryParts) []byte {
+	if n2InfoContainer == nil {
+		return nil
+	}
+	var n2InfoContent *models.N2InfoContent
+	switch n2InfoContainer.N2InformationClass {
+	case models.N2InformationClass_PWS, models.N2InformationClass_PWS_BCAL, models.N2InformationClass_PWS_RF:
+		if n2InfoContainer.PwsInfo != nil {
+			n2InfoContent = n2InfoContainer.PwsInfo.PwsContainer
+		}
+	case models.N2InformationClass_NRP_PA:
+		if n2InfoContainer.NrppaInfo != nil {
+			n2InfoContent = n2InfoContainer.NrppaInfo.NrppaPdu
+		}
+	case models.N2InformationClass_RAN:
+		if n2InfoContainer.RanInfo != nil {
+			n2InfoContent = n2InfoContainer.RanInfo.N2InfoContent
+		}
+	case models.N2InformationClass_SM:
+		if n2InfoContainer.SmInfo != nil {
+			n2InfoContent = n2InfoContainer.SmInfo.N2InfoContent
+		}
+	}
+	ngapData, err := binaryParts.NgapData(n2InfoContent)
+	if err != nil {
+		return nil
+	}
+	return ngapData
 }
 
 func (s *Server) HTTPNonUeN2InfoSubscribe(c *gin.Context) {
-	logger.CommLog.Warnf("Handle Non Ue N2 Info Subscribe is not implemented.")
-	c.JSON(http.StatusNotImplemented, gin.H{})
+	var nonUeN2InfoSubscriptionCreateData models.NonUeN2InfoSubscriptionCreateData
+
+	requestBody, err := c.GetRawData()
+	if err != nil {
+		logger.CommLog.Errorf("Get Request Body error: %+v", err)
+		problemDetail := models.ProblemDetails{
+			Title:  "System failure",
+			Status: http.StatusInternalServerError,
+			Detail: err.Error(),
+			Cause:  "SYSTEM_FAILURE",
+		}
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetail.Cause)
+		c.JSON(http.StatusInternalServerError, problemDetail)
+		return
+	}
+
+	err = openapi.Deserialize(&nonUeN2InfoSubscriptionCreateData, requestBody, applicationjson)
+	if err != nil {
+		problemDetail := reqbody + err.Error()
+		rsp := models.ProblemDetails{
+			Title:  "Malformed request syntax",
+			Status: http.StatusBadRequest,
+			Detail: problemDetail,
+		}
+		logger.CommLog.Errorln(problemDetail)
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, http.StatusText(http.StatusBadRequest))
+		c.JSON(http.StatusBadRequest, rsp)
+		return
+	}
+	s.Processor().HandleNonUeN2InfoSubscribeRequest(c, nonUeN2InfoSubscriptionCreateData)
 }
 
 func (s *Server) HTTPAMFStatusChangeSubscribe(c *gin.Context) {
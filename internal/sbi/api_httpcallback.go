@@ -51,6 +51,30 @@ func (s *Server) getHttpCallBackRoutes() []Route {
 			Pattern: "/deregistration/:ueid",
 			APIFunc: s.HTTPHandleDeregistrationNotification,
 		},
+		{
+			Name:    "N2InfoNotifyHandoverComplete",
+			Method:  http.MethodPost,
+			Pattern: "/handover-complete/:ueContextId",
+			APIFunc: s.HTTPN2InfoNotifyHandoverComplete,
+		},
+		{
+			Name:    "SdmDataChangeNotification",
+			Method:  http.MethodPost,
+			Pattern: "/sdm-change/:supi",
+			APIFunc: s.HTTPSdmDataChangeNotification,
+		},
+		{
+			Name:    "NssaaReauthNotification",
+			Method:  http.MethodPost,
+			Pattern: "/nssaa-reauth/:supi",
+			APIFunc: s.HTTPNssaaNotification,
+		},
+		{
+			Name:    "NssaaRevocationNotification",
+			Method:  http.MethodPost,
+			Pattern: "/nssaa-revocation/:supi",
+			APIFunc: s.HTTPNssaaNotification,
+		},
 	}
 }
 
@@ -140,6 +164,107 @@ func (s *Server) HTTPN1MessageNotify(c *gin.Context) {
 	s.Processor().HandleN1MessageNotify(c, n1MessageNotify)
 }
 
+func (s *Server) HTTPN2InfoNotifyHandoverComplete(c *gin.Context) {
+	var n2InformationNotification models.N2InformationNotification
+
+	requestBody, err := c.GetRawData()
+	if err != nil {
+		logger.CallbackLog.Errorf("Get Request Body error: %+v", err)
+		problemDetail := models.ProblemDetails{
+			Title:  "System failure",
+			Status: http.StatusInternalServerError,
+			Detail: err.Error(),
+			Cause:  "SYSTEM_FAILURE",
+		}
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetail.Cause)
+		c.JSON(http.StatusInternalServerError, problemDetail)
+		return
+	}
+
+	err = openapi.Deserialize(&n2InformationNotification, requestBody, "application/json")
+	if err != nil {
+		problemDetail := reqbody + err.Error()
+		rsp := models.ProblemDetails{
+			Title:  "Malformed request syntax",
+			Status: http.StatusBadRequest,
+			Detail: problemDetail,
+		}
+		logger.CallbackLog.Errorln(problemDetail)
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, http.StatusText(http.StatusBadRequest))
+		c.JSON(http.StatusBadRequest, rsp)
+		return
+	}
+	s.Processor().HandleN2InfoNotifyHandoverComplete(c, n2InformationNotification)
+}
+
+// TS 29.503 5.2.2.3.2
+func (s *Server) HTTPSdmDataChangeNotification(c *gin.Context) {
+	var modificationNotification models.ModificationNotification
+
+	requestBody, err := c.GetRawData()
+	if err != nil {
+		logger.CallbackLog.Errorf("Get Request Body error: %+v", err)
+		problemDetail := models.ProblemDetails{
+			Title:  "System failure",
+			Status: http.StatusInternalServerError,
+			Detail: err.Error(),
+			Cause:  "SYSTEM_FAILURE",
+		}
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetail.Cause)
+		c.JSON(http.StatusInternalServerError, problemDetail)
+		return
+	}
+
+	err = openapi.Deserialize(&modificationNotification, requestBody, "application/json")
+	if err != nil {
+		problemDetail := reqbody + err.Error()
+		rsp := models.ProblemDetails{
+			Title:  "Malformed request syntax",
+			Status: http.StatusBadRequest,
+			Detail: problemDetail,
+		}
+		logger.CallbackLog.Errorln(problemDetail)
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, http.StatusText(http.StatusBadRequest))
+		c.JSON(http.StatusBadRequest, rsp)
+		return
+	}
+	s.Processor().HandleSdmDataChangeNotification(c, modificationNotification)
+}
+
+// TS 29.526 5.2.2.3 and 5.2.2.4
+func (s *Server) HTTPNssaaNotification(c *gin.Context) {
+	var nssaaNotification amf_context.NssaaNotification
+
+	requestBody, err := c.GetRawData()
+	if err != nil {
+		logger.CallbackLog.Errorf("Get Request Body error: %+v", err)
+		problemDetail := models.ProblemDetails{
+			Title:  "System failure",
+			Status: http.StatusInternalServerError,
+			Detail: err.Error(),
+			Cause:  "SYSTEM_FAILURE",
+		}
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetail.Cause)
+		c.JSON(http.StatusInternalServerError, problemDetail)
+		return
+	}
+
+	err = openapi.Deserialize(&nssaaNotification, requestBody, "application/json")
+	if err != nil {
+		problemDetail := reqbody + err.Error()
+		rsp := models.ProblemDetails{
+			Title:  "Malformed request syntax",
+			Status: http.StatusBadRequest,
+			Detail: problemDetail,
+		}
+		logger.CallbackLog.Errorln(problemDetail)
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, http.StatusText(http.StatusBadRequest))
+		c.JSON(http.StatusBadRequest, rsp)
+		return
+	}
+	s.Processor().HandleNssaaNotification(c, nssaaNotification)
+}
+
 func (s *Server) HTTPSmContextStatusNotify(c *gin.Context) {
 	var smContextStatusNotification models.SmfPduSessionSmContextStatusNotification
 
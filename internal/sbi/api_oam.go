@@ -4,6 +4,12 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+
+	"github.com/free5gc/amf/internal/logger"
+	"github.com/free5gc/amf/internal/sbi/processor"
+	"github.com/free5gc/openapi"
+	"github.com/free5gc/openapi/models"
+	"github.com/free5gc/util/metrics/sbi"
 )
 
 func (s *Server) getOAMRoutes() []Route {
@@ -27,6 +33,30 @@ func (s *Server) getOAMRoutes() []Route {
 			Pattern: "/registered-ue-context/:supi",
 			APIFunc: s.HTTPRegisteredUEContext,
 		},
+		{
+			Name:    "ActivateTrace",
+			Method:  http.MethodPut,
+			Pattern: "/trace/:supi",
+			APIFunc: s.HTTPActivateTrace,
+		},
+		{
+			Name:    "DeactivateTrace",
+			Method:  http.MethodDelete,
+			Pattern: "/trace/:supi",
+			APIFunc: s.HTTPDeactivateTrace,
+		},
+		{
+			Name:    "GetAmfConfiguration",
+			Method:  http.MethodGet,
+			Pattern: "/amf-configuration",
+			APIFunc: s.HTTPGetAmfConfiguration,
+		},
+		{
+			Name:    "UpdateAmfConfiguration",
+			Method:  http.MethodPut,
+			Pattern: "/amf-configuration",
+			APIFunc: s.HTTPUpdateAmfConfiguration,
+		},
 	}
 }
 
@@ -43,3 +73,85 @@ func (s *Server) HTTPRegisteredUEContext(c *gin.Context) {
 	s.setCorsHeader(c)
 	s.Processor().HandleOAMRegisteredUEContext(c)
 }
+
+func (s *Server) HTTPActivateTrace(c *gin.Context) {
+	s.setCorsHeader(c)
+
+	var traceData models.TraceData
+
+	requestBody, err := c.GetRawData()
+	if err != nil {
+		logger.ProducerLog.Errorf("Get Request Body error: %+v", err)
+		problemDetail := models.ProblemDetails{
+			Title:  "System failure",
+			Status: http.StatusInternalServerError,
+			Detail: err.Error(),
+			Cause:  "SYSTEM_FAILURE",
+		}
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetail.Cause)
+		c.JSON(http.StatusInternalServerError, problemDetail)
+		return
+	}
+
+	err = openapi.Deserialize(&traceData, requestBody, "application/json")
+	if err != nil {
+		problemDetail := reqbody + err.Error()
+		rsp := models.ProblemDetails{
+			Title:  "Malformed request syntax",
+			Status: http.StatusBadRequest,
+			Detail: problemDetail,
+		}
+		logger.ProducerLog.Errorln(problemDetail)
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, http.StatusText(http.StatusBadRequest))
+		c.JSON(http.StatusBadRequest, rsp)
+		return
+	}
+
+	s.Processor().HandleOAMActivateTrace(c, traceData)
+}
+
+func (s *Server) HTTPDeactivateTrace(c *gin.Context) {
+	s.setCorsHeader(c)
+	s.Processor().HandleOAMDeactivateTrace(c)
+}
+
+func (s *Server) HTTPGetAmfConfiguration(c *gin.Context) {
+	s.setCorsHeader(c)
+	s.Processor().HandleOAMGetAmfConfiguration(c)
+}
+
+func (s *Server) HTTPUpdateAmfConfiguration(c *gin.Context) {
+	s.setCorsHeader(c)
+
+	var amfConfiguration processor.AmfConfiguration
+
+	requestBody, err := c.GetRawData()
+	if err != nil {
+		logger.ProducerLog.Errorf("Get Request Body error: %+v", err)
+		problemDetail := models.ProblemDetails{
+			Title:  "System failure",
+			Status: http.StatusInternalServerError,
+			Detail: err.Error(),
+			Cause:  "SYSTEM_FAILURE",
+		}
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetail.Cause)
+		c.JSON(http.StatusInternalServerError, problemDetail)
+		return
+	}
+
+	err = openapi.Deserialize(&amfConfiguration, requestBody, "application/json")
+	if err != nil {
+		problemDetail := reqbody + err.Error()
+		rsp := models.ProblemDetails{
+			Title:  "Malformed request syntax",
+			Status: http.StatusBadRequest,
+			Detail: problemDetail,
+		}
+		logger.ProducerLog.Errorln(problemDetail)
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, http.StatusText(http.StatusBadRequest))
+		c.JSON(http.StatusBadRequest, rsp)
+		return
+	}
+
+	s.Processor().HandleOAMUpdateAmfConfiguration(c, amfConfiguration)
+}
@@ -1,12 +1,21 @@
 package consumer
 
 import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
 	"sync"
 
 	amf_context "github.com/free5gc/amf/internal/context"
 	"github.com/free5gc/amf/internal/logger"
+	"github.com/free5gc/amf/internal/util"
 	"github.com/free5gc/nas/nasMessage"
+	"github.com/free5gc/nas/security"
 	"github.com/free5gc/openapi"
 	Namf_Communication "github.com/free5gc/openapi/amf/Communication"
 	"github.com/free5gc/openapi/models"
@@ -46,11 +55,16 @@ func (s *namfService) getComClient(uri string) *Namf_Communication.APIClient {
 
 func (s *namfService) BuildUeContextCreateData(ue *amf_context.AmfUe, targetRanId models.NgRanTargetId,
 	sourceToTargetData models.N2InfoContent, pduSessionList []models.N2SmInformation,
-	n2NotifyUri string, ngapCause *models.NgApCause,
+	n2NotifyUri string, ngapCause *models.NgApCause, binaryParts util.NgapBinaryParts,
 ) models.UeContextCreateData {
 	var ueContextCreateData models.UeContextCreateData
 
 	ueContext := s.BuildUeContextModel(ue)
+	// TS 23.502 4.9.1.3.2 step 3, target AMF continues the handover with the security context
+	// and the SM contexts of source AMF
+	ueContext.SeafData = s.buildSeafData(ue)
+	ueContext.MmContextList = append(ueContext.MmContextList, s.buildMmContext(ue, models.AccessType__3_GPP_ACCESS))
+	ueContext.SessionContextList = s.buildSessionContextList(ue)
 	ueContextCreateData.UeContext = &ueContext
 	ueContextCreateData.TargetId = &targetRanId
 	ueContextCreateData.SourceToTargetData = &sourceToTargetData
@@ -58,10 +72,11 @@ func (s *namfService) BuildUeContextCreateData(ue *amf_context.AmfUe, targetRanI
 	ueContextCreateData.N2NotifyUri = n2NotifyUri
 
 	if ue.UeRadioCapability != "" {
-		ueContextCreateData.UeRadioCapability = &models.N2InfoContent{
-			NgapData: &models.RefToBinaryData{
-				ContentId: ue.UeRadioCapability,
-			},
+		if ueRadioCapability, err := hex.DecodeString(ue.UeRadioCapability); err != nil {
+			logger.ConsumerLog.Warnf("Decode UE Radio Capability error: %+v", err)
+		} else {
+			ueContextCreateData.UeRadioCapability = binaryParts.N2InfoContent(
+				models.AmfCommunicationNgapIeType_UE_RADIO_CAPABILITY, ueRadioCapability)
 		}
 	}
 	ueContextCreateData.NgapCause = ngapCause
@@ -130,6 +145,87 @@ func (s *namfService) BuildUeContextModel(ue *amf_context.AmfUe) (ueContext mode
 	return ueContext
 }
 
+func (s *namfService) buildSeafData(ue *amf_context.AmfUe) *models.SeafData {
+	seafData := &models.SeafData{
+		NgKsi: &models.NgKsi{
+			Tsc: ue.NgKsi.Tsc,
+			Ksi: ue.NgKsi.Ksi,
+		},
+		KeyAmf: &models.KeyAmf{
+			KeyType: models.KeyAmfType_KAMF,
+			KeyVal:  ue.Kamf,
+		},
+		Ncc: int32(ue.NCC),
+	}
+	if ue.NH != nil {
+		seafData.Nh = hex.EncodeToString(ue.NH)
+	}
+	return seafData
+}
+
+func (s *namfService) buildMmContext(ue *amf_context.AmfUe, anType models.AccessType) models.MmContext {
+	mmContext := models.MmContext{
+		AccessType:       anType,
+		NasSecurityMode:  new(models.NasSecurityMode),
+		NasDownlinkCount: int32(ue.DLCount.Get()),
+		NasUplinkCount:   int32(ue.ULCount.Get()),
+	}
+
+	switch ue.IntegrityAlg {
+	case security.AlgIntegrity128NIA0:
+		mmContext.NasSecurityMode.IntegrityAlgorithm = models.IntegrityAlgorithm_NIA0
+	case security.AlgIntegrity128NIA1:
+		mmContext.NasSecurityMode.IntegrityAlgorithm = models.IntegrityAlgorithm_NIA1
+	case security.AlgIntegrity128NIA2:
+		mmContext.NasSecurityMode.IntegrityAlgorithm = models.IntegrityAlgorithm_NIA2
+	case security.AlgIntegrity128NIA3:
+		mmContext.NasSecurityMode.IntegrityAlgorithm = models.IntegrityAlgorithm_NIA3
+	}
+	switch ue.CipheringAlg {
+	case security.AlgCiphering128NEA0:
+		mmContext.NasSecurityMode.CipheringAlgorithm = models.CipheringAlgorithm_NEA0
+	case security.AlgCiphering128NEA1:
+		mmContext.NasSecurityMode.CipheringAlgorithm = models.CipheringAlgorithm_NEA1
+	case security.AlgCiphering128NEA2:
+		mmContext.NasSecurityMode.CipheringAlgorithm = models.CipheringAlgorithm_NEA2
+	case security.AlgCiphering128NEA3:
+		mmContext.NasSecurityMode.CipheringAlgorithm = models.CipheringAlgorithm_NEA3
+	}
+
+	if ue.UESecurityCapability.Buffer != nil {
+		mmContext.UeSecurityCapability = base64.StdEncoding.EncodeToString(ue.UESecurityCapability.Buffer)
+	}
+	for _, allowedSnssai := range ue.AllowedNssai[anType] {
+		mmContext.AllowedNssai = append(mmContext.AllowedNssai, *allowedSnssai.AllowedSnssai)
+	}
+	mmContext.NssaaStatusList = ue.NssaaStatusList
+	return mmContext
+}
+
+func (s *namfService) buildSessionContextList(ue *amf_context.AmfUe) (sessionContextList []models.PduSessionContext) {
+	ue.SmContextList.Range(func(key, value interface{}) bool {
+		smContext := value.(*amf_context.SmContext)
+		snssai := smContext.Snssai()
+		// the SMF is the H-SMF in non-roaming scenario
+		hSmfID := smContext.HSmfID()
+		if hSmfID == "" {
+			hSmfID = smContext.SmfID()
+		}
+		sessionContextList = append(sessionContextList, models.PduSessionContext{
+			PduSessionId: smContext.PduSessionID(),
+			SmContextRef: smContext.SmContextRef(),
+			SNssai:       &snssai,
+			Dnn:          smContext.Dnn(),
+			AccessType:   smContext.AccessType(),
+			HsmfId:       hSmfID,
+			VsmfId:       smContext.VSmfID(),
+			NsInstance:   smContext.NsInstance(),
+		})
+		return true
+	})
+	return
+}
+
 func (s *namfService) buildAmPolicyReqTriggers(
 	triggers []models.PcfAmPolicyControlRequestTrigger,
 ) (amPolicyReqTriggers []models.PolicyReqTrigger) {
@@ -152,39 +248,90 @@ func (s *namfService) buildAmPolicyReqTriggers(
 	return
 }
 
-func (s *namfService) CreateUEContextRequest(ue *amf_context.AmfUe, ueContextCreateData models.UeContextCreateData) (
-	ueContextCreatedData *models.UeContextCreatedData, problemDetails *models.ProblemDetails, err error,
+// The NGAP data of the N2 information is carried in the binary parts of the multipart/related body, which the
+// generated Namf_Communication client can not encode for the PDU session list, the request is built with the openapi
+// helpers instead (TS 29.518 5.3.2.2.2)
+func (s *namfService) CreateUEContextRequest(ue *amf_context.AmfUe, ueContextCreateData models.UeContextCreateData,
+	binaryParts util.NgapBinaryParts,
+) (ueContextCreatedData *models.UeContextCreatedData, createdBinaryParts util.NgapBinaryParts,
+	problemDetails *models.ProblemDetails, err error,
 ) {
-	client := s.getComClient(ue.TargetAmfUri)
-	if client == nil {
-		return nil, nil, openapi.ReportError("amf not found")
+	if ue.TargetAmfUri == "" {
+		return nil, nil, nil, openapi.ReportError("amf not found")
 	}
+	cfg := Namf_Communication.NewConfiguration()
+	cfg.SetBasePath(ue.TargetAmfUri)
+	cfg.SetMetrics(sbi_metrics.SbiMetricHook)
 
-	req := models.CreateUeContextRequest{
-		JsonData: &ueContextCreateData,
+	body, contentType, err := util.MultipartRelatedEncode(&ueContextCreateData, binaryParts)
+	if err != nil {
+		return nil, nil, nil, err
 	}
 	ctx, _, err := amf_context.GetSelf().GetTokenCtx(models.ServiceName_NAMF_COMM, models.NrfNfManagementNfType_AMF)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
-	creatuectxreq := Namf_Communication.CreateUEContextRequest{
-		UeContextId:            &ue.Supi,
-		CreateUeContextRequest: &req,
+	headerParams := map[string]string{
+		"Accept": "application/json, multipart/related, application/problem+json",
 	}
+	req, err := openapi.PrepareRequest(ctx, cfg, cfg.BasePath()+"/ue-contexts/"+url.PathEscape(ue.Supi),
+		http.MethodPut, nil, headerParams, url.Values{}, url.Values{}, "", "", nil)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	// the multipart body is set here, openapi.PrepareRequest would encode it again
+	req.Header.Set("Content-Type", contentType)
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
 
-	res, localErr := client.IndividualUeContextDocumentApi.CreateUEContext(ctx, &creatuectxreq)
-	if localErr == nil {
-		ueContextCreatedData = res.CreateUeContextResponse201.JsonData
+	defer countSbiRequest()()
+	rsp, err := openapi.CallAPI(cfg, req)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	rspData, err := io.ReadAll(rsp.Body)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if err = rsp.Body.Close(); err != nil {
+		return nil, nil, nil, err
+	}
+
+	rspContentType := rsp.Header.Get("Content-Type")
+	switch {
+	case rsp.StatusCode == http.StatusCreated:
+		ueContextCreatedData = new(models.UeContextCreatedData)
+		createdBinaryParts, err = util.MultipartRelatedDecode(ueContextCreatedData, rspData, rspContentType)
+		if err != nil {
+			return nil, nil, nil, err
+		}
 		logger.ConsumerLog.Debugf("UeContextCreatedData: %+v", *ueContextCreatedData)
-	} else {
-		if apiErr, ok := localErr.(openapi.GenericOpenAPIError); ok {
-			creatErr := apiErr.Model().(*Namf_Communication.CreateUEContextError)
-			return nil, &creatErr.ProblemDetails, nil
+		return ueContextCreatedData, createdBinaryParts, nil, nil
+	case rsp.StatusCode == http.StatusForbidden:
+		var ueContextCreateError models.UeContextCreateError
+		if strings.HasPrefix(rspContentType, "multipart/related") {
+			_, err = util.MultipartRelatedDecode(&ueContextCreateError, rspData, rspContentType)
+		} else {
+			err = openapi.Deserialize(&ueContextCreateError, rspData, rspContentType)
+		}
+		if err == nil && ueContextCreateError.Error != nil {
+			return nil, nil, ueContextCreateError.Error, nil
+		}
+	case rsp.StatusCode < http.StatusBadRequest:
+		return nil, nil, nil, openapi.ReportError("unexpected status code: %d", rsp.StatusCode)
+	}
+
+	problemDetails = &models.ProblemDetails{
+		Status: int32(rsp.StatusCode),
+		Cause:  http.StatusText(rsp.StatusCode),
+	}
+	if strings.Contains(rspContentType, "json") && len(rspData) > 0 {
+		if err = openapi.Deserialize(problemDetails, rspData, rspContentType); err != nil {
+			return nil, nil, nil, err
 		}
-		return nil, nil, localErr
 	}
-	return ueContextCreatedData, problemDetails, err
+	return nil, nil, problemDetails, nil
 }
 
 func (s *namfService) ReleaseUEContextRequest(ue *amf_context.AmfUe, ngapCause models.NgApCause) (
@@ -219,6 +366,7 @@ func (s *namfService) ReleaseUEContextRequest(ue *amf_context.AmfUe, ngapCause m
 		UeContextRelease: &ueContextRelease,
 	}
 
+	defer countSbiRequest()()
 	_, err = client.IndividualUeContextDocumentApi.ReleaseUEContext(
 		ctx, &ueCtxReleaseReq)
 	if err != nil {
@@ -282,6 +430,7 @@ func (s *namfService) UEContextTransferRequest(
 		UeContextTransferRequest: &req,
 	}
 
+	defer countSbiRequest()()
 	res, localErr := client.IndividualUeContextDocumentApi.UEContextTransfer(ctx, &ueCtxTransferReq)
 	if localErr == nil {
 		ueContextTransferRspData = res.UeContextTransferResponse200.JsonData
@@ -327,6 +476,7 @@ func (s *namfService) RegistrationStatusUpdate(ue *amf_context.AmfUe, request mo
 		UeRegStatusUpdateReqData: &request,
 	}
 
+	defer countSbiRequest()()
 	res, localErr := client.IndividualUeContextDocumentApi.
 		RegistrationStatusUpdate(ctx, &regStatusUpdateReq)
 	if localErr == nil {
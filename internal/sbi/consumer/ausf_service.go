@@ -57,7 +57,7 @@ func (s *nausfService) SendUEAuthenticationAuthenticateRequest(ue *amf_context.A
 	}
 
 	amfSelf := amf_context.GetSelf()
-	servedGuami := amfSelf.ServedGuamiList[0]
+	servedGuami := amfSelf.GetServedGuamiList()[0]
 
 	var authInfo models.AuthenticationInfo
 	authInfo.SupiOrSuci = ue.Suci
@@ -78,6 +78,7 @@ func (s *nausfService) SendUEAuthenticationAuthenticateRequest(ue *amf_context.A
 		AuthenticationInfo: &authInfo,
 	}
 
+	defer countSbiRequest()()
 	res, localErr := client.DefaultApi.UeAuthenticationsPost(ctx, &authReq)
 	if localErr == nil {
 		return &res.UeAuthenticationCtx, nil, nil
@@ -142,6 +143,7 @@ func (s *nausfService) SendAuth5gAkaConfirmRequest(ue *amf_context.AmfUe, resSta
 			ResStar: resStar,
 		},
 	}
+	defer countSbiRequest()()
 	confirmResult, localErr := client.DefaultApi.UeAuthenticationsAuthCtxId5gAkaConfirmationPut(
 		ctx, confirmData)
 	if localErr == nil {
@@ -206,6 +208,7 @@ func (s *nausfService) SendEapAuthConfirmRequest(ue *amf_context.AmfUe, eapMsg n
 		return nil, nil, err
 	}
 
+	defer countSbiRequest()()
 	eapSession, localErr := client.DefaultApi.EapAuthMethod(ctx, &eapSessionReq)
 
 	if localErr == nil {
@@ -1,9 +1,11 @@
 package consumer
 
 import (
+	amf_context "github.com/free5gc/amf/internal/context"
 	"github.com/free5gc/amf/pkg/app"
 	Namf_Communication "github.com/free5gc/openapi/amf/Communication"
 	Nausf_UEAuthentication "github.com/free5gc/openapi/ausf/UEAuthentication"
+	Nlmf_Location "github.com/free5gc/openapi/lmf/Location"
 	Nnrf_NFDiscovery "github.com/free5gc/openapi/nrf/NFDiscovery"
 	Nnrf_NFManagement "github.com/free5gc/openapi/nrf/NFManagement"
 	Nnssf_NSSelection "github.com/free5gc/openapi/nssf/NSSelection"
@@ -30,6 +32,10 @@ type Consumer struct {
 	*nsmfService
 	*nudmService
 	*nausfService
+	*nlmfService
+	*nsmsfService
+	*nnssaafService
+	*tceService
 }
 
 func GetConsumer() *Consumer {
@@ -77,6 +83,29 @@ func NewConsumer(amf ConsumerAmf) (*Consumer, error) {
 		consumer:                c,
 		UEAuthenticationClients: make(map[string]*Nausf_UEAuthentication.APIClient),
 	}
+
+	c.nlmfService = &nlmfService{
+		consumer:        c,
+		LocationClients: make(map[string]*Nlmf_Location.APIClient),
+	}
+	c.nsmsfService = &nsmsfService{
+		consumer: c,
+	}
+	c.nnssaafService = &nnssaafService{
+		consumer: c,
+	}
+	c.tceService = &tceService{
+		consumer:     c,
+		traceReports: make(chan *amf_context.TraceReport, traceReportQueueSize),
+	}
 	consumer = c
 	return c, nil
 }
+
+// Count the consumer request in the SBI backlog of the AMF load, the returned func removes it when the request is
+// completed, e.g. defer countSbiRequest()()
+func countSbiRequest() func() {
+	load := &amf_context.GetSelf().Load
+	load.SbiRequestStarted()
+	return load.SbiRequestCompleted
+}
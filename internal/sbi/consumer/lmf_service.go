@@ -0,0 +1,157 @@
+package consumer
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	amf_context "github.com/free5gc/amf/internal/context"
+	"github.com/free5gc/amf/internal/util"
+	"github.com/free5gc/openapi"
+	Nlmf_Location "github.com/free5gc/openapi/lmf/Location"
+	"github.com/free5gc/openapi/models"
+	Nnrf_NFDiscovery "github.com/free5gc/openapi/nrf/NFDiscovery"
+	sbi_metrics "github.com/free5gc/util/metrics/sbi"
+)
+
+type nlmfService struct {
+	consumer *Consumer
+
+	LocationMu sync.RWMutex
+
+	LocationClients map[string]*Nlmf_Location.APIClient
+}
+
+func (s *nlmfService) getLocationClient(uri string) *Nlmf_Location.APIClient {
+	if uri == "" {
+		return nil
+	}
+	s.LocationMu.RLock()
+	client, ok := s.LocationClients[uri]
+	if ok {
+		s.LocationMu.RUnlock()
+		return client
+	}
+
+	configuration := Nlmf_Location.NewConfiguration()
+	configuration.SetBasePath(uri)
+	configuration.SetMetrics(sbi_metrics.SbiMetricHook)
+	client = Nlmf_Location.NewAPIClient(configuration)
+
+	s.LocationMu.RUnlock()
+	s.LocationMu.Lock()
+	defer s.LocationMu.Unlock()
+	s.LocationClients[uri] = client
+	return client
+}
+
+// Select the LMF serving the location request, the LMF instance is specified by lmfID if not empty
+func (s *nlmfService) SelectLmf(ue *amf_context.AmfUe, lmfID string) (string, string, error) {
+	param := Nnrf_NFDiscovery.SearchNFInstancesRequest{
+		ServiceNames: []models.ServiceName{models.ServiceName_NLMF_LOC},
+	}
+	if lmfID != "" {
+		param.TargetNfInstanceId = &lmfID
+	}
+	if ue.PlmnId.Mcc != "" {
+		param.TargetPlmnList = append(param.TargetPlmnList, ue.PlmnId)
+	}
+
+	result, err := s.consumer.SendSearchNFInstances(ue.ServingAMF().NrfUri, models.NrfNfManagementNfType_LMF,
+		models.NrfNfManagementNfType_AMF, &param)
+	if err != nil {
+		return "", "", err
+	}
+
+	// select the first LMF, TODO: select base on other info
+	for index := range result.NfInstances {
+		lmfUri := util.SearchNFServiceUri(&result.NfInstances[index], models.ServiceName_NLMF_LOC,
+			models.NfServiceStatus_REGISTERED)
+		if lmfUri != "" {
+			return result.NfInstances[index].NfInstanceId, lmfUri, nil
+		}
+	}
+	return "", "", fmt.Errorf("AMF can not select an LMF by NRF")
+}
+
+// The location determination is aborted when ctx is cancelled, e.g. by the cancel location from the GMLC
+func (s *nlmfService) DetermineLocation(ctx context.Context, lmfUri string,
+	inputData *models.LmfLocationInputData,
+) (*models.LmfLocationLocationData, *models.ProblemDetails, error) {
+	client := s.getLocationClient(lmfUri)
+	if client == nil {
+		return nil, nil, openapi.ReportError("lmf not found")
+	}
+
+	tokenCtx, _, err := amf_context.GetSelf().GetTokenCtx(models.ServiceName_NLMF_LOC, models.NrfNfManagementNfType_LMF)
+	if err != nil {
+		return nil, nil, err
+	}
+	// carry the token of tokenCtx within the cancellable ctx
+	if token := tokenCtx.Value(openapi.ContextOAuth2); token != nil {
+		ctx = context.WithValue(ctx, openapi.ContextOAuth2, token)
+	}
+
+	req := &Nlmf_Location.DetermineLocationRequest{
+		DetermineLocationRequest: &models.DetermineLocationRequest{
+			JsonData: inputData,
+		},
+	}
+	defer countSbiRequest()()
+	rsp, localErr := client.DetermineLocationApi.DetermineLocation(ctx, req)
+	if localErr == nil {
+		return &rsp.LmfLocationLocationData, nil, nil
+	}
+	switch apiErr := localErr.(type) {
+	case openapi.GenericOpenAPIError:
+		switch errorModel := apiErr.Model().(type) {
+		case Nlmf_Location.DetermineLocationError:
+			return nil, &errorModel.ProblemDetails, nil
+		case error:
+			return nil, openapi.ProblemDetailsSystemFailure(errorModel.Error()), nil
+		default:
+			return nil, nil, openapi.ReportError("openapi error")
+		}
+	case error:
+		return nil, nil, apiErr
+	default:
+		return nil, nil, openapi.ReportError("openapi error")
+	}
+}
+
+func (s *nlmfService) CancelLocation(lmfUri string, cancelLocData *models.LmfLocationCancelLocData) (
+	*models.ProblemDetails, error,
+) {
+	client := s.getLocationClient(lmfUri)
+	if client == nil {
+		return nil, openapi.ReportError("lmf not found")
+	}
+
+	ctx, _, err := amf_context.GetSelf().GetTokenCtx(models.ServiceName_NLMF_LOC, models.NrfNfManagementNfType_LMF)
+	if err != nil {
+		return nil, err
+	}
+
+	defer countSbiRequest()()
+	_, localErr := client.CancelLocationApi.CancelLocation(ctx, &Nlmf_Location.CancelLocationRequest{
+		LmfLocationCancelLocData: cancelLocData,
+	})
+	if localErr == nil {
+		return nil, nil
+	}
+	switch apiErr := localErr.(type) {
+	case openapi.GenericOpenAPIError:
+		switch errorModel := apiErr.Model().(type) {
+		case Nlmf_Location.CancelLocationError:
+			return &errorModel.ProblemDetails, nil
+		case error:
+			return openapi.ProblemDetailsSystemFailure(errorModel.Error()), nil
+		default:
+			return nil, openapi.ReportError("openapi error")
+		}
+	case error:
+		return nil, apiErr
+	default:
+		return nil, openapi.ReportError("openapi error")
+	}
+}
@@ -89,6 +89,7 @@ func (s *nnrfService) SendSearchNFInstances(nrfUri string, targetNfType, request
 	if err != nil {
 		return nil, err
 	}
+	defer countSbiRequest()()
 	res, err := client.NFInstancesStoreApi.SearchNFInstances(ctx, param)
 	var result *models.SearchResult
 	if err != nil {
@@ -189,32 +190,34 @@ func (s *nnrfService) BuildNFInstance(context *amf_context.AMFContext) (
 	profile.NfInstanceId = context.NfId
 	profile.NfType = models.NrfNfManagementNfType_AMF
 	profile.NfStatus = models.NrfNfManagementNfStatus_REGISTERED
+	plmnSupportList := context.GetPlmnSupportList()
+	servedGuamiList := context.GetServedGuamiList()
 	var plmns []models.PlmnId
-	for _, plmnItem := range context.PlmnSupportList {
+	for _, plmnItem := range plmnSupportList {
 		plmns = append(plmns, *plmnItem.PlmnId)
 	}
 	if len(plmns) > 0 {
 		profile.PlmnList = plmns
 		// TODO: change to Per Plmn Support Snssai List
 		var SnssaiList []models.ExtSnssai
-		for _, snssaiItem := range context.PlmnSupportList[0].SNssaiList {
+		for _, snssaiItem := range plmnSupportList[0].SNssaiList {
 			SnssaiList = append(SnssaiList, util.SnssaiModelsToExtSnssai(snssaiItem))
 		}
 		profile.SNssais = SnssaiList
 	}
 	amfInfo := models.NrfNfManagementAmfInfo{}
-	if len(context.ServedGuamiList) == 0 {
+	if len(servedGuamiList) == 0 {
 		err = fmt.Errorf("gumai List is Empty in AMF")
 		return profile, err
 	}
-	regionId, setId, _, err1 := util.SeperateAmfId(context.ServedGuamiList[0].AmfId)
+	regionId, setId, _, err1 := util.SeperateAmfId(servedGuamiList[0].AmfId)
 	if err1 != nil {
 		err = err1
 		return profile, err
 	}
 	amfInfo.AmfRegionId = regionId
 	amfInfo.AmfSetId = setId
-	amfInfo.GuamiList = context.ServedGuamiList
+	amfInfo.GuamiList = servedGuamiList
 	if len(context.SupportTaiLists) == 0 {
 		err = fmt.Errorf("SupportTaiList is Empty in AMF")
 		return profile, err
@@ -266,7 +269,9 @@ func (s *nnrfService) SendRegisterNFInstance(ctx context.Context, nrfUri, nfInst
 		case <-ctx.Done():
 			return "", "", fmt.Errorf("context done")
 		default:
+			completed := countSbiRequest()
 			res, err = client.NFInstanceIDDocumentApi.RegisterNFInstance(ctx, registerNFInstanceRequest)
+			completed()
 			if err != nil || res == nil {
 				// TODO : add log
 				logger.ConsumerLog.Errorf("AMF register to NRF Error[%s]", err.Error())
@@ -306,6 +311,36 @@ func (s *nnrfService) SendRegisterNFInstance(ctx context.Context, nrfUri, nfInst
 	return resouceNrfUri, retrieveNfInstanceId, err
 }
 
+// Update the NF profile registered in the NRF by replacing it with the one built from the AMF context, e.g. after the
+// AMF configuration is changed at runtime (TS 29.510 5.2.2.3.2)
+func (s *nnrfService) SendUpdateNFInstance() error {
+	logger.ConsumerLog.Infof("[AMF] Send Update NFInstance")
+	amfContext := s.consumer.Context()
+
+	profile, err := s.BuildNFInstance(amfContext)
+	if err != nil {
+		return err
+	}
+	client := s.getNFManagementClient(amfContext.NrfUri)
+	if client == nil {
+		return openapi.ReportError("nrf not found")
+	}
+
+	ctx, _, err := amf_context.GetSelf().GetTokenCtx(models.ServiceName_NNRF_NFM, models.NrfNfManagementNfType_NRF)
+	if err != nil {
+		return err
+	}
+
+	request := &Nnrf_NFManagement.RegisterNFInstanceRequest{
+		NfInstanceID:             &amfContext.NfId,
+		NrfNfManagementNfProfile: &profile,
+	}
+
+	defer countSbiRequest()()
+	_, err = client.NFInstanceIDDocumentApi.RegisterNFInstance(ctx, request)
+	return err
+}
+
 func (s *nnrfService) SendDeregisterNFInstance() (problemDetails *models.ProblemDetails, err error) {
 	logger.ConsumerLog.Infof("[AMF] Send Deregister NFInstance")
 	amfContext := s.consumer.Context()
@@ -324,6 +359,7 @@ func (s *nnrfService) SendDeregisterNFInstance() (problemDetails *models.Problem
 		NfInstanceID: &amfContext.NfId,
 	}
 
+	defer countSbiRequest()()
 	_, err = client.NFInstanceIDDocumentApi.DeregisterNFInstance(ctx, request)
 	if err != nil {
 		switch apiErr := err.(type) {
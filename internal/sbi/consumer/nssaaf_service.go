@@ -0,0 +1,211 @@
+package consumer
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	amf_context "github.com/free5gc/amf/internal/context"
+	"github.com/free5gc/amf/internal/util"
+	"github.com/free5gc/amf/pkg/factory"
+	"github.com/free5gc/openapi"
+	"github.com/free5gc/openapi/models"
+	Nnrf_NFDiscovery "github.com/free5gc/openapi/nrf/NFDiscovery"
+	sbi_metrics "github.com/free5gc/util/metrics/sbi"
+)
+
+// The openapi module does not provide the Nnssaaf_NSSAA client (TS 29.526), the requests are built with the
+// openapi helpers and the data types below.
+
+// TS 29.526 6.1.6.2.2
+type sliceAuthInfo struct {
+	Supi           string        `json:"supi,omitempty"`
+	Gpsi           string        `json:"gpsi,omitempty"`
+	Snssai         models.Snssai `json:"snssai"`
+	EapIdRsp       []byte        `json:"eapIdRsp"`
+	AmfInstanceId  string        `json:"amfInstanceId,omitempty"`
+	ReauthNotifUri string        `json:"reauthNotifUri,omitempty"`
+	RevocNotifUri  string        `json:"revocNotifUri,omitempty"`
+}
+
+// TS 29.526 6.1.6.2.3
+type sliceAuthContext struct {
+	Supi       string        `json:"supi,omitempty"`
+	Gpsi       string        `json:"gpsi,omitempty"`
+	Snssai     models.Snssai `json:"snssai"`
+	AuthCtxId  string        `json:"authCtxId"`
+	EapMessage []byte        `json:"eapMessage"`
+}
+
+// TS 29.526 6.1.6.2.4
+type sliceAuthConfirmationData struct {
+	Supi       string        `json:"supi,omitempty"`
+	Gpsi       string        `json:"gpsi,omitempty"`
+	Snssai     models.Snssai `json:"snssai"`
+	EapMessage []byte        `json:"eapMessage"`
+}
+
+// TS 29.526 6.1.6.2.5
+type sliceAuthConfirmationResponse struct {
+	Supi       string            `json:"supi,omitempty"`
+	Gpsi       string            `json:"gpsi,omitempty"`
+	Snssai     models.Snssai     `json:"snssai"`
+	EapMessage []byte            `json:"eapMessage"`
+	AuthResult models.AuthStatus `json:"authResult,omitempty"`
+}
+
+type nssaafConfiguration struct {
+	basePath string
+}
+
+func (c *nssaafConfiguration) BasePath() string                    { return c.basePath }
+func (c *nssaafConfiguration) Host() string                        { return "" }
+func (c *nssaafConfiguration) UserAgent() string                   { return "AMF" }
+func (c *nssaafConfiguration) DefaultHeader() map[string]string    { return nil }
+func (c *nssaafConfiguration) HTTPClient() *http.Client            { return nil }
+func (c *nssaafConfiguration) Metrics() openapi.RequestMetricsHook { return sbi_metrics.SbiMetricHook }
+
+type nnssaafService struct {
+	consumer *Consumer
+}
+
+// Select the NSSAAF of the UE by NRF (TS 23.502 4.2.9.2)
+func (s *nnssaafService) SelectNssaaf(ue *amf_context.AmfUe) error {
+	param := Nnrf_NFDiscovery.SearchNFInstancesRequest{
+		ServiceNames: []models.ServiceName{models.ServiceName_NNSSAAF_NSSAA},
+	}
+	if ue.PlmnId.Mcc != "" {
+		param.TargetPlmnList = append(param.TargetPlmnList, ue.PlmnId)
+	}
+
+	result, err := s.consumer.SendSearchNFInstances(ue.ServingAMF().NrfUri, models.NrfNfManagementNfType_NSSAAF,
+		models.NrfNfManagementNfType_AMF, &param)
+	if err != nil {
+		return err
+	}
+
+	// select the first NSSAAF, TODO: select base on other info
+	for index := range result.NfInstances {
+		nssaafUri := util.SearchNFServiceUri(&result.NfInstances[index], models.ServiceName_NNSSAAF_NSSAA,
+			models.NfServiceStatus_REGISTERED)
+		if nssaafUri != "" {
+			ue.NssaafUri = nssaafUri
+			return nil
+		}
+	}
+	return fmt.Errorf("AMF can not select an NSSAAF by NRF")
+}
+
+// Nnssaaf_NSSAA_Authenticate with the EAP identity response of the UE, the EAP message of the NSSAAF and the
+// authentication context ID are returned
+func (s *nnssaafService) NssaaAuthenticate(ue *amf_context.AmfUe, snssai models.Snssai, eapIdRsp []byte) (
+	eapMessage []byte, authCtxId string, problemDetails *models.ProblemDetails, err error,
+) {
+	amfSelf := amf_context.GetSelf()
+	callbackUri := amfSelf.GetIPv4Uri() + factory.AmfCallbackResUriPrefix
+	authInfo := &sliceAuthInfo{
+		Supi:           ue.Supi,
+		Gpsi:           ue.Gpsi,
+		Snssai:         snssai,
+		EapIdRsp:       eapIdRsp,
+		AmfInstanceId:  amfSelf.NfId,
+		ReauthNotifUri: callbackUri + "/nssaa-reauth/" + ue.Supi,
+		RevocNotifUri:  callbackUri + "/nssaa-revocation/" + ue.Supi,
+	}
+
+	var authCtx sliceAuthContext
+	status, problemDetails, err := s.sendNssaafRequest(ue, http.MethodPost, "/slice-authentications", authInfo,
+		&authCtx)
+	if err != nil || problemDetails != nil {
+		return nil, "", problemDetails, err
+	}
+	if status != http.StatusCreated {
+		return nil, "", nil, openapi.ReportError("unexpected status[%d] of NSSAA Authenticate", status)
+	}
+	return authCtx.EapMessage, authCtx.AuthCtxId, nil, nil
+}
+
+// Nnssaaf_NSSAA_Authenticate with the subsequent EAP message of the UE, the EAP message of the NSSAAF and the
+// result are returned, the result is empty until the EAP authentication completes
+func (s *nnssaafService) NssaaConfirm(ue *amf_context.AmfUe, authCtxId string, snssai models.Snssai,
+	eapMessage []byte,
+) (rspEapMessage []byte, authResult models.AuthStatus, problemDetails *models.ProblemDetails, err error) {
+	confirmationData := &sliceAuthConfirmationData{
+		Supi:       ue.Supi,
+		Gpsi:       ue.Gpsi,
+		Snssai:     snssai,
+		EapMessage: eapMessage,
+	}
+
+	var confirmationRsp sliceAuthConfirmationResponse
+	status, problemDetails, err := s.sendNssaafRequest(ue, http.MethodPut, "/slice-authentications/"+authCtxId,
+		confirmationData, &confirmationRsp)
+	if err != nil || problemDetails != nil {
+		return nil, "", problemDetails, err
+	}
+	if status != http.StatusOK {
+		return nil, "", nil, openapi.ReportError("unexpected status[%d] of NSSAA Authenticate", status)
+	}
+	return confirmationRsp.EapMessage, confirmationRsp.AuthResult, nil, nil
+}
+
+func (s *nnssaafService) sendNssaafRequest(ue *amf_context.AmfUe, method, path string, body, rspBody interface{}) (
+	int, *models.ProblemDetails, error,
+) {
+	if ue.NssaafUri == "" {
+		return 0, nil, openapi.ReportError("nssaaf not found")
+	}
+	cfg := &nssaafConfiguration{
+		basePath: ue.NssaafUri + "/nnssaaf-nssaa/v1",
+	}
+
+	ctx, _, err := amf_context.GetSelf().GetTokenCtx(models.ServiceName_NNSSAAF_NSSAA,
+		models.NrfNfManagementNfType_NSSAAF)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	headerParams := map[string]string{
+		"Accept":       "application/json, application/problem+json",
+		"Content-Type": "application/json",
+	}
+	req, err := openapi.PrepareRequest(ctx, cfg, cfg.BasePath()+path, method, body, headerParams,
+		url.Values{}, url.Values{}, "", "", nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer countSbiRequest()()
+	rsp, err := openapi.CallAPI(cfg, req)
+	if err != nil {
+		return 0, nil, err
+	}
+	rspData, err := io.ReadAll(rsp.Body)
+	if err != nil {
+		return 0, nil, err
+	}
+	if err = rsp.Body.Close(); err != nil {
+		return 0, nil, err
+	}
+
+	contentType := rsp.Header.Get("Content-Type")
+	if rsp.StatusCode >= http.StatusBadRequest {
+		var problemDetails models.ProblemDetails
+		if strings.Contains(contentType, "json") && len(rspData) > 0 {
+			if err = openapi.Deserialize(&problemDetails, rspData, contentType); err != nil {
+				return rsp.StatusCode, nil, err
+			}
+		} else {
+			problemDetails.Status = int32(rsp.StatusCode)
+			problemDetails.Cause = http.StatusText(rsp.StatusCode)
+		}
+		return rsp.StatusCode, &problemDetails, nil
+	}
+	if len(rspData) > 0 {
+		if err = openapi.Deserialize(rspBody, rspData, contentType); err != nil {
+			return rsp.StatusCode, nil, err
+		}
+	}
+	return rsp.StatusCode, nil, nil
+}
@@ -77,6 +77,7 @@ func (s *nssfService) NSSelectionGetForRegistration(ue *amf_context.AmfUe, reque
 		Tai:                             &ue.Tai, // TS 29.531 R15.3 6.1.3.2.3.1
 	}
 
+	defer countSbiRequest()()
 	res, localErr := client.NetworkSliceInformationDocumentApi.NSSelectionGet(ctx,
 		&paramOpt)
 	if localErr == nil {
@@ -135,6 +136,7 @@ func (s *nssfService) NSSelectionGetForPduSession(ue *amf_context.AmfUe, snssai
 	if err != nil {
 		return nil, nil, err
 	}
+	defer countSbiRequest()()
 	res, localErr := client.NetworkSliceInformationDocumentApi.NSSelectionGet(ctx, &paramOpt)
 
 	if localErr == nil {
@@ -68,7 +68,7 @@ func (s *npcfService) AMPolicyControlCreate(
 			Mcc: ue.PlmnId.Mcc,
 			Mnc: ue.PlmnId.Mnc,
 		},
-		Guami: &amfSelf.ServedGuamiList[0],
+		Guami: &amfSelf.GetServedGuamiList()[0],
 	}
 	var policyAssociationreq Npcf_AMPolicy.CreateIndividualAMPolicyAssociationRequest
 
@@ -78,6 +78,7 @@ func (s *npcfService) AMPolicyControlCreate(
 		policyAssociationRequest.Rfsp = ue.AccessAndMobilitySubscriptionData.RfspIndex
 	}
 
+	defer countSbiRequest()()
 	res, localErr := client.AMPolicyAssociationsCollectionApi.
 		CreateIndividualAMPolicyAssociation(ctx, &policyAssociationreq)
 	if localErr == nil {
@@ -144,6 +145,7 @@ func (s *npcfService) AMPolicyControlUpdate(
 	policyUpdateReq.SetPolAssoId(ue.PolicyAssociationId)
 	policyUpdateReq.SetPcfAmPolicyControlPolicyAssociationUpdateRequest(updateRequest)
 
+	defer countSbiRequest()()
 	res, localErr := client.IndividualAMPolicyAssociationDocumentApi.
 		ReportObservedEventTriggersForIndividualAMPolicyAssociation(ctx, &policyUpdateReq)
 	if localErr == nil {
@@ -199,6 +201,7 @@ func (s *npcfService) AMPolicyControlDelete(ue *amf_context.AmfUe) (problemDetai
 	var deleteReq Npcf_AMPolicy.DeleteIndividualAMPolicyAssociationRequest
 	deleteReq.SetPolAssoId(ue.PolicyAssociationId)
 
+	defer countSbiRequest()()
 	_, err = client.IndividualAMPolicyAssociationDocumentApi.DeleteIndividualAMPolicyAssociation(ctx, &deleteReq)
 	if err == nil {
 		ue.RemoveAmPolicyAssociation()
@@ -139,6 +139,7 @@ func (s *nsmfService) SelectSmf(
 
 	// select the first SMF, TODO: select base on other info
 	for index := range result.NfInstances {
+		smfID = result.NfInstances[index].NfInstanceId
 		smfUri = util.SearchNFServiceUri(&result.NfInstances[index], models.ServiceName_NSMF_PDUSESSION,
 			models.NfServiceStatus_REGISTERED)
 		if smfUri != "" {
@@ -150,12 +151,90 @@ func (s *nsmfService) SelectSmf(
 	return smContext, 0, nil
 }
 
+// The SMF of the emergency PDU session is configured, or discovered by NRF with the DNN and S-NSSAI for
+// emergency services (TS 23.501 5.16.4.5), the network slice selection is not applied to it
+func (s *nsmfService) SelectEmergencySmf(
+	ue *amf_context.AmfUe,
+	anType models.AccessType,
+	pduSessionID int32,
+) (*amf_context.SmContext, uint8, error) {
+	emergency := amf_context.GetSelf().Emergency
+	ue.GmmLog.Infof("Select emergency SMF [snssai: %+v, dnn: %+v]", *emergency.Snssai, emergency.Dnn)
+
+	smContext := amf_context.NewSmContext(pduSessionID)
+	smContext.SetSnssai(*emergency.Snssai)
+	smContext.SetDnn(emergency.Dnn)
+	smContext.SetAccessType(anType)
+	if emergency.SmfUri != "" {
+		smContext.SetSmfUri(emergency.SmfUri)
+		return smContext, 0, nil
+	}
+
+	param := Nnrf_NFDiscovery.SearchNFInstancesRequest{
+		ServiceNames: []models.ServiceName{models.ServiceName_NSMF_PDUSESSION},
+		Dnn:          &emergency.Dnn,
+		Snssais:      []models.Snssai{*emergency.Snssai},
+	}
+	result, err := s.consumer.SendSearchNFInstances(ue.ServingAMF().NrfUri, models.NrfNfManagementNfType_SMF,
+		models.NrfNfManagementNfType_AMF, &param)
+	if err != nil {
+		return nil, nasMessage.Cause5GMMPayloadWasNotForwarded, err
+	}
+
+	// select the first SMF, TODO: select base on other info
+	for index := range result.NfInstances {
+		smfUri := util.SearchNFServiceUri(&result.NfInstances[index], models.ServiceName_NSMF_PDUSESSION,
+			models.NfServiceStatus_REGISTERED)
+		if smfUri != "" {
+			smContext.SetSmfID(result.NfInstances[index].NfInstanceId)
+			smContext.SetSmfUri(smfUri)
+			return smContext, 0, nil
+		}
+	}
+	return nil, nasMessage.Cause5GMMPayloadWasNotForwarded,
+		fmt.Errorf("AMF can not select an SMF for emergency services by NRF")
+}
+
+// The PDU session context received from another AMF only identifies the SMF instance,
+// search the Nsmf_PDUSession service URI of it from NRF
+func (s *nsmfService) SearchSmfInstanceOfSmContext(ue *amf_context.AmfUe, smContext *amf_context.SmContext) error {
+	smfID := smContext.HSmfID()
+	if smfID == "" {
+		return fmt.Errorf("SMF of PDU Session[%d] is unknown", smContext.PduSessionID())
+	}
+
+	param := Nnrf_NFDiscovery.SearchNFInstancesRequest{
+		ServiceNames:       []models.ServiceName{models.ServiceName_NSMF_PDUSESSION},
+		TargetNfInstanceId: &smfID,
+	}
+	result, err := s.consumer.SendSearchNFInstances(ue.ServingAMF().NrfUri, models.NrfNfManagementNfType_SMF,
+		models.NrfNfManagementNfType_AMF, &param)
+	if err != nil {
+		return err
+	}
+
+	var smfUri string
+	for index := range result.NfInstances {
+		smfUri = util.SearchNFServiceUri(&result.NfInstances[index], models.ServiceName_NSMF_PDUSESSION,
+			models.NfServiceStatus_REGISTERED)
+		if smfUri != "" {
+			break
+		}
+	}
+	if smfUri == "" {
+		return fmt.Errorf("AMF can not find SMF[%s] by NRF", smfID)
+	}
+	smContext.SetSmfID(smfID)
+	smContext.SetSmfUri(smfUri)
+	return nil
+}
+
 func (s *nsmfService) SendCreateSmContextRequest(ue *amf_context.AmfUe, smContext *amf_context.SmContext,
 	requestType *models.RequestType, nasPdu []byte) (
 	smContextRef string, errorResponse *models.PostSmContextsError,
 	problemDetail *models.ProblemDetails, err1 error,
 ) {
-	smContextCreateData := s.buildCreateSmContextRequest(ue, smContext, nil)
+	smContextCreateData := s.buildCreateSmContextRequest(ue, smContext, requestType)
 
 	postSmContextsRequest := Nsmf_PDUSession.PostSmContextsRequest{
 		PostSmContextsRequest: &models.PostSmContextsRequest{
@@ -173,6 +252,7 @@ func (s *nsmfService) SendCreateSmContextRequest(ue *amf_context.AmfUe, smContex
 	if err != nil {
 		return "", nil, nil, err
 	}
+	defer countSbiRequest()()
 	postSmContextReponse, localErr := client.SMContextsCollectionApi.
 		PostSmContexts(ctx, &postSmContextsRequest)
 	if localErr == nil {
@@ -221,8 +301,9 @@ func (s *nsmfService) buildCreateSmContextRequest(ue *amf_context.AmfUe, smConte
 	smContextCreateData.SNssai = &snssai
 	smContextCreateData.Dnn = smContext.Dnn()
 	smContextCreateData.ServingNfId = context.NfId
-	smContextCreateData.Guami = &context.ServedGuamiList[0]
-	smContextCreateData.ServingNetwork = context.ServedGuamiList[0].PlmnId
+	servedGuami := context.GetServedGuamiList()[0]
+	smContextCreateData.Guami = &servedGuami
+	smContextCreateData.ServingNetwork = servedGuami.PlmnId
 	if requestType != nil {
 		smContextCreateData.RequestType = *requestType
 	}
@@ -234,8 +315,13 @@ func (s *nsmfService) buildCreateSmContextRequest(ue *amf_context.AmfUe, smConte
 	}
 	smContextCreateData.UeLocation = &ue.Location
 	smContextCreateData.UeTimeZone = ue.TimeZone
+	// the UE registered for emergency services without SUPI is identified by the PEI
+	ueId := ue.Supi
+	if ueId == "" {
+		ueId = ue.Pei
+	}
 	smContextCreateData.SmContextStatusUri = context.GetIPv4Uri() + factory.AmfCallbackResUriPrefix + "/smContextStatus/" +
-		ue.Supi + "/" + strconv.Itoa(int(smContext.PduSessionID()))
+		ueId + "/" + strconv.Itoa(int(smContext.PduSessionID()))
 
 	return smContextCreateData
 }
@@ -317,6 +403,24 @@ func (s *nsmfService) SendUpdateSmContextN2Info(
 	return s.consumer.SendUpdateSmContextRequest(smContext, &updateData, nil, n2SmInfo)
 }
 
+// Forward the Secondary RAT usage data buffered during the N2 handover to the SMFs (TS 23.502 4.9.1.3.3 step 2a)
+func (s *nsmfService) SendBufferedSecondaryRatUsageReports(ue *amf_context.AmfUe) {
+	ue.SmContextList.Range(func(key, value interface{}) bool {
+		smContext := value.(*amf_context.SmContext)
+		for _, report := range smContext.PopSecondaryRatUsageReports() {
+			_, _, problemDetails, err := s.SendUpdateSmContextN2Info(ue, smContext,
+				models.N2SmInfoType_SECONDARY_RAT_USAGE, report)
+			if problemDetails != nil {
+				ue.GmmLog.Errorf("Send Secondary RAT usage of PDU Session[%d] Failed Problem[%+v]",
+					smContext.PduSessionID(), problemDetails)
+			} else if err != nil {
+				ue.GmmLog.Errorf("Send Secondary RAT usage of PDU Session[%d] Error[%v]", smContext.PduSessionID(), err)
+			}
+		}
+		return true
+	})
+}
+
 func (s *nsmfService) SendUpdateSmContextXnHandover(
 	ue *amf_context.AmfUe, smContext *amf_context.SmContext, n2SmType models.N2SmInfoType, n2SmInfo []byte) (
 	*models.UpdateSmContextResponse200, *models.UpdateSmContextResponse400, *models.ProblemDetails, error,
@@ -488,6 +592,7 @@ func (s *nsmfService) SendUpdateSmContextRequest(smContext *amf_context.SmContex
 	if err != nil {
 		return nil, nil, nil, err
 	}
+	defer countSbiRequest()()
 	updateSmContextReponse, localErr := client.IndividualSMContextApi.
 		UpdateSmContext(ctx, &updateSmContextRequest)
 	if localErr == nil {
@@ -540,6 +645,7 @@ func (s *nsmfService) SendReleaseSmContextRequest(ue *amf_context.AmfUe, smConte
 	if err != nil {
 		return nil, err
 	}
+	defer countSbiRequest()()
 	_, localErr := client.IndividualSMContextApi.ReleaseSmContext(
 		ctx, &releaseSmContextRequest)
 
@@ -0,0 +1,217 @@
+package consumer
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/google/uuid"
+
+	amf_context "github.com/free5gc/amf/internal/context"
+	"github.com/free5gc/amf/internal/util"
+	"github.com/free5gc/openapi"
+	"github.com/free5gc/openapi/models"
+	Nnrf_NFDiscovery "github.com/free5gc/openapi/nrf/NFDiscovery"
+	sbi_metrics "github.com/free5gc/util/metrics/sbi"
+)
+
+// The openapi module does not provide the Nsmsf_SMService client (TS 29.540), the requests are built with the
+// openapi helpers and the data types below.
+
+// TS 29.540 6.1.6.2.2
+type ueSmsContextData struct {
+	Supi       string               `json:"supi"`
+	Gpsi       string               `json:"gpsi,omitempty"`
+	Pei        string               `json:"pei,omitempty"`
+	AccessType models.AccessType    `json:"accessType"`
+	AmfId      string               `json:"amfId"`
+	Guamis     []models.Guami       `json:"guamis,omitempty"`
+	UeLocation *models.UserLocation `json:"ueLocation,omitempty"`
+	UeTimeZone string               `json:"ueTimeZone,omitempty"`
+}
+
+// TS 29.540 6.1.6.2.3
+type smsRecordData struct {
+	SmsRecordId string                  `json:"smsRecordId"`
+	SmsPayload  *models.RefToBinaryData `json:"smsPayload"`
+	AccessType  models.AccessType       `json:"accessType,omitempty"`
+	Gpsi        string                  `json:"gpsi,omitempty"`
+	Pei         string                  `json:"pei,omitempty"`
+	UeLocation  *models.UserLocation    `json:"ueLocation,omitempty"`
+	UeTimeZone  string                  `json:"ueTimeZone,omitempty"`
+}
+
+// multipart/related body of the UplinkSMS request
+type uplinkSmsRequest struct {
+	JsonData  *smsRecordData `multipart:"contentType:application/json"`
+	BinarySms []byte         `multipart:"contentType:application/vnd.3gpp.sms,ref:JsonData.SmsPayload.ContentId"`
+}
+
+const smsPayloadContentId = "sms"
+
+type smsfConfiguration struct {
+	basePath string
+}
+
+func (c *smsfConfiguration) BasePath() string                    { return c.basePath }
+func (c *smsfConfiguration) Host() string                        { return "" }
+func (c *smsfConfiguration) UserAgent() string                   { return "AMF" }
+func (c *smsfConfiguration) DefaultHeader() map[string]string    { return nil }
+func (c *smsfConfiguration) HTTPClient() *http.Client            { return nil }
+func (c *smsfConfiguration) Metrics() openapi.RequestMetricsHook { return sbi_metrics.SbiMetricHook }
+
+type nsmsfService struct {
+	consumer *Consumer
+}
+
+// Select the SMSF of the UE by NRF (TS 23.502 4.13.2.1)
+func (s *nsmsfService) SelectSmsf(ue *amf_context.AmfUe) error {
+	param := Nnrf_NFDiscovery.SearchNFInstancesRequest{
+		ServiceNames: []models.ServiceName{models.ServiceName_NSMSF_SMS},
+		Supi:         &ue.Supi,
+	}
+	if ue.PlmnId.Mcc != "" {
+		param.TargetPlmnList = append(param.TargetPlmnList, ue.PlmnId)
+	}
+
+	result, err := s.consumer.SendSearchNFInstances(ue.ServingAMF().NrfUri, models.NrfNfManagementNfType_SMSF,
+		models.NrfNfManagementNfType_AMF, &param)
+	if err != nil {
+		return err
+	}
+
+	// select the first SMSF, TODO: select base on other info
+	for index := range result.NfInstances {
+		smsfUri := util.SearchNFServiceUri(&result.NfInstances[index], models.ServiceName_NSMSF_SMS,
+			models.NfServiceStatus_REGISTERED)
+		if smsfUri != "" {
+			ue.SmsfId = result.NfInstances[index].NfInstanceId
+			ue.SmsfUri = smsfUri
+			return nil
+		}
+	}
+	return fmt.Errorf("AMF can not select an SMSF by NRF")
+}
+
+// Nsmsf_SMService_Activate, the SMS over NAS is activated for the UE over the access
+func (s *nsmsfService) SmServiceActivate(ue *amf_context.AmfUe, anType models.AccessType) (
+	*models.ProblemDetails, error,
+) {
+	amfSelf := amf_context.GetSelf()
+	contextData := &ueSmsContextData{
+		Supi:       ue.Supi,
+		Gpsi:       ue.Gpsi,
+		Pei:        ue.Pei,
+		AccessType: anType,
+		AmfId:      amfSelf.NfId,
+		Guamis:     amfSelf.GetServedGuamiList(),
+		UeLocation: &ue.Location,
+		UeTimeZone: ue.TimeZone,
+	}
+	status, problemDetails, err := s.sendSmsfRequest(ue, http.MethodPut, "/ue-contexts/"+ue.Supi, contextData)
+	if err != nil || problemDetails != nil {
+		return problemDetails, err
+	}
+	if status != http.StatusCreated && status != http.StatusNoContent {
+		return nil, openapi.ReportError("unexpected status[%d] of SMService Activate", status)
+	}
+	return nil, nil
+}
+
+// Nsmsf_SMService_Deactivate
+func (s *nsmsfService) SmServiceDeactivate(ue *amf_context.AmfUe) (*models.ProblemDetails, error) {
+	status, problemDetails, err := s.sendSmsfRequest(ue, http.MethodDelete, "/ue-contexts/"+ue.Supi, nil)
+	if err != nil || problemDetails != nil {
+		return problemDetails, err
+	}
+	if status != http.StatusNoContent {
+		return nil, openapi.ReportError("unexpected status[%d] of SMService Deactivate", status)
+	}
+	return nil, nil
+}
+
+// Nsmsf_SMService_UplinkSMS, the SMS payload received over the access is forwarded to the SMSF
+func (s *nsmsfService) SmServiceUplinkSms(ue *amf_context.AmfUe, anType models.AccessType, smsPayload []byte) (
+	*models.ProblemDetails, error,
+) {
+	request := &uplinkSmsRequest{
+		JsonData: &smsRecordData{
+			SmsRecordId: uuid.New().String(),
+			SmsPayload:  &models.RefToBinaryData{ContentId: smsPayloadContentId},
+			AccessType:  anType,
+			Gpsi:        ue.Gpsi,
+			Pei:         ue.Pei,
+			UeLocation:  &ue.Location,
+			UeTimeZone:  ue.TimeZone,
+		},
+		BinarySms: smsPayload,
+	}
+	status, problemDetails, err := s.sendSmsfRequest(ue, http.MethodPost, "/ue-contexts/"+ue.Supi+"/sendsms",
+		request)
+	if err != nil || problemDetails != nil {
+		return problemDetails, err
+	}
+	if status != http.StatusOK {
+		return nil, openapi.ReportError("unexpected status[%d] of SMService UplinkSMS", status)
+	}
+	return nil, nil
+}
+
+func (s *nsmsfService) sendSmsfRequest(ue *amf_context.AmfUe, method, path string, body interface{}) (
+	int, *models.ProblemDetails, error,
+) {
+	if ue.SmsfUri == "" {
+		return 0, nil, openapi.ReportError("smsf not found")
+	}
+	cfg := &smsfConfiguration{
+		basePath: ue.SmsfUri + "/nsmsf-sms/v2",
+	}
+
+	ctx, _, err := amf_context.GetSelf().GetTokenCtx(models.ServiceName_NSMSF_SMS, models.NrfNfManagementNfType_SMSF)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	headerParams := map[string]string{
+		"Accept": "application/json, application/problem+json",
+	}
+	if _, ok := body.(*uplinkSmsRequest); ok {
+		headerParams["Content-Type"] = "multipart/related"
+	} else if body != nil {
+		headerParams["Content-Type"] = "application/json"
+	}
+	req, err := openapi.PrepareRequest(ctx, cfg, cfg.BasePath()+path, method, body, headerParams,
+		url.Values{}, url.Values{}, "", "", nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer countSbiRequest()()
+	rsp, err := openapi.CallAPI(cfg, req)
+	if err != nil {
+		return 0, nil, err
+	}
+	rspBody, err := io.ReadAll(rsp.Body)
+	if err != nil {
+		return 0, nil, err
+	}
+	if err = rsp.Body.Close(); err != nil {
+		return 0, nil, err
+	}
+
+	if rsp.StatusCode >= http.StatusBadRequest {
+		var problemDetails models.ProblemDetails
+		contentType := rsp.Header.Get("Content-Type")
+		if strings.Contains(contentType, "json") && len(rspBody) > 0 {
+			if err = openapi.Deserialize(&problemDetails, rspBody, contentType); err != nil {
+				return rsp.StatusCode, nil, err
+			}
+		} else {
+			problemDetails.Status = int32(rsp.StatusCode)
+			problemDetails.Cause = http.StatusText(rsp.StatusCode)
+		}
+		return rsp.StatusCode, &problemDetails, nil
+	}
+	return rsp.StatusCode, nil, nil
+}
@@ -0,0 +1,98 @@
+package consumer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	amf_context "github.com/free5gc/amf/internal/context"
+	"github.com/free5gc/amf/internal/logger"
+	"github.com/free5gc/openapi"
+)
+
+// The trace collection entity is not a 5GC NF, the trace reports are posted in HTTP/1.1 to the configured URI with
+// the openapi helpers.
+
+var tceHTTPClient = &http.Client{Timeout: 5 * time.Second}
+
+// The trace reports of the NGAP handlers are queued and posted by RunTraceReporter in order, the reports are dropped
+// if the queue is full so that the NGAP handlers are never blocked by the TCE
+const traceReportQueueSize = 1024
+
+type tceConfiguration struct {
+	basePath string
+}
+
+func (c *tceConfiguration) BasePath() string                    { return c.basePath }
+func (c *tceConfiguration) Host() string                        { return "" }
+func (c *tceConfiguration) UserAgent() string                   { return "AMF" }
+func (c *tceConfiguration) DefaultHeader() map[string]string    { return nil }
+func (c *tceConfiguration) HTTPClient() *http.Client            { return tceHTTPClient }
+func (c *tceConfiguration) Metrics() openapi.RequestMetricsHook { return nil }
+
+type tceService struct {
+	consumer *Consumer
+
+	traceReports chan *amf_context.TraceReport
+}
+
+// Queue the trace event to be reported to the TCE, nothing is queued if the TCE is not configured
+func (s *tceService) QueueTraceReport(report *amf_context.TraceReport) error {
+	if amf_context.GetSelf().TraceCollectionEntity == nil {
+		return nil
+	}
+	select {
+	case s.traceReports <- report:
+		return nil
+	default:
+		return fmt.Errorf("trace report queue is full, %s report is dropped", report.Event)
+	}
+}
+
+// Report the queued trace events to the TCE until done is closed
+func (s *tceService) RunTraceReporter(done <-chan struct{}) {
+	for {
+		select {
+		case <-done:
+			return
+		case report := <-s.traceReports:
+			if err := s.sendTraceReport(report); err != nil {
+				logger.ConsumerLog.Errorf("Report %s to TCE error: %+v", report.Event, err)
+			}
+		}
+	}
+}
+
+// Report the trace event to the trace collection entity, nothing is reported if the TCE is not configured
+func (s *tceService) sendTraceReport(report *amf_context.TraceReport) error {
+	tce := amf_context.GetSelf().TraceCollectionEntity
+	if tce == nil {
+		return nil
+	}
+	cfg := &tceConfiguration{
+		basePath: tce.Uri,
+	}
+
+	headerParams := map[string]string{
+		"Content-Type": "application/json",
+	}
+	req, err := openapi.PrepareRequest(context.Background(), cfg, cfg.BasePath(), http.MethodPost, report,
+		headerParams, url.Values{}, url.Values{}, "", "", nil)
+	if err != nil {
+		return err
+	}
+	defer countSbiRequest()()
+	rsp, err := openapi.CallAPI(cfg, req)
+	if err != nil {
+		return err
+	}
+	if err = rsp.Body.Close(); err != nil {
+		return err
+	}
+	if rsp.StatusCode >= http.StatusMultipleChoices {
+		return openapi.ReportError("unexpected status[%d] of trace report", rsp.StatusCode)
+	}
+	return nil
+}
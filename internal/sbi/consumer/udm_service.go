@@ -86,6 +86,7 @@ func (s *nudmService) PutUpuAck(ue *amf_context.AmfUe, upuMacIue string) error {
 		Supi:            &ue.Supi,
 		AcknowledgeInfo: &ackInfo,
 	}
+	defer countSbiRequest()()
 	_, err = client.ProvidingAcknowledgementOfUEParametersUpdateApi.
 		UpuAck(ctx, &upuReq)
 
@@ -111,6 +112,7 @@ func (s *nudmService) SDMGetAmData(ue *amf_context.AmfUe) (problemDetails *model
 		return nil, err
 	}
 
+	defer countSbiRequest()()
 	data, localErr := client.AccessAndMobilitySubscriptionDataRetrievalApi.GetAmData(
 		ctx, &getAmDataParamReq)
 	if localErr == nil {
@@ -156,6 +158,7 @@ func (s *nudmService) SDMGetSmfSelectData(ue *amf_context.AmfUe) (problemDetails
 		return nil, err
 	}
 
+	defer countSbiRequest()()
 	data, localErr := client.SMFSelectionSubscriptionDataRetrievalApi.
 		GetSmfSelData(ctx, &paramReq)
 
@@ -201,6 +204,7 @@ func (s *nudmService) SDMGetUeContextInSmfData(
 		Supi: &ue.Supi,
 	}
 
+	defer countSbiRequest()()
 	data, localErr := client.UEContextInSMFDataRetrievalApi.
 		GetUeCtxInSmfData(ctx, &getUeCtxInSmfDataReq)
 	if localErr == nil {
@@ -227,6 +231,93 @@ func (s *nudmService) SDMGetUeContextInSmfData(
 	return problemDetails, err
 }
 
+func (s *nudmService) SDMGetSmsData(ue *amf_context.AmfUe) (problemDetails *models.ProblemDetails, err error) {
+	client := s.getSubscriberDMngmntClients(ue.NudmSDMUri)
+	if client == nil {
+		return nil, openapi.ReportError("udm not found")
+	}
+
+	paramReq := Nudm_SubscriberDataManagement.GetSmsDataRequest{
+		Supi:   &ue.Supi,
+		PlmnId: &ue.PlmnId,
+	}
+
+	ctx, _, err := amf_context.GetSelf().GetTokenCtx(models.ServiceName_NUDM_SDM, models.NrfNfManagementNfType_UDM)
+	if err != nil {
+		return nil, err
+	}
+
+	defer countSbiRequest()()
+	data, localErr := client.SMSSubscriptionDataRetrievalApi.GetSmsData(ctx, &paramReq)
+	if localErr == nil {
+		ue.SmsSubscriptionData = &data.SmsSubscriptionData
+	} else {
+		err = localErr
+		switch errType := localErr.(type) {
+		case openapi.GenericOpenAPIError:
+			switch errModel := errType.Model().(type) {
+			case Nudm_SubscriberDataManagement.GetSmsDataError:
+				problemDetails = &errModel.ProblemDetails
+			case error:
+				err = errModel
+			default:
+				err = openapi.ReportError("openapi error")
+			}
+		case error:
+			problemDetails = openapi.ProblemDetailsSystemFailure(err.Error())
+		default:
+			err = openapi.ReportError("openapi error")
+		}
+	}
+
+	return problemDetails, err
+}
+
+// The trace data of the UE (TS 29.503 6.1.3.7), nil if the trace is not activated for the UE
+func (s *nudmService) SDMGetTraceData(ue *amf_context.AmfUe) (
+	traceData *models.TraceData, problemDetails *models.ProblemDetails, err error,
+) {
+	client := s.getSubscriberDMngmntClients(ue.NudmSDMUri)
+	if client == nil {
+		return nil, nil, openapi.ReportError("udm not found")
+	}
+
+	paramReq := Nudm_SubscriberDataManagement.GetTraceConfigDataRequest{
+		Supi:   &ue.Supi,
+		PlmnId: &ue.PlmnId,
+	}
+
+	ctx, _, err := amf_context.GetSelf().GetTokenCtx(models.ServiceName_NUDM_SDM, models.NrfNfManagementNfType_UDM)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer countSbiRequest()()
+	data, localErr := client.TraceConfigurationDataRetrievalApi.GetTraceConfigData(ctx, &paramReq)
+	if localErr == nil {
+		traceData = data.TraceDataResponse.TraceData
+	} else {
+		err = localErr
+		switch errType := localErr.(type) {
+		case openapi.GenericOpenAPIError:
+			switch errModel := errType.Model().(type) {
+			case Nudm_SubscriberDataManagement.GetTraceConfigDataError:
+				problemDetails = &errModel.ProblemDetails
+			case error:
+				err = errModel
+			default:
+				err = openapi.ReportError("openapi error")
+			}
+		case error:
+			problemDetails = openapi.ProblemDetailsSystemFailure(err.Error())
+		default:
+			err = openapi.ReportError("openapi error")
+		}
+	}
+
+	return traceData, problemDetails, err
+}
+
 func (s *nudmService) SDMSubscribe(ue *amf_context.AmfUe) (problemDetails *models.ProblemDetails, err error) {
 	client := s.getSubscriberDMngmntClients(ue.NudmSDMUri)
 	if client == nil {
@@ -234,9 +325,18 @@ func (s *nudmService) SDMSubscribe(ue *amf_context.AmfUe) (problemDetails *model
 	}
 
 	amfSelf := amf_context.GetSelf()
+	// the changes of the subscription data are notified to the SDM change callback (TS 29.503 5.2.2.3.2)
+	resourceUri := ue.NudmSDMUri + "/nudm-sdm/v2/" + ue.Supi
 	sdmSubscription := models.SdmSubscription{
-		NfInstanceId: amfSelf.NfId,
-		PlmnId:       &ue.PlmnId,
+		NfInstanceId:      amfSelf.NfId,
+		PlmnId:            &ue.PlmnId,
+		CallbackReference: amfSelf.GetIPv4Uri() + factory.AmfCallbackResUriPrefix + "/sdm-change/" + ue.Supi,
+		MonitoredResourceUris: []string{
+			resourceUri + "/am-data",
+			resourceUri + "/nssai",
+			resourceUri + "/smf-select-data",
+			resourceUri + "/trace-data",
+		},
 	}
 
 	subscribeReq := Nudm_SubscriberDataManagement.SubscribeRequest{
@@ -249,6 +349,7 @@ func (s *nudmService) SDMSubscribe(ue *amf_context.AmfUe) (problemDetails *model
 		return nil, err
 	}
 
+	defer countSbiRequest()()
 	resSubscription, localErr := client.SubscriptionCreationApi.Subscribe(
 		ctx, &subscribeReq)
 	if localErr == nil {
@@ -293,30 +394,12 @@ func (s *nudmService) SDMGetSliceSelectionSubscriptionData(
 		return nil, err
 	}
 
+	defer countSbiRequest()()
 	nssai, localErr := client.SliceSelectionSubscriptionDataRetrievalApi.
 		GetNSSAI(ctx, &paramReq)
 
 	if localErr == nil {
-		for _, defaultSnssai := range nssai.Nssai.DefaultSingleNssais {
-			subscribedSnssai := models.SubscribedSnssai{
-				SubscribedSnssai: &models.Snssai{
-					Sst: defaultSnssai.Sst,
-					Sd:  defaultSnssai.Sd,
-				},
-				DefaultIndication: true,
-			}
-			ue.SubscribedNssai = append(ue.SubscribedNssai, subscribedSnssai)
-		}
-		for _, snssai := range nssai.Nssai.SingleNssais {
-			subscribedSnssai := models.SubscribedSnssai{
-				SubscribedSnssai: &models.Snssai{
-					Sst: snssai.Sst,
-					Sd:  snssai.Sd,
-				},
-				DefaultIndication: false,
-			}
-			ue.SubscribedNssai = append(ue.SubscribedNssai, subscribedSnssai)
-		}
+		ue.SetSubscribedNssaiData(&nssai.Nssai)
 	} else {
 		err = localErr
 		// API error
@@ -355,6 +438,7 @@ func (s *nudmService) SDMUnsubscribe(ue *amf_context.AmfUe) (problemDetails *mod
 		SubscriptionId: &ue.SdmSubscriptionId,
 	}
 
+	defer countSbiRequest()()
 	_, localErr := client.SubscriptionDeletionApi.Unsubscribe(ctx, &unsubscribeReq)
 
 	if localErr != nil {
@@ -404,7 +488,7 @@ func (s *nudmService) UeCmRegistration(
 		registrationData := models.Amf3GppAccessRegistration{
 			AmfInstanceId:          amfSelf.NfId,
 			InitialRegistrationInd: initialRegistrationInd,
-			Guami:                  &amfSelf.ServedGuamiList[0],
+			Guami:                  &amfSelf.GetServedGuamiList()[0],
 			RatType:                ue.RatType,
 			DeregCallbackUri:       deregCallbackUri,
 			// TODO: not support Homogenous Support of IMS Voice over PS Sessions this stage
@@ -416,6 +500,7 @@ func (s *nudmService) UeCmRegistration(
 			Amf3GppAccessRegistration: &registrationData,
 		}
 
+		defer countSbiRequest()()
 		_, localErr := client.AMFRegistrationFor3GPPAccessApi.Call3GppRegistration(ctx,
 			&regReq)
 		if localErr == nil {
@@ -442,7 +527,7 @@ func (s *nudmService) UeCmRegistration(
 	case models.AccessType_NON_3_GPP_ACCESS:
 		registrationData := models.AmfNon3GppAccessRegistration{
 			AmfInstanceId: amfSelf.NfId,
-			Guami:         &amfSelf.ServedGuamiList[0],
+			Guami:         &amfSelf.GetServedGuamiList()[0],
 			RatType:       ue.RatType,
 		}
 
@@ -451,6 +536,7 @@ func (s *nudmService) UeCmRegistration(
 			AmfNon3GppAccessRegistration: &registrationData,
 		}
 
+		defer countSbiRequest()()
 		_, localErr := client.AMFRegistrationForNon3GPPAccessApi.
 			Non3GppRegistration(ctx, &regReq)
 
@@ -496,7 +582,7 @@ func (s *nudmService) UeCmDeregistration(
 	switch accessType {
 	case models.AccessType__3_GPP_ACCESS:
 		modificationData := models.Amf3GppAccessRegistrationModification{
-			Guami:     &amfSelf.ServedGuamiList[0],
+			Guami:     &amfSelf.GetServedGuamiList()[0],
 			PurgeFlag: true,
 		}
 
@@ -505,6 +591,7 @@ func (s *nudmService) UeCmDeregistration(
 			Amf3GppAccessRegistrationModification: &modificationData,
 		}
 
+		defer countSbiRequest()()
 		_, localErr := client.ParameterUpdateInTheAMFRegistrationFor3GPPAccessApi.Update3GppRegistration(ctx,
 			&modificationReq)
 
@@ -530,7 +617,7 @@ func (s *nudmService) UeCmDeregistration(
 		}
 	case models.AccessType_NON_3_GPP_ACCESS:
 		modificationData := models.AmfNon3GppAccessRegistrationModification{
-			Guami:     &amfSelf.ServedGuamiList[0],
+			Guami:     &amfSelf.GetServedGuamiList()[0],
 			PurgeFlag: true,
 		}
 		modificationReq := Nudm_UEContextManagement.UpdateNon3GppRegistrationRequest{
@@ -538,6 +625,7 @@ func (s *nudmService) UeCmDeregistration(
 			AmfNon3GppAccessRegistrationModification: &modificationData,
 		}
 
+		defer countSbiRequest()()
 		_, localErr := client.ParameterUpdateInTheAMFRegistrationForNon3GPPAccessApi.UpdateNon3GppRegistration(
 			ctx, &modificationReq)
 
@@ -3,17 +3,21 @@ package processor
 import (
 	"fmt"
 	"net/http"
+	"net/url"
+	"path"
 	"runtime/debug"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 
 	"github.com/free5gc/amf/internal/context"
+	"github.com/free5gc/amf/internal/gmm"
 	gmm_common "github.com/free5gc/amf/internal/gmm/common"
 	gmm_message "github.com/free5gc/amf/internal/gmm/message"
 	"github.com/free5gc/amf/internal/logger"
 	amf_nas "github.com/free5gc/amf/internal/nas"
 	ngap_message "github.com/free5gc/amf/internal/ngap/message"
+	"github.com/free5gc/amf/internal/util"
 	"github.com/free5gc/ngap/ngapType"
 	"github.com/free5gc/openapi/models"
 	"github.com/free5gc/util/metrics/sbi"
@@ -157,6 +161,9 @@ func (p *Processor) AmPolicyControlUpdateNotifyUpdateProcedure(polAssoID string,
 				}
 			}()
 
+			ue.Lock.Lock()
+			defer ue.Lock.Unlock()
+
 			configurationUpdateCommandFlags := &context.ConfigurationUpdateCommandFlags{
 				NeedGUTI:            true,
 				NeedAllowedNSSAI:    true,
@@ -173,6 +180,9 @@ func (p *Processor) AmPolicyControlUpdateNotifyUpdateProcedure(polAssoID string,
 					models.AccessType__3_GPP_ACCESS,
 					configurationUpdateCommandFlags,
 				)
+			} else if ue.MicoMode {
+				// UE in MICO mode is not paged, the configuration is updated after its next registration
+				ue.ConfigurationUpdateCommandFlags = configurationUpdateCommandFlags
 			} else {
 				// UE is CM-IDLE => paging
 				ue.ConfigurationUpdateCommandFlags = configurationUpdateCommandFlags
@@ -181,12 +191,9 @@ func (p *Processor) AmPolicyControlUpdateNotifyUpdateProcedure(polAssoID string,
 					Procedure: context.OnGoingProcedurePaging,
 				})
 
-				pkg, err := ngap_message.BuildPaging(ue, nil, false)
-				if err != nil {
-					logger.NgapLog.Errorf("Build Paging failed : %s", err.Error())
-					return
+				if err := ngap_message.SendPaging(ue, nil, false); err != nil {
+					logger.NgapLog.Errorf("Send Paging failed : %s", err.Error())
 				}
-				ngap_message.SendPaging(ue, pkg)
 			}
 		}()
 	}
@@ -306,14 +313,12 @@ func (p *Processor) N1MessageNotifyProcedure(n1MessageNotify models.N1MessageNot
 		}
 
 		amfUe.Lock.Lock()
-		defer amfUe.Lock.Unlock()
-
 		amfUe.CopyDataFromUeContextModel(ueContext)
 
 		ranUe := ran.RanUeFindByRanUeNgapID(int64(registrationCtxtContainer.AnN2ApId))
 
 		ranUe.Location = *registrationCtxtContainer.UserLocation
-		amfUe.Location = *registrationCtxtContainer.UserLocation
+		amfUe.SetLocation(*registrationCtxtContainer.UserLocation)
 		ranUe.UeContextRequest = registrationCtxtContainer.UeContextRequest
 		ranUe.OldAmfName = registrationCtxtContainer.InitialAmfName
 
@@ -327,8 +332,267 @@ func (p *Processor) N1MessageNotifyProcedure(n1MessageNotify models.N1MessageNot
 		}
 
 		gmm_common.AttachRanUeToAmfUeAndReleaseOldIfAny(amfUe, ranUe)
+		amfUe.Lock.Unlock()
 
+		// the NAS message is handled as the ones from the NG-RAN, without holding the UE lock
 		amf_nas.HandleNAS(ranUe, ngapType.ProcedureCodeInitialUEMessage, n1MessageNotify.BinaryDataN1Message, true)
 	}()
 	return nil
 }
+
+// TS 23.502 4.9.1.3.3 step 6a, the T-AMF notifies the S-AMF that the N2 handover is completed
+func (p *Processor) HandleN2InfoNotifyHandoverComplete(c *gin.Context,
+	n2InformationNotification models.N2InformationNotification,
+) {
+	logger.ProducerLog.Infoln("[AMF] Handle N2 Info Notify Handover Complete")
+
+	ueContextID := c.Param("ueContextId")
+	problemDetails := p.N2InfoNotifyHandoverCompleteProcedure(ueContextID, n2InformationNotification)
+	if problemDetails != nil {
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetails.Cause)
+		c.JSON(int(problemDetails.Status), problemDetails)
+	} else {
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func (p *Processor) N2InfoNotifyHandoverCompleteProcedure(ueContextID string,
+	n2InformationNotification models.N2InformationNotification,
+) *models.ProblemDetails {
+	amfSelf := context.GetSelf()
+
+	ue, ok := amfSelf.AmfUeFindByUeContextID(ueContextID)
+	if !ok {
+		problemDetails := &models.ProblemDetails{
+			Status: http.StatusNotFound,
+			Cause:  "CONTEXT_NOT_FOUND",
+			Detail: fmt.Sprintf("UE Context[%s] Not Found", ueContextID),
+		}
+		return problemDetails
+	}
+
+	if n2InformationNotification.NotifyReason != models.N2InfoNotifyReason_HANDOVER_COMPLETED {
+		problemDetails := &models.ProblemDetails{
+			Status: http.StatusBadRequest,
+			Cause:  "INVALID_MSG_FORMAT",
+			InvalidParams: []models.InvalidParam{
+				{Param: "notifyReason", Reason: "invalid value"},
+			},
+		}
+		return problemDetails
+	}
+
+	ue.Lock.Lock()
+	defer ue.Lock.Unlock()
+
+	// the Secondary RAT usage data reported by the source NG-RAN during the handover
+	p.Consumer().SendBufferedSecondaryRatUsageReports(ue)
+
+	// TS 23.502 4.9.1.3.3 step 6c, the UE context in S-AMF and source NG-RAN are released
+	gmm_common.StopAll5GSMMTimers(ue)
+	sourceUe := ue.RanUe[models.AccessType__3_GPP_ACCESS]
+	if sourceUe == nil {
+		gmm_common.RemoveAmfUe(ue, false)
+		return nil
+	}
+	// the AmfUe is removed after the UE Context Release Complete is received
+	ngap_message.SendUEContextReleaseCommand(sourceUe, context.UeContextReleaseUeContext,
+		ngapType.CausePresentRadioNetwork, ngapType.CauseRadioNetworkPresentSuccessfulHandover)
+	return nil
+}
+
+// TS 29.526 5.2.2.3 and 5.2.2.4, the NSSAAF triggers the re-authentication or the revocation of the S-NSSAI
+func (p *Processor) HandleNssaaNotification(c *gin.Context, nssaaNotification context.NssaaNotification) {
+	logger.ProducerLog.Infof("Handle NSSAA Notification [%s]", nssaaNotification.NotifType)
+
+	supi := c.Param("supi")
+	problemDetails := p.NssaaNotificationProcedure(supi, nssaaNotification)
+	if problemDetails != nil {
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetails.Cause)
+		c.JSON(int(problemDetails.Status), problemDetails)
+	} else {
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func (p *Processor) NssaaNotificationProcedure(supi string,
+	nssaaNotification context.NssaaNotification,
+) *models.ProblemDetails {
+	if nssaaNotification.Snssai == nil || (nssaaNotification.NotifType != context.NssaaNotificationTypeReauth &&
+		nssaaNotification.NotifType != context.NssaaNotificationTypeRevocation) {
+		problemDetails := &models.ProblemDetails{
+			Status: http.StatusBadRequest,
+			Cause:  "INVALID_MSG_FORMAT",
+		}
+		return problemDetails
+	}
+
+	ue, ok := context.GetSelf().AmfUeFindBySupi(supi)
+	if !ok {
+		problemDetails := &models.ProblemDetails{
+			Status: http.StatusNotFound,
+			Cause:  "CONTEXT_NOT_FOUND",
+			Detail: fmt.Sprintf("Supi[%s] Not Found", supi),
+		}
+		return problemDetails
+	}
+
+	// use go routine to write response first to ensure the order of the procedure
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				// Print stack for panic to log. Fatalf() will let program exit.
+				logger.CallbackLog.Fatalf("panic: %v\n%s", p, string(debug.Stack()))
+			}
+		}()
+
+		ue.Lock.Lock()
+		defer ue.Lock.Unlock()
+
+		if nssaaNotification.NotifType == context.NssaaNotificationTypeReauth {
+			gmm.HandleNssaaReauthentication(ue, *nssaaNotification.Snssai)
+		} else {
+			gmm.HandleNssaaRevocation(ue, *nssaaNotification.Snssai)
+		}
+	}()
+	return nil
+}
+
+// TS 29.503 5.2.2.3.2, the subscription data of the UE is changed in the UDM
+func (p *Processor) HandleSdmDataChangeNotification(c *gin.Context,
+	modificationNotification models.ModificationNotification,
+) {
+	logger.ProducerLog.Infoln("Handle SDM Data Change Notification")
+
+	supi := c.Param("supi")
+	problemDetails := p.SdmDataChangeNotificationProcedure(supi, modificationNotification)
+	if problemDetails != nil {
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetails.Cause)
+		c.JSON(int(problemDetails.Status), problemDetails)
+	} else {
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func (p *Processor) SdmDataChangeNotificationProcedure(supi string,
+	modificationNotification models.ModificationNotification,
+) *models.ProblemDetails {
+	ue, ok := context.GetSelf().AmfUeFindBySupi(supi)
+	if !ok {
+		problemDetails := &models.ProblemDetails{
+			Status: http.StatusNotFound,
+			Cause:  "CONTEXT_NOT_FOUND",
+			Detail: fmt.Sprintf("Supi[%s] Not Found", supi),
+		}
+		return problemDetails
+	}
+
+	ue.Lock.Lock()
+	defer ue.Lock.Unlock()
+
+	// the changes are applied to the copies, and stored to the UE after all the changes are applied
+	var amData *models.AccessAndMobilitySubscriptionData
+	var nssai *models.Nssai
+	var smfSelData *models.SmfSelectionSubscriptionData
+	var traceData *models.TraceData
+	traceDataChanged := false
+	for _, notifyItem := range modificationNotification.NotifyItems {
+		resourceUri, err := url.Parse(notifyItem.ResourceId)
+		if err != nil {
+			return sdmDataChangeInvalidParam(notifyItem.ResourceId, err)
+		}
+
+		switch path.Base(resourceUri.Path) {
+		case "am-data":
+			if amData == nil {
+				amData = ue.AccessAndMobilitySubscriptionData
+			}
+			patched := new(models.AccessAndMobilitySubscriptionData)
+			err = util.ApplyChangeItems(amData, notifyItem.Changes, patched)
+			amData = patched
+		case "nssai":
+			if nssai == nil {
+				nssai = ue.SubscribedNssaiData()
+			}
+			patched := new(models.Nssai)
+			err = util.ApplyChangeItems(nssai, notifyItem.Changes, patched)
+			nssai = patched
+		case "smf-select-data":
+			if smfSelData == nil {
+				smfSelData = ue.SmfSelectionData
+			}
+			patched := new(models.SmfSelectionSubscriptionData)
+			err = util.ApplyChangeItems(smfSelData, notifyItem.Changes, patched)
+			smfSelData = patched
+		case "trace-data":
+			if !traceDataChanged {
+				traceData, traceDataChanged = ue.TraceData, true
+			}
+			// the trace data is null if the trace is deactivated
+			var patched *models.TraceData
+			if err = util.ApplyChangeItems(traceData, notifyItem.Changes, &patched); err == nil && patched != nil {
+				err = context.ValidateTraceData(patched)
+			}
+			traceData = patched
+		default:
+			ue.ProducerLog.Warnf("Change of the resource[%s] is not handled", notifyItem.ResourceId)
+		}
+		if err != nil {
+			return sdmDataChangeInvalidParam(notifyItem.ResourceId, err)
+		}
+	}
+
+	if amData != nil {
+		ue.AccessAndMobilitySubscriptionData = amData
+		if len(amData.Gpsis) > 0 {
+			ue.Gpsi = amData.Gpsis[0] // TODO: select GPSI
+		}
+	}
+	if smfSelData != nil {
+		ue.SmfSelectionData = smfSelData
+	}
+	if traceDataChanged {
+		if traceData == nil {
+			gmm_common.DeactivateTrace(ue)
+		} else {
+			gmm_common.ActivateTrace(ue, traceData)
+		}
+	}
+	// the UE in MICO mode re-negotiates the MICO mode if it's no longer allowed by the subscription
+	micoChanged := amData != nil && ue.MicoMode && !amData.MicoAllowed
+	if nssai != nil {
+		ue.SetSubscribedNssaiData(nssai)
+	}
+	if nssai != nil || micoChanged {
+		// use go routine to write response first to ensure the order of the procedure
+		go func() {
+			defer func() {
+				if p := recover(); p != nil {
+					// Print stack for panic to log. Fatalf() will let program exit.
+					logger.CallbackLog.Fatalf("panic: %v\n%s", p, string(debug.Stack()))
+				}
+			}()
+
+			ue.Lock.Lock()
+			defer ue.Lock.Unlock()
+
+			if nssai != nil {
+				gmm.HandleSubscribedNssaiChange(ue)
+			}
+			if micoChanged {
+				gmm.HandleSubscribedMicoChange(ue)
+			}
+		}()
+	}
+	return nil
+}
+
+func sdmDataChangeInvalidParam(resourceId string, err error) *models.ProblemDetails {
+	return &models.ProblemDetails{
+		Status: http.StatusBadRequest,
+		Cause:  "INVALID_MSG_FORMAT",
+		InvalidParams: []models.InvalidParam{
+			{Param: resourceId, Reason: err.Error()},
+		},
+	}
+}
@@ -32,7 +32,6 @@ func (p *Processor) HandleCreateAMFEventSubscription(c *gin.Context,
 	}
 }
 
-// TODO: handle event filter
 func (p *Processor) CreateAMFEventSubscriptionProcedure(createEventSubscription models.AmfCreateEventSubscription) (
 	*models.AmfCreatedEventSubscription, *models.ProblemDetails,
 ) {
@@ -172,7 +171,7 @@ func (p *Processor) CreateAMFEventSubscriptionProcedure(createEventSubscription
 			}
 			for i, flag := range immediateFlags {
 				if flag {
-					report, ok := p.newAmfEventReport(ue, subscription.EventList[i].Type, newSubscriptionID)
+					report, ok := p.newAmfEventReport(ue, subscription.EventList[i], newSubscriptionID)
 					if ok {
 						reportlist = append(reportlist, report)
 					}
@@ -196,7 +195,7 @@ func (p *Processor) CreateAMFEventSubscriptionProcedure(createEventSubscription
 			if ue.GroupID == subscription.GroupId {
 				for i, flag := range immediateFlags {
 					if flag {
-						report, ok := p.newAmfEventReport(ue, subscription.EventList[i].Type, newSubscriptionID)
+						report, ok := p.newAmfEventReport(ue, subscription.EventList[i], newSubscriptionID)
 						if ok {
 							reportlist = append(reportlist, report)
 						}
@@ -219,7 +218,7 @@ func (p *Processor) CreateAMFEventSubscriptionProcedure(createEventSubscription
 		}
 		for i, flag := range immediateFlags {
 			if flag {
-				report, ok := p.newAmfEventReport(ue, subscription.EventList[i].Type, newSubscriptionID)
+				report, ok := p.newAmfEventReport(ue, subscription.EventList[i], newSubscriptionID)
 				if ok {
 					reportlist = append(reportlist, report)
 				}
@@ -230,6 +229,22 @@ func (p *Processor) CreateAMFEventSubscriptionProcedure(createEventSubscription
 			delete(ue.EventSubscriptionsInfo, newSubscriptionID)
 		}
 	}
+	// the number of UEs in the areas is reported for the subscription instead of each UE
+	for i, flag := range immediateFlags {
+		if event := subscription.EventList[i]; flag && event.Type == models.AmfEventType_UES_IN_AREA_REPORT {
+			now := time.Now().UTC()
+			active := subscription.Options == nil || subscription.Options.Trigger != models.AmfEventTrigger_ONE_TIME
+			reportlist = append(reportlist, models.AmfEventReport{
+				Type:        event.Type,
+				State:       &models.AmfEventState{Active: active},
+				TimeStamp:   &now,
+				AnyUe:       subscription.AnyUE,
+				AreaList:    event.AreaList,
+				RefId:       event.RefId,
+				NumberOfUes: contextEventSubscription.NumberOfUesInArea(event.AreaList),
+			})
+		}
+	}
 	if len(reportlist) > 0 {
 		createdEventSubscription.ReportList = reportlist
 		// delete subscription
@@ -378,18 +393,18 @@ func (p *Processor) subReports(ue *context.AmfUe, subscriptionId string) {
 	*remainReport--
 }
 
-// DO NOT handle AmfEventType_PRESENCE_IN_AOI_REPORT and AmfEventType_UES_IN_AREA_REPORT(about area)
-func (p *Processor) newAmfEventReport(ue *context.AmfUe, amfEventType models.AmfEventType, subscriptionId string) (
+// The immediate report of the event, AmfEventType_UES_IN_AREA_REPORT is reported for the subscription instead
+func (p *Processor) newAmfEventReport(ue *context.AmfUe, event models.AmfEvent, subscriptionId string) (
 	report models.AmfEventReport, ok bool,
 ) {
+	amfEventType := event.Type
 	ueSubscription, ok := ue.EventSubscriptionsInfo[subscriptionId]
 	if !ok {
 		return report, ok
 	}
 
+	report = ue.NewAmfEventReport(amfEventType)
 	report.AnyUe = ueSubscription.AnyUe
-	report.Supi = ue.Supi
-	report.Type = amfEventType
 	report.TimeStamp = &ueSubscription.Timestamp
 	report.State = new(models.AmfEventState)
 	mode := ueSubscription.EventSubscription.Options
@@ -397,7 +412,8 @@ func (p *Processor) newAmfEventReport(ue *context.AmfUe, amfEventType models.Amf
 		report.State.Active = true
 	} else if mode.Trigger == models.AmfEventTrigger_ONE_TIME {
 		report.State.Active = false
-	} else if *ueSubscription.RemainReports <= 0 {
+	} else if mode.MaxReports > 0 && ueSubscription.RemainReports != nil && *ueSubscription.RemainReports <= 0 {
+		// the number of reports is not limited if maxReports is absent
 		report.State.Active = false
 	} else {
 		report.State.Active = p.getDuration(mode.Expiry, &report.State.RemainDuration)
@@ -406,38 +422,18 @@ func (p *Processor) newAmfEventReport(ue *context.AmfUe, amfEventType models.Amf
 		}
 	}
 
+	report.RefId = event.RefId
+
 	switch amfEventType {
-	case models.AmfEventType_LOCATION_REPORT:
-		report.Location = &ue.Location
-	// case models.AmfEventType_PRESENCE_IN_AOI_REPORT:
-	// report.AreaList = (*subscription.EventList)[eventIndex].AreaList
-	case models.AmfEventType_TIMEZONE_REPORT:
-		report.Timezone = ue.TimeZone
-	case models.AmfEventType_ACCESS_TYPE_REPORT:
-		for accessType, state := range ue.State {
-			if state.Is(context.Registered) {
-				report.AccessTypeList = append(report.AccessTypeList, accessType)
-			}
-		}
-	case models.AmfEventType_REGISTRATION_STATE_REPORT:
-		var rmInfos []models.RmInfo
-		for accessType, state := range ue.State {
-			rmInfo := models.RmInfo{
-				RmState:    models.RmState_DEREGISTERED,
-				AccessType: accessType,
-			}
-			if state.Is(context.Registered) {
-				rmInfo.RmState = models.RmState_REGISTERED
-			}
-			rmInfos = append(rmInfos, rmInfo)
-		}
-		report.RmInfoList = rmInfos
-	case models.AmfEventType_CONNECTIVITY_STATE_REPORT:
-		report.CmInfoList = ue.GetCmInfo()
-	case models.AmfEventType_REACHABILITY_REPORT:
-		report.Reachability = ue.Reachability
+	case models.AmfEventType_PRESENCE_IN_AOI_REPORT:
+		report.AreaList, _ = ue.AreaPresence(event, ueSubscription)
+	case models.AmfEventType_UES_IN_AREA_REPORT:
+		// the presence of the UE is kept to detect the change of the number of UEs
+		ue.AreaPresence(event, ueSubscription)
+		return report, false
 	case models.AmfEventType_COMMUNICATION_FAILURE_REPORT:
-		// TODO : report.CommFailure
+		// reported when the UE context is released abnormally
+		return report, false
 	case models.AmfEventType_SUBSCRIPTION_ID_CHANGE:
 		report.SubscriptionId = subscriptionId
 	case models.AmfEventType_SUBSCRIPTION_ID_ADDITION:
@@ -1,12 +1,16 @@
 package processor
 
 import (
+	gocontext "context"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
 	"github.com/free5gc/amf/internal/context"
 	"github.com/free5gc/amf/internal/logger"
+	ngap_message "github.com/free5gc/amf/internal/ngap/message"
+	callback "github.com/free5gc/amf/internal/sbi/processor/notifier"
 	"github.com/free5gc/openapi/models"
 	"github.com/free5gc/util/metrics/sbi"
 )
@@ -57,7 +61,8 @@ func (p *Processor) ProvideLocationInfoProcedure(requestLocInfo models.RequestLo
 	ranUe := ue.RanUe[anType]
 	if requestLocInfo.Req5gsLoc || requestLocInfo.ReqCurrentLoc {
 		provideLocInfo.CurrentLoc = true
-		provideLocInfo.Location = &ue.Location
+		location := ue.GetLocation()
+		provideLocInfo.Location = &location
 	}
 
 	if requestLocInfo.ReqRatType {
@@ -73,3 +78,298 @@ func (p *Processor) ProvideLocationInfoProcedure(requestLocInfo models.RequestLo
 	}
 	return provideLocInfo, nil
 }
+
+func (p *Processor) HandleProvidePositioningInfoRequest(c *gin.Context, requestPosInfo models.RequestPosInfo) {
+	logger.ProducerLog.Info("Handle Provide Positioning Info Request")
+
+	ueContextID := c.Param("ueContextId")
+
+	providePosInfo, problemDetails := p.ProvidePositioningInfoProcedure(requestPosInfo, ueContextID)
+	if problemDetails != nil {
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetails.Cause)
+		c.JSON(int(problemDetails.Status), problemDetails)
+	} else if providePosInfo == nil {
+		// the location of the deferred location request is notified to the locationNotificationUri
+		c.Status(http.StatusNoContent)
+	} else {
+		c.JSON(http.StatusOK, providePosInfo)
+	}
+}
+
+// TS 29.518 5.2.2.5.2, TS 23.273 6.1.2 and 6.3.1
+func (p *Processor) ProvidePositioningInfoProcedure(requestPosInfo models.RequestPosInfo, ueContextID string) (
+	*models.ProvidePosInfo, *models.ProblemDetails,
+) {
+	amfSelf := context.GetSelf()
+
+	ue, ok := amfSelf.AmfUeFindByUeContextID(ueContextID)
+	if !ok {
+		logger.CtxLog.Warnf("AmfUe Context[%s] not found", ueContextID)
+		problemDetails := &models.ProblemDetails{
+			Status: http.StatusNotFound,
+			Cause:  "CONTEXT_NOT_FOUND",
+		}
+		return nil, problemDetails
+	}
+
+	deferred := requestPosInfo.LcsLocation == models.AmfLocationLocationType_DEFERRED_LOCATION
+	if deferred && requestPosInfo.LocationNotificationUri == "" {
+		problemDetails := &models.ProblemDetails{
+			Status: http.StatusBadRequest,
+			Cause:  "MANDATORY_IE_MISSING",
+			Detail: "locationNotificationUri is required for the deferred location",
+		}
+		return nil, problemDetails
+	}
+
+	if !ue.State[models.AccessType__3_GPP_ACCESS].Is(context.Registered) {
+		problemDetails := &models.ProblemDetails{
+			Status: http.StatusGatewayTimeout,
+			Cause:  "UE_NOT_REACHABLE",
+		}
+		return nil, problemDetails
+	}
+
+	lmfID, lmfUri, err := p.Consumer().SelectLmf(ue, "")
+	if err != nil {
+		ue.ProducerLog.Errorf("Select LMF failed: %+v", err)
+		problemDetails := &models.ProblemDetails{
+			Status: http.StatusInternalServerError,
+			Cause:  "POSITIONING_FAILED",
+			Detail: err.Error(),
+		}
+		return nil, problemDetails
+	}
+
+	locationRequest, ctx := ue.NewLocationRequest(gocontext.Background(), requestPosInfo.LdrReference,
+		requestPosInfo.HgmlcCallBackURI, lmfID, lmfUri, deferred)
+
+	if deferred {
+		go func() {
+			defer ue.DeleteLocationRequest(locationRequest)
+			providePosInfo, problemDetails := p.determineLocation(ctx, ue, locationRequest, &requestPosInfo)
+			if problemDetails != nil {
+				ue.ProducerLog.Errorf("Deferred location failed: %+v", problemDetails)
+				return
+			}
+			notifiedPosInfo := &models.NotifiedPosInfo{
+				LocationEvent:               models.AmfLocationLocationEvent_ACTIVATION_OF_DEFERRED_LOCATION,
+				Supi:                        ue.Supi,
+				Gpsi:                        ue.Gpsi,
+				Pei:                         ue.Pei,
+				LocationEstimate:            providePosInfo.LocationEstimate,
+				LocalLocationEstimate:       providePosInfo.LocalLocationEstimate,
+				AgeOfLocationEstimate:       providePosInfo.AgeOfLocationEstimate,
+				TimestampOfLocationEstimate: providePosInfo.TimestampOfLocationEstimate,
+				VelocityEstimate:            providePosInfo.VelocityEstimate,
+				PositioningDataList:         providePosInfo.PositioningDataList,
+				GnssPositioningDataList:     providePosInfo.GnssPositioningDataList,
+				Ecgi:                        providePosInfo.Ecgi,
+				Ncgi:                        providePosInfo.Ncgi,
+				CivicAddress:                providePosInfo.CivicAddress,
+				BarometricPressure:          providePosInfo.BarometricPressure,
+				Altitude:                    providePosInfo.Altitude,
+				HgmlcCallBackURI:            requestPosInfo.HgmlcCallBackURI,
+				LdrReference:                requestPosInfo.LdrReference,
+				ServingLMFIdentification:    providePosInfo.ServingLMFIdentification,
+				AchievedQos:                 providePosInfo.AchievedQos,
+				HaGnssMetrics:               providePosInfo.HaGnssMetrics,
+			}
+			callback.SendPositioningInfoNotify(requestPosInfo.LocationNotificationUri, notifiedPosInfo)
+		}()
+		return nil, nil
+	}
+
+	defer ue.DeleteLocationRequest(locationRequest)
+	return p.determineLocation(ctx, ue, locationRequest, &requestPosInfo)
+}
+
+// Page the UE if it is CM-IDLE, then request the LMF to determine the location of the UE. The UE lock is
+// not held during the determination since the LMF exchanges the LPP/NRPPa messages via this AMF meanwhile.
+func (p *Processor) determineLocation(ctx gocontext.Context, ue *context.AmfUe,
+	locationRequest *context.LocationRequest, requestPosInfo *models.RequestPosInfo,
+) (*models.ProvidePosInfo, *models.ProblemDetails) {
+	if !ue.CmConnect(models.AccessType__3_GPP_ACCESS) {
+		// the UE in MICO mode is not paged
+		if ue.MicoMode {
+			return nil, &models.ProblemDetails{
+				Status: http.StatusGatewayTimeout,
+				Cause:  "UE_NOT_REACHABLE",
+			}
+		}
+		ue.Lock.Lock()
+		if ue.OnGoing(models.AccessType__3_GPP_ACCESS).Procedure != context.OnGoingProcedurePaging {
+			ue.SetOnGoing(models.AccessType__3_GPP_ACCESS, &context.OnGoing{
+				Procedure: context.OnGoingProcedurePaging,
+			})
+			if err := ngap_message.SendPaging(ue, nil, false); err != nil {
+				ue.SetOnGoing(models.AccessType__3_GPP_ACCESS, &context.OnGoing{
+					Procedure: context.OnGoingProcedureNothing,
+				})
+				ue.Lock.Unlock()
+				logger.NgapLog.Errorf("Send Paging failed : %s", err.Error())
+				return nil, &models.ProblemDetails{
+					Status: http.StatusInternalServerError,
+					Cause:  "SYSTEM_FAILURE",
+					Detail: err.Error(),
+				}
+			}
+		}
+		ue.Lock.Unlock()
+
+		t3513Cfg := context.GetSelf().T3513Cfg
+		if !ue.WaitCmConnected(t3513Cfg.ExpireTime * time.Duration(t3513Cfg.MaxRetryTimes+1)) {
+			return nil, &models.ProblemDetails{
+				Status: http.StatusGatewayTimeout,
+				Cause:  "UE_NOT_REACHABLE",
+			}
+		}
+	}
+
+	inputData := &models.LmfLocationInputData{
+		ExternalClientType:    requestPosInfo.LcsClientType,
+		AmfId:                 context.GetSelf().NfId,
+		LocationQoS:           requestPosInfo.LcsQoS,
+		Supi:                  ue.Supi,
+		Pei:                   ue.Pei,
+		Gpsi:                  ue.Gpsi,
+		Priority:              requestPosInfo.Priority,
+		VelocityRequested:     requestPosInfo.VelocityRequested,
+		LcsServiceType:        requestPosInfo.LcsServiceType,
+		LdrType:               requestPosInfo.LdrType,
+		HgmlcCallBackURI:      requestPosInfo.HgmlcCallBackURI,
+		LdrReference:          requestPosInfo.LdrReference,
+		PeriodicEventInfo:     requestPosInfo.PeriodicEventInfo,
+		AreaEventInfo:         requestPosInfo.AreaEventInfo,
+		MotionEventInfo:       requestPosInfo.MotionEventInfo,
+		ScheduledLocTime:      requestPosInfo.ScheduledLocTime,
+		ReliableLocReq:        requestPosInfo.ReliableLocReq,
+		IntegrityRequirements: requestPosInfo.IntegrityRequirements,
+	}
+	if requestPosInfo.LcsSupportedGADShapes != "" {
+		inputData.SupportedGADShapes = append([]models.SupportedGadShapes{requestPosInfo.LcsSupportedGADShapes},
+			requestPosInfo.AdditionalLcsSuppGADShapes...)
+	}
+	if location := ue.GetLocation(); location.NrLocation != nil {
+		inputData.Ncgi = location.NrLocation.Ncgi
+	} else if location.EutraLocation != nil {
+		inputData.Ecgi = location.EutraLocation.Ecgi
+	}
+
+	locationData, problemDetails, err := p.Consumer().DetermineLocation(ctx, locationRequest.LmfUri, inputData)
+	if err != nil {
+		ue.ProducerLog.Errorf("Determine Location failed: %+v", err)
+		return nil, &models.ProblemDetails{
+			Status: http.StatusInternalServerError,
+			Cause:  "POSITIONING_FAILED",
+			Detail: err.Error(),
+		}
+	} else if problemDetails != nil {
+		ue.ProducerLog.Errorf("Determine Location failed: %+v", problemDetails)
+		return nil, &models.ProblemDetails{
+			Status: http.StatusInternalServerError,
+			Cause:  "POSITIONING_FAILED",
+			Detail: problemDetails.Cause,
+		}
+	}
+
+	providePosInfo := &models.ProvidePosInfo{
+		LocationEstimate:            locationData.LocationEstimate,
+		LocalLocationEstimate:       locationData.LocalLocationEstimate,
+		AccuracyFulfilmentIndicator: locationData.AccuracyFulfilmentIndicator,
+		AgeOfLocationEstimate:       locationData.AgeOfLocationEstimate,
+		TimestampOfLocationEstimate: locationData.TimestampOfLocationEstimate,
+		VelocityEstimate:            locationData.VelocityEstimate,
+		PositioningDataList:         locationData.PositioningDataList,
+		GnssPositioningDataList:     locationData.GnssPositioningDataList,
+		Ecgi:                        locationData.Ecgi,
+		Ncgi:                        locationData.Ncgi,
+		CivicAddress:                locationData.CivicAddress,
+		BarometricPressure:          locationData.BarometricPressure,
+		Altitude:                    locationData.Altitude,
+		ServingLMFIdentification:    locationData.ServingLMFIdentification,
+		AchievedQos:                 locationData.AchievedQos,
+		AcceptedPeriodicEventInfo:   locationData.AcceptedPeriodicEventInfo,
+		HaGnssMetrics:               locationData.HaGnssMetrics,
+	}
+	if providePosInfo.ServingLMFIdentification == "" {
+		providePosInfo.ServingLMFIdentification = locationRequest.LmfId
+	}
+	return providePosInfo, nil
+}
+
+func (p *Processor) HandleCancelLocationRequest(c *gin.Context, cancelPosInfo models.CancelPosInfo) {
+	logger.ProducerLog.Info("Handle Cancel Location Request")
+
+	ueContextID := c.Param("ueContextId")
+
+	problemDetails := p.CancelLocationProcedure(cancelPosInfo, ueContextID)
+	if problemDetails != nil {
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetails.Cause)
+		c.JSON(int(problemDetails.Status), problemDetails)
+	} else {
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// TS 29.518 5.2.2.5.3, cancel the periodic or triggered location request identified by ldrReference
+func (p *Processor) CancelLocationProcedure(cancelPosInfo models.CancelPosInfo,
+	ueContextID string,
+) *models.ProblemDetails {
+	amfSelf := context.GetSelf()
+
+	ue, ok := amfSelf.AmfUeFindByUeContextID(ueContextID)
+	if !ok {
+		logger.CtxLog.Warnf("AmfUe Context[%s] not found", ueContextID)
+		problemDetails := &models.ProblemDetails{
+			Status: http.StatusNotFound,
+			Cause:  "CONTEXT_NOT_FOUND",
+		}
+		return problemDetails
+	}
+
+	if cancelPosInfo.LdrReference == "" || cancelPosInfo.HgmlcCallBackURI == "" {
+		problemDetails := &models.ProblemDetails{
+			Status: http.StatusBadRequest,
+			Cause:  "MANDATORY_IE_MISSING",
+		}
+		return problemDetails
+	}
+
+	var lmfUri string
+	locationRequest, ok := ue.LocationRequestFind(cancelPosInfo.LdrReference)
+	if ok {
+		lmfUri = locationRequest.LmfUri
+	} else if cancelPosInfo.ServingLMFIdentification != "" {
+		// the location request may be initiated via the old AMF before the mobility of the UE
+		var err error
+		if _, lmfUri, err = p.Consumer().SelectLmf(ue, cancelPosInfo.ServingLMFIdentification); err != nil {
+			ue.ProducerLog.Errorf("Select LMF[%s] failed: %+v", cancelPosInfo.ServingLMFIdentification, err)
+		}
+	}
+	if lmfUri == "" {
+		problemDetails := &models.ProblemDetails{
+			Status: http.StatusNotFound,
+			Cause:  "CONTEXT_NOT_FOUND",
+			Detail: "Location request[" + cancelPosInfo.LdrReference + "] not found",
+		}
+		return problemDetails
+	}
+
+	cancelLocData := &models.LmfLocationCancelLocData{
+		HgmlcCallBackURI:  cancelPosInfo.HgmlcCallBackURI,
+		LdrReference:      cancelPosInfo.LdrReference,
+		SupportedFeatures: cancelPosInfo.SupportedFeatures,
+	}
+	problemDetails, err := p.Consumer().CancelLocation(lmfUri, cancelLocData)
+	if err != nil {
+		ue.ProducerLog.Errorf("Cancel Location failed: %+v", err)
+	} else if problemDetails != nil {
+		ue.ProducerLog.Warnf("Cancel Location failed: %+v", problemDetails)
+	}
+
+	if locationRequest != nil {
+		ue.DeleteLocationRequest(locationRequest)
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package processor
 import (
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
@@ -143,6 +144,16 @@ func (p *Processor) N1N2MessageTransferProcedure(ueContextID string, reqUri stri
 					anType = smContext.AccessType()
 				}
 			}
+		case models.N2InformationClass_NRP_PA:
+			ue.ProducerLog.Debug("Receive N2 NRPPa Message")
+			if requestData.N2InfoContainer.NrppaInfo == nil || requestData.N2InfoContainer.NrppaInfo.NfId == "" {
+				ue.ProducerLog.Error("NRPPa Information or LMF ID not found")
+				problemDetails = &models.ProblemDetails{
+					Status: http.StatusBadRequest,
+					Cause:  "MANDATORY_IE_MISSING",
+				}
+				return nil, "", problemDetails, nil
+			}
 		default:
 			ue.ProducerLog.Warnf("N2 Information type [%s] is not supported", requestData.N2InfoContainer.N2InformationClass)
 			problemDetails = &models.ProblemDetails{
@@ -158,6 +169,13 @@ func (p *Processor) N1N2MessageTransferProcedure(ueContextID string, reqUri stri
 	// TODO: Error Status 307, 403 in TS29.518 Table 6.1.3.5.3.1-3
 	switch onGoing.Procedure {
 	case context.OnGoingProcedurePaging:
+		// the asynchronous transfer is queued regardless of the ongoing paging
+		if context.IsAsynchronousN1N2Transfer(requestData) {
+			break
+		}
+		// TS 29.518 5.2.2.3.1.2: the request with the same or lower priority than the one triggering the ongoing
+		// paging is rejected; otherwise the UE is paged again with the higher priority, and the previous transfer
+		// is kept to be delivered together when the UE responds to the paging
 		if requestData.Ppi == 0 || (onGoing.Ppi != 0 && onGoing.Ppi <= requestData.Ppi) {
 			transferErr = new(models.N1N2MessageTransferError)
 			transferErr.Error = &models.ProblemDetails{
@@ -167,7 +185,8 @@ func (p *Processor) N1N2MessageTransferProcedure(ueContextID string, reqUri stri
 			return nil, "", nil, transferErr
 		}
 		ue.StopT3513()
-		callback.SendN1N2TransferFailureNotification(ue, models.N1N2MessageTransferCause_UE_NOT_RESPONDING)
+		ue.QueueN1N2Message(ue.N1N2Message)
+		ue.N1N2Message = nil
 	case context.OnGoingProcedureRegistration:
 		transferErr = new(models.N1N2MessageTransferError)
 		transferErr.Error = &models.ProblemDetails{
@@ -190,9 +209,17 @@ func (p *Processor) N1N2MessageTransferProcedure(ueContextID string, reqUri stri
 			nasPdu []byte
 			err    error
 		)
+		if n1Msg != nil && n2Info == nil && n1MsgType == nasMessage.PayloadContainerTypeLPP {
+			ue.ProducerLog.Debug("Forward LPP Message to UE")
+			gmm_message.SendDLNASTransport(ue.RanUe[anType], nasMessage.PayloadContainerTypeLPP, n1Msg, 0, 0, nil, 0,
+				context.LppRoutingInformation(requestData.N1MessageContainer.NfId))
+			n1n2MessageTransferRspData = new(models.N1N2MessageTransferRspData)
+			n1n2MessageTransferRspData.Cause = models.N1N2MessageTransferCause_N1_N2_TRANSFER_INITIATED
+			return n1n2MessageTransferRspData, "", nil, nil
+		}
 		if n1Msg != nil {
 			nasPdu, err = gmm_message.
-				BuildDLNASTransport(ue, anType, n1MsgType, n1Msg, uint8(requestData.PduSessionId), nil, nil, 0)
+				BuildDLNASTransport(ue, anType, n1MsgType, n1Msg, uint8(requestData.PduSessionId), nil, nil, 0, nil)
 			if err != nil {
 				ue.ProducerLog.Errorf("Build DL NAS Transport error: %+v", err)
 				problemDetails = &models.ProblemDetails{
@@ -212,6 +239,16 @@ func (p *Processor) N1N2MessageTransferProcedure(ueContextID string, reqUri stri
 			}
 		}
 
+		if n2Info != nil && requestData.N2InfoContainer.N2InformationClass == models.N2InformationClass_NRP_PA {
+			ue.ProducerLog.Debugln("AMF Transfer NRPPa PDU from LMF")
+			ranUe := ue.RanUe[anType]
+			ranUe.RoutingID = context.RoutingIDFromNfId(requestData.N2InfoContainer.NrppaInfo.NfId)
+			ngap_message.SendDownlinkUEAssociatedNRPPaTransport(ranUe, ngapType.NRPPaPDU{Value: n2Info})
+			n1n2MessageTransferRspData = new(models.N1N2MessageTransferRspData)
+			n1n2MessageTransferRspData.Cause = models.N1N2MessageTransferCause_N1_N2_TRANSFER_INITIATED
+			return n1n2MessageTransferRspData, "", nil, nil
+		}
+
 		// TODO: only support transfer N2 SM information now
 		if n2Info != nil {
 			smInfo := requestData.N2InfoContainer.SmInfo
@@ -260,9 +297,9 @@ func (p *Processor) N1N2MessageTransferProcedure(ueContextID string, reqUri stri
 
 	// UE is CM-IDLE
 
-	// 409: transfer a N2 PDU Session Resource Release Command to a 5G-AN and if the UE is in CM-IDLE
-	if n2Info != nil &&
-		requestData.N2InfoContainer.SmInfo.N2InfoContent.NgapIeType == models.AmfCommunicationNgapIeType_PDU_RES_REL_CMD {
+	// 409: transfer a N2 PDU Session Resource Release Command or a NRPPa PDU to a 5G-AN and if the UE is in CM-IDLE
+	if n2Info != nil && (requestData.N2InfoContainer.N2InformationClass == models.N2InformationClass_NRP_PA ||
+		requestData.N2InfoContainer.SmInfo.N2InfoContent.NgapIeType == models.AmfCommunicationNgapIeType_PDU_RES_REL_CMD) {
 		transferErr = new(models.N1N2MessageTransferError)
 		transferErr.Error = &models.ProblemDetails{
 			Status: http.StatusConflict,
@@ -271,7 +308,7 @@ func (p *Processor) N1N2MessageTransferProcedure(ueContextID string, reqUri stri
 		return nil, "", nil, transferErr
 	}
 	// 504: the UE in MICO mode or the UE is only registered over Non-3GPP access and its state is CM-IDLE
-	if !ue.State[models.AccessType__3_GPP_ACCESS].Is(context.Registered) {
+	if !ue.State[models.AccessType__3_GPP_ACCESS].Is(context.Registered) || ue.MicoMode {
 		transferErr = new(models.N1N2MessageTransferError)
 		transferErr.Error = &models.ProblemDetails{
 			Status: http.StatusGatewayTimeout,
@@ -279,6 +316,23 @@ func (p *Processor) N1N2MessageTransferProcedure(ueContextID string, reqUri stri
 		}
 		return nil, "", nil, transferErr
 	}
+	// 504: TS 23.502 4.2.3.3, the UE in eDRX is not reachable for paging out of its paging time window, and the SMF
+	// is informed of the estimated maximum waiting time to buffer the downlink data meanwhile. The transfer is not
+	// held; the UE being paged is in its paging time window.
+	if anType == models.AccessType__3_GPP_ACCESS && !context.IsAsynchronousN1N2Transfer(requestData) &&
+		(!requestData.SkipInd || n2Info != nil) && onGoing.Procedure != context.OnGoingProcedurePaging {
+		if wait := ue.TimeToPagingTimeWindow(time.Now()); wait > 0 {
+			transferErr = new(models.N1N2MessageTransferError)
+			transferErr.Error = &models.ProblemDetails{
+				Status: http.StatusGatewayTimeout,
+				Cause:  "UE_NOT_REACHABLE",
+			}
+			transferErr.ErrInfo = &models.N1N2MsgTxfrErrDetail{
+				MaxWaitingTime: int32((wait + ue.Edrx.PagingTimeWindowLength()) / time.Second),
+			}
+			return nil, "", nil, transferErr
+		}
+	}
 
 	n1n2MessageTransferRspData = new(models.N1N2MessageTransferRspData)
 
@@ -301,7 +355,15 @@ func (p *Processor) N1N2MessageTransferProcedure(ueContextID string, reqUri stri
 	// Case A (UE is CM-IDLE in 3GPP access and the associated access type is 3GPP access)
 	// in subclause 5.2.2.3.1.2 of TS29518
 	if anType == models.AccessType__3_GPP_ACCESS {
-		if requestData.SkipInd && n2Info == nil {
+		if context.IsAsynchronousN1N2Transfer(requestData) {
+			// the message is delivered when the UE becomes CM-CONNECTED, e.g. by a service request
+			n1n2MessageTransferRspData.Cause = models.N1N2MessageTransferCause_WAITING_FOR_ASYNCHRONOUS_TRANSFER
+			ue.QueueN1N2Message(&context.N1N2Message{
+				Request:     n1n2MessageTransferRequest,
+				Status:      n1n2MessageTransferRspData.Cause,
+				ResourceUri: locationHeader,
+			})
+		} else if requestData.SkipInd && n2Info == nil {
 			n1n2MessageTransferRspData.Cause = models.N1N2MessageTransferCause_N1_MSG_NOT_TRANSFERRED
 		} else {
 			n1n2MessageTransferRspData.Cause = models.N1N2MessageTransferCause_ATTEMPTING_TO_REACH_UE
@@ -316,18 +378,15 @@ func (p *Processor) N1N2MessageTransferProcedure(ueContextID string, reqUri stri
 				Ppi:       requestData.Ppi,
 			})
 
-			if onGoing.Ppi != 0 {
+			if requestData.Ppi != 0 {
 				pagingPriority = new(ngapType.PagingPriority)
-				pagingPriority.Value = aper.Enumerated(onGoing.Ppi)
+				pagingPriority.Value = aper.Enumerated(requestData.Ppi)
 			}
-			pkg, err := ngap_message.BuildPaging(ue, pagingPriority, false)
-			if err != nil {
-				logger.NgapLog.Errorf("Build Paging failed : %s", err.Error())
-				return n1n2MessageTransferRspData, locationHeader, problemDetails, transferErr
+			if err := ngap_message.SendPaging(ue, pagingPriority, false); err != nil {
+				logger.NgapLog.Errorf("Send Paging failed : %s", err.Error())
+				return nil, "", nil, pagingFailure(ue, anType)
 			}
-			ngap_message.SendPaging(ue, pkg)
 		}
-		// TODO: WAITING_FOR_ASYNCHRONOUS_TRANSFER
 		return n1n2MessageTransferRspData, locationHeader, nil, nil
 	} else {
 		// Case B (UE is CM-IDLE in Non-3GPP access but CM-CONNECTED in 3GPP access and the associated
@@ -336,7 +395,7 @@ func (p *Processor) N1N2MessageTransferProcedure(ueContextID string, reqUri stri
 			if n2Info == nil {
 				n1n2MessageTransferRspData.Cause = models.N1N2MessageTransferCause_N1_N2_TRANSFER_INITIATED
 				gmm_message.SendDLNASTransport(ue.RanUe[models.AccessType__3_GPP_ACCESS],
-					nasMessage.PayloadContainerTypeN1SMInfo, n1Msg, requestData.PduSessionId, 0, nil, 0)
+					nasMessage.PayloadContainerTypeN1SMInfo, n1Msg, requestData.PduSessionId, 0, nil, 0, nil)
 			} else {
 				n1n2MessageTransferRspData.Cause = models.N1N2MessageTransferCause_ATTEMPTING_TO_REACH_UE
 				message := context.N1N2Message{
@@ -368,20 +427,35 @@ func (p *Processor) N1N2MessageTransferProcedure(ueContextID string, reqUri stri
 				Procedure: context.OnGoingProcedurePaging,
 				Ppi:       requestData.Ppi,
 			})
-			if onGoing.Ppi != 0 {
+			if requestData.Ppi != 0 {
 				pagingPriority = new(ngapType.PagingPriority)
-				pagingPriority.Value = aper.Enumerated(onGoing.Ppi)
+				pagingPriority.Value = aper.Enumerated(requestData.Ppi)
 			}
-			pkg, err := ngap_message.BuildPaging(ue, pagingPriority, true)
-			if err != nil {
-				logger.NgapLog.Errorf("Build Paging failed : %s", err.Error())
+			if err := ngap_message.SendPaging(ue, pagingPriority, true); err != nil {
+				logger.NgapLog.Errorf("Send Paging failed : %s", err.Error())
+				return nil, "", nil, pagingFailure(ue, anType)
 			}
-			ngap_message.SendPaging(ue, pkg)
 			return n1n2MessageTransferRspData, locationHeader, nil, nil
 		}
 	}
 }
 
+// The paging is not started, e.g. there is no RAN to page the UE, so the transfer is not held. The transfers
+// preempted by this one are notified as failed since the paging for them has been stopped.
+func pagingFailure(ue *context.AmfUe, anType models.AccessType) *models.N1N2MessageTransferError {
+	ue.N1N2Message = nil
+	ue.SetOnGoing(anType, &context.OnGoing{
+		Procedure: context.OnGoingProcedureNothing,
+	})
+	callback.SendN1N2TransferFailureNotification(ue, models.N1N2MessageTransferCause_UE_NOT_RESPONDING)
+	return &models.N1N2MessageTransferError{
+		Error: &models.ProblemDetails{
+			Status: http.StatusGatewayTimeout,
+			Cause:  "UE_NOT_REACHABLE",
+		},
+	}
+}
+
 func (p *Processor) HandleN1N2MessageTransferStatusRequest(c *gin.Context) {
 	logger.CommLog.Info("Handle N1N2Message Transfer Status Request")
 
@@ -417,8 +491,8 @@ func (p *Processor) N1N2MessageTransferStatusProcedure(ueContextID string,
 	defer ue.Lock.Unlock()
 
 	resourceUri := amfSelf.GetIPv4Uri() + reqUri
-	n1n2Message := ue.N1N2Message
-	if n1n2Message == nil || n1n2Message.ResourceUri != resourceUri {
+	n1n2Message, ok := ue.FindN1N2Message(resourceUri)
+	if !ok {
 		problemDetails := &models.ProblemDetails{
 			Status: http.StatusNotFound,
 			Cause:  "CONTEXT_NOT_FOUND",
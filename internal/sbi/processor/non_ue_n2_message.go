@@ -0,0 +1,372 @@
+package processor
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/free5gc/amf/internal/context"
+	"github.com/free5gc/amf/internal/logger"
+	ngap_message "github.com/free5gc/amf/internal/ngap/message"
+	libngap "github.com/free5gc/ngap"
+	"github.com/free5gc/ngap/ngapConvert"
+	"github.com/free5gc/ngap/ngapType"
+	"github.com/free5gc/openapi/models"
+	"github.com/free5gc/util/metrics/sbi"
+)
+
+// TS 29.518 5.2.2.4.1
+func (p *Processor) HandleNonUeN2MessageTransferRequest(c *gin.Context,
+	nonUeN2MessageTransferRequest models.NonUeN2MessageTransferRequest,
+) {
+	logger.CommLog.Info("Handle Non UE N2 Message Transfer Request")
+
+	n2InformationTransferRspData, n2InformationTransferError := p.NonUeN2MessageTransferProcedure(
+		nonUeN2MessageTransferRequest)
+	if n2InformationTransferError != nil {
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, n2InformationTransferError.Error.Cause)
+		c.JSON(int(n2InformationTransferError.Error.Status), n2InformationTransferError)
+	} else {
+		c.JSON(http.StatusOK, n2InformationTransferRspData)
+	}
+}
+
+func (p *Processor) NonUeN2MessageTransferProcedure(nonUeN2MessageTransferRequest models.NonUeN2MessageTransferRequest) (
+	*models.N2InformationTransferRspData, *models.N2InformationTransferError,
+) {
+	n2InformationTransferReqData := nonUeN2MessageTransferRequest.JsonData
+	if n2InformationTransferReqData == nil || n2InformationTransferReqData.N2Information == nil {
+		return nil, newN2InformationTransferError(http.StatusBadRequest, "MANDATORY_IE_MISSING",
+			"Missing IE [n2Information]")
+	}
+
+	switch n2InformationTransferReqData.N2Information.N2InformationClass {
+	case models.N2InformationClass_PWS:
+		return p.pwsMessageTransfer(n2InformationTransferReqData, nonUeN2MessageTransferRequest.BinaryDataN2Information)
+	case models.N2InformationClass_NRP_PA:
+		return p.nrppaMessageTransfer(n2InformationTransferReqData, nonUeN2MessageTransferRequest.BinaryDataN2Information)
+	default:
+		return nil, newN2InformationTransferError(http.StatusBadRequest, "INVALID_MSG_FORMAT",
+			fmt.Sprintf("N2 Information Class[%s] is not supported",
+				n2InformationTransferReqData.N2Information.N2InformationClass))
+	}
+}
+
+// TS 23.041 9.1.3.5.1 and 9.1.3.5.2, the Write-Replace-Warning Request or PWS Cancel Request from the CBCF
+// is forwarded to the NG-RAN nodes of the warning area, and the responses of them are aggregated
+func (p *Processor) pwsMessageTransfer(n2InformationTransferReqData *models.N2InformationTransferReqData,
+	pwsContainer []byte,
+) (*models.N2InformationTransferRspData, *models.N2InformationTransferError) {
+	amfSelf := context.GetSelf()
+
+	if n2InformationTransferReqData.N2Information.PwsInfo == nil || len(pwsContainer) == 0 {
+		return nil, newN2InformationTransferError(http.StatusBadRequest, "MANDATORY_IE_MISSING",
+			"Missing IE [pwsInfo]")
+	}
+
+	pdu, err := libngap.Decoder(pwsContainer)
+	if err != nil {
+		return nil, newN2InformationTransferError(http.StatusBadRequest, "INVALID_MSG_FORMAT",
+			fmt.Sprintf("Decode PWS container error: %+v", err))
+	}
+	if pdu.Present != ngapType.NGAPPDUPresentInitiatingMessage {
+		return nil, newN2InformationTransferError(http.StatusBadRequest, "INVALID_MSG_FORMAT",
+			"PWS container is not an NGAP initiating message")
+	}
+
+	var msgType string
+	var messageIdentifier *ngapType.MessageIdentifier
+	var serialNumber *ngapType.SerialNumber
+	var warningAreaList *ngapType.WarningAreaList
+	initiatingMessage := pdu.InitiatingMessage
+	switch initiatingMessage.Value.Present {
+	case ngapType.InitiatingMessagePresentWriteReplaceWarningRequest:
+		msgType = "WriteReplaceWarningRequest"
+		for _, ie := range initiatingMessage.Value.WriteReplaceWarningRequest.ProtocolIEs.List {
+			switch ie.Id.Value {
+			case ngapType.ProtocolIEIDMessageIdentifier:
+				messageIdentifier = ie.Value.MessageIdentifier
+			case ngapType.ProtocolIEIDSerialNumber:
+				serialNumber = ie.Value.SerialNumber
+			case ngapType.ProtocolIEIDWarningAreaList:
+				warningAreaList = ie.Value.WarningAreaList
+			}
+		}
+	case ngapType.InitiatingMessagePresentPWSCancelRequest:
+		msgType = "PWSCancelRequest"
+		for _, ie := range initiatingMessage.Value.PWSCancelRequest.ProtocolIEs.List {
+			switch ie.Id.Value {
+			case ngapType.ProtocolIEIDMessageIdentifier:
+				messageIdentifier = ie.Value.MessageIdentifier
+			case ngapType.ProtocolIEIDSerialNumber:
+				serialNumber = ie.Value.SerialNumber
+			case ngapType.ProtocolIEIDWarningAreaList:
+				warningAreaList = ie.Value.WarningAreaList
+			}
+		}
+	default:
+		return nil, newN2InformationTransferError(http.StatusBadRequest, "INVALID_MSG_FORMAT",
+			"PWS container is neither Write-Replace-Warning Request nor PWS Cancel Request")
+	}
+	if messageIdentifier == nil || serialNumber == nil {
+		return nil, newN2InformationTransferError(http.StatusBadRequest, "INVALID_MSG_FORMAT",
+			"Missing Message Identifier or Serial Number in PWS container")
+	}
+
+	// the NG-RAN nodes respond with the identifiers in the NGAP message
+	msgId, serial := context.PwsIdentifiersToInt(*messageIdentifier, *serialNumber)
+	procedureCode := initiatingMessage.ProcedureCode.Value
+
+	taiList := n2InformationTransferReqData.TaiList
+	if warningAreaList != nil && warningAreaList.TAIListForWarning != nil {
+		for _, tai := range warningAreaList.TAIListForWarning.List {
+			taiList = append(taiList, ngapConvert.TaiToModels(tai))
+		}
+	}
+	rans := selectPwsRans(n2InformationTransferReqData, warningAreaList)
+
+	transaction := amfSelf.NewPwsTransaction(procedureCode, msgId, serial, len(rans))
+	defer amfSelf.DeletePwsTransaction(transaction)
+
+	numOfSent := 0
+	for _, ran := range rans {
+		if ngap_message.SendPwsRequest(ran, msgType, pwsContainer) {
+			numOfSent++
+		}
+	}
+	respondedRans := transaction.WaitResponses(numOfSent, context.TimePwsResponse)
+	if len(respondedRans) < numOfSent {
+		logger.CommLog.Warnf("%d of %d NG-RAN nodes have not responded to %s", numOfSent-len(respondedRans),
+			numOfSent, msgType)
+	}
+
+	// the TAIs not served by any responding NG-RAN node are reported as unknown
+	var unknownTaiList []models.Tai
+	for _, tai := range taiList {
+		known := false
+		for _, ran := range respondedRans {
+			if ran.InTaiList([]models.Tai{tai}) {
+				known = true
+				break
+			}
+		}
+		if !known {
+			unknownTaiList = append(unknownTaiList, tai)
+		}
+	}
+
+	n2InformationTransferRspData := &models.N2InformationTransferRspData{
+		Result: models.N2InformationTransferResult_N2_INFO_TRANSFER_INITIATED,
+		PwsRspData: &models.PwsResponseData{
+			NgapMessageType:   int32(procedureCode),
+			SerialNumber:      serial,
+			MessageIdentifier: msgId,
+			UnknownTaiList:    unknownTaiList,
+		},
+	}
+	return n2InformationTransferRspData, nil
+}
+
+// TS 23.502 4.13.5.6, the NRPPa PDU from the LMF is forwarded to the NG-RAN nodes by
+// Downlink Non UE Associated NRPPa Transport with the LMF as the Routing ID
+func (p *Processor) nrppaMessageTransfer(n2InformationTransferReqData *models.N2InformationTransferReqData,
+	nrppaPdu []byte,
+) (*models.N2InformationTransferRspData, *models.N2InformationTransferError) {
+	nrppaInfo := n2InformationTransferReqData.N2Information.NrppaInfo
+	if nrppaInfo == nil || nrppaInfo.NfId == "" || len(nrppaPdu) == 0 {
+		return nil, newN2InformationTransferError(http.StatusBadRequest, "MANDATORY_IE_MISSING",
+			"Missing IE [nrppaInfo]")
+	}
+	if len(n2InformationTransferReqData.GlobalRanNodeList) == 0 {
+		return nil, newN2InformationTransferError(http.StatusBadRequest, "MANDATORY_IE_MISSING",
+			"Missing IE [globalRanNodeList]")
+	}
+
+	routingID := context.RoutingIDFromNfId(nrppaInfo.NfId)
+	numOfSent := 0
+	for _, ranNodeId := range n2InformationTransferReqData.GlobalRanNodeList {
+		ran, ok := context.GetSelf().AmfRanFindByRanID(ranNodeId)
+		if !ok {
+			logger.CommLog.Warnf("NG-RAN node[%+v] for NRPPa not found", ranNodeId)
+			continue
+		}
+		ngap_message.SendDownlinkNonUEAssociatedNRPPATransport(ran, routingID, ngapType.NRPPaPDU{Value: nrppaPdu})
+		numOfSent++
+	}
+	if numOfSent == 0 {
+		return nil, newN2InformationTransferError(http.StatusNotFound, "CONTEXT_NOT_FOUND",
+			"None of the NG-RAN nodes is found")
+	}
+
+	n2InformationTransferRspData := &models.N2InformationTransferRspData{
+		Result: models.N2InformationTransferResult_N2_INFO_TRANSFER_INITIATED,
+	}
+	return n2InformationTransferRspData, nil
+}
+
+// The NG-RAN nodes are selected by the Global RAN Node IDs if present, otherwise by the TAIs and cells
+// of the warning area. The warning is broadcast by all NG-RAN nodes if no area is specified.
+func selectPwsRans(n2InformationTransferReqData *models.N2InformationTransferReqData,
+	warningAreaList *ngapType.WarningAreaList,
+) (rans []*context.AmfRan) {
+	context.GetSelf().AmfRanPool.Range(func(key, value interface{}) bool {
+		ran := value.(*context.AmfRan)
+		if ran.RanId == nil || ran.AnType != models.AccessType__3_GPP_ACCESS {
+			return true
+		}
+		switch n2InformationTransferReqData.RatSelector {
+		case models.RatSelector_NR:
+			if ran.RanPresent != context.RanPresentGNbId {
+				return true
+			}
+		case models.RatSelector_E_UTRA:
+			if ran.RanPresent != context.RanPresentNgeNbId {
+				return true
+			}
+		}
+		if isPwsTargetRan(ran, n2InformationTransferReqData, warningAreaList) {
+			rans = append(rans, ran)
+		}
+		return true
+	})
+	return rans
+}
+
+func isPwsTargetRan(ran *context.AmfRan, n2InformationTransferReqData *models.N2InformationTransferReqData,
+	warningAreaList *ngapType.WarningAreaList,
+) bool {
+	if len(n2InformationTransferReqData.GlobalRanNodeList) > 0 {
+		for _, ranNodeId := range n2InformationTransferReqData.GlobalRanNodeList {
+			if targetRan, ok := context.GetSelf().AmfRanFindByRanID(ranNodeId); ok && targetRan == ran {
+				return true
+			}
+		}
+		return false
+	}
+
+	hasArea := false
+	if len(n2InformationTransferReqData.TaiList) > 0 {
+		hasArea = true
+		if ran.InTaiList(n2InformationTransferReqData.TaiList) {
+			return true
+		}
+	}
+	if warningAreaList != nil {
+		switch warningAreaList.Present {
+		case ngapType.WarningAreaListPresentTAIListForWarning:
+			hasArea = true
+			for _, tai := range warningAreaList.TAIListForWarning.List {
+				if ran.InTaiList([]models.Tai{ngapConvert.TaiToModels(tai)}) {
+					return true
+				}
+			}
+		case ngapType.WarningAreaListPresentNRCGIListForWarning:
+			hasArea = true
+			for _, nrCgi := range warningAreaList.NRCGIListForWarning.List {
+				if ran.HasNrCell(nrCgi) {
+					return true
+				}
+			}
+		case ngapType.WarningAreaListPresentEUTRACGIListForWarning:
+			hasArea = true
+			for _, eutraCgi := range warningAreaList.EUTRACGIListForWarning.List {
+				if ran.HasEutraCell(eutraCgi) {
+					return true
+				}
+			}
+		}
+		// the emergency areas are resolved by the NG-RAN nodes
+	}
+	return !hasArea
+}
+
+// TS 29.518 5.2.2.4.2
+func (p *Processor) HandleNonUeN2InfoSubscribeRequest(c *gin.Context,
+	nonUeN2InfoSubscriptionCreateData models.NonUeN2InfoSubscriptionCreateData,
+) {
+	logger.CommLog.Info("Handle Non UE N2 Info Subscribe Request")
+
+	nonUeN2InfoSubscriptionCreatedData, locationHeader, problemDetails := p.NonUeN2InfoSubscribeProcedure(
+		c.Request.URL.Path, nonUeN2InfoSubscriptionCreateData)
+	if problemDetails != nil {
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetails.Cause)
+		c.JSON(int(problemDetails.Status), problemDetails)
+		return
+	}
+
+	c.Header("Location", locationHeader)
+	c.JSON(http.StatusCreated, nonUeN2InfoSubscriptionCreatedData)
+}
+
+func (p *Processor) NonUeN2InfoSubscribeProcedure(reqUri string,
+	nonUeN2InfoSubscriptionCreateData models.NonUeN2InfoSubscriptionCreateData,
+) (*models.NonUeN2InfoSubscriptionCreatedData, string, *models.ProblemDetails) {
+	amfSelf := context.GetSelf()
+
+	if nonUeN2InfoSubscriptionCreateData.N2InformationClass == "" ||
+		nonUeN2InfoSubscriptionCreateData.N2NotifyCallbackUri == "" {
+		return nil, "", &models.ProblemDetails{
+			Status: http.StatusBadRequest,
+			Cause:  "MANDATORY_IE_MISSING",
+			Detail: "Missing IE [n2InformationClass] or [n2NotifyCallbackUri]",
+		}
+	}
+
+	n2NotifySubscriptionID := amfSelf.NewNonUeN2InfoSubscription(nonUeN2InfoSubscriptionCreateData)
+	if n2NotifySubscriptionID == "" {
+		return nil, "", &models.ProblemDetails{
+			Status: http.StatusInternalServerError,
+			Cause:  "SYSTEM_FAILURE",
+			Detail: "Allocate n2NotifySubscriptionId error",
+		}
+	}
+	logger.CommLog.Infof("new Non UE N2 Info Subscription[%s] of class[%s]", n2NotifySubscriptionID,
+		nonUeN2InfoSubscriptionCreateData.N2InformationClass)
+
+	nonUeN2InfoSubscriptionCreatedData := &models.NonUeN2InfoSubscriptionCreatedData{
+		N2NotifySubscriptionId: n2NotifySubscriptionID,
+		N2InformationClass:     nonUeN2InfoSubscriptionCreateData.N2InformationClass,
+	}
+	locationHeader := amfSelf.GetIPv4Uri() + reqUri + "/" + n2NotifySubscriptionID
+	return nonUeN2InfoSubscriptionCreatedData, locationHeader, nil
+}
+
+// TS 29.518 5.2.2.4.3
+func (p *Processor) HandleNonUeN2InfoUnSubscribeRequest(c *gin.Context) {
+	logger.CommLog.Info("Handle Non UE N2 Info UnSubscribe Request")
+
+	n2NotifySubscriptionID := c.Param("n2NotifySubscriptionId")
+
+	problemDetails := p.NonUeN2InfoUnSubscribeProcedure(n2NotifySubscriptionID)
+	if problemDetails != nil {
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetails.Cause)
+		c.JSON(int(problemDetails.Status), problemDetails)
+	} else {
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func (p *Processor) NonUeN2InfoUnSubscribeProcedure(n2NotifySubscriptionID string) *models.ProblemDetails {
+	amfSelf := context.GetSelf()
+
+	if _, ok := amfSelf.FindNonUeN2InfoSubscription(n2NotifySubscriptionID); !ok {
+		return &models.ProblemDetails{
+			Status: http.StatusNotFound,
+			Cause:  "SUBSCRIPTION_NOT_FOUND",
+		}
+	}
+	logger.CommLog.Debugf("Delete Non UE N2 Info Subscription[%s]", n2NotifySubscriptionID)
+	amfSelf.DeleteNonUeN2InfoSubscription(n2NotifySubscriptionID)
+	return nil
+}
+
+func newN2InformationTransferError(status int32, cause, detail string) *models.N2InformationTransferError {
+	return &models.N2InformationTransferError{
+		Error: &models.ProblemDetails{
+			Status: status,
+			Cause:  cause,
+			Detail: detail,
+		},
+	}
+}
@@ -0,0 +1,129 @@
+package callback
+
+import (
+	"context"
+	"sync"
+
+	amf_context "github.com/free5gc/amf/internal/context"
+	Namf_EventExposure "github.com/free5gc/openapi/amf/EventExposure"
+	"github.com/free5gc/openapi/models"
+)
+
+// TS 23.502 4.15.4.2, the event of the UE is reported to the NFs subscribed to it when the event is detected.
+// The report is taken from the state of the UE when the event happens, and it's evaluated against the event
+// subscriptions of the UE asynchronously, so that the event can be reported with or without holding ue.Lock.
+func SendAmfEventReport(ue *amf_context.AmfUe, eventType models.AmfEventType) {
+	// the event subscriptions are of the UEs identified by SUPI
+	if ue == nil || ue.Supi == "" {
+		return
+	}
+	reports := []models.AmfEventReport{ue.NewAmfEventReport(eventType)}
+	if eventType == models.AmfEventType_LOCATION_REPORT {
+		// the presence in the areas of interest is evaluated upon the change of the UE location
+		reports = append(reports,
+			ue.NewAmfEventReport(models.AmfEventType_PRESENCE_IN_AOI_REPORT),
+			ue.NewAmfEventReport(models.AmfEventType_UES_IN_AREA_REPORT))
+	}
+	enqueueAmfEventReports(ue, reports)
+}
+
+// The communication failure of the UE is reported with the NGAP cause of the UE context release
+func SendCommunicationFailureReport(ue *amf_context.AmfUe, ngapCause *models.NgApCause) {
+	if ue == nil || ue.Supi == "" || ngapCause == nil {
+		return
+	}
+	report := ue.NewAmfEventReport(models.AmfEventType_COMMUNICATION_FAILURE_REPORT)
+	report.CommFailure = &models.CommunicationFailure{
+		RanReleaseCode: ngapCause,
+	}
+	enqueueAmfEventReports(ue, []models.AmfEventReport{report})
+}
+
+var (
+	// The reports pending to be evaluated by UE, the UE has an evaluator running while it is in the map
+	amfEventReportQueues   = make(map[*amf_context.AmfUe][]models.AmfEventReport)
+	amfEventReportQueuesMu sync.Mutex
+)
+
+func enqueueAmfEventReports(ue *amf_context.AmfUe, reports []models.AmfEventReport) {
+	amfEventReportQueuesMu.Lock()
+	defer amfEventReportQueuesMu.Unlock()
+	pending, running := amfEventReportQueues[ue]
+	amfEventReportQueues[ue] = append(pending, reports...)
+	if !running {
+		go evaluateAmfEventReportQueue(ue)
+	}
+}
+
+// Evaluate the reports of the UE in the order of the events, the event subscriptions of the UE are
+// evaluated under ue.Lock as they're created and deleted by the event exposure service
+func evaluateAmfEventReportQueue(ue *amf_context.AmfUe) {
+	for {
+		amfEventReportQueuesMu.Lock()
+		pending := amfEventReportQueues[ue]
+		if len(pending) == 0 {
+			delete(amfEventReportQueues, ue)
+			amfEventReportQueuesMu.Unlock()
+			return
+		}
+		amfEventReportQueues[ue] = nil
+		amfEventReportQueuesMu.Unlock()
+
+		ue.Lock.Lock()
+		for _, report := range pending {
+			for _, notify := range ue.EvaluateEventSubscriptions(report) {
+				enqueueAmfEventNotify(notify)
+			}
+		}
+		ue.Lock.Unlock()
+	}
+}
+
+var (
+	// The notifications pending to be sent by subscription ID, the subscription has a sender running
+	// while it is in the map
+	amfEventNotifyQueues   = make(map[string][]amf_context.AmfEventNotify)
+	amfEventNotifyQueuesMu sync.Mutex
+)
+
+func enqueueAmfEventNotify(notify amf_context.AmfEventNotify) {
+	amfEventNotifyQueuesMu.Lock()
+	defer amfEventNotifyQueuesMu.Unlock()
+	pending, running := amfEventNotifyQueues[notify.SubscriptionId]
+	amfEventNotifyQueues[notify.SubscriptionId] = append(pending, notify)
+	if !running {
+		go sendAmfEventNotifyQueue(notify.SubscriptionId)
+	}
+}
+
+// Send the notifications of the subscription one by one, so that they are received in the order of the events
+func sendAmfEventNotifyQueue(subscriptionId string) {
+	for {
+		amfEventNotifyQueuesMu.Lock()
+		pending := amfEventNotifyQueues[subscriptionId]
+		if len(pending) == 0 {
+			delete(amfEventNotifyQueues, subscriptionId)
+			amfEventNotifyQueuesMu.Unlock()
+			return
+		}
+		notify := pending[0]
+		amfEventNotifyQueues[subscriptionId] = pending[1:]
+		amfEventNotifyQueuesMu.Unlock()
+
+		sendAmfEventNotify(notify.Uri, &notify.Notification)
+	}
+}
+
+func sendAmfEventNotify(uri string, notification *models.AmfEventNotification) {
+	configuration := Namf_EventExposure.NewConfiguration()
+	client := Namf_EventExposure.NewAPIClient(configuration)
+
+	req := Namf_EventExposure.CreateSubscriptionOnEventReportPostRequest{
+		AmfEventNotification: notification,
+	}
+	_, err := client.SubscriptionsCollectionCollectionApi.CreateSubscriptionOnEventReportPost(
+		context.Background(), uri, &req)
+	if err != nil {
+		HttpLog.Errorf("AMF Event Notify to [%s] failed: %+v", uri, err)
+	}
+}
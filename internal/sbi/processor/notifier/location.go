@@ -0,0 +1,24 @@
+package callback
+
+import (
+	"context"
+
+	Namf_Location "github.com/free5gc/openapi/amf/Location"
+	"github.com/free5gc/openapi/models"
+)
+
+// TS 29.518 5.2.2.5.2, the location of the deferred location request is notified to the
+// locationNotificationUri given in the ProvidePositioningInfo request
+func SendPositioningInfoNotify(uri string, notifiedPosInfo *models.NotifiedPosInfo) {
+	configuration := Namf_Location.NewConfiguration()
+	client := Namf_Location.NewAPIClient(configuration)
+
+	req := Namf_Location.ProvidePositioningInfoOnUELocationNotificationPostRequest{
+		NotifiedPosInfo: notifiedPosInfo,
+	}
+	_, err := client.IndividualUEContextDocumentApi.ProvidePositioningInfoOnUELocationNotificationPost(
+		context.Background(), uri, &req)
+	if err != nil {
+		HttpLog.Errorf("Positioning Info Notify to [%s] failed: %+v", uri, err)
+	}
+}
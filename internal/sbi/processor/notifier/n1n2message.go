@@ -18,42 +18,47 @@ func init() {
 	HttpLog = logger.HttpLog
 }
 
+// TS 29.518 5.2.2.3.1.2, the transfers attempting to reach the UE are ended when the paging fails, and the
+// failure is notified to the n1n2FailureTxfNotifURI of each transfer so that the SMF knows the downlink data
+// triggering failed. The notifications are sent asynchronously since the caller may hold ue.Lock.
 func SendN1N2TransferFailureNotification(ue *amf_context.AmfUe, cause models.N1N2MessageTransferCause) {
-	if ue.N1N2Message == nil {
-		return
-	}
-	n1n2Message := ue.N1N2Message
-	uri := n1n2Message.Request.JsonData.N1n2FailureTxfNotifURI
-	if n1n2Message.Status == models.N1N2MessageTransferCause_ATTEMPTING_TO_REACH_UE && uri != "" {
-		configuration := Namf_Communication.NewConfiguration()
-		client := Namf_Communication.NewAPIClient(configuration)
-
-		n1N2MsgTxfrFailureNotificationReq := Namf_Communication.N1N2TransferFailureNotificationRequest{
-			N1N2MsgTxfrFailureNotification: &models.N1N2MsgTxfrFailureNotification{
-				Cause:          cause,
-				N1n2MsgDataUri: n1n2Message.ResourceUri,
-			},
+	for _, n1n2Message := range ue.AbortN1N2MessagesOnPaging(cause) {
+		uri := n1n2Message.Request.JsonData.N1n2FailureTxfNotifURI
+		if uri == "" {
+			continue
 		}
+		go sendN1N2TransferFailureNotification(uri, &models.N1N2MsgTxfrFailureNotification{
+			Cause:          cause,
+			N1n2MsgDataUri: n1n2Message.ResourceUri,
+		})
+	}
+}
+
+func sendN1N2TransferFailureNotification(uri string, notification *models.N1N2MsgTxfrFailureNotification) {
+	configuration := Namf_Communication.NewConfiguration()
+	client := Namf_Communication.NewAPIClient(configuration)
 
-		_, err := client.N1N2MessageCollectionCollectionApi.
-			N1N2TransferFailureNotification(context.Background(), uri, &n1N2MsgTxfrFailureNotificationReq)
+	n1N2MsgTxfrFailureNotificationReq := Namf_Communication.N1N2TransferFailureNotificationRequest{
+		N1N2MsgTxfrFailureNotification: notification,
+	}
 
-		if err != nil {
-			HttpLog.Errorln(err.Error())
-		} else {
-			ue.N1N2Message = nil
-		}
+	_, err := client.N1N2MessageCollectionCollectionApi.
+		N1N2TransferFailureNotification(context.Background(), uri, &n1N2MsgTxfrFailureNotificationReq)
+	if err != nil {
+		HttpLog.Errorf("N1N2 Transfer Failure Notify to [%s] failed: %+v", uri, err)
 	}
 }
 
-func SendN1MessageNotify(ue *amf_context.AmfUe, n1class models.N1MessageClass, n1Msg []byte,
+// The N1 message is notified to the subscriptions of the class, and only to those of the NF if nfId is given
+func SendN1MessageNotify(ue *amf_context.AmfUe, n1class models.N1MessageClass, nfId string, n1Msg []byte,
 	registerContext *models.RegistrationContextContainer,
 ) {
 	ue.N1N2MessageSubscription.Range(func(key, value interface{}) bool {
 		subscriptionID := key.(int64)
 		subscription := value.(models.UeN1N2InfoSubscriptionCreateData)
 
-		if subscription.N1NotifyCallbackUri != "" && subscription.N1MessageClass == n1class {
+		if subscription.N1NotifyCallbackUri != "" && subscription.N1MessageClass == n1class &&
+			(nfId == "" || subscription.NfId == nfId) {
 			configuration := Namf_Communication.NewConfiguration()
 			client := Namf_Communication.NewAPIClient(configuration)
 			n1MessageNotify := models.N1MessageNotifyRequest{
@@ -64,6 +69,7 @@ func SendN1MessageNotify(ue *amf_context.AmfUe, n1class models.N1MessageClass, n
 						N1MessageContent: &models.RefToBinaryData{
 							ContentId: "n1Msg",
 						},
+						NfId: nfId,
 					},
 					RegistrationCtxtContainer: registerContext,
 				},
@@ -126,12 +132,14 @@ func SendN1MessageNotifyAtAMFReAllocation(
 	return nil
 }
 
-func SendN2InfoNotify(ue *amf_context.AmfUe, n2class models.N2InformationClass, n1Msg, n2Msg []byte) {
+// The N2 information is notified to the subscriptions of the class, and only to those of the NF if nfId is given
+func SendN2InfoNotify(ue *amf_context.AmfUe, n2class models.N2InformationClass, nfId string, n1Msg, n2Msg []byte) {
 	ue.N1N2MessageSubscription.Range(func(key, value interface{}) bool {
 		subscriptionID := key.(int64)
 		subscription := value.(models.UeN1N2InfoSubscriptionCreateData)
 
-		if subscription.N2NotifyCallbackUri != "" && subscription.N2InformationClass == n2class {
+		if subscription.N2NotifyCallbackUri != "" && subscription.N2InformationClass == n2class &&
+			(nfId == "" || subscription.NfId == nfId) {
 			configuration := Namf_Communication.NewConfiguration()
 			client := Namf_Communication.NewAPIClient(configuration)
 
@@ -159,6 +167,7 @@ func SendN2InfoNotify(ue *amf_context.AmfUe, n2class models.N2InformationClass,
 				}
 			case models.N2InformationClass_NRP_PA:
 				n2InformationNotify.JsonData.N2InfoContainer.NrppaInfo = &models.NrppaInformation{
+					NfId: nfId,
 					NrppaPdu: &models.N2InfoContent{
 						NgapData: &models.RefToBinaryData{
 							ContentId: "n2Info",
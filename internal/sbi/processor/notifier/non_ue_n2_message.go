@@ -0,0 +1,98 @@
+package callback
+
+import (
+	"context"
+	"reflect"
+
+	amf_context "github.com/free5gc/amf/internal/context"
+	Namf_Communication "github.com/free5gc/openapi/amf/Communication"
+	"github.com/free5gc/openapi/models"
+)
+
+// TS 29.518 5.2.2.4.4, the N2 information from the NG-RAN node is notified to the NFs subscribed to its class,
+// and only to the NF of nfId if given. The notifications are sent asynchronously not to block the NGAP handler.
+func SendNonUeN2InfoNotify(ran *amf_context.AmfRan, n2class models.N2InformationClass, nfId string, n2Msg []byte) {
+	amfSelf := amf_context.GetSelf()
+
+	for n2NotifySubscriptionID, subscription := range amfSelf.NonUeN2InfoSubscriptionsOfClass(n2class) {
+		if nfId != "" && subscription.NfId != nfId {
+			continue
+		}
+		if !isNonUeN2InfoSubscribedRan(ran, subscription) {
+			continue
+		}
+
+		n2InformationNotify := models.NonUeN2InfoNotifyRequest{
+			JsonData: &models.N2InformationNotification{
+				N2NotifySubscriptionId: n2NotifySubscriptionID,
+				N2InfoContainer: &models.N2InfoContainer{
+					N2InformationClass: n2class,
+				},
+				RanNodeId: ran.RanId,
+			},
+			BinaryDataN2Information: n2Msg,
+		}
+		n2InfoContent := &models.N2InfoContent{
+			NgapData: &models.RefToBinaryData{
+				ContentId: "n2Info",
+			},
+		}
+		switch n2class {
+		case models.N2InformationClass_PWS, models.N2InformationClass_PWS_BCAL, models.N2InformationClass_PWS_RF:
+			n2InformationNotify.JsonData.N2InfoContainer.PwsInfo = &models.PwsInformation{
+				PwsContainer: n2InfoContent,
+			}
+		case models.N2InformationClass_NRP_PA:
+			n2InformationNotify.JsonData.N2InfoContainer.NrppaInfo = &models.NrppaInformation{
+				NfId:     nfId,
+				NrppaPdu: n2InfoContent,
+			}
+		case models.N2InformationClass_RAN:
+			n2InformationNotify.JsonData.N2InfoContainer.RanInfo = &models.N2RanInformation{
+				N2InfoContent: n2InfoContent,
+			}
+		}
+
+		n2InformationNotifyReq := Namf_Communication.NonUeN2InfoNotifyRequest{
+			NonUeN2InfoNotifyRequest: &n2InformationNotify,
+		}
+
+		HttpLog.Infof("Send Non UE N2 Info Notify[%s] to %s", n2class, subscription.N2NotifyCallbackUri)
+		go sendNonUeN2InfoNotify(subscription.N2NotifyCallbackUri, &n2InformationNotifyReq)
+	}
+}
+
+func sendNonUeN2InfoNotify(uri string, n2InformationNotifyReq *Namf_Communication.NonUeN2InfoNotifyRequest) {
+	configuration := Namf_Communication.NewConfiguration()
+	client := Namf_Communication.NewAPIClient(configuration)
+
+	_, err := client.NonUEN2MessagesSubscriptionsCollectionCollectionApi.
+		NonUeN2InfoNotify(context.Background(), uri, n2InformationNotifyReq)
+	if err != nil {
+		HttpLog.Errorf("Non UE N2 Info Notify to [%s] failed: %+v", uri, err)
+	}
+}
+
+func isNonUeN2InfoSubscribedRan(ran *amf_context.AmfRan, subscription models.NonUeN2InfoSubscriptionCreateData) bool {
+	if len(subscription.AnTypeList) > 0 {
+		found := false
+		for _, anType := range subscription.AnTypeList {
+			if anType == ran.AnType {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(subscription.GlobalRanNodeList) > 0 {
+		for _, ranNodeId := range subscription.GlobalRanNodeList {
+			if reflect.DeepEqual(&ranNodeId, ran.RanId) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
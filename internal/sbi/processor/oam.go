@@ -1,13 +1,18 @@
 package processor
 
 import (
+	"fmt"
 	"net/http"
+	"regexp"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 
 	"github.com/free5gc/amf/internal/context"
+	gmm_common "github.com/free5gc/amf/internal/gmm/common"
 	"github.com/free5gc/amf/internal/logger"
+	"github.com/free5gc/amf/internal/ngap"
+	"github.com/free5gc/amf/pkg/factory"
 	"github.com/free5gc/openapi/models"
 	"github.com/free5gc/util/metrics/sbi"
 )
@@ -128,3 +133,198 @@ func (p *Processor) buildUEContext(ue *context.AmfUe, accessType models.AccessTy
 	}
 	return nil
 }
+
+// Activate the trace of the UE by the OAM, the trace data replaces the one given by the UDM (TS 32.422 4.2.2.9)
+func (p *Processor) HandleOAMActivateTrace(c *gin.Context, traceData models.TraceData) {
+	logger.ProducerLog.Infof("[OAM] Handle Activate Trace")
+
+	supi := c.Param("supi")
+
+	problemDetails := p.OAMActivateTraceProcedure(supi, traceData)
+	if problemDetails != nil {
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetails.Cause)
+		c.JSON(int(problemDetails.Status), problemDetails)
+	} else {
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func (p *Processor) OAMActivateTraceProcedure(supi string, traceData models.TraceData) *models.ProblemDetails {
+	if err := context.ValidateTraceData(&traceData); err != nil {
+		problemDetails := &models.ProblemDetails{
+			Status: http.StatusBadRequest,
+			Cause:  "INVALID_MSG_FORMAT",
+			Detail: err.Error(),
+		}
+		return problemDetails
+	}
+
+	ue, ok := context.GetSelf().AmfUeFindBySupi(supi)
+	if !ok {
+		problemDetails := &models.ProblemDetails{
+			Status: http.StatusNotFound,
+			Cause:  "CONTEXT_NOT_FOUND",
+		}
+		return problemDetails
+	}
+
+	ue.Lock.Lock()
+	defer ue.Lock.Unlock()
+
+	gmm_common.ActivateTrace(ue, &traceData)
+	return nil
+}
+
+func (p *Processor) HandleOAMDeactivateTrace(c *gin.Context) {
+	logger.ProducerLog.Infof("[OAM] Handle Deactivate Trace")
+
+	supi := c.Param("supi")
+
+	problemDetails := p.OAMDeactivateTraceProcedure(supi)
+	if problemDetails != nil {
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetails.Cause)
+		c.JSON(int(problemDetails.Status), problemDetails)
+	} else {
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func (p *Processor) OAMDeactivateTraceProcedure(supi string) *models.ProblemDetails {
+	ue, ok := context.GetSelf().AmfUeFindBySupi(supi)
+	if !ok {
+		problemDetails := &models.ProblemDetails{
+			Status: http.StatusNotFound,
+			Cause:  "CONTEXT_NOT_FOUND",
+		}
+		return problemDetails
+	}
+
+	ue.Lock.Lock()
+	defer ue.Lock.Unlock()
+
+	gmm_common.DeactivateTrace(ue)
+	return nil
+}
+
+// The AMF configuration sent to the NG-RAN nodes, the items absent are not changed when updated by the OAM
+type AmfConfiguration struct {
+	AmfName          string
+	ServedGuamiList  []models.Guami
+	PlmnSupportList  []factory.PlmnSupportItem
+	RelativeCapacity *int64
+}
+
+type AmfConfigurationUpdateStatus struct {
+	RanId   *models.GlobalRanNodeId
+	RanName string
+	State   context.AmfConfigUpdateState
+}
+
+type AmfConfigurationStatus struct {
+	AmfConfiguration
+	UpdateStatus []AmfConfigurationUpdateStatus
+}
+
+var amfIdRegexp = regexp.MustCompile("^[A-Fa-f0-9]{6}$")
+
+func (p *Processor) HandleOAMGetAmfConfiguration(c *gin.Context) {
+	logger.ProducerLog.Infof("[OAM] Handle Get AMF Configuration")
+
+	c.JSON(http.StatusOK, p.OAMGetAmfConfigurationProcedure())
+}
+
+// The AMF configuration and the state of the AMF Configuration Update towards each NG-RAN node
+func (p *Processor) OAMGetAmfConfigurationProcedure() AmfConfigurationStatus {
+	amfSelf := context.GetSelf()
+	relativeCapacity := amfSelf.GetRelativeCapacity()
+	status := AmfConfigurationStatus{
+		AmfConfiguration: AmfConfiguration{
+			AmfName:          amfSelf.GetName(),
+			ServedGuamiList:  amfSelf.GetServedGuamiList(),
+			PlmnSupportList:  amfSelf.GetPlmnSupportList(),
+			RelativeCapacity: &relativeCapacity,
+		},
+	}
+	amfSelf.AmfRanPool.Range(func(key, value interface{}) bool {
+		ran := value.(*context.AmfRan)
+		if ran.RanId != nil {
+			status.UpdateStatus = append(status.UpdateStatus, AmfConfigurationUpdateStatus{
+				RanId:   ran.RanId,
+				RanName: ran.Name,
+				State:   ran.AmfConfigUpdateState(),
+			})
+		}
+		return true
+	})
+	return status
+}
+
+// Update the AMF configuration at runtime, the changes are sent to the NG-RAN nodes by AMF Configuration Update
+func (p *Processor) HandleOAMUpdateAmfConfiguration(c *gin.Context, amfConfiguration AmfConfiguration) {
+	logger.ProducerLog.Infof("[OAM] Handle Update AMF Configuration")
+
+	problemDetails := p.OAMUpdateAmfConfigurationProcedure(amfConfiguration)
+	if problemDetails != nil {
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetails.Cause)
+		c.JSON(int(problemDetails.Status), problemDetails)
+	} else {
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func (p *Processor) OAMUpdateAmfConfigurationProcedure(amfConfiguration AmfConfiguration) *models.ProblemDetails {
+	if err := validateAmfConfiguration(&amfConfiguration); err != nil {
+		problemDetails := &models.ProblemDetails{
+			Status: http.StatusBadRequest,
+			Cause:  "INVALID_MSG_FORMAT",
+			Detail: err.Error(),
+		}
+		return problemDetails
+	}
+
+	amfSelf := context.GetSelf()
+	if amfSelf.UpdateAmfConfiguration(amfConfiguration.AmfName, amfConfiguration.ServedGuamiList,
+		amfConfiguration.PlmnSupportList, amfConfiguration.RelativeCapacity) {
+		ngap.UpdateAMFConfiguration()
+		// the served GUAMIs and the S-NSSAIs in the NF profile are kept up to date in the NRF
+		if err := p.Consumer().SendUpdateNFInstance(); err != nil {
+			logger.ProducerLog.Errorf("Update NF profile in NRF error: %+v", err)
+		}
+	}
+	return nil
+}
+
+// TS 38.413 9.2.6.3
+func validateAmfConfiguration(amfConfiguration *AmfConfiguration) error {
+	if len(amfConfiguration.AmfName) > 150 {
+		return fmt.Errorf("AMF name is longer than 150 characters")
+	}
+	if amfConfiguration.ServedGuamiList != nil {
+		if len(amfConfiguration.ServedGuamiList) == 0 ||
+			len(amfConfiguration.ServedGuamiList) > context.MaxNumOfServedGuamiList {
+			return fmt.Errorf("number of served GUAMIs should be in the range of 1~%d",
+				context.MaxNumOfServedGuamiList)
+		}
+		for _, guami := range amfConfiguration.ServedGuamiList {
+			if guami.PlmnId == nil || !amfIdRegexp.MatchString(guami.AmfId) {
+				return fmt.Errorf("invalid served GUAMI: %+v", guami)
+			}
+		}
+	}
+	if amfConfiguration.PlmnSupportList != nil {
+		if len(amfConfiguration.PlmnSupportList) == 0 ||
+			len(amfConfiguration.PlmnSupportList) > context.MaxNumOfPLMNs {
+			return fmt.Errorf("number of supported PLMNs should be in the range of 1~%d", context.MaxNumOfPLMNs)
+		}
+		for _, plmnItem := range amfConfiguration.PlmnSupportList {
+			if plmnItem.PlmnId == nil || len(plmnItem.SNssaiList) == 0 {
+				return fmt.Errorf("invalid supported PLMN: %+v", plmnItem)
+			}
+		}
+	}
+	if amfConfiguration.RelativeCapacity != nil &&
+		(*amfConfiguration.RelativeCapacity < 0 || *amfConfiguration.RelativeCapacity > 255) {
+		return fmt.Errorf("relative capacity should be in the range of 0~255")
+	}
+	return nil
+}
@@ -36,7 +36,7 @@ func (p *Processor) AMFStatusChangeSubscribeProcedure(subscriptionDataReq models
 	amfSelf := context.GetSelf()
 
 	for _, guami := range subscriptionDataReq.GuamiList {
-		for _, servedGumi := range amfSelf.ServedGuamiList {
+		for _, servedGumi := range amfSelf.GetServedGuamiList() {
 			if reflect.DeepEqual(guami, servedGumi) {
 				// AMF status is available
 				subscriptionDataRsp.GuamiList = append(subscriptionDataRsp.GuamiList, guami)
@@ -5,6 +5,7 @@ import (
 	"encoding/hex"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
@@ -12,129 +13,445 @@ import (
 	gmm_common "github.com/free5gc/amf/internal/gmm/common"
 	"github.com/free5gc/amf/internal/logger"
 	"github.com/free5gc/amf/internal/nas/nas_security"
+	ngap_message "github.com/free5gc/amf/internal/ngap/message"
+	"github.com/free5gc/amf/internal/util"
+	"github.com/free5gc/aper"
 	"github.com/free5gc/nas/security"
+	"github.com/free5gc/ngap/ngapType"
+	"github.com/free5gc/openapi"
 	"github.com/free5gc/openapi/models"
 	"github.com/free5gc/util/metrics/sbi"
 )
 
 // TS 29.518 5.2.2.2.3
-func (p *Processor) HandleCreateUEContextRequest(c *gin.Context, createUeContextRequest models.CreateUeContextRequest) {
+func (p *Processor) HandleCreateUEContextRequest(c *gin.Context, createUeContextRequest models.CreateUeContextRequest,
+	binaryParts util.NgapBinaryParts,
+) {
 	logger.CommLog.Infof("Handle Create UE Context Request")
 
 	ueContextID := c.Param("ueContextId")
 
-	createUeContextResponse, ueContextCreateError := p.CreateUEContextProcedure(ueContextID, createUeContextRequest)
+	createUeContextResponse, createdBinaryParts, ueContextCreateError := p.CreateUEContextProcedure(ueContextID,
+		createUeContextRequest, binaryParts)
 	if ueContextCreateError != nil {
 		c.Set(sbi.IN_PB_DETAILS_CTX_STR, ueContextCreateError.JsonData.Error.Cause)
-		c.JSON(int(ueContextCreateError.JsonData.Error.Status), ueContextCreateError)
-	} else {
-		c.JSON(http.StatusCreated, createUeContextResponse)
+		c.JSON(int(ueContextCreateError.JsonData.Error.Status), ueContextCreateError.JsonData)
+		return
+	}
+	// the NGAP data of the N2 information is carried in the binary parts (TS 29.518 6.1.2.4)
+	body, contentType, err := util.MultipartRelatedEncode(createUeContextResponse.JsonData, createdBinaryParts)
+	if err != nil {
+		logger.CommLog.Errorf("Encode UeContextCreatedData error: %+v", err)
+		problemDetails := openapi.ProblemDetailsSystemFailure(err.Error())
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetails.Cause)
+		c.JSON(int(problemDetails.Status), problemDetails)
+		return
 	}
+	c.Data(http.StatusCreated, contentType, body)
 }
 
-func (p *Processor) CreateUEContextProcedure(ueContextID string, createUeContextRequest models.CreateUeContextRequest) (
-	*models.CreateUeContextResponse201, *models.CreateUeContextResponse403,
-) {
+func (p *Processor) CreateUEContextProcedure(ueContextID string, createUeContextRequest models.CreateUeContextRequest,
+	binaryParts util.NgapBinaryParts,
+) (*models.CreateUeContextResponse201, util.NgapBinaryParts, *models.CreateUeContextResponse403) {
 	amfSelf := context.GetSelf()
 	ueContextCreateData := createUeContextRequest.JsonData
 
 	if ueContextCreateData.UeContext == nil || ueContextCreateData.TargetId == nil ||
 		ueContextCreateData.PduSessionList == nil || ueContextCreateData.SourceToTargetData == nil ||
 		ueContextCreateData.N2NotifyUri == "" {
-		ueCtxCreateError := models.UeContextCreateError{
-			Error: &models.ProblemDetails{
-				Status: http.StatusForbidden,
-				Cause:  "HANDOVER_FAILURE",
-			},
-		}
-		ueContextCreateError := &models.CreateUeContextResponse403{
-			JsonData: &ueCtxCreateError,
-		}
-		return nil, ueContextCreateError
+		return nil, nil, newCreateUeContextResponse403(nil)
+	}
+	if ueContextCreateData.TargetId.RanNodeId == nil || ueContextCreateData.TargetId.Tai == nil {
+		return nil, nil, newCreateUeContextResponse403(nil)
+	}
+
+	targetRan, ok := amfSelf.AmfRanFindByRanID(*ueContextCreateData.TargetId.RanNodeId)
+	if !ok {
+		logger.CommLog.Warnf("Target Ran[%+v] is not served by this AMF", *ueContextCreateData.TargetId.RanNodeId)
+		return nil, nil, newCreateUeContextResponse403(nil)
 	}
+
 	// create the UE context in target amf
 	ue := amfSelf.NewAmfUe(ueContextID)
+
+	result, ok := p.handoverResourceAllocation(ue, targetRan, ueContextCreateData, binaryParts,
+		ngapType.HandoverTypePresentIntra5gs)
+	if !ok {
+		p.removeHandoverUeContext(ue)
+		return nil, nil, newCreateUeContextResponse403(nil)
+	}
+
+	n2HandoverResult := p.waitHandoverResourceAllocation(ue, targetRan, result)
+	if n2HandoverResult.NgapCause != nil {
+		return nil, nil, newCreateUeContextResponse403(n2HandoverResult.NgapCause)
+	}
+
+	createUeContextResponse := new(models.CreateUeContextResponse201)
+	createUeContextResponse.JsonData = n2HandoverResult.UeContextCreatedData
+	createUeContextResponse.JsonData.UeContext = &models.UeContext{
+		Supi: ueContextCreateData.UeContext.Supi,
+	}
+	// TODO: When  Target AMF selects a nw PCF for AM policy, set the flag to true.
+	createUeContextResponse.JsonData.PcfReselectedInd = false
+
+	return createUeContextResponse, n2HandoverResult.BinaryParts, nil
+}
+
+// TS 23.502 4.9.1.3.2 step 4-9, return the channel to receive the result from target NG-RAN
+func (p *Processor) handoverResourceAllocation(ue *context.AmfUe, targetRan *context.AmfRan,
+	ueContextCreateData *models.UeContextCreateData, binaryParts util.NgapBinaryParts, handoverType aper.Enumerated,
+) (<-chan *context.N2HandoverResult, bool) {
+	amfSelf := context.GetSelf()
+
 	ue.Lock.Lock()
 	defer ue.Lock.Unlock()
 
-	// amfSelf.AmfRanSetByRanId(*ueContextCreateData.TargetId.RanNodeId)
-	// ue.N1N2Message[ueContextId] = &context.N1N2Message{}
-	// ue.N1N2Message[ueContextId].Request.JsonData = &models.N1N2MessageTransferReqData{
-	// 	N2InfoContainer: &models.N2InfoContainer{
-	// 		SmInfo: &models.N2SmInformation{
-	// 			N2InfoContent: ueContextCreateData.SourceToTargetData,
-	// 		},
-	// 	},
-	// }
+	ue.CopyDataFromUeContextModel(ueContextCreateData.UeContext)
 	ue.HandoverNotifyUri = ueContextCreateData.N2NotifyUri
+	ue.RatType = targetRan.UeRatType()
+	ue.Tai = *ueContextCreateData.TargetId.Tai
+	if ueContextCreateData.UeRadioCapability != nil {
+		if ueRadioCapability, err := binaryParts.NgapData(ueContextCreateData.UeRadioCapability); err != nil {
+			ue.ProducerLog.Warnf("UE Radio Capability error: %+v", err)
+		} else {
+			ue.UeRadioCapability = hex.EncodeToString(ueRadioCapability)
+		}
+	}
 
-	amfSelf.AmfRanFindByRanID(*ueContextCreateData.TargetId.RanNodeId)
-	supportedTAI := context.NewSupportedTAI()
-	supportedTAI.Tai.Tac = ueContextCreateData.TargetId.Tai.Tac
-	supportedTAI.Tai.PlmnId = ueContextCreateData.TargetId.Tai.PlmnId
-	// ue.N1N2MessageSubscribeInfo[ueContextID] = &models.UeN1N2InfoSubscriptionCreateData{
-	// 	N2NotifyCallbackUri: ueContextCreateData.N2NotifyUri,
-	// }
-	ue.UnauthenticatedSupi = ueContextCreateData.UeContext.SupiUnauthInd
-	// should be smInfo list
-
-	//	for _, smInfo := range ueContextCreateData.PduSessionList {
-	//		if smInfo.N2InfoContent.NgapIeType == "NgapIeType_HANDOVER_REQUIRED" {
-	// 			ue.N1N2Message[amfSelf.Uri].Request.JsonData.N2InfoContainer.SmInfo = &smInfo
-	//		}
-	//	}
+	if ue.AccessAndMobilitySubscriptionData == nil || ue.AccessAndMobilitySubscriptionData.SubscribedUeAmbr == nil {
+		ue.ProducerLog.Errorln("Subscribed UE-AMBR is missing in UE context")
+		return nil, false
+	}
 
-	ue.RoutingIndicator = ueContextCreateData.UeContext.RoutingIndicator
-
-	// optional
-	ue.UdmGroupId = ueContextCreateData.UeContext.UdmGroupId
-	ue.AusfGroupId = ueContextCreateData.UeContext.AusfGroupId
-	// ueContextCreateData.UeContext.HpcfId
-	ue.RatType = ueContextCreateData.UeContext.RestrictedRatList[0] // minItem = -1
-	// ueContextCreateData.UeContext.ForbiddenAreaList
-	// ueContextCreateData.UeContext.ServiceAreaRestriction
-	// ueContextCreateData.UeContext.RestrictedCoreNwTypeList
-
-	// it's not in 5.2.2.1.1 step 2a, so don't support
-	// ue.Gpsi = ueContextCreateData.UeContext.GpsiList
-	// ue.Pei = ueContextCreateData.UeContext.Pei
-	// ueContextCreateData.UeContext.GroupList
-	// ueContextCreateData.UeContext.DrxParameter
-	// ueContextCreateData.UeContext.SubRfsp
-	// ueContextCreateData.UeContext.UsedRfsp
-	// ue.UEAMBR = ueContextCreateData.UeContext.SubUeAmbr
-	// ueContextCreateData.UeContext.SmsSupport
-	// ueContextCreateData.UeContext.SmsfId
-	// ueContextCreateData.UeContext.SeafData
-	// ueContextCreateData.UeContext.Var5gMmCapability
-	// ueContextCreateData.UeContext.PcfId
-	// ueContextCreateData.UeContext.PcfAmPolicyUri
-	// ueContextCreateData.UeContext.AmPolicyReqTriggerList
-	// ueContextCreateData.UeContext.EventSubscriptionList
-	// ueContextCreateData.UeContext.MmContextList
-	// ue.CurPduSession.PduSessionId = ueContextCreateData.UeContext.SessionContextList.
-	// ue.TraceData = ueContextCreateData.UeContext.TraceData
-	createUeContextResponse := new(models.CreateUeContextResponse201)
-	createUeContextResponse.JsonData = &models.UeContextCreatedData{
+	sourceToTargetData, err := binaryParts.NgapData(ueContextCreateData.SourceToTargetData)
+	if err != nil {
+		ue.ProducerLog.Errorf("Source to Target Transparent Container error: %+v", err)
+		return nil, false
+	}
+
+	var pduSessionReqList ngapType.PDUSessionResourceSetupListHOReq
+	for _, smInfo := range ueContextCreateData.PduSessionList {
+		smContext, ok := ue.SmContextFindByPDUSessionID(smInfo.PduSessionId)
+		if !ok {
+			ue.ProducerLog.Warnf("SmContext[PDU Session ID:%d] not found", smInfo.PduSessionId)
+			continue
+		}
+		if smContext.SmfUri() == "" {
+			if err = p.Consumer().SearchSmfInstanceOfSmContext(ue, smContext); err != nil {
+				ue.ProducerLog.Errorf("Search SMF of PDU Session[%d] error: %+v", smInfo.PduSessionId, err)
+				continue
+			}
+		}
+		handoverRequiredTransfer, err := binaryParts.NgapData(smInfo.N2InfoContent)
+		if err != nil {
+			ue.ProducerLog.Errorf("HandoverRequiredTransfer of PDU Session[%d] error: %+v", smInfo.PduSessionId, err)
+			continue
+		}
+		response, _, _, err := p.Consumer().SendUpdateSmContextN2HandoverPreparing(ue, smContext,
+			models.N2SmInfoType_HANDOVER_REQUIRED, handoverRequiredTransfer, amfSelf.NfId, ueContextCreateData.TargetId)
+		if err != nil {
+			ue.ProducerLog.Errorf("SendUpdateSmContextN2HandoverPreparing Error: %+v", err)
+		}
+		if response != nil && response.BinaryDataN2SmInformation != nil {
+			ngap_message.AppendPDUSessionResourceSetupListHOReq(&pduSessionReqList, smInfo.PduSessionId,
+				smContext.Snssai(), response.BinaryDataN2SmInformation)
+		}
+	}
+	if len(pduSessionReqList.List) == 0 {
+		ue.ProducerLog.Errorln("No PDU Session can be handed over")
+		return nil, false
+	}
+
+	targetUe, err := targetRan.NewRanUe(context.RanUeNgapIdUnspecified)
+	if err != nil {
+		ue.ProducerLog.Errorf("Create target UE error: %+v", err)
+		return nil, false
+	}
+	targetUe.HandOverType.Value = handoverType
+	targetUe.HandOverStartTime = time.Now()
+	gmm_common.AttachRanUeToAmfUeAndReleaseOldIfAny(ue, targetUe)
+	ue.SetOnGoing(targetRan.AnType, &context.OnGoing{
+		Procedure: context.OnGoingProcedureN2Handover,
+	})
+
+	cause := ngapType.Cause{
+		Present: ngapType.CausePresentMisc,
+		Misc: &ngapType.CauseMisc{
+			Value: ngapType.CauseMiscPresentUnspecified,
+		},
+	}
+	if ueContextCreateData.NgapCause != nil {
+		cause = ngapCauseFromModels(*ueContextCreateData.NgapCause)
+	}
+
+	result := ue.WaitN2HandoverResult()
+	if !ngap_message.SendHandoverRequestToTargetUe(targetUe, cause, pduSessionReqList,
+		ngapType.SourceToTargetTransparentContainer{Value: sourceToTargetData}, false) {
+		return nil, false
+	}
+	return result, true
+}
+
+// TS 23.502 4.9.1.3.2 step 10-12, the returned result has NgapCause set if the handover
+// resource allocation failed, and the UE context has been rolled back in this case. The
+// rollback is owned here once the result is sent by SendN2HandoverResult.
+func (p *Processor) waitHandoverResourceAllocation(ue *context.AmfUe, targetRan *context.AmfRan,
+	result <-chan *context.N2HandoverResult,
+) *context.N2HandoverResult {
+	var n2HandoverResult *context.N2HandoverResult
+	select {
+	case n2HandoverResult = <-result:
+	case <-time.After(context.TimeN2HandoverResourceAllocation):
+		ue.ProducerLog.Warnln("Handover resource allocation in target NG-RAN timeout")
+		ue.StopWaitingN2HandoverResult()
+		// the result sent before it's no longer waited for is still handled here
+		select {
+		case n2HandoverResult = <-result:
+		default:
+		}
+	}
+
+	ue.Lock.Lock()
+	defer ue.Lock.Unlock()
+
+	if n2HandoverResult == nil {
+		// the target NG-RAN has not responded, release the UE context in it
+		n2HandoverResult = &context.N2HandoverResult{
+			NgapCause: &models.NgApCause{
+				Group: int32(ngapType.CausePresentRadioNetwork),
+				Value: int32(ngapType.CauseRadioNetworkPresentTngrelocprepExpiry),
+			},
+		}
+	}
+	if n2HandoverResult.NgapCause != nil {
+		p.releaseHandoverUeContext(ue, targetRan.AnType, n2HandoverResult.NgapCause)
+	}
+	return n2HandoverResult
+}
+
+// Roll back the UE context of the handover resource allocation failed or canceled, the UE context in target
+// NG-RAN is released and the AmfUe is removed after the UE Context Release Complete is received
+func (p *Processor) releaseHandoverUeContext(ue *context.AmfUe, anType models.AccessType,
+	ngapCause *models.NgApCause,
+) {
+	p.cancelHandoverResourceAllocation(ue, ngapCause)
+	gmm_common.StopAll5GSMMTimers(ue)
+	if targetUe := ue.RanUe[anType]; targetUe != nil {
+		ngap_message.SendUEContextReleaseCommand(targetUe, context.UeContextReleaseUeContext,
+			int(ngapCause.Group), aper.Enumerated(ngapCause.Value))
+	} else {
+		gmm_common.RemoveAmfUe(ue, false)
+	}
+}
+
+// Roll back the UE context created for the handover before the target NG-RAN is involved
+func (p *Processor) removeHandoverUeContext(ue *context.AmfUe) {
+	ue.Lock.Lock()
+	defer ue.Lock.Unlock()
+
+	p.cancelHandoverResourceAllocation(ue, nil)
+	gmm_common.RemoveAmfUe(ue, false)
+}
+
+// TS 23.502 4.9.1.4.2 step 1, the session resources prepared in SMF and UPF are released
+func (p *Processor) cancelHandoverResourceAllocation(ue *context.AmfUe, ngapCause *models.NgApCause) {
+	causeAll := context.CauseAll{
+		NgapCause: &models.NgApCause{
+			Group: int32(ngapType.CausePresentRadioNetwork),
+			Value: int32(ngapType.CauseRadioNetworkPresentHoFailureInTarget5GCNgranNodeOrTargetSystem),
+		},
+	}
+	if ngapCause != nil {
+		causeAll.NgapCause = ngapCause
+	}
+	ue.SmContextList.Range(func(key, value interface{}) bool {
+		smContext := value.(*context.SmContext)
+		if smContext.SmfUri() == "" {
+			return true
+		}
+		_, _, _, err := p.Consumer().SendUpdateSmContextN2HandoverCanceled(ue, smContext, causeAll)
+		if err != nil {
+			ue.ProducerLog.Errorf("Send UpdateSmContextN2HandoverCanceled Error for pduSessionID[%d]",
+				smContext.PduSessionID())
+		}
+		return true
+	})
+}
+
+func newCreateUeContextResponse403(ngapCause *models.NgApCause) *models.CreateUeContextResponse403 {
+	return &models.CreateUeContextResponse403{
+		JsonData: &models.UeContextCreateError{
+			Error:     newHandoverFailureProblemDetails(),
+			NgapCause: ngapCause,
+		},
+	}
+}
+
+func ngapCauseFromModels(ngapCause models.NgApCause) ngapType.Cause {
+	cause := ngapType.Cause{
+		Present: int(ngapCause.Group),
+	}
+	value := aper.Enumerated(ngapCause.Value)
+	switch cause.Present {
+	case ngapType.CausePresentRadioNetwork:
+		cause.RadioNetwork = &ngapType.CauseRadioNetwork{Value: value}
+	case ngapType.CausePresentTransport:
+		cause.Transport = &ngapType.CauseTransport{Value: value}
+	case ngapType.CausePresentNas:
+		cause.Nas = &ngapType.CauseNas{Value: value}
+	case ngapType.CausePresentProtocol:
+		cause.Protocol = &ngapType.CauseProtocol{Value: value}
+	default:
+		cause.Present = ngapType.CausePresentMisc
+		cause.Misc = &ngapType.CauseMisc{Value: ngapType.CauseMiscPresentUnspecified}
+		if ngapCause.Group == int32(ngapType.CausePresentMisc) {
+			cause.Misc.Value = value
+		}
+	}
+	return cause
+}
+
+// TS 29.518 5.2.2.2.5
+func (p *Processor) HandleRelocateUEContextRequest(c *gin.Context,
+	relocateUeContextRequest models.RelocateUeContextRequest, binaryParts util.NgapBinaryParts,
+) {
+	logger.CommLog.Info("Handle Relocate UE Context Request")
+
+	ueContextID := c.Param("ueContextId")
+
+	ueContextRelocatedData, problemDetails := p.RelocateUEContextProcedure(ueContextID, relocateUeContextRequest,
+		binaryParts)
+	if problemDetails != nil {
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetails.Cause)
+		c.JSON(int(problemDetails.Status), problemDetails)
+	} else {
+		c.JSON(http.StatusCreated, ueContextRelocatedData)
+	}
+}
+
+// The UE context is relocated from the source AMF during EPS to 5GS handover (TS 23.502 4.11.1.2.2),
+// the target NG-RAN is prepared in the same way as Namf_Communication_CreateUEContext
+func (p *Processor) RelocateUEContextProcedure(ueContextID string,
+	relocateUeContextRequest models.RelocateUeContextRequest, binaryParts util.NgapBinaryParts,
+) (*models.UeContextRelocatedData, *models.ProblemDetails) {
+	amfSelf := context.GetSelf()
+	ueContextRelocateData := relocateUeContextRequest.JsonData
+
+	// the GTPv2 Forward Relocation Request is not processed, the UE context and the N2 information
+	// needed to prepare the target NG-RAN are taken from the JSON data
+	if ueContextRelocateData.UeContext == nil || ueContextRelocateData.TargetId == nil ||
+		ueContextRelocateData.SourceToTargetData == nil ||
+		ueContextRelocateData.TargetId.RanNodeId == nil || ueContextRelocateData.TargetId.Tai == nil {
+		problemDetails := &models.ProblemDetails{
+			Status: http.StatusBadRequest,
+			Cause:  "MANDATORY_IE_MISSING",
+		}
+		return nil, problemDetails
+	}
+
+	targetRan, ok := amfSelf.AmfRanFindByRanID(*ueContextRelocateData.TargetId.RanNodeId)
+	if !ok {
+		logger.CommLog.Warnf("Target Ran[%+v] is not served by this AMF", *ueContextRelocateData.TargetId.RanNodeId)
+		return nil, newHandoverFailureProblemDetails()
+	}
+
+	ueContextCreateData := &models.UeContextCreateData{
+		UeContext:          ueContextRelocateData.UeContext,
+		TargetId:           ueContextRelocateData.TargetId,
+		SourceToTargetData: ueContextRelocateData.SourceToTargetData,
+		PduSessionList:     ueContextRelocateData.PduSessionList,
+		UeRadioCapability:  ueContextRelocateData.UeRadioCapability,
+		NgapCause:          ueContextRelocateData.NgapCause,
+	}
+
+	// create the UE context in target amf
+	ue := amfSelf.NewAmfUe(ueContextID)
+
+	result, ok := p.handoverResourceAllocation(ue, targetRan, ueContextCreateData, binaryParts,
+		ngapType.HandoverTypePresentEpsTo5gs)
+	if !ok {
+		p.removeHandoverUeContext(ue)
+		return nil, newHandoverFailureProblemDetails()
+	}
+
+	n2HandoverResult := p.waitHandoverResourceAllocation(ue, targetRan, result)
+	if n2HandoverResult.NgapCause != nil {
+		return nil, newHandoverFailureProblemDetails()
+	}
+
+	ueContextRelocatedData := &models.UeContextRelocatedData{
 		UeContext: &models.UeContext{
-			Supi: ueContextCreateData.UeContext.Supi,
+			Supi: ueContextRelocateData.UeContext.Supi,
 		},
 	}
+	return ueContextRelocatedData, nil
+}
 
-	// response.JsonData.TargetToSourceData =
-	// ue.N1N2Message[ueContextId].Request.JsonData.N2InfoContainer.SmInfo.N2InfoContent
-	createUeContextResponse.JsonData.PduSessionList = ueContextCreateData.PduSessionList
-	createUeContextResponse.JsonData.PcfReselectedInd = false
-	// TODO: When  Target AMF selects a nw PCF for AM policy, set the flag to true.
+// TS 29.518 5.2.2.2.6
+func (p *Processor) HandleCancelRelocateUEContextRequest(c *gin.Context,
+	cancelRelocateUeContextRequest models.CancelRelocateUeContextRequest,
+) {
+	logger.CommLog.Info("Handle Cancel Relocate UE Context Request")
+
+	ueContextID := c.Param("ueContextId")
+
+	problemDetails := p.CancelRelocateUEContextProcedure(ueContextID, cancelRelocateUeContextRequest)
+	if problemDetails != nil {
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetails.Cause)
+		c.JSON(int(problemDetails.Status), problemDetails)
+	} else {
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// The relocation is canceled by the source AMF (TS 23.502 4.11.1.2.3), the SM contexts and the UE context
+// in target NG-RAN and this AMF are rolled back
+func (p *Processor) CancelRelocateUEContextProcedure(ueContextID string,
+	cancelRelocateUeContextRequest models.CancelRelocateUeContextRequest,
+) *models.ProblemDetails {
+	amfSelf := context.GetSelf()
+	ueContextCancelRelocateData := cancelRelocateUeContextRequest.JsonData
+
+	if ueContextCancelRelocateData == nil || ueContextCancelRelocateData.RelocationCancelRequest == nil {
+		problemDetails := &models.ProblemDetails{
+			Status: http.StatusBadRequest,
+			Cause:  "MANDATORY_IE_MISSING",
+		}
+		return problemDetails
+	}
 
-	//	response.UeContext = ueContextCreateData.UeContext
-	//	response.TargetToSourceData = ue.N1N2Message[amfSelf.Uri].Request.JsonData.N2InfoContainer.SmInfo.N2InfoContent
-	//	response.PduSessionList = ueContextCreateData.PduSessionList
-	//	response.PcfReselectedInd = false // TODO:When  Target AMF selects a nw PCF for AM policy, set the flag to true.
-	//
+	ue, ok := amfSelf.AmfUeFindByUeContextID(ueContextID)
+	if !ok && ueContextCancelRelocateData.Supi != "" {
+		ue, ok = amfSelf.AmfUeFindBySupi(ueContextCancelRelocateData.Supi)
+	}
+	if !ok {
+		logger.CommLog.Errorf("Relocated UE Context[%s] not found", ueContextID)
+		problemDetails := &models.ProblemDetails{
+			Status: http.StatusNotFound,
+			Cause:  "CONTEXT_NOT_FOUND",
+		}
+		return problemDetails
+	}
 
-	// return httpwrapper.NewResponse(http.StatusCreated, nil, createUeContextResponse)
-	return createUeContextResponse, nil
+	ue.Lock.Lock()
+	defer ue.Lock.Unlock()
+
+	ngapCause := &models.NgApCause{
+		Group: int32(ngapType.CausePresentRadioNetwork),
+		Value: int32(ngapType.CauseRadioNetworkPresentHandoverCancelled),
+	}
+	// the relocation procedure still waiting for the target NG-RAN rolls back the UE context by itself
+	if !ue.SendN2HandoverResult(&context.N2HandoverResult{NgapCause: ngapCause}) {
+		p.releaseHandoverUeContext(ue, models.AccessType__3_GPP_ACCESS, ngapCause)
+	}
+	return nil
+}
+
+func newHandoverFailureProblemDetails() *models.ProblemDetails {
+	return &models.ProblemDetails{
+		Status: http.StatusForbidden,
+		Cause:  "HANDOVER_FAILURE",
+	}
 }
 
 // TS 29.518 5.2.2.2.4
@@ -157,16 +474,6 @@ func (p *Processor) ReleaseUEContextProcedure(ueContextID string,
 ) *models.ProblemDetails {
 	amfSelf := context.GetSelf()
 
-	// TODO: UE is emergency registered and the SUPI is not authenticated
-	if ueContextRelease.Supi != "" {
-		logger.GmmLog.Warnf("Emergency registered UE is not supported.")
-		problemDetails := &models.ProblemDetails{
-			Status: http.StatusForbidden,
-			Cause:  "UNSPECIFIED",
-		}
-		return problemDetails
-	}
-
 	if ueContextRelease.NgapCause == nil {
 		problemDetails := &models.ProblemDetails{
 			Status: http.StatusBadRequest,
@@ -187,6 +494,17 @@ func (p *Processor) ReleaseUEContextProcedure(ueContextID string,
 		return problemDetails
 	}
 
+	// the SUPI is included if the UE is emergency registered and the SUPI is not authenticated (TS 29.518 6.1.6.2.13)
+	if ueContextRelease.Supi != "" && (!ue.IsUnauthenticatedEmergency() || ue.Supi != ueContextRelease.Supi) {
+		logger.CtxLog.Warnf("AmfUe Context[%s] is not emergency registered with SUPI[%s]", ueContextID,
+			ueContextRelease.Supi)
+		problemDetails := &models.ProblemDetails{
+			Status: http.StatusForbidden,
+			Cause:  "UNSPECIFIED",
+		}
+		return problemDetails
+	}
+
 	ue.Lock.Lock()
 	defer ue.Lock.Unlock()
 
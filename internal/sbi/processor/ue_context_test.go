@@ -0,0 +1,72 @@
+package processor
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/free5gc/amf/internal/context"
+	"github.com/free5gc/ngap/ngapType"
+	"github.com/free5gc/openapi/models"
+)
+
+func TestCancelRelocateUEContextProcedure(t *testing.T) {
+	amfSelf := context.GetSelf()
+	defer func(servedGuamiList []models.Guami) {
+		amfSelf.UpdateAmfConfiguration("", servedGuamiList, nil, nil)
+	}(amfSelf.GetServedGuamiList())
+	amfSelf.UpdateAmfConfiguration("", []models.Guami{
+		{
+			PlmnId: &models.PlmnIdNid{Mcc: "208", Mnc: "93"},
+			AmfId:  "cafe00",
+		},
+	}, nil, nil)
+	p := &Processor{}
+	const supi = "imsi-208930000000001"
+	newRequest := func(supi string) models.CancelRelocateUeContextRequest {
+		return models.CancelRelocateUeContextRequest{
+			JsonData: &models.UeContextCancelRelocateData{
+				Supi: supi,
+				RelocationCancelRequest: &models.RefToBinaryData{
+					ContentId: "gtpc",
+				},
+			},
+		}
+	}
+
+	// the Relocation Cancel Request is mandatory
+	problemDetails := p.CancelRelocateUEContextProcedure(supi, models.CancelRelocateUeContextRequest{
+		JsonData: &models.UeContextCancelRelocateData{Supi: supi},
+	})
+	require.NotNil(t, problemDetails)
+	require.Equal(t, int32(http.StatusBadRequest), problemDetails.Status)
+
+	// the relocated UE context is not found
+	problemDetails = p.CancelRelocateUEContextProcedure(supi, newRequest(supi))
+	require.NotNil(t, problemDetails)
+	require.Equal(t, int32(http.StatusNotFound), problemDetails.Status)
+
+	// the relocation waiting for the target NG-RAN is canceled, the UE context is rolled back by the waiting one
+	ue := amfSelf.NewAmfUe(supi)
+	result := ue.WaitN2HandoverResult()
+	problemDetails = p.CancelRelocateUEContextProcedure("5g-guti-unknown", newRequest(supi))
+	require.Nil(t, problemDetails)
+	select {
+	case n2HandoverResult := <-result:
+		require.Equal(t, &models.NgApCause{
+			Group: int32(ngapType.CausePresentRadioNetwork),
+			Value: int32(ngapType.CauseRadioNetworkPresentHandoverCancelled),
+		}, n2HandoverResult.NgapCause)
+	default:
+		t.Fatal("relocation is not canceled")
+	}
+	_, ok := amfSelf.AmfUeFindBySupi(supi)
+	require.True(t, ok)
+
+	// the relocation not waiting for the target NG-RAN is canceled, and the UE context is removed
+	problemDetails = p.CancelRelocateUEContextProcedure("5g-guti-unknown", newRequest(supi))
+	require.Nil(t, problemDetails)
+	_, ok = amfSelf.AmfUeFindBySupi(supi)
+	require.False(t, ok)
+}
@@ -0,0 +1,173 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/free5gc/openapi/models"
+)
+
+// Apply the change items of the data change notification (TS 29.503 6.1.6.2.19) to the JSON representation of
+// data, the paths of the change items are the JSON pointers (RFC 6901) in data. The result is decoded to patched.
+func ApplyChangeItems(data interface{}, changes []models.ChangeItem, patched interface{}) error {
+	buf, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	var doc interface{}
+	if err = json.Unmarshal(buf, &doc); err != nil {
+		return err
+	}
+
+	for _, change := range changes {
+		switch change.Op {
+		case models.ChangeType_ADD, models.ChangeType_REPLACE:
+			doc, err = setJSONPointer(doc, change.Path, jsonValue(change.NewValue), change.Op == models.ChangeType_ADD)
+		case models.ChangeType_REMOVE:
+			doc, _, err = removeJSONPointer(doc, change.Path)
+		case models.ChangeType_MOVE:
+			var value interface{}
+			doc, value, err = removeJSONPointer(doc, change.From)
+			if err == nil {
+				doc, err = setJSONPointer(doc, change.Path, value, true)
+			}
+		default:
+			err = fmt.Errorf("unknown change type: %s", change.Op)
+		}
+		if err != nil {
+			return fmt.Errorf("%s %s: %w", change.Op, change.Path, err)
+		}
+	}
+
+	if buf, err = json.Marshal(doc); err != nil {
+		return err
+	}
+	return json.Unmarshal(buf, patched)
+}
+
+// a nil map is null in JSON
+func jsonValue(value map[string]interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	return value
+}
+
+func jsonPointerTokens(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer")
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func setJSONPointer(doc interface{}, pointer string, value interface{}, add bool) (interface{}, error) {
+	tokens, err := jsonPointerTokens(pointer)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return value, nil
+	}
+	return updateJSONNode(doc, tokens, func(node interface{}, token string) (interface{}, error) {
+		switch n := node.(type) {
+		case map[string]interface{}:
+			n[token] = value
+			return n, nil
+		case []interface{}:
+			if add && token == "-" {
+				return append(n, value), nil
+			}
+			i, errIndex := strconv.Atoi(token)
+			switch {
+			case errIndex != nil || i < 0 || i > len(n) || (!add && i == len(n)):
+				return nil, fmt.Errorf("invalid array index: %s", token)
+			case add:
+				n = append(n, nil)
+				copy(n[i+1:], n[i:])
+				n[i] = value
+			default:
+				n[i] = value
+			}
+			return n, nil
+		default:
+			return nil, fmt.Errorf("%s is not in an object or an array", token)
+		}
+	})
+}
+
+// the removed value is returned
+func removeJSONPointer(doc interface{}, pointer string) (interface{}, interface{}, error) {
+	tokens, err := jsonPointerTokens(pointer)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, doc, nil
+	}
+	var removed interface{}
+	doc, err = updateJSONNode(doc, tokens, func(node interface{}, token string) (interface{}, error) {
+		switch n := node.(type) {
+		case map[string]interface{}:
+			var ok bool
+			if removed, ok = n[token]; !ok {
+				return nil, fmt.Errorf("%s not found", token)
+			}
+			delete(n, token)
+			return n, nil
+		case []interface{}:
+			i, errIndex := strconv.Atoi(token)
+			if errIndex != nil || i < 0 || i >= len(n) {
+				return nil, fmt.Errorf("invalid array index: %s", token)
+			}
+			removed = n[i]
+			return append(n[:i], n[i+1:]...), nil
+		default:
+			return nil, fmt.Errorf("%s is not in an object or an array", token)
+		}
+	})
+	return doc, removed, err
+}
+
+// Walk to the parent of the last token and update it, the updated node is returned
+func updateJSONNode(node interface{}, tokens []string,
+	update func(node interface{}, token string) (interface{}, error),
+) (interface{}, error) {
+	if len(tokens) == 1 {
+		return update(node, tokens[0])
+	}
+	switch n := node.(type) {
+	case map[string]interface{}:
+		child, ok := n[tokens[0]]
+		if !ok {
+			return nil, fmt.Errorf("%s not found", tokens[0])
+		}
+		updated, err := updateJSONNode(child, tokens[1:], update)
+		if err != nil {
+			return nil, err
+		}
+		n[tokens[0]] = updated
+		return n, nil
+	case []interface{}:
+		i, err := strconv.Atoi(tokens[0])
+		if err != nil || i < 0 || i >= len(n) {
+			return nil, fmt.Errorf("invalid array index: %s", tokens[0])
+		}
+		updated, err := updateJSONNode(n[i], tokens[1:], update)
+		if err != nil {
+			return nil, err
+		}
+		n[i] = updated
+		return n, nil
+	default:
+		return nil, fmt.Errorf("%s is not in an object or an array", tokens[0])
+	}
+}
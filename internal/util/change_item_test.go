@@ -0,0 +1,99 @@
+package util_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/free5gc/amf/internal/util"
+	"github.com/free5gc/openapi/models"
+)
+
+func TestApplyChangeItems(t *testing.T) {
+	nssai := &models.Nssai{
+		DefaultSingleNssais: []models.Snssai{{Sst: 1, Sd: "010203"}},
+		SingleNssais:        []models.Snssai{{Sst: 1, Sd: "112233"}},
+	}
+
+	testCases := []struct {
+		name     string
+		changes  []models.ChangeItem
+		expected *models.Nssai
+		err      bool
+	}{
+		{
+			name: "add and remove S-NSSAIs",
+			changes: []models.ChangeItem{
+				{
+					Op:       models.ChangeType_ADD,
+					Path:     "/singleNssais/-",
+					NewValue: map[string]interface{}{"sst": 2},
+				},
+				{
+					Op:   models.ChangeType_REMOVE,
+					Path: "/singleNssais/0",
+				},
+			},
+			expected: &models.Nssai{
+				DefaultSingleNssais: []models.Snssai{{Sst: 1, Sd: "010203"}},
+				SingleNssais:        []models.Snssai{{Sst: 2}},
+			},
+		},
+		{
+			name: "replace and move S-NSSAIs",
+			changes: []models.ChangeItem{
+				{
+					Op:       models.ChangeType_REPLACE,
+					Path:     "/defaultSingleNssais/0",
+					NewValue: map[string]interface{}{"sst": 1, "sd": "445566"},
+				},
+				{
+					Op:   models.ChangeType_MOVE,
+					From: "/singleNssais/0",
+					Path: "/defaultSingleNssais/0",
+				},
+			},
+			expected: &models.Nssai{
+				DefaultSingleNssais: []models.Snssai{{Sst: 1, Sd: "112233"}, {Sst: 1, Sd: "445566"}},
+				SingleNssais:        []models.Snssai{},
+			},
+		},
+		{
+			name: "replace the resource",
+			changes: []models.ChangeItem{
+				{
+					Op: models.ChangeType_REPLACE,
+					NewValue: map[string]interface{}{
+						"defaultSingleNssais": []interface{}{map[string]interface{}{"sst": 3}},
+					},
+				},
+			},
+			expected: &models.Nssai{
+				DefaultSingleNssais: []models.Snssai{{Sst: 3}},
+			},
+		},
+		{
+			name: "remove nonexistent S-NSSAI",
+			changes: []models.ChangeItem{
+				{
+					Op:   models.ChangeType_REMOVE,
+					Path: "/singleNssais/1",
+				},
+			},
+			err: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			patched := new(models.Nssai)
+			err := util.ApplyChangeItems(nssai, tc.changes, patched)
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, patched)
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"sort"
+	"strings"
+
+	"github.com/free5gc/openapi"
+	"github.com/free5gc/openapi/models"
+)
+
+const (
+	multipartRelated = "multipart/related"
+	applicationJson  = "application/json"
+	applicationNgap  = "application/vnd.3gpp.ngap"
+)
+
+// The NGAP data of the N2 information is carried in the binary parts of the multipart/related body, and referenced
+// by the Content-ID in N2InfoContent (TS 29.518 6.1.2.4, TS 29.500 6.1.2.2.2). The binary parts referenced through
+// a list (e.g. PduSessionList of UeContextCreateData) can not be handled by openapi, so they are kept by Content-ID.
+type NgapBinaryParts map[string][]byte
+
+// Add the NGAP data as a binary part, and return the N2 information referencing it
+func (p NgapBinaryParts) N2InfoContent(ngapIeType models.AmfCommunicationNgapIeType,
+	ngapData []byte,
+) *models.N2InfoContent {
+	contentId := fmt.Sprintf("%s-%d", strings.ToLower(string(ngapIeType)), len(p)+1)
+	p[contentId] = ngapData
+	return &models.N2InfoContent{
+		NgapIeType: ngapIeType,
+		NgapData: &models.RefToBinaryData{
+			ContentId: contentId,
+		},
+	}
+}
+
+// The NGAP data in the binary part referenced by the N2 information
+func (p NgapBinaryParts) NgapData(n2InfoContent *models.N2InfoContent) ([]byte, error) {
+	if n2InfoContent == nil || n2InfoContent.NgapData == nil {
+		return nil, fmt.Errorf("NGAP data of N2 information is missing")
+	}
+	ngapData, ok := p[n2InfoContent.NgapData.ContentId]
+	if !ok {
+		return nil, fmt.Errorf("binary part[%s] of N2 information is missing", n2InfoContent.NgapData.ContentId)
+	}
+	return ngapData, nil
+}
+
+// Encode the JSON data and the binary parts into multipart/related body, the content type with the boundary is
+// returned. The binary parts are encoded in the order of their Content-ID.
+func MultipartRelatedEncode(jsonData interface{}, binaryParts NgapBinaryParts) ([]byte, string, error) {
+	body := new(bytes.Buffer)
+	w := multipart.NewWriter(body)
+
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Type", applicationJson)
+	part, err := w.CreatePart(h)
+	if err != nil {
+		return nil, "", err
+	}
+	if err = json.NewEncoder(part).Encode(jsonData); err != nil {
+		return nil, "", err
+	}
+
+	contentIds := make([]string, 0, len(binaryParts))
+	for contentId := range binaryParts {
+		contentIds = append(contentIds, contentId)
+	}
+	sort.Strings(contentIds)
+	for _, contentId := range contentIds {
+		h = make(textproto.MIMEHeader)
+		h.Set("Content-Type", applicationNgap)
+		h.Set("Content-Id", contentId)
+		if part, err = w.CreatePart(h); err != nil {
+			return nil, "", err
+		}
+		if _, err = part.Write(binaryParts[contentId]); err != nil {
+			return nil, "", err
+		}
+	}
+	if err = w.Close(); err != nil {
+		return nil, "", err
+	}
+	return body.Bytes(), mime.FormatMediaType(multipartRelated, map[string]string{
+		"boundary": w.Boundary(),
+		"type":     applicationJson,
+	}), nil
+}
+
+// Decode the multipart/related body, the JSON part is decoded into jsonData and the binary parts are returned by
+// their Content-ID
+func MultipartRelatedDecode(jsonData interface{}, body []byte, contentType string) (NgapBinaryParts, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, err
+	}
+	if mediaType != multipartRelated {
+		return nil, fmt.Errorf("unexpected content type: %s", mediaType)
+	}
+	binaryParts := make(NgapBinaryParts)
+	hasJsonPart := false
+	reader := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if openapi.KindOfMediaType(part.Header.Get("Content-Type")) == openapi.MediaKindJSON {
+			if err = json.NewDecoder(part).Decode(jsonData); err != nil {
+				return nil, err
+			}
+			hasJsonPart = true
+			continue
+		}
+		data, err := io.ReadAll(part)
+		if err != nil {
+			return nil, err
+		}
+		contentId := strings.Trim(part.Header.Get("Content-Id"), "<>")
+		binaryParts[contentId] = data
+	}
+	if !hasJsonPart {
+		return nil, fmt.Errorf("no JSON part in multipart body")
+	}
+	return binaryParts, nil
+}
@@ -0,0 +1,61 @@
+package util_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/free5gc/amf/internal/util"
+	"github.com/free5gc/openapi/models"
+)
+
+func TestMultipartRelated(t *testing.T) {
+	binaryParts := make(util.NgapBinaryParts)
+	ueContextCreateData := models.UeContextCreateData{
+		SourceToTargetData: binaryParts.N2InfoContent(models.AmfCommunicationNgapIeType_SRC_TO_TAR_CONTAINER,
+			[]byte{0x00, 0x01, 0x7f}),
+		PduSessionList: []models.N2SmInformation{
+			{
+				PduSessionId: 1,
+				N2InfoContent: binaryParts.N2InfoContent(models.AmfCommunicationNgapIeType_HANDOVER_REQUIRED,
+					[]byte{0x80, 0xff}),
+			},
+			{
+				PduSessionId: 2,
+				N2InfoContent: binaryParts.N2InfoContent(models.AmfCommunicationNgapIeType_HANDOVER_REQUIRED,
+					[]byte{0x0d, 0x0a, 0x2d, 0x2d}),
+			},
+		},
+		N2NotifyUri: "http://127.0.0.18:8000/namf-callback/v1/handover-complete/imsi-208930000000001",
+	}
+
+	body, contentType, err := util.MultipartRelatedEncode(&ueContextCreateData, binaryParts)
+	require.NoError(t, err)
+
+	var decoded models.UeContextCreateData
+	decodedParts, err := util.MultipartRelatedDecode(&decoded, body, contentType)
+	require.NoError(t, err)
+	require.Equal(t, ueContextCreateData, decoded)
+
+	ngapData, err := decodedParts.NgapData(decoded.SourceToTargetData)
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x00, 0x01, 0x7f}, ngapData)
+	for i, pduSession := range decoded.PduSessionList {
+		ngapData, err = decodedParts.NgapData(pduSession.N2InfoContent)
+		require.NoError(t, err)
+		require.Equal(t, binaryParts[ueContextCreateData.PduSessionList[i].N2InfoContent.NgapData.ContentId], ngapData)
+	}
+
+	_, err = decodedParts.NgapData(&models.N2InfoContent{})
+	require.Error(t, err)
+	_, err = decodedParts.NgapData(&models.N2InfoContent{
+		NgapData: &models.RefToBinaryData{
+			ContentId: "n2Info",
+		},
+	})
+	require.Error(t, err)
+
+	// the JSON part is mandatory
+	_, err = util.MultipartRelatedDecode(&decoded, []byte("--b--\r\n"), "multipart/related; boundary=b")
+	require.Error(t, err)
+}
@@ -107,6 +107,14 @@ type Configuration struct {
 	Locality               string            `yaml:"locality,omitempty" valid:"type(string),optional"`
 	SCTP                   *Sctp             `yaml:"sctp,omitempty" valid:"optional"`
 	DefaultUECtxReq        bool              `yaml:"defaultUECtxReq,omitempty" valid:"type(bool),optional"`
+	PagingPolicy           *PagingPolicy     `yaml:"pagingPolicy,omitempty" valid:"optional"`
+	Emergency              *Emergency        `yaml:"emergency,omitempty" valid:"optional"`
+	Mico                   *Mico             `yaml:"mico,omitempty" valid:"optional"`
+	Edrx                   *Edrx             `yaml:"edrx,omitempty" valid:"optional"`
+	TraceCollectionEntity  *Tce              `yaml:"traceCollectionEntity,omitempty" valid:"optional"`
+	OverloadControl        *OverloadControl  `yaml:"overloadControl,omitempty" valid:"optional"`
+	CongestionControl      *Congestion       `yaml:"congestionControl,omitempty" valid:"optional"`
+	UeRetention            *UeRetention      `yaml:"ueRetention,omitempty" valid:"optional"`
 }
 
 type Logger struct {
@@ -311,6 +319,54 @@ func (c *Configuration) validate() (bool, error) {
 		}
 	}
 
+	if c.PagingPolicy != nil {
+		if _, err := c.PagingPolicy.validate(); err != nil {
+			return false, err
+		}
+	}
+
+	if c.Emergency != nil {
+		if _, err := c.Emergency.validate(); err != nil {
+			return false, err
+		}
+	}
+
+	if c.Mico != nil {
+		if _, err := c.Mico.validate(); err != nil {
+			return false, err
+		}
+	}
+
+	if c.Edrx != nil {
+		if _, err := c.Edrx.validate(); err != nil {
+			return false, err
+		}
+	}
+
+	if c.TraceCollectionEntity != nil {
+		if _, err := c.TraceCollectionEntity.validate(); err != nil {
+			return false, err
+		}
+	}
+
+	if c.OverloadControl != nil {
+		if _, err := c.OverloadControl.validate(); err != nil {
+			return false, err
+		}
+	}
+
+	if c.CongestionControl != nil {
+		if _, err := c.CongestionControl.validate(); err != nil {
+			return false, err
+		}
+	}
+
+	if c.UeRetention != nil {
+		if _, err := c.UeRetention.validate(); err != nil {
+			return false, err
+		}
+	}
+
 	if _, err := govalidator.ValidateStruct(c); err != nil {
 		return false, appendInvalid(err)
 	}
@@ -632,6 +688,345 @@ func (n *Sctp) validate() (bool, error) {
 	return true, nil
 }
 
+const (
+	// the RAN node of the last known cell of the UE and the RAN nodes recommended for paging by the NG-RAN
+	PagingAreaLastKnownRan = "lastKnownRan"
+	// the TAIs of the registration area of the UE
+	PagingAreaRegistrationArea = "registrationArea"
+	// all the TAIs supported by the AMF
+	PagingAreaAllTai = "allTai"
+)
+
+// The paging area is escalated step by step on each T3513 expiry, and the area of the last step is used for
+// the remaining paging attempts. The steps of the first rule matching the paging trigger are applied, otherwise
+// the default steps.
+type PagingPolicy struct {
+	Steps []string           `yaml:"steps,omitempty" valid:"optional"`
+	Rules []PagingPolicyRule `yaml:"rules,omitempty" valid:"optional"`
+}
+
+// The rule matches the N1N2MessageTransfer triggering the paging by Paging Policy Indicator, DNN and 5QI,
+// and the absent (zero) ones match any value
+type PagingPolicyRule struct {
+	Ppi    int32    `yaml:"ppi,omitempty" valid:"optional"`
+	Dnn    string   `yaml:"dnn,omitempty" valid:"optional"`
+	Var5qi int32    `yaml:"5qi,omitempty" valid:"optional"`
+	Steps  []string `yaml:"steps,omitempty" valid:"required"`
+}
+
+func (p *PagingPolicy) validate() (bool, error) {
+	var errs govalidator.Errors
+	errs = append(errs, validatePagingSteps("pagingPolicy.steps", p.Steps)...)
+	for i, rule := range p.Rules {
+		if len(rule.Steps) == 0 {
+			errs = append(errs, fmt.Errorf("invalid pagingPolicy.rules[%d].steps: value is required", i))
+		}
+		if result := govalidator.InRangeInt(rule.Ppi, 0, 7); !result {
+			errs = append(errs, fmt.Errorf("invalid pagingPolicy.rules[%d].ppi: %d, should be in the range of 0~7",
+				i, rule.Ppi))
+		}
+		if result := govalidator.InRangeInt(rule.Var5qi, 0, 255); !result {
+			errs = append(errs, fmt.Errorf("invalid pagingPolicy.rules[%d].5qi: %d, should be in the range of 0~255",
+				i, rule.Var5qi))
+		}
+		errs = append(errs, validatePagingSteps(fmt.Sprintf("pagingPolicy.rules[%d].steps", i), rule.Steps)...)
+	}
+	if len(errs) > 0 {
+		return false, errs
+	}
+	return true, nil
+}
+
+func validatePagingSteps(name string, steps []string) (errs govalidator.Errors) {
+	for _, step := range steps {
+		switch step {
+		case PagingAreaLastKnownRan, PagingAreaRegistrationArea, PagingAreaAllTai:
+		default:
+			errs = append(errs, fmt.Errorf("invalid %s: %s, should be one of %s, %s and %s", name, step,
+				PagingAreaLastKnownRan, PagingAreaRegistrationArea, PagingAreaAllTai))
+		}
+	}
+	return errs
+}
+
+// The emergency services (TS 23.501 5.16.4) are supported if configured. The emergency PDU sessions are
+// established to the DNN and S-NSSAI, and the SMF is discovered by NRF if the SMF URI is absent. The UEs are
+// registered for emergency services without authentication only if allowed by the local regulation.
+type Emergency struct {
+	Dnn                  string         `yaml:"dnn,omitempty" valid:"type(string),minstringlength(1),required"`
+	Snssai               *models.Snssai `yaml:"snssai,omitempty" valid:"required"`
+	SmfUri               string         `yaml:"smfUri,omitempty" valid:"optional,url"`
+	AllowUnauthenticated bool           `yaml:"allowUnauthenticated,omitempty" valid:"type(bool),optional"`
+}
+
+func (e *Emergency) validate() (bool, error) {
+	if _, err := govalidator.ValidateStruct(e); err != nil {
+		return false, appendInvalid(err)
+	}
+
+	var errs govalidator.Errors
+	if result := govalidator.InRangeInt(e.Snssai.Sst, 0, 255); !result {
+		err := fmt.Errorf("invalid emergency.snssai.sst: %d, should be in the range of 0~255", e.Snssai.Sst)
+		errs = append(errs, err)
+	}
+	if e.Snssai.Sd != "" {
+		if result := govalidator.StringMatches(e.Snssai.Sd, "^[A-Fa-f0-9]{6}$"); !result {
+			err := fmt.Errorf("invalid emergency.snssai.sd: %s, should be 3 bytes hex string, range: 000000~FFFFFF",
+				e.Snssai.Sd)
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return false, errs
+	}
+	return true, nil
+}
+
+// The MICO mode (TS 23.501 5.4.1.3) is allowed to the UEs requesting it if enabled and subscribed. The
+// extended periodic registration timer is assigned to the UEs in MICO mode if not given by the subscription,
+// and the UEs are allowed to register in all PLMN registration area if requested.
+type Mico struct {
+	Enable                  bool `yaml:"enable,omitempty" valid:"type(bool),optional"`
+	T3512Value              int  `yaml:"t3512Value,omitempty" valid:"type(int),optional"` // unit is second
+	AllPlmnRegistrationArea bool `yaml:"allPlmnRegistrationArea,omitempty" valid:"type(bool),optional"`
+}
+
+// The maximum value of GPRS timer 3 (TS 24.008 10.5.7.4a), 31 * 320 hours
+const maxGPRSTimer3Value = 31 * 320 * 60 * 60
+
+func (m *Mico) validate() (bool, error) {
+	if _, err := govalidator.ValidateStruct(m); err != nil {
+		return false, appendInvalid(err)
+	}
+
+	if result := govalidator.InRangeInt(m.T3512Value, 0, maxGPRSTimer3Value); !result {
+		err := fmt.Errorf("invalid mico.t3512Value: %d, should be in the range of 0~%d", m.T3512Value,
+			maxGPRSTimer3Value)
+		return false, govalidator.Errors{err}
+	}
+	return true, nil
+}
+
+// The extended DRX (TS 23.501 5.31.7.2) is allowed to the UEs requesting it if enabled. The eDRX cycle of the
+// UEs is limited to the maximum cycle, and the paging time window is assigned if configured; otherwise the
+// requested or subscribed values are used.
+type Edrx struct {
+	Enable           bool `yaml:"enable,omitempty" valid:"type(bool),optional"`
+	MaxCycle         int  `yaml:"maxCycle,omitempty" valid:"type(int),optional"`         // unit is second
+	PagingTimeWindow int  `yaml:"pagingTimeWindow,omitempty" valid:"type(int),optional"` // unit is second
+}
+
+// The eDRX cycle and paging time window ranges of TS 24.008 10.5.5.32 for WB-S1 mode, in seconds
+const (
+	minEdrxCycle            = 6
+	maxEdrxCycle            = 10486
+	maxEdrxPagingTimeWindow = 21
+)
+
+func (e *Edrx) validate() (bool, error) {
+	if _, err := govalidator.ValidateStruct(e); err != nil {
+		return false, appendInvalid(err)
+	}
+
+	var errs govalidator.Errors
+	if e.MaxCycle != 0 {
+		if result := govalidator.InRangeInt(e.MaxCycle, minEdrxCycle, maxEdrxCycle); !result {
+			err := fmt.Errorf("invalid edrx.maxCycle: %d, should be in the range of %d~%d", e.MaxCycle,
+				minEdrxCycle, maxEdrxCycle)
+			errs = append(errs, err)
+		}
+	}
+	if result := govalidator.InRangeInt(e.PagingTimeWindow, 0, maxEdrxPagingTimeWindow); !result {
+		err := fmt.Errorf("invalid edrx.pagingTimeWindow: %d, should be in the range of 0~%d", e.PagingTimeWindow,
+			maxEdrxPagingTimeWindow)
+		errs = append(errs, err)
+	}
+	if len(errs) > 0 {
+		return false, errs
+	}
+	return true, nil
+}
+
+// The trace collection entity (TS 32.422 4.8), to which the trace failures and the SUPI and IMEI(SV) of the UEs
+// in the cell traffic traces are reported by HTTP POST
+type Tce struct {
+	Uri string `yaml:"uri" valid:"required,url"`
+}
+
+func (t *Tce) validate() (bool, error) {
+	if _, err := govalidator.ValidateStruct(t); err != nil {
+		return false, appendInvalid(err)
+	}
+	return true, nil
+}
+
+// The overload control of the NG-RAN nodes (TS 23.501 5.19.5.2). The load of the AMF is sampled every interval,
+// and the NG-RAN nodes are requested to reduce the signalling load by Overload Start once any load reaches its
+// start threshold. Overload Stop is sent once all the loads fall below their stop thresholds.
+type OverloadControl struct {
+	Enable               bool               `yaml:"enable,omitempty" valid:"type(bool),optional"`
+	Interval             int                `yaml:"interval,omitempty" valid:"type(int),optional"` // unit is second
+	UeNum                *OverloadThreshold `yaml:"ueNum,omitempty" valid:"optional"`
+	NgapMessageRate      *OverloadThreshold `yaml:"ngapMessageRate,omitempty" valid:"optional"` // messages per second
+	DispatchLatency      *OverloadThreshold `yaml:"dispatchLatency,omitempty" valid:"optional"` // unit is millisecond
+	SbiBacklog           *OverloadThreshold `yaml:"sbiBacklog,omitempty" valid:"optional"`      // requests in flight
+	OverloadAction       string             `yaml:"overloadAction,omitempty" valid:"type(string),optional"`
+	TrafficLoadReduction int                `yaml:"trafficLoadReduction,omitempty" valid:"type(int),optional"` // percent
+	SliceOverloadList    []SliceOverload    `yaml:"sliceOverloadList,omitempty" valid:"optional"`
+}
+
+// The load threshold to start and stop the overload control, the stop threshold is the start one if not set
+type OverloadThreshold struct {
+	Start int `yaml:"start" valid:"type(int),required"`
+	Stop  int `yaml:"stop,omitempty" valid:"type(int),optional"`
+}
+
+// The overload response and traffic load reduction of the S-NSSAIs (TS 38.413 9.3.1.139)
+type SliceOverload struct {
+	SnssaiList           []models.Snssai `yaml:"snssaiList" valid:"required"`
+	OverloadAction       string          `yaml:"overloadAction,omitempty" valid:"type(string),optional"`
+	TrafficLoadReduction int             `yaml:"trafficLoadReduction,omitempty" valid:"type(int),optional"`
+}
+
+// The overload actions of the NG-RAN (TS 38.413 9.3.1.105)
+const (
+	OverloadActionRejectNonEmergencyMoDt = "rejectNonEmergencyMoDt"
+	OverloadActionRejectRrcCrSignalling  = "rejectRrcCrSignalling"
+	// permit emergency sessions and mobile terminated services only
+	OverloadActionPermitEmergencyAndMt = "permitEmergencyAndMt"
+	// permit high priority sessions and mobile terminated services only
+	OverloadActionPermitHighPriorityAndMt = "permitHighPriorityAndMt"
+)
+
+func (o *OverloadControl) validate() (bool, error) {
+	if _, err := govalidator.ValidateStruct(o); err != nil {
+		return false, appendInvalid(err)
+	}
+
+	var errs govalidator.Errors
+	if o.Interval < 0 {
+		errs = append(errs, fmt.Errorf("invalid overloadControl.interval: %d, should not be negative", o.Interval))
+	}
+	if o.Enable && o.UeNum == nil && o.NgapMessageRate == nil && o.DispatchLatency == nil && o.SbiBacklog == nil {
+		errs = append(errs, fmt.Errorf("invalid overloadControl: no threshold is set"))
+	}
+	thresholds := map[string]*OverloadThreshold{
+		"ueNum":           o.UeNum,
+		"ngapMessageRate": o.NgapMessageRate,
+		"dispatchLatency": o.DispatchLatency,
+		"sbiBacklog":      o.SbiBacklog,
+	}
+	for name, threshold := range thresholds {
+		if threshold != nil && (threshold.Start <= 0 || threshold.Stop < 0 || threshold.Stop > threshold.Start) {
+			errs = append(errs, fmt.Errorf("invalid overloadControl.%s: start %d and stop %d, should be "+
+				"0 < stop <= start", name, threshold.Start, threshold.Stop))
+		}
+	}
+	errs = append(errs, validateOverloadResponse("overloadControl", o.OverloadAction, o.TrafficLoadReduction)...)
+	for i, sliceOverload := range o.SliceOverloadList {
+		name := fmt.Sprintf("overloadControl.sliceOverloadList[%d]", i)
+		errs = append(errs, validateOverloadResponse(name, sliceOverload.OverloadAction,
+			sliceOverload.TrafficLoadReduction)...)
+		for j, snssai := range sliceOverload.SnssaiList {
+			if result := govalidator.InRangeInt(snssai.Sst, 0, 255); !result {
+				errs = append(errs, fmt.Errorf("invalid %s.snssaiList[%d].sst: %d, should be in the range of 0~255",
+					name, j, snssai.Sst))
+			}
+			if snssai.Sd != "" && !govalidator.StringMatches(snssai.Sd, "^[A-Fa-f0-9]{6}$") {
+				errs = append(errs, fmt.Errorf("invalid %s.snssaiList[%d].sd: %s, should be 3 bytes hex string",
+					name, j, snssai.Sd))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return false, errs
+	}
+	return true, nil
+}
+
+func validateOverloadResponse(name, overloadAction string, trafficLoadReduction int) (errs govalidator.Errors) {
+	switch overloadAction {
+	case "", OverloadActionRejectNonEmergencyMoDt, OverloadActionRejectRrcCrSignalling,
+		OverloadActionPermitEmergencyAndMt, OverloadActionPermitHighPriorityAndMt:
+	default:
+		errs = append(errs, fmt.Errorf("invalid %s.overloadAction: %s, should be one of %s, %s, %s and %s", name,
+			overloadAction, OverloadActionRejectNonEmergencyMoDt, OverloadActionRejectRrcCrSignalling,
+			OverloadActionPermitEmergencyAndMt, OverloadActionPermitHighPriorityAndMt))
+	}
+	// TS 38.413 9.3.1.106, 0 if not indicated
+	if result := govalidator.InRangeInt(trafficLoadReduction, 0, 99); !result {
+		errs = append(errs, fmt.Errorf("invalid %s.trafficLoadReduction: %d, should be in the range of 0~99", name,
+			trafficLoadReduction))
+	}
+	return errs
+}
+
+// The NAS level congestion control (TS 23.501 5.19.7) is active while the AMF is overloaded, see OverloadControl.
+// The registration and service requests are rejected with the back-off timer T3346 if the mobility management
+// congestion control is enabled, and the 5GSM messages of the congested DNNs and S-NSSAIs are sent back to the UEs
+// with the back-off timer of the session management.
+type Congestion struct {
+	MobilityManagement bool            `yaml:"mobilityManagement,omitempty" valid:"type(bool),optional"`
+	T3346              *BackoffTimer   `yaml:"t3346,omitempty" valid:"optional"`
+	DnnList            []string        `yaml:"dnnList,omitempty" valid:"optional"`
+	SnssaiList         []models.Snssai `yaml:"snssaiList,omitempty" valid:"optional"`
+	SmBackoffTimer     *BackoffTimer   `yaml:"smBackoffTimer,omitempty" valid:"optional"`
+}
+
+// The back-off timer is randomised between min and max across the UEs, so that the deferred requests of the UEs are
+// not synchronized
+type BackoffTimer struct {
+	Min int `yaml:"min" valid:"type(int),required"` // unit is second
+	Max int `yaml:"max" valid:"type(int),required"` // unit is second
+}
+
+// The maximum value of GPRS timer 2 (TS 24.008 10.5.7.4), 31 decihours
+const maxGPRSTimer2Value = 31 * 6 * 60
+
+func (c *Congestion) validate() (bool, error) {
+	if _, err := govalidator.ValidateStruct(c); err != nil {
+		return false, appendInvalid(err)
+	}
+
+	var errs govalidator.Errors
+	if c.T3346 != nil {
+		errs = append(errs, c.T3346.validate("congestionControl.t3346", maxGPRSTimer2Value)...)
+	}
+	if c.SmBackoffTimer != nil {
+		errs = append(errs, c.SmBackoffTimer.validate("congestionControl.smBackoffTimer", maxGPRSTimer3Value)...)
+	}
+	for i, dnn := range c.DnnList {
+		if dnn == "" {
+			errs = append(errs, fmt.Errorf("invalid congestionControl.dnnList[%d]: should not be empty", i))
+		}
+	}
+	for i, snssai := range c.SnssaiList {
+		if result := govalidator.InRangeInt(snssai.Sst, 0, 255); !result {
+			errs = append(errs, fmt.Errorf("invalid congestionControl.snssaiList[%d].sst: %d, should be in the range "+
+				"of 0~255", i, snssai.Sst))
+		}
+		if snssai.Sd != "" && !govalidator.StringMatches(snssai.Sd, "^[A-Fa-f0-9]{6}$") {
+			errs = append(errs, fmt.Errorf("invalid congestionControl.snssaiList[%d].sd: %s, should be 3 bytes hex "+
+				"string", i, snssai.Sd))
+		}
+	}
+	if len(errs) > 0 {
+		return false, errs
+	}
+	return true, nil
+}
+
+func (b *BackoffTimer) validate(name string, maxValue int) (errs govalidator.Errors) {
+	if result := govalidator.InRangeInt(b.Min, 1, maxValue); !result {
+		errs = append(errs, fmt.Errorf("invalid %s.min: %d, should be in the range of 1~%d", name, b.Min, maxValue))
+	}
+	if result := govalidator.InRangeInt(b.Max, b.Min, maxValue); !result {
+		errs = append(errs, fmt.Errorf("invalid %s.max: %d, should be in the range of %d~%d", name, b.Max, b.Min,
+			maxValue))
+	}
+	return errs
+}
+
 type NasIE struct {
 	NetworkFeatureSupport5GS *NetworkFeatureSupport5GS `yaml:"networkFeatureSupport5GS,omitempty" valid:"optional"`
 }
@@ -1031,3 +1426,28 @@ func (c *Config) GetCertKeyPath() string {
 	defer c.RUnlock()
 	return c.Configuration.Sbi.Tls.Key
 }
+
+// The UE contexts of the NG-RAN node are retained (TS 38.413 8.7.1.2) if enabled. When the NG connection of the
+// NG-RAN node is lost, the UE-associated logical NG-connections are kept for the grace period, and resumed if the
+// NG-RAN node with the same Global RAN Node ID sets up the NG interface again requesting the UE retention;
+// otherwise they are released after the grace period.
+type UeRetention struct {
+	Enable      bool `yaml:"enable,omitempty" valid:"type(bool),optional"`
+	GracePeriod int  `yaml:"gracePeriod,omitempty" valid:"type(int),optional"` // unit is second
+}
+
+// The maximum grace period to retain the UE contexts, one hour
+const maxUeRetentionGracePeriod = 60 * 60
+
+func (u *UeRetention) validate() (bool, error) {
+	if _, err := govalidator.ValidateStruct(u); err != nil {
+		return false, appendInvalid(err)
+	}
+
+	if result := govalidator.InRangeInt(u.GracePeriod, 0, maxUeRetentionGracePeriod); !result {
+		err := fmt.Errorf("invalid ueRetention.gracePeriod: %d, should be in the range of 0~%d", u.GracePeriod,
+			maxUeRetentionGracePeriod)
+		return false, govalidator.Errors{err}
+	}
+	return true, nil
+}
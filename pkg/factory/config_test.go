@@ -8,6 +8,8 @@ import (
 	"testing"
 
 	"github.com/asaskevich/govalidator"
+
+	"github.com/free5gc/openapi/models"
 )
 
 func TestSctp_validate(t *testing.T) {
@@ -110,3 +112,428 @@ func TestSctp_validate(t *testing.T) {
 		})
 	}
 }
+
+func TestPagingPolicy_validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  PagingPolicy
+		wantErr bool
+		numErr  int
+	}{
+		{
+			name: "test OK",
+			policy: PagingPolicy{
+				Steps: []string{PagingAreaLastKnownRan, PagingAreaRegistrationArea, PagingAreaAllTai},
+				Rules: []PagingPolicyRule{
+					{Ppi: 1, Dnn: "internet", Var5qi: 9, Steps: []string{PagingAreaRegistrationArea}},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "test Error -- unknown step",
+			policy: PagingPolicy{
+				Steps: []string{"cell"},
+			},
+			wantErr: true,
+			numErr:  1,
+		},
+		{
+			name: "test Error -- invalid rule",
+			policy: PagingPolicy{
+				Rules: []PagingPolicyRule{
+					{Ppi: 8, Var5qi: 256},
+				},
+			},
+			wantErr: true,
+			numErr:  3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.policy.validate()
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("PagingPolicy.validate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				errs := err.(govalidator.Errors)
+				if len(errs) != tt.numErr {
+					t.Errorf("PagingPolicy.validate() error = %v, numErr %v", err, tt.numErr)
+				}
+				return
+			}
+			if !got {
+				t.Errorf("PagingPolicy.validate() = %v, want true", got)
+			}
+		})
+	}
+}
+
+func TestEmergency_validate(t *testing.T) {
+	tests := []struct {
+		name      string
+		emergency Emergency
+		wantErr   bool
+		numErr    int
+	}{
+		{
+			name: "test OK",
+			emergency: Emergency{
+				Dnn:                  "sos",
+				Snssai:               &models.Snssai{Sst: 1, Sd: "010203"},
+				SmfUri:               "http://127.0.0.2:8000",
+				AllowUnauthenticated: true,
+			},
+			wantErr: false,
+		},
+		{
+			name: "test Error -- missing DNN and S-NSSAI",
+			emergency: Emergency{
+				SmfUri: "http://127.0.0.2:8000",
+			},
+			wantErr: true,
+			numErr:  2,
+		},
+		{
+			name: "test Error -- invalid S-NSSAI",
+			emergency: Emergency{
+				Dnn:    "sos",
+				Snssai: &models.Snssai{Sst: 256, Sd: "0102"},
+			},
+			wantErr: true,
+			numErr:  2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.emergency.validate()
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Emergency.validate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				errs := err.(govalidator.Errors)
+				if len(errs) != tt.numErr {
+					t.Errorf("Emergency.validate() error = %v, numErr %v", err, tt.numErr)
+				}
+				return
+			}
+			if !got {
+				t.Errorf("Emergency.validate() = %v, want true", got)
+			}
+		})
+	}
+}
+
+func TestMico_validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		mico    Mico
+		wantErr bool
+	}{
+		{
+			name: "test OK",
+			mico: Mico{
+				Enable:                  true,
+				T3512Value:              86400,
+				AllPlmnRegistrationArea: true,
+			},
+			wantErr: false,
+		},
+		{
+			name: "test Error -- T3512 value out of range",
+			mico: Mico{
+				Enable:     true,
+				T3512Value: 31*320*60*60 + 1,
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.mico.validate()
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Mico.validate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !got {
+				t.Errorf("Mico.validate() = %v, want true", got)
+			}
+		})
+	}
+}
+
+func TestEdrx_validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		edrx    Edrx
+		wantErr bool
+		numErr  int
+	}{
+		{
+			name: "test OK",
+			edrx: Edrx{
+				Enable:           true,
+				MaxCycle:         164,
+				PagingTimeWindow: 10,
+			},
+			wantErr: false,
+		},
+		{
+			name: "test OK -- requested values",
+			edrx: Edrx{
+				Enable: true,
+			},
+			wantErr: false,
+		},
+		{
+			name: "test Error -- out of range",
+			edrx: Edrx{
+				Enable:           true,
+				MaxCycle:         5,
+				PagingTimeWindow: 30,
+			},
+			wantErr: true,
+			numErr:  2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.edrx.validate()
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Edrx.validate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				errs := err.(govalidator.Errors)
+				if len(errs) != tt.numErr {
+					t.Errorf("Edrx.validate() error = %v, numErr %v", err, tt.numErr)
+				}
+				return
+			}
+			if !got {
+				t.Errorf("Edrx.validate() = %v, want true", got)
+			}
+		})
+	}
+}
+
+func TestTce_validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		tce     Tce
+		wantErr bool
+	}{
+		{
+			name: "test OK",
+			tce: Tce{
+				Uri: "http://127.0.0.1:8080/trace-reports",
+			},
+			wantErr: false,
+		},
+		{
+			name:    "test Error -- not set",
+			tce:     Tce{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.tce.validate()
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Tce.validate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !got {
+				t.Errorf("Tce.validate() = %v, want true", got)
+			}
+		})
+	}
+}
+
+func TestOverloadControl_validate(t *testing.T) {
+	tests := []struct {
+		name            string
+		overloadControl OverloadControl
+		wantErr         bool
+	}{
+		{
+			name: "test OK",
+			overloadControl: OverloadControl{
+				Enable:               true,
+				UeNum:                &OverloadThreshold{Start: 1000, Stop: 800},
+				SbiBacklog:           &OverloadThreshold{Start: 100},
+				OverloadAction:       OverloadActionRejectNonEmergencyMoDt,
+				TrafficLoadReduction: 50,
+				SliceOverloadList: []SliceOverload{
+					{
+						SnssaiList:     []models.Snssai{{Sst: 1, Sd: "010203"}},
+						OverloadAction: OverloadActionPermitEmergencyAndMt,
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "test Error -- no threshold",
+			overloadControl: OverloadControl{
+				Enable: true,
+			},
+			wantErr: true,
+		},
+		{
+			name: "test Error -- stop threshold above start threshold",
+			overloadControl: OverloadControl{
+				Enable: true,
+				UeNum:  &OverloadThreshold{Start: 800, Stop: 1000},
+			},
+			wantErr: true,
+		},
+		{
+			name: "test Error -- invalid overload action",
+			overloadControl: OverloadControl{
+				Enable:         true,
+				UeNum:          &OverloadThreshold{Start: 1000},
+				OverloadAction: "rejectAll",
+			},
+			wantErr: true,
+		},
+		{
+			name: "test Error -- traffic load reduction out of range",
+			overloadControl: OverloadControl{
+				Enable:               true,
+				UeNum:                &OverloadThreshold{Start: 1000},
+				TrafficLoadReduction: 100,
+			},
+			wantErr: true,
+		},
+		{
+			name: "test Error -- invalid S-NSSAI",
+			overloadControl: OverloadControl{
+				Enable: true,
+				UeNum:  &OverloadThreshold{Start: 1000},
+				SliceOverloadList: []SliceOverload{
+					{
+						SnssaiList: []models.Snssai{{Sst: 1, Sd: "0102"}},
+					},
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.overloadControl.validate()
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("OverloadControl.validate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !got {
+				t.Errorf("OverloadControl.validate() = %v, want true", got)
+			}
+		})
+	}
+}
+
+func TestCongestion_validate(t *testing.T) {
+	tests := []struct {
+		name       string
+		congestion Congestion
+		wantErr    bool
+	}{
+		{
+			name: "test OK",
+			congestion: Congestion{
+				MobilityManagement: true,
+				T3346:              &BackoffTimer{Min: 900, Max: 1800},
+				DnnList:            []string{"internet"},
+				SnssaiList:         []models.Snssai{{Sst: 1, Sd: "010203"}},
+				SmBackoffTimer:     &BackoffTimer{Min: 60, Max: 120},
+			},
+			wantErr: false,
+		},
+		{
+			name: "test Error -- max below min",
+			congestion: Congestion{
+				T3346: &BackoffTimer{Min: 1800, Max: 900},
+			},
+			wantErr: true,
+		},
+		{
+			name: "test Error -- T3346 out of range",
+			congestion: Congestion{
+				T3346: &BackoffTimer{Min: 900, Max: 12000},
+			},
+			wantErr: true,
+		},
+		{
+			name: "test Error -- invalid S-NSSAI",
+			congestion: Congestion{
+				SnssaiList: []models.Snssai{{Sst: 256}},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.congestion.validate()
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Congestion.validate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !got {
+				t.Errorf("Congestion.validate() = %v, want true", got)
+			}
+		})
+	}
+}
+
+func TestUeRetention_validate(t *testing.T) {
+	tests := []struct {
+		name        string
+		ueRetention UeRetention
+		wantErr     bool
+	}{
+		{
+			name:        "test OK",
+			ueRetention: UeRetention{Enable: true, GracePeriod: 30},
+			wantErr:     false,
+		},
+		{
+			name:        "test OK -- default grace period",
+			ueRetention: UeRetention{Enable: true},
+			wantErr:     false,
+		},
+		{
+			name:        "test Error -- negative grace period",
+			ueRetention: UeRetention{Enable: true, GracePeriod: -1},
+			wantErr:     true,
+		},
+		{
+			name:        "test Error -- grace period out of range",
+			ueRetention: UeRetention{Enable: true, GracePeriod: 7200},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.ueRetention.validate()
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("UeRetention.validate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !got {
+				t.Errorf("UeRetention.validate() = %v, want true", got)
+			}
+		})
+	}
+}
@@ -202,6 +202,18 @@ func (a *AmfApp) Start() {
 	a.wg.Add(1)
 	go a.listenShutdownEvent()
 
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		ngap.RunOverloadControl(a.ctx.Done())
+	}()
+
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		a.Consumer().RunTraceReporter(a.ctx.Done())
+	}()
+
 	if a.cfg.AreMetricsEnabled() && a.metricsServer != nil {
 		go func() {
 			a.metricsServer.Run(&a.wg)
@@ -298,12 +310,12 @@ func (a *AmfApp) terminateProcedure() {
 	// send AMF status indication to ran to notify ran that this AMF will be unavailable
 	logger.MainLog.Infof("Send AMF Status Indication to Notify RANs due to AMF terminating")
 	amfSelf := a.Context()
-	unavailableGuamiList := ngap_message.BuildUnavailableGUAMIList(amfSelf.ServedGuamiList)
+	unavailableGuamiList := ngap_message.BuildUnavailableGUAMIList(amfSelf.GetServedGuamiList())
 	amfSelf.AmfRanPool.Range(func(key, value interface{}) bool {
 		ran := value.(*amf_context.AmfRan)
 		ngap_message.SendAMFStatusIndication(ran, unavailableGuamiList)
 		return true
 	})
 	ngap_service.Stop()
-	callback.SendAmfStatusChangeNotify((string)(models.StatusChange_UNAVAILABLE), amfSelf.ServedGuamiList)
+	callback.SendAmfStatusChangeNotify((string)(models.StatusChange_UNAVAILABLE), amfSelf.GetServedGuamiList())
 }